@@ -0,0 +1,3 @@
+CREATE USER snmpcoluser WITH PASSWORD 'snmpcolpass';
+CREATE DATABASE snmpcollector OWNER snmpcoluser ENCODING 'UTF8';
+GRANT ALL PRIVILEGES ON DATABASE snmpcollector TO snmpcoluser;
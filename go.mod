@@ -5,11 +5,13 @@ go 1.13
 require (
 	github.com/Knetic/govaluate v3.0.0+incompatible
 	github.com/Unknwon/com v0.0.0-20181010210213-41959bdd855f // indirect
+	github.com/coreos/go-oidc v2.2.1+incompatible
 	github.com/go-macaron/binding v0.0.0-20170611065819-ac54ee249c27
 	github.com/go-macaron/inject v0.0.0-20160627170012-d8a0b8677191 // indirect
 	github.com/go-macaron/session v0.0.0-20181107031828-068d408f9c54
 	github.com/go-macaron/toolbox v0.0.0-20180818072302-a77f45a7ce90
 	github.com/go-sql-driver/mysql v1.4.0
+	github.com/go-xorm/builder v0.3.2
 	github.com/go-xorm/core v0.6.0
 	github.com/go-xorm/xorm v0.7.1
 	github.com/google/go-cmp v0.2.0
@@ -18,14 +20,26 @@ require (
 	github.com/influxdata/influxdb v1.7.0
 	github.com/influxdata/platform v0.0.0-20181110005748-2f8893f5d5e3 // indirect
 	github.com/kelseyhightower/envconfig v1.4.0
+	github.com/lib/pq v1.10.0
+	github.com/lor00x/goldap v0.0.0-20180618054307-a546dffdd1a3
 	github.com/mattn/go-sqlite3 v1.10.0
+	github.com/pelletier/go-toml v1.2.0
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.0.0
+	github.com/pquerna/cachecontrol v0.2.0 // indirect
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/sirupsen/logrus v1.2.0
 	github.com/smartystreets/assertions v0.0.0-20190116191733-b6c0e53d7304 // indirect
 	github.com/smartystreets/goconvey v0.0.0-20181108003508-044398e4856c // indirect
 	github.com/spf13/cast v1.3.0 // indirect
 	github.com/spf13/viper v1.2.1
+	github.com/vjeantet/ldapserver v1.0.1
+	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2
+	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45
 	golang.org/x/sys v0.0.0-20191020152052-9984515f0562 // indirect
 	gopkg.in/ini.v1 v1.39.0 // indirect
+	gopkg.in/ldap.v3 v3.1.0
 	gopkg.in/macaron.v1 v1.3.1
+	gopkg.in/square/go-jose.v2 v2.4.0
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -7,6 +7,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/bus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/cache"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/selfmon"
@@ -49,6 +50,9 @@ var (
 	// Bus is the messaging system used to send messages to the devices
 	Bus = bus.NewBus()
 
+	// LastValues keeps the last gathered values of all device measurements
+	LastValues = cache.New()
+
 	// MainConfig contains the global configuration
 	MainConfig config.Config
 
@@ -70,6 +74,8 @@ var (
 	// gatherWg synchronizes device specific goroutines
 	gatherWg sync.WaitGroup
 	senderWg sync.WaitGroup
+	// preReloadHook is called before each configuration reload
+	preReloadHook func()
 )
 
 // SetLogger sets the current log output.
@@ -77,6 +83,12 @@ func SetLogger(l *logrus.Logger) {
 	log = l
 }
 
+// SetPreReloadHook sets a function to be called before each configuration reload
+// (used to take configuration snapshots from packages depending on agent).
+func SetPreReloadHook(f func()) {
+	preReloadHook = f
+}
+
 // Reload Mutex Related Methods.
 
 // CheckReloadProcess checks if the agent is currently reloading config.
@@ -197,6 +209,8 @@ func DeviceProcessStart() {
 	devices = make(map[string]*device.SnmpDevice)
 	mutex.Unlock()
 
+	// signatures should be taken before outputs initialization
+	deviceSignatures, outputSignatures = cfgSignatures(&DBConfig)
 	for k, c := range DBConfig.SnmpDevice {
 		AddDeviceInRuntime(k, c)
 	}
@@ -212,6 +226,7 @@ func ReleaseDevices() {
 }
 
 func init() {
+	output.SetBus(Bus)
 	go Bus.Start()
 }
 
@@ -269,15 +284,22 @@ func DeleteDeviceInRuntime(id string) error {
 }
 
 // AddDeviceInRuntime initializes each SNMP device and puts the pointer to the global device map.
-func AddDeviceInRuntime(k string, cfg *config.SnmpDeviceCfg) {
+// Returns an error if there is no runtime output for the device (it needs a reload)
+func AddDeviceInRuntime(k string, cfg *config.SnmpDeviceCfg) error {
 	// Initialize each SNMP device and put pointer to the global map devices
 	dev := device.New(cfg)
+
+	// send a db map to initialize each one its own db if needed
+	outdb, err := dev.GetOutSenderFromMap(influxdb)
+	if err != nil {
+		log.Errorf("Device %s not added to runtime: %s", k, err)
+		return err
+	}
 	dev.AttachToBus(Bus)
 	dev.InitCatalogVar(DBConfig.VarCatalog)
 	dev.SetSelfMonitoring(selfmonProc)
-
-	// send a db map to initialize each one its own db if needed
-	outdb, _ := dev.GetOutSenderFromMap(influxdb)
+	dev.SetLastValues(LastValues)
+	dev.SetMaintWindows(MaintWindows)
 	outdb.Init()
 	outdb.StartSender(&senderWg)
 
@@ -285,16 +307,28 @@ func AddDeviceInRuntime(k string, cfg *config.SnmpDeviceCfg) {
 	devices[k] = dev
 	dev.StartGather(&gatherWg)
 	mutex.Unlock()
+	return nil
+}
+
+// setDBConfig sets the runtime configuration, the running devices keep the
+// configuration they were initialized with
+func setDBConfig(c *config.DBConfig) {
+	DBConfig = *c
+	device.SetDBConfig(c)
 }
 
 // LoadConf loads the DB conf and initializes the device metric config.
 func LoadConf() {
-	MainConfig.Database.LoadDbConfig(&DBConfig)
+	newcfg := &config.DBConfig{}
+	MainConfig.Database.LoadDbConfig(newcfg)
+	setDBConfig(newcfg)
+	MaintWindows.Load(DBConfig.MaintWindows)
 	influxdb = PrepareInfluxDBs()
 
 	// begin self monitoring process if needed, before all goroutines
 	initSelfMonitoring(influxdb)
 	config.InitMetricsCfg(&DBConfig)
+	initAlerting()
 }
 
 // Start loads the agent configuration and starts it.
@@ -333,14 +367,34 @@ func End() (time.Duration, error) {
 	return time.Since(start), nil
 }
 
-// ReloadConf stops the polling, reloads all configuration and restart the polling.
-func ReloadConf() (time.Duration, error) {
+// FullReloadConf stops the polling, reloads all configuration and restart the polling.
+func FullReloadConf() (time.Duration, error) {
 	start := time.Now()
 	if CheckAndSetReloadProcess() == true {
-		log.Warning("RELOADCONF: There is another reload process running while trying to reload at %s  ", start.String())
+		log.Warningf("RELOADCONF: There is another reload process running while trying to reload at %s  ", start.String())
 		return time.Since(start), fmt.Errorf("There is another reload process running.... please wait until finished ")
 	}
 
+	if preReloadHook != nil {
+		preReloadHook()
+	}
+	Bus.Publish(&bus.Event{Type: bus.EventReloadStart, Data: map[string]interface{}{"Full": true}})
+	fullReload(start)
+	CheckAndUnSetReloadProcess()
+	publishReloadFinish(start, true)
+
+	return time.Since(start), nil
+}
+
+// publishReloadFinish notifies the bus subscribers the end of the reload process
+func publishReloadFinish(start time.Time, full bool) {
+	Bus.Publish(&bus.Event{Type: bus.EventReloadFinish, Data: map[string]interface{}{
+		"Full":     full,
+		"Duration": time.Since(start).Seconds(),
+	}})
+}
+
+func fullReload(start time.Time) {
 	log.Infof("RELOADCONF INIT: begin device Gather processes stop... at %s", start.String())
 	End()
 
@@ -351,7 +405,42 @@ func ReloadConf() (time.Duration, error) {
 	DeviceProcessStart()
 
 	log.Infof("RELOADCONF END: Finished from %s to %s [Duration : %s]", start.String(), time.Now().String(), time.Since(start).String())
+}
+
+// ReloadConf reloads the configuration restarting only the devices whose configuration, or
+// the configuration of any object they depend on, has changed (the other ones keep running
+// with their counters last values). A full reload is done if global variables or the self
+// monitoring output have changed.
+func ReloadConf() (time.Duration, error) {
+	start := time.Now()
+	if CheckAndSetReloadProcess() == true {
+		log.Warningf("RELOADCONF: There is another reload process running while trying to reload at %s  ", start.String())
+		return time.Since(start), fmt.Errorf("There is another reload process running.... please wait until finished ")
+	}
+
+	if preReloadHook != nil {
+		preReloadHook()
+	}
+	Bus.Publish(&bus.Event{Type: bus.EventReloadStart, Data: map[string]interface{}{"Full": false}})
+
+	log.Infof("RELOADCONF INIT: loading configuration at %s", start.String())
+	newcfg := config.DBConfig{}
+	MainConfig.Database.LoadDbConfig(&newcfg)
+	config.InitMetricsCfg(&newcfg)
+	p := planReload(&newcfg)
+	if p.Full {
+		log.Infof("RELOADCONF: full reload needed: %s", p.Reason)
+		fullReload(start)
+	} else {
+		log.Infof("RELOADCONF: devices added %v, changed %v, removed %v, unchanged %d, outputs changed %v",
+			p.AddedDevices, p.ChangedDevices, p.RemovedDevices, p.Unchanged, p.ChangedOutputs)
+		MaintWindows.Load(newcfg.MaintWindows)
+		applyReload(p, &newcfg)
+		initAlerting()
+		log.Infof("RELOADCONF END: Finished from %s to %s [Duration : %s]", start.String(), time.Now().String(), time.Since(start).String())
+	}
 	CheckAndUnSetReloadProcess()
+	publishReloadFinish(start, p.Full)
 
 	return time.Since(start), nil
 }
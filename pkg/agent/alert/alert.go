@@ -0,0 +1,462 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Knetic/govaluate"
+	"github.com/influxdata/influxdb/client/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/bus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/cache"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/measurement"
+)
+
+var (
+	log *logrus.Logger
+)
+
+// SetLogger sets the current log output.
+func SetLogger(l *logrus.Logger) {
+	log = l
+}
+
+// Alert states
+const (
+	StatePending  = "pending"  // expression true for less than the rule for duration
+	StateFiring   = "firing"   // notified as firing
+	StateResolved = "resolved" // only used on notifications, resolved alerts are removed
+)
+
+// DefaultEventMeasurement is the measurement name of the alert event points
+const DefaultEventMeasurement = "snmpcollector_alerts"
+
+// defaultWebhookTimeout is used with receivers without timeout
+const defaultWebhookTimeout = 5 * time.Second
+
+// webhookQueueSize is the number of pending webhook notifications before dropping new ones
+const webhookQueueSize = 1000
+
+// Alert is the state of a rule on a measurement point
+type Alert struct {
+	Rule        string
+	Severity    string
+	Device      string
+	Measurement string
+	Index       string
+	Tags        map[string]string
+	Values      map[string]interface{} // values of the variables used on the rule expressions
+	State       string
+	ActiveSince time.Time // first evaluation with the expression true
+	FiredAt     time.Time
+	LastEval    time.Time
+}
+
+// Notification is the alert state change sent to the webhook receivers and the bus subscribers
+type Notification struct {
+	Status      string // firing or resolved
+	Rule        string
+	Severity    string
+	Description string
+	Device      string
+	Measurement string
+	Index       string
+	Tags        map[string]string
+	Values      map[string]interface{}
+	StartsAt    time.Time
+	EndsAt      *time.Time `json:",omitempty"`
+}
+
+// rule is the compiled rule config
+type rule struct {
+	cfg     *config.AlertRuleCfg
+	expr    *govaluate.EvaluableExpression
+	clear   *govaluate.EvaluableExpression
+	vars    []string
+	devices []string
+	query   *cache.Query
+}
+
+func newRule(cfg *config.AlertRuleCfg) (*rule, error) {
+	if err := cfg.Init(); err != nil {
+		return nil, err
+	}
+	r := &rule{cfg: cfg, devices: cfg.GetDevices()}
+	r.expr, _ = govaluate.NewEvaluableExpression(cfg.Expression)
+	r.vars = r.expr.Vars()
+	if len(cfg.ClearExpression) > 0 {
+		r.clear, _ = govaluate.NewEvaluableExpression(cfg.ClearExpression)
+		r.vars = append(r.vars, r.clear.Vars()...)
+	}
+	tags, _ := cfg.GetTagSelector()
+	r.query = &cache.Query{Tags: tags}
+	return r, nil
+}
+
+// matchDevice checks if the rule applies to the device
+func (r *rule) matchDevice(id string) bool {
+	if len(r.devices) == 0 {
+		return true
+	}
+	for _, d := range r.devices {
+		if d == id || (strings.HasSuffix(d, "*") && strings.HasPrefix(id, strings.TrimSuffix(d, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// evalBool evaluates the expression, false with error if not a boolean result
+func evalBool(expr *govaluate.EvaluableExpression, params map[string]interface{}) (bool, error) {
+	res, err := expr.Evaluate(params)
+	if err != nil {
+		return false, err
+	}
+	b, ok := res.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %s result %v is not a boolean", expr, res)
+	}
+	return b, nil
+}
+
+// Engine evaluates the alert rules after each device gather cycle
+type Engine struct {
+	mutex     sync.Mutex
+	rules     []*rule
+	receivers map[string]*config.AlertReceiverCfg
+	alerts    map[string]*Alert
+	values    *cache.LastValues
+	bus       *bus.Bus
+	sub       *bus.Subscription
+	output    func(device string) *output.InfluxDB
+	measName  string
+	webhooks  chan *webhook
+	suppress  func(device string) bool
+}
+
+// webhook is a notification pending to be sent to a receiver
+type webhook struct {
+	rcv *config.AlertReceiverCfg
+	n   *Notification
+}
+
+// NewEngine creates an alert engine over the last values store, event points are
+// written to the output returned for each device ( if any )
+func NewEngine(values *cache.LastValues, b *bus.Bus, out func(device string) *output.InfluxDB, measName string) *Engine {
+	if len(measName) == 0 {
+		measName = DefaultEventMeasurement
+	}
+	e := &Engine{
+		receivers: make(map[string]*config.AlertReceiverCfg),
+		alerts:    make(map[string]*Alert),
+		values:    values,
+		bus:       b,
+		output:    out,
+		measName:  measName,
+		webhooks:  make(chan *webhook, webhookQueueSize),
+	}
+	// a single sender keeps the notifications order ( resolved after firing )
+	go func() {
+		for w := range e.webhooks {
+			postWebhook(w.rcv, w.n)
+		}
+	}()
+	return e
+}
+
+// Load sets the active rules and receivers from the configuration, current
+// alerts are kept only for unchanged rules and configured devices ( removed
+// firing alerts are notified as resolved )
+func (e *Engine) Load(cfg *config.DBConfig) {
+	var rules []*rule
+	ids := make([]string, 0, len(cfg.AlertRules))
+	for id := range cfg.AlertRules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		c := cfg.AlertRules[id]
+		if !c.Active {
+			continue
+		}
+		r, err := newRule(c)
+		if err != nil {
+			log.Errorf("ALERT: rule %s disabled: %s", id, err)
+			continue
+		}
+		rules = append(rules, r)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	old := make(map[string]*rule, len(e.rules))
+	for _, r := range e.rules {
+		old[r.cfg.ID] = r
+	}
+	now := time.Now()
+	for k, a := range e.alerts {
+		c, ok := cfg.AlertRules[a.Rule]
+		_, devok := cfg.SnmpDevice[a.Device]
+		if !ok || !c.Active || !devok || old[a.Rule] == nil || !reflect.DeepEqual(c, old[a.Rule].cfg) {
+			log.Infof("ALERT: removing %s alert %s on device %s index %s after config reload", a.State, a.Rule, a.Device, a.Index)
+			delete(e.alerts, k)
+			// firing alerts are resolved with the previous rule and receivers
+			if a.State == StateFiring && old[a.Rule] != nil {
+				e.notify(old[a.Rule], a, StateResolved, now)
+			}
+		}
+	}
+	e.rules = rules
+	e.receivers = cfg.AlertReceivers
+	log.Infof("ALERT: loaded %d active rules and %d receivers", len(rules), len(cfg.AlertReceivers))
+}
+
+// SetSuppress sets the check of the devices whose rules should not be evaluated
+// ( alerts keep their state until the device is evaluated again )
+func (e *Engine) SetSuppress(f func(device string) bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.suppress = f
+}
+
+// Start evaluates the rules on each device gather cycle end
+func (e *Engine) Start() {
+	e.sub = e.bus.Subscribe(bus.SubOptions{Name: "alerting", Topics: []string{string(bus.EventGatherDone)}})
+	go func() {
+		for ev := range e.sub.C {
+			e.Eval(ev.Device, ev.Time)
+		}
+	}()
+}
+
+// Stop ends the rules evaluation
+func (e *Engine) Stop() {
+	if e.sub != nil {
+		e.sub.Unsubscribe()
+		e.sub = nil
+	}
+}
+
+func alertKey(r string, device string, idx string) string {
+	return r + "|" + device + "|" + idx
+}
+
+// Eval evaluates all the device rules over its last gathered values
+func (e *Engine) Eval(device string, now time.Time) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.suppress != nil && e.suppress(device) {
+		return
+	}
+	for _, r := range e.rules {
+		if !r.matchDevice(device) {
+			continue
+		}
+		seen := make(map[string]bool)
+		// with the measurement not gathered anymore all its alerts are removed
+		entry, err := e.values.Get(device, r.cfg.Measurement, r.query)
+		if err != nil {
+			log.Debugf("ALERT: rule %s not evaluated on device %s: %s", r.cfg.ID, device, err)
+		} else {
+			for _, row := range entry.Rows {
+				seen[row.Index] = true
+				// not valid points keep its alert state until gathered again
+				if !row.Valid {
+					continue
+				}
+				e.evalRow(r, device, entry.Measurement, row, now)
+			}
+		}
+		// points not gathered anymore ( removed by filters or tag changes )
+		prefix := alertKey(r.cfg.ID, device, "")
+		for k, a := range e.alerts {
+			if strings.HasPrefix(k, prefix) && !seen[a.Index] {
+				delete(e.alerts, k)
+				if a.State == StateFiring {
+					e.notify(r, a, StateResolved, now)
+				}
+			}
+		}
+	}
+}
+
+func (e *Engine) evalRow(r *rule, device string, meas string, row *measurement.LastValueRow, now time.Time) {
+	params := make(map[string]interface{}, len(row.Tags)+len(row.Fields))
+	for k, v := range row.Tags {
+		params[k] = v
+	}
+	for k, v := range row.Fields {
+		if v.Valid {
+			params[k] = v.Value
+		}
+	}
+	active, err := evalBool(r.expr, params)
+	if err != nil {
+		log.Debugf("ALERT: rule %s not evaluated on device %s index %s: %s", r.cfg.ID, device, row.Index, err)
+		return
+	}
+	key := alertKey(r.cfg.ID, device, row.Index)
+	a, ok := e.alerts[key]
+	if !ok {
+		if !active {
+			return
+		}
+		a = &Alert{Rule: r.cfg.ID, Severity: r.cfg.Severity, Device: device, Measurement: meas, Index: row.Index, State: StatePending, ActiveSince: now}
+		e.alerts[key] = a
+	}
+	a.Tags = row.Tags
+	a.LastEval = now
+	a.Values = make(map[string]interface{}, len(r.vars))
+	for _, v := range r.vars {
+		if val, ok := params[v]; ok {
+			a.Values[v] = val
+		}
+	}
+
+	switch a.State {
+	case StatePending:
+		if !active {
+			delete(e.alerts, key)
+			return
+		}
+		if now.Sub(a.ActiveSince) >= time.Duration(r.cfg.For)*time.Second {
+			a.State = StateFiring
+			a.FiredAt = now
+			e.notify(r, a, StateFiring, now)
+		}
+	case StateFiring:
+		resolved := !active
+		if r.clear != nil {
+			if resolved, err = evalBool(r.clear, params); err != nil {
+				log.Debugf("ALERT: rule %s clear expression not evaluated on device %s index %s: %s", r.cfg.ID, device, row.Index, err)
+				return
+			}
+		}
+		if resolved {
+			delete(e.alerts, key)
+			e.notify(r, a, StateResolved, now)
+		}
+	}
+}
+
+// notify sends the alert state change to the bus, the output and the webhook receivers
+func (e *Engine) notify(r *rule, a *Alert, status string, now time.Time) {
+	log.Infof("ALERT: rule %s on device %s index %s %s %v", a.Rule, a.Device, a.Index, status, a.Values)
+	n := &Notification{
+		Status:      status,
+		Rule:        a.Rule,
+		Severity:    a.Severity,
+		Description: r.cfg.Description,
+		Device:      a.Device,
+		Measurement: a.Measurement,
+		Index:       a.Index,
+		Tags:        a.Tags,
+		Values:      a.Values,
+		StartsAt:    a.FiredAt,
+	}
+	topic := bus.EventAlertFiring
+	if status == StateResolved {
+		topic = bus.EventAlertResolved
+		n.EndsAt = &now
+	}
+	e.bus.Publish(&bus.Event{Time: now, Type: topic, Device: a.Device, Data: n})
+	e.writeEvent(n, now)
+	for _, id := range r.cfg.Receivers {
+		rcv, ok := e.receivers[id]
+		if !ok || (status == StateResolved && !rcv.SendResolved) {
+			continue
+		}
+		select {
+		case e.webhooks <- &webhook{rcv: rcv, n: n}:
+		default:
+			log.Warnf("ALERT: webhook queue full, dropping %s notification for rule %s to receiver %s", status, a.Rule, id)
+		}
+	}
+}
+
+// writeEvent sends the alert event point to the device output
+func (e *Engine) writeEvent(n *Notification, now time.Time) {
+	if e.output == nil {
+		return
+	}
+	db := e.output(n.Device)
+	if db == nil {
+		return
+	}
+	tags := map[string]string{"alert_rule": n.Rule, "severity": n.Severity, "measurement": n.Measurement}
+	for k, v := range n.Tags {
+		tags[k] = v
+	}
+	fields := map[string]interface{}{"status": n.Status, "firing": 0}
+	if n.Status == StateFiring {
+		fields["firing"] = 1
+	} else if !n.StartsAt.IsZero() {
+		fields["duration"] = now.Sub(n.StartsAt).Seconds()
+	}
+	for k, v := range n.Values {
+		if _, ok := tags[k]; !ok && v != nil {
+			fields[k] = v
+		}
+	}
+	pt, err := client.NewPoint(e.measName, tags, fields, now)
+	if err != nil {
+		log.Warnf("ALERT: error on build event point for rule %s: %s", n.Rule, err)
+		return
+	}
+	bp, err := db.BP()
+	if err != nil {
+		return
+	}
+	(*bp).AddPoint(pt)
+	db.Send(bp)
+}
+
+// postWebhook posts the notification as JSON to the receiver URL
+func postWebhook(rcv *config.AlertReceiverCfg, n *Notification) {
+	body, err := json.Marshal(n)
+	if err != nil {
+		log.Warnf("ALERT: error on marshal notification for rule %s: %s", n.Rule, err)
+		return
+	}
+	timeout := defaultWebhookTimeout
+	if rcv.Timeout > 0 {
+		timeout = time.Duration(rcv.Timeout) * time.Second
+	}
+	cli := &http.Client{Timeout: timeout}
+	resp, err := cli.Post(rcv.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Warnf("ALERT: error on send %s notification for rule %s to receiver %s: %s", n.Status, n.Rule, rcv.ID, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Warnf("ALERT: receiver %s returned status %s on %s notification for rule %s", rcv.ID, resp.Status, n.Status, n.Rule)
+	}
+}
+
+// GetAlerts returns a copy of the current alerts on the state ( all if empty ) sorted by rule, device and index
+func (e *Engine) GetAlerts(state string) []*Alert {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	list := make([]*Alert, 0, len(e.alerts))
+	for _, a := range e.alerts {
+		if len(state) > 0 && a.State != state {
+			continue
+		}
+		c := *a
+		list = append(list, &c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return alertKey(list[i].Rule, list[i].Device, list[i].Index) < alertKey(list[j].Rule, list[j].Device, list[j].Index)
+	})
+	return list
+}
@@ -0,0 +1,155 @@
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/bus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/cache"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/measurement"
+)
+
+// setCPU stores the cpu value for the sw01 device processors, the first one not valid if invalid
+func setCPU(lv *cache.LastValues, invalid bool, cpus ...int64) {
+	var rows []*measurement.LastValueRow
+	for i, v := range cpus {
+		idx := string(rune('1' + i))
+		valid := i > 0 || !invalid
+		rows = append(rows, &measurement.LastValueRow{Index: idx, Valid: valid, Tags: map[string]string{"cpu": idx},
+			Fields: map[string]*measurement.LastValue{"load": {Value: v, Valid: valid}}})
+	}
+	lv.Update("sw01", "cpu_stats", "cpu", rows)
+}
+
+func TestEngine(t *testing.T) {
+	SetLogger(logrus.New())
+	bus.SetLogger(logrus.New())
+
+	received := make(chan *Notification, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := &Notification{}
+		json.NewDecoder(r.Body).Decode(n)
+		received <- n
+	}))
+	defer srv.Close()
+
+	lv := cache.New()
+	b := bus.NewBus()
+	sub := b.Subscribe(bus.SubOptions{Topics: []string{"alert.*"}})
+	e := NewEngine(lv, b, nil, "")
+	rule := &config.AlertRuleCfg{ID: "highcpu", Active: true, Measurement: "cpu", Devices: "sw*", TagSelector: "cpu=1",
+		Expression: "load > 90", ClearExpression: "load < 80", For: 60, Severity: "critical", Receivers: []string{"hook"}}
+	cfg := &config.DBConfig{
+		SnmpDevice:     map[string]*config.SnmpDeviceCfg{"sw01": {ID: "sw01"}},
+		AlertRules:     map[string]*config.AlertRuleCfg{"highcpu": rule},
+		AlertReceivers: map[string]*config.AlertReceiverCfg{"hook": {ID: "hook", URL: srv.URL, SendResolved: true}},
+	}
+	e.Load(cfg)
+
+	now := time.Now()
+	steps := []struct {
+		after   time.Duration
+		cpu     int64
+		invalid bool
+		state   string
+	}{
+		{0, 95, false, StatePending},
+		{30 * time.Second, 50, false, ""},            // back to normal before the for duration
+		{40 * time.Second, 95, false, StatePending},  // pending again
+		{100 * time.Second, 92, false, StateFiring},  // 60s after
+		{105 * time.Second, 0, true, StateFiring},    // not valid values keep the state
+		{110 * time.Second, 85, false, StateFiring},  // hysteresis: not resolved until load < 80
+		{120 * time.Second, 79, false, ""},           // resolved
+		{130 * time.Second, 99, false, StatePending}, // new alert
+	}
+	for _, s := range steps {
+		setCPU(lv, s.invalid, s.cpu, 99) // second cpu excluded by the tag selector
+		e.Eval("sw01", now.Add(s.after))
+		e.Eval("other", now.Add(s.after))
+		alerts := e.GetAlerts("")
+		state := ""
+		if len(alerts) > 1 {
+			t.Fatalf("got %d alerts, want 1: %+v", len(alerts), alerts)
+		}
+		if len(alerts) == 1 {
+			state = alerts[0].State
+		}
+		if state != s.state {
+			t.Errorf("after %s with load %d got state %q, want %q", s.after, s.cpu, state, s.state)
+		}
+	}
+
+	for _, status := range []string{StateFiring, StateResolved} {
+		select {
+		case n := <-received:
+			if n.Status != status || n.Rule != "highcpu" || n.Device != "sw01" || n.Tags["cpu"] != "1" || n.StartsAt.IsZero() {
+				t.Errorf("unexpected %s webhook notification %+v", status, n)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s webhook notification not received", status)
+		}
+		if ev := <-sub.C; ev.Device != "sw01" || ev.Data.(*Notification).Status != status {
+			t.Errorf("unexpected bus event %+v", ev)
+		}
+	}
+
+	// unchanged rules keep the alerts, changed ones start again
+	e.Load(cfg)
+	if len(e.GetAlerts(StatePending)) != 1 {
+		t.Error("alert removed on reload with unchanged rule")
+	}
+	changed := *rule
+	changed.For = 0
+	cfg.AlertRules = map[string]*config.AlertRuleCfg{"highcpu": &changed}
+	e.Load(cfg)
+	if len(e.GetAlerts("")) != 0 {
+		t.Error("alert kept on reload with changed rule")
+	}
+
+	// firing alerts removed are notified as resolved
+	expectResolved := func(reason string) {
+		select {
+		case n := <-received:
+			if n.Status != StateResolved || n.Rule != "highcpu" || n.EndsAt == nil {
+				t.Errorf("unexpected webhook notification %s %+v", reason, n)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("resolved webhook notification not received %s", reason)
+		}
+		if ev := <-sub.C; ev.Data.(*Notification).Status != StateResolved {
+			t.Errorf("unexpected bus event %s %+v", reason, ev)
+		}
+	}
+	fire := func() {
+		setCPU(lv, false, 95)
+		e.Eval("sw01", now)
+		if len(e.GetAlerts(StateFiring)) != 1 {
+			t.Fatal("alert not firing")
+		}
+		<-received
+		<-sub.C
+	}
+	fire()
+	cfg.AlertRules = map[string]*config.AlertRuleCfg{}
+	e.Load(cfg)
+	if len(e.GetAlerts("")) != 0 {
+		t.Error("alert kept on reload with deleted rule")
+	}
+	expectResolved("after rule deletion")
+
+	cfg.AlertRules = map[string]*config.AlertRuleCfg{"highcpu": &changed}
+	e.Load(cfg)
+	fire()
+	lv.DeleteDevice("sw01")
+	lv.Update("sw01", "mem_stats", "mem", nil)
+	e.Eval("sw01", now.Add(time.Minute))
+	if len(e.GetAlerts("")) != 0 {
+		t.Error("alert kept without the measurement values")
+	}
+	expectResolved("after measurement removal")
+}
@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"github.com/toni-moreno/snmpcollector/pkg/agent/alert"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+)
+
+// alertEngine evaluates the alert rules, nil if alerting is disabled
+var alertEngine *alert.Engine
+
+// deviceOutput returns the output of the running device
+func deviceOutput(id string) *output.InfluxDB {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if dev, ok := devices[id]; ok {
+		return dev.Influx
+	}
+	return nil
+}
+
+// initAlerting starts the alert engine the first time and (re)loads its rules
+func initAlerting() {
+	if MainConfig.Alerting.Disabled {
+		return
+	}
+	if alertEngine == nil {
+		alertEngine = alert.NewEngine(LastValues, Bus, deviceOutput, MainConfig.Alerting.EventMeasurement)
+		alertEngine.SetSuppress(deviceInMaintenance)
+		alertEngine.Start()
+	}
+	alertEngine.Load(&DBConfig)
+}
+
+// GetAlerts returns the current alerts on the state ( pending or firing, all if empty )
+func GetAlerts(state string) []*alert.Alert {
+	if alertEngine == nil {
+		return []*alert.Alert{}
+	}
+	return alertEngine.GetAlerts(state)
+}
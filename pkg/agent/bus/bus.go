@@ -34,6 +34,8 @@ type Bus struct {
 	waitsync chan bool
 	nodes    []*Node
 	nodeLock sync.Mutex
+	subs     []*Subscription
+	subLock  sync.Mutex
 }
 
 // NewBus creates a new broadcast bus.
@@ -0,0 +1,197 @@
+package bus
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Topic is the type of the events published on the bus, with the subsystem
+// as prefix ( device.*, gather.*, output.* ... )
+type Topic string
+
+// Runtime event topics published to the bus subscribers
+const (
+	EventDeviceConnect      Topic = "device.connect"
+	EventDeviceDisconnect   Topic = "device.disconnect"
+	EventDeviceAvailability Topic = "device.availability"
+	EventGatherDone         Topic = "gather.done"
+	EventFilterChanged      Topic = "filter.changed"
+	EventOutputError        Topic = "output.error"
+	EventReloadStart        Topic = "reload.start"
+	EventReloadFinish       Topic = "reload.finish"
+	EventAlertFiring        Topic = "alert.firing"
+	EventAlertResolved      Topic = "alert.resolved"
+	EventMaintenanceStart   Topic = "maintenance.start"
+	EventMaintenanceEnd     Topic = "maintenance.end"
+)
+
+// Match checks the topic against a subscription pattern: the exact topic,
+// a "prefix.*" wildcard or "*" for all topics
+func (t Topic) Match(pattern string) bool {
+	switch {
+	case pattern == "*" || pattern == string(t):
+		return true
+	case strings.HasSuffix(pattern, ".*"):
+		return strings.HasPrefix(string(t), strings.TrimSuffix(pattern, "*"))
+	}
+	return false
+}
+
+// EventBufferSize is the default number of events queued for each subscriber
+// before new events are dropped
+const EventBufferSize = 256
+
+// Event is a runtime state change published to the bus subscribers
+type Event struct {
+	Time   time.Time
+	Type   Topic
+	Device string      `json:",omitempty"`
+	Data   interface{} `json:",omitempty"`
+}
+
+// SubOptions set the subscriber name and the events to be received
+type SubOptions struct {
+	Name    string   // subscriber name shown on the bus stats
+	Topics  []string // topic patterns, all topics if empty
+	Devices []string // device IDs, all devices if empty
+	Buffer  int      // queued events, EventBufferSize if 0
+}
+
+// Subscription receives the published events matching its topics and devices
+type Subscription struct {
+	C         chan *Event
+	opts      SubOptions
+	bus       *Bus
+	devices   map[string]bool
+	delivered int64
+	dropped   int64
+}
+
+// SubStats are the delivery counters of a bus subscriber
+type SubStats struct {
+	Name      string
+	Topics    []string `json:",omitempty"`
+	Devices   []string `json:",omitempty"`
+	Buffer    int
+	Queued    int
+	Delivered int64
+	Dropped   int64
+}
+
+// match checks the event against the subscription filters, events without
+// device (reload, output) are sent to all subscribers of its topic
+func (s *Subscription) match(e *Event) bool {
+	if len(s.opts.Topics) > 0 {
+		found := false
+		for _, p := range s.opts.Topics {
+			if e.Type.Match(p) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if s.devices != nil && len(e.Device) > 0 && !s.devices[e.Device] {
+		return false
+	}
+	return true
+}
+
+// Dropped returns the number of events lost because the subscriber was too slow
+func (s *Subscription) Dropped() int64 {
+	return atomic.LoadInt64(&s.dropped)
+}
+
+// Stats returns the subscription delivery counters
+func (s *Subscription) Stats() SubStats {
+	return SubStats{
+		Name:      s.opts.Name,
+		Topics:    s.opts.Topics,
+		Devices:   s.opts.Devices,
+		Buffer:    cap(s.C),
+		Queued:    len(s.C),
+		Delivered: atomic.LoadInt64(&s.delivered),
+		Dropped:   atomic.LoadInt64(&s.dropped),
+	}
+}
+
+// Unsubscribe removes the subscription from the bus and closes its channel
+func (s *Subscription) Unsubscribe() {
+	b := s.bus
+	b.subLock.Lock()
+	defer b.subLock.Unlock()
+	for i, sub := range b.subs {
+		if sub == s {
+			b.subs = append(b.subs[:i], b.subs[i+1:]...)
+			close(s.C)
+			return
+		}
+	}
+}
+
+// Subscribe returns a new subscription to the bus events
+func (b *Bus) Subscribe(o SubOptions) *Subscription {
+	if o.Buffer <= 0 {
+		o.Buffer = EventBufferSize
+	}
+	s := &Subscription{
+		C:    make(chan *Event, o.Buffer),
+		opts: o,
+		bus:  b,
+	}
+	if len(o.Devices) > 0 {
+		s.devices = make(map[string]bool, len(o.Devices))
+		for _, id := range o.Devices {
+			s.devices[id] = true
+		}
+	}
+	b.subLock.Lock()
+	b.subs = append(b.subs, s)
+	b.subLock.Unlock()
+	return s
+}
+
+// SubscriberStats returns the delivery counters of all current subscribers
+func (b *Bus) SubscriberStats() []SubStats {
+	b.subLock.Lock()
+	defer b.subLock.Unlock()
+	stats := make([]SubStats, 0, len(b.subs))
+	for _, s := range b.subs {
+		stats = append(stats, s.Stats())
+	}
+	return stats
+}
+
+// Publish sends the event to all matching subscribers without blocking,
+// the event is dropped for subscribers with its buffer full
+func (b *Bus) Publish(e *Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.subLock.Lock()
+	defer b.subLock.Unlock()
+	for _, s := range b.subs {
+		if !s.match(e) {
+			continue
+		}
+		select {
+		case s.C <- e:
+			atomic.AddInt64(&s.delivered, 1)
+		default:
+			if atomic.AddInt64(&s.dropped, 1) == 1 {
+				log.Warnf("BUS: subscriber %s buffer full, dropping %s events", s.opts.Name, e.Type)
+			}
+		}
+	}
+}
+
+// Publish sends an event from this node to the bus subscribers
+func (n *Node) Publish(topic Topic, data interface{}) {
+	if n == nil || n.bus == nil {
+		return
+	}
+	n.bus.Publish(&Event{Type: topic, Device: n.ID, Data: data})
+}
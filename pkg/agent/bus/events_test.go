@@ -0,0 +1,62 @@
+package bus
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestTopicMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"*", true},
+		{"device.connect", true},
+		{"device.*", true},
+		{"device", false},
+		{"dev.*", false},
+		{"gather.done", false},
+	}
+	for _, tt := range tests {
+		if got := EventDeviceConnect.Match(tt.pattern); got != tt.want {
+			t.Errorf("%s Match(%q) = %t, want %t", EventDeviceConnect, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	SetLogger(logrus.New())
+	b := NewBus()
+	all := b.Subscribe(SubOptions{Name: "all", Buffer: 4})
+	dev := b.Subscribe(SubOptions{Name: "dev1", Devices: []string{"dev1"}, Topics: []string{"gather.*"}})
+	defer all.Unsubscribe()
+
+	n := NewNode("dev1")
+	b.Join(n)
+	n.Publish(EventGatherDone, nil)
+	NewNode("dev2").Publish(EventGatherDone, nil) // not attached
+	b.Publish(&Event{Type: EventReloadStart})
+	b.Publish(&Event{Type: EventGatherDone, Device: "dev2"})
+
+	if len(all.C) != 3 || len(dev.C) != 1 {
+		t.Fatalf("got %d/%d events, want 3/1", len(all.C), len(dev.C))
+	}
+	if e := <-dev.C; e.Device != "dev1" || e.Time.IsZero() {
+		t.Errorf("unexpected event %+v", e)
+	}
+	dev.Unsubscribe()
+	if _, ok := <-dev.C; ok {
+		t.Error("channel not closed on unsubscribe")
+	}
+
+	// publishing never blocks on slow subscribers
+	for i := 0; i < 3; i++ {
+		b.Publish(&Event{Type: EventOutputError})
+	}
+	stats := b.SubscriberStats()
+	want := SubStats{Name: "all", Buffer: 4, Queued: 4, Delivered: 4, Dropped: 2}
+	if len(stats) != 1 || stats[0].Name != want.Name || stats[0].Queued != want.Queued || stats[0].Delivered != want.Delivered || stats[0].Dropped != want.Dropped {
+		t.Errorf("got subscriber stats %+v, want %+v", stats, want)
+	}
+}
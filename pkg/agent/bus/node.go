@@ -1,9 +1,10 @@
 package bus
 
-// Message a basic message type
+// Message a basic message type, request messages are answered with Reply
 type Message struct {
-	Type string
-	Data interface{}
+	Type  string
+	Data  interface{}
+	reply chan *Reply
 }
 
 // Node represents node of a Broadcast bus.
@@ -0,0 +1,64 @@
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimeout is returned when a request is not read or replied on time
+var ErrTimeout = errors.New("bus request timeout")
+
+// Reply is the answer of a node to a request message
+type Reply struct {
+	Data interface{}
+	Err  error
+}
+
+// Reply answers a request message, only the first reply is sent and it
+// does nothing on messages sent without waiting for reply
+func (m *Message) Reply(data interface{}, err error) {
+	if m.reply == nil {
+		return
+	}
+	select {
+	case m.reply <- &Reply{Data: data, Err: err}:
+	default:
+	}
+}
+
+// Request sends the message to the node and waits for its reply, the timeout
+// includes the time waiting for the node to read the message
+func (n *Node) Request(m *Message, timeout time.Duration) (interface{}, error) {
+	m.reply = make(chan *Reply, 1)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case n.Read <- m:
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: node %s busy, %s message not read after %s", ErrTimeout, n.ID, m.Type, timeout)
+	}
+	select {
+	case r := <-m.reply:
+		return r.Data, r.Err
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: node %s did not reply to %s message after %s", ErrTimeout, n.ID, m.Type, timeout)
+	}
+}
+
+// Request sends the message to the node with the id and waits for its reply
+func (b *Bus) Request(id string, m *Message, timeout time.Duration) (interface{}, error) {
+	var node *Node
+	b.nodeLock.Lock()
+	for _, n := range b.nodes {
+		if n.ID == id {
+			node = n
+			break
+		}
+	}
+	b.nodeLock.Unlock()
+	if node == nil {
+		return nil, fmt.Errorf("There is no node %s in the bus", id)
+	}
+	return node.Request(m, timeout)
+}
@@ -0,0 +1,48 @@
+package bus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRequest(t *testing.T) {
+	SetLogger(logrus.New())
+	b := NewBus()
+	n := NewNode("dev1")
+	b.Join(n)
+
+	if _, err := b.Request("dev2", &Message{Type: "forcegather"}, time.Second); err == nil {
+		t.Error("expected error on request to unknown node")
+	}
+	// nobody reading the node messages
+	if _, err := b.Request("dev1", &Message{Type: "forcegather"}, 10*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected busy node timeout, got %v", err)
+	}
+
+	go func() {
+		for m := range n.Read {
+			switch m.Type {
+			case "forcegather":
+				m.Reply(m.Data, nil)
+				m.Reply("ignored", nil)
+			case "slow":
+				time.Sleep(50 * time.Millisecond)
+				m.Reply(nil, nil)
+			}
+		}
+	}()
+	defer n.Close()
+
+	r, err := b.Request("dev1", &Message{Type: "forcegather", Data: 1}, time.Second)
+	if err != nil || r != 1 {
+		t.Errorf("got reply %v error %v", r, err)
+	}
+	if _, err := n.Request(&Message{Type: "slow"}, 10*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected reply timeout, got %v", err)
+	}
+	// messages sent without waiting for reply are not blocked on Reply
+	n.SendMsg(&Message{Type: "forcegather"})
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/data/measurement"
+)
+
+// Entry the last values of a device measurement
+type Entry struct {
+	Device      string
+	Measurement string
+	Name        string // measurement name on the output database
+	Updated     time.Time
+	Rows        []*measurement.LastValueRow
+}
+
+// EntryInfo the measurement entry without values
+type EntryInfo struct {
+	Measurement string
+	Name        string
+	Updated     time.Time
+	Rows        int
+}
+
+// Query selects the rows and fields of a measurement entry
+type Query struct {
+	Tags      map[string]string // tag values, with "*" suffix to match by prefix
+	Fields    []string          // all fields if empty
+	ValidOnly bool              // skip rows and fields not valid
+}
+
+// LastValues is the in memory store of the last gathered values of each device measurement
+type LastValues struct {
+	mutex sync.RWMutex
+	data  map[string]map[string]*Entry
+}
+
+// New creates an empty last values store
+func New() *LastValues {
+	return &LastValues{data: make(map[string]map[string]*Entry)}
+}
+
+// Update replaces the device measurement values ( rows are not modified after stored )
+func (lv *LastValues) Update(device string, measid string, name string, rows []*measurement.LastValueRow) {
+	sorted := make([]*measurement.LastValueRow, len(rows))
+	copy(sorted, rows)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+	lv.mutex.Lock()
+	defer lv.mutex.Unlock()
+	if _, ok := lv.data[device]; !ok {
+		lv.data[device] = make(map[string]*Entry)
+	}
+	lv.data[device][measid] = &Entry{Device: device, Measurement: measid, Name: name, Updated: time.Now(), Rows: sorted}
+}
+
+// DeleteDevice removes all the device values
+func (lv *LastValues) DeleteDevice(device string) {
+	lv.mutex.Lock()
+	defer lv.mutex.Unlock()
+	delete(lv.data, device)
+}
+
+// List returns the device measurements with values
+func (lv *LastValues) List(device string) ([]*EntryInfo, error) {
+	lv.mutex.RLock()
+	defer lv.mutex.RUnlock()
+	meas, ok := lv.data[device]
+	if !ok {
+		return nil, fmt.Errorf("There is no values for device %s", device)
+	}
+	list := make([]*EntryInfo, 0, len(meas))
+	for _, e := range meas {
+		list = append(list, &EntryInfo{Measurement: e.Measurement, Name: e.Name, Updated: e.Updated, Rows: len(e.Rows)})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Measurement < list[j].Measurement })
+	return list, nil
+}
+
+func tagMatch(value string, pattern string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(value, strings.TrimSuffix(pattern, "*"))
+	}
+	return value == pattern
+}
+
+// match checks the row tags against the query
+func (q *Query) match(row *measurement.LastValueRow) bool {
+	if q.ValidOnly && !row.Valid {
+		return false
+	}
+	for k, v := range q.Tags {
+		if tv, ok := row.Tags[k]; !ok || !tagMatch(tv, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterFields returns a copy of the row with only the selected fields
+func (q *Query) filterFields(row *measurement.LastValueRow) *measurement.LastValueRow {
+	if len(q.Fields) == 0 && !q.ValidOnly {
+		return row
+	}
+	r := *row
+	r.Fields = make(map[string]*measurement.LastValue)
+	for k, v := range row.Fields {
+		if q.ValidOnly && !v.Valid {
+			continue
+		}
+		r.Fields[k] = v
+	}
+	if len(q.Fields) > 0 {
+		selected := make(map[string]*measurement.LastValue, len(q.Fields))
+		for _, f := range q.Fields {
+			if v, ok := r.Fields[f]; ok {
+				selected[f] = v
+			}
+		}
+		r.Fields = selected
+	}
+	return &r
+}
+
+// Get returns the values of the device measurement ( by ID or name ) with the
+// rows and fields selected by the query
+func (lv *LastValues) Get(device string, meas string, q *Query) (*Entry, error) {
+	lv.mutex.RLock()
+	defer lv.mutex.RUnlock()
+	dev, ok := lv.data[device]
+	if !ok {
+		return nil, fmt.Errorf("There is no values for device %s", device)
+	}
+	e, ok := dev[meas]
+	if !ok {
+		for _, v := range dev {
+			if v.Name == meas {
+				e = v
+				break
+			}
+		}
+		if e == nil {
+			return nil, fmt.Errorf("There is no values for measurement %s on device %s", meas, device)
+		}
+	}
+	res := *e
+	if q == nil {
+		return &res, nil
+	}
+	res.Rows = make([]*measurement.LastValueRow, 0, len(e.Rows))
+	for _, row := range e.Rows {
+		if q.match(row) {
+			res.Rows = append(res.Rows, q.filterFields(row))
+		}
+	}
+	return &res, nil
+}
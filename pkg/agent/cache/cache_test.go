@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/toni-moreno/snmpcollector/pkg/data/measurement"
+)
+
+func TestLastValues(t *testing.T) {
+	lv := New()
+	lv.Update("sw01", "ifmib", "interfaces", []*measurement.LastValueRow{
+		{Index: "Gi1/0/2", Valid: true, Tags: map[string]string{"portName": "Gi1/0/2"}, Fields: map[string]*measurement.LastValue{
+			"in": {Value: int64(20), Valid: true}, "out": {Value: int64(21), Valid: false}}},
+		{Index: "Gi1/0/1", Valid: true, Tags: map[string]string{"portName": "Gi1/0/1"}, Fields: map[string]*measurement.LastValue{
+			"in": {Value: int64(10), Valid: true}, "out": {Value: int64(11), Valid: true}}},
+		{Index: "Te1/1/1", Valid: false, Tags: map[string]string{"portName": "Te1/1/1"}},
+	})
+
+	if _, err := lv.Get("sw02", "ifmib", nil); err == nil {
+		t.Error("expected error on unknown device")
+	}
+	if _, err := lv.Get("sw01", "other", nil); err == nil {
+		t.Error("expected error on unknown measurement")
+	}
+	e, err := lv.Get("sw01", "interfaces", nil)
+	if err != nil || len(e.Rows) != 3 || e.Rows[0].Index != "Gi1/0/1" {
+		t.Fatalf("got %+v (error %v), want 3 rows sorted by index", e, err)
+	}
+
+	e, _ = lv.Get("sw01", "ifmib", &Query{Tags: map[string]string{"portName": "Gi1/0/*"}, Fields: []string{"out"}, ValidOnly: true})
+	if len(e.Rows) != 2 || len(e.Rows[0].Fields) != 1 || len(e.Rows[1].Fields) != 0 {
+		t.Fatalf("unexpected query result %+v", e.Rows)
+	}
+	if e.Rows[0].Fields["out"].Value != int64(11) {
+		t.Errorf("got field %+v", e.Rows[0].Fields["out"])
+	}
+	// stored rows are not modified by queries
+	if e, _ := lv.Get("sw01", "ifmib", nil); len(e.Rows[1].Fields) != 2 {
+		t.Errorf("stored row modified %+v", e.Rows[1])
+	}
+
+	if list, err := lv.List("sw01"); err != nil || len(list) != 1 || list[0].Rows != 3 {
+		t.Errorf("got list %+v (error %v)", list, err)
+	}
+	lv.DeleteDevice("sw01")
+	if _, err := lv.List("sw01"); err == nil {
+		t.Error("expected error on deleted device")
+	}
+}
@@ -26,6 +26,7 @@ func (d *SnmpDevice) measConcurrentGatherAndSend() {
 			//prepare batchpoint
 			metSent, metError, measSent, measError, points := m.GetInfluxPoint(d.TagMap)
 			d.stats.AddMeasStats(metSent, metError, measSent, measError)
+			d.updateLastValues(m)
 			startInfluxStats := time.Now()
 			if bpts != nil {
 				(*bpts).AddPoints(points)
@@ -65,6 +66,7 @@ func (d *SnmpDevice) measSeqGatherAndSend() {
 		//prepare batchpoint
 		metSent, metError, measSent, measError, points := m.GetInfluxPoint(d.TagMap)
 		d.stats.AddMeasStats(metSent, metError, measSent, measError)
+		d.updateLastValues(m)
 		if bpts != nil {
 			(*bpts).AddPoints(points)
 		}
@@ -0,0 +1,106 @@
+package device
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// gatherBuckets fits gather periods from 50ms up to ~3.4 minutes
+var gatherBuckets = prometheus.ExponentialBuckets(0.05, 2, 13)
+
+// Prometheus metrics of the devices, DevStat counters are reset on each gather
+// cycle so they are added to never reset counters when the cycle stats are sent
+var (
+	promGatherDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "snmpcollector",
+		Subsystem: "device",
+		Name:      "gather_duration_seconds",
+		Help:      "Time taken to gather all device measurements on each cycle.",
+		Buckets:   gatherBuckets,
+	}, []string{"device"})
+	promFilterDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "snmpcollector",
+		Subsystem: "device",
+		Name:      "filter_duration_seconds",
+		Help:      "Time taken to update the device measurement indexes and filters.",
+		Buckets:   gatherBuckets,
+	}, []string{"device"})
+	promSentDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "snmpcollector",
+		Subsystem: "device",
+		Name:      "backend_sent_duration_seconds",
+		Help:      "Time taken to queue the device batchpoints to the output database.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"device"})
+
+	promUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "snmpcollector",
+		Subsystem: "device",
+		Name:      "up",
+		Help:      "Device availability on the last gather cycle (1 up, 0 down).",
+	}, []string{"device"})
+	promProbeRTT = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "snmpcollector",
+		Subsystem: "device",
+		Name:      "probe_rtt_seconds",
+		Help:      "Response time of the last device connectivity probe.",
+	}, []string{"device"})
+
+	promCounters = map[DevStatType]*prometheus.CounterVec{
+		SnmpOIDGetAll:         newPromCounter("snmp_oid_get_all_total", "OID values gathered."),
+		SnmpOIDGetProcessed:   newPromCounter("snmp_oid_get_processed_total", "OID values matching the measurement filters."),
+		SnmpOIDGetErrors:      newPromCounter("snmp_oid_get_errors_total", "OID values with errors."),
+		MetricSent:            newPromCounter("metric_sent_total", "Measurement fields sent."),
+		MetricSentErrors:      newPromCounter("metric_sent_errors_total", "Measurement fields with errors."),
+		MeasurementSent:       newPromCounter("measurement_sent_total", "Measurement points sent."),
+		MeasurementSentErrors: newPromCounter("measurement_sent_errors_total", "Measurement points with errors."),
+	}
+)
+
+func newPromCounter(name string, help string) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "snmpcollector",
+		Subsystem: "device",
+		Name:      name,
+		Help:      help,
+	}, []string{"device"})
+}
+
+// PrometheusCollectors returns the device metrics to register
+func PrometheusCollectors() []prometheus.Collector {
+	c := []prometheus.Collector{promGatherDuration, promFilterDuration, promSentDuration, promUp, promProbeRTT}
+	for _, v := range promCounters {
+		c = append(c, v)
+	}
+	return c
+}
+
+// promAddCounters adds the gather cycle counters to the device metrics
+func promAddCounters(id string, counters []interface{}) {
+	for k, c := range promCounters {
+		if v, ok := counters[k].(int); ok {
+			c.WithLabelValues(id).Add(float64(v))
+		}
+	}
+}
+
+// promSetAvailability sets the device availability gauges
+func promSetAvailability(id string, up bool, rtt float64) {
+	v := 0.0
+	if up {
+		v = 1
+	}
+	promUp.WithLabelValues(id).Set(v)
+	promProbeRTT.WithLabelValues(id).Set(rtt)
+}
+
+// promDelete removes the device metrics
+func promDelete(id string) {
+	promGatherDuration.DeleteLabelValues(id)
+	promFilterDuration.DeleteLabelValues(id)
+	promSentDuration.DeleteLabelValues(id)
+	promUp.DeleteLabelValues(id)
+	promProbeRTT.DeleteLabelValues(id)
+	for _, c := range promCounters {
+		c.DeleteLabelValues(id)
+	}
+}
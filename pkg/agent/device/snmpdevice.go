@@ -13,6 +13,8 @@ import (
 	"github.com/gosnmp/gosnmp"
 	"github.com/sirupsen/logrus"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/bus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/cache"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/maint"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/selfmon"
 	"github.com/toni-moreno/snmpcollector/pkg/config"
@@ -22,12 +24,16 @@ import (
 )
 
 var (
-	cfg    *config.DBConfig
-	logDir string
+	cfg      *config.DBConfig
+	cfgMutex sync.RWMutex
+	logDir   string
 )
 
-// SetDBConfig set agent config
+// SetDBConfig set agent config, used only by the devices initialized after it
+// ( running devices keep the config they were initialized with )
 func SetDBConfig(c *config.DBConfig) {
+	cfgMutex.Lock()
+	defer cfgMutex.Unlock()
 	cfg = c
 }
 
@@ -38,8 +44,9 @@ func SetLogDir(l string) {
 
 // SnmpDevice contains all runtime device related device configu ns and state
 type SnmpDevice struct {
-	cfg *config.SnmpDeviceCfg
-	log *logrus.Logger
+	cfg   *config.SnmpDeviceCfg
+	dbcfg *config.DBConfig // agent config when the device was initialized
+	log   *logrus.Logger
 	//basic sistem info
 	SysInfo *snmp.SysInfo
 	//runtime built TagMap
@@ -68,10 +75,16 @@ type SnmpDevice struct {
 	DeviceActive    bool
 	DeviceConnected bool
 	StateDebug      bool
+	lastConnected   bool
 
 	Node      *bus.Node `json:"-"`
 	isStopped chan bool `json:"-"`
 
+	lastValues *cache.LastValues
+
+	maintWindows *maint.Windows
+	maint        *maint.State // active maintenance window
+
 	CurLogLevel     string
 	Gather          func()                                                              `json:"-"`
 	InitSnmpConnect func(mkey string, debug bool, maxrep uint8) (*gosnmp.GoSNMP, error) `json:"-"`
@@ -123,6 +136,7 @@ func (d *SnmpDevice) getBasicStats() *DevStat {
 	stat.DeviceActive = d.DeviceActive
 	stat.DeviceConnected = d.DeviceConnected
 	stat.NumMeasurements = len(d.Measurements)
+	stat.Maintenance = d.maint
 	stat.NumMetrics = sum
 	if d.SysInfo != nil {
 		stat.SysDescription = d.SysInfo.SysDescr
@@ -132,6 +146,26 @@ func (d *SnmpDevice) getBasicStats() *DevStat {
 	return stat
 }
 
+// updateStats refreshes the public stats copy and publishes the connection
+// state changes to the bus subscribers
+func (d *SnmpDevice) updateStats() *DevStat {
+	stats := d.getBasicStats()
+	d.statsData.Lock()
+	d.Stats = stats
+	d.statsData.Unlock()
+	if d.DeviceConnected != d.lastConnected {
+		d.lastConnected = d.DeviceConnected
+		if d.maint != nil {
+			d.Infof("Connection state change to [%t] not notified on maintenance window %s", d.DeviceConnected, d.maint.Window)
+		} else if d.DeviceConnected {
+			d.Node.Publish(bus.EventDeviceConnect, stats)
+		} else {
+			d.Node.Publish(bus.EventDeviceDisconnect, stats)
+		}
+	}
+	return stats
+}
+
 func (d *SnmpDevice) setReloadLoopsPending(val int) {
 	d.ReloadLoopsPending = val
 }
@@ -169,6 +203,16 @@ func (d *SnmpDevice) ForceGather() {
 	d.Node.SendMsg(&bus.Message{Type: "forcegather"})
 }
 
+// ForceGatherWait send message to force a data gather execution and waits until
+// finished, returns the device stats after the gather
+func (d *SnmpDevice) ForceGatherWait(timeout time.Duration) (*DevStat, error) {
+	r, err := d.Node.Request(&bus.Message{Type: "forcegather"}, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return r.(*DevStat), nil
+}
+
 // ForceFltUpdate send info to update the filter counter to the next execution
 func (d *SnmpDevice) ForceFltUpdate() {
 	d.Node.SendMsg(&bus.Message{Type: "filterupdate"})
@@ -233,7 +277,7 @@ func (d *SnmpDevice) InitDevMeasurements() {
 		//Selecting all Metric Groups that matches with device.MeasurementGroups
 		selGroups := make(map[string]*config.MGroupsCfg, 0)
 		//var RegExp = regexp.MustCompile(devMeas)
-		for key, val := range cfg.GetGroups {
+		for key, val := range d.dbcfg.GetGroups {
 			if key == devMeas {
 				selGroups[key] = val
 			}
@@ -255,7 +299,7 @@ func (d *SnmpDevice) InitDevMeasurements() {
 		d.Debugf("DEVICE MEASUREMENT: %s HOST: %s ", devMeas, d.cfg.Host)
 		for _, val := range selMeasUniq {
 			//check if measurement exist
-			if mVal, ok := cfg.Measurements[val]; !ok {
+			if mVal, ok := d.dbcfg.Measurements[val]; !ok {
 				d.Warnf("no measurement configured with name %s in host : %s", val, d.cfg.Host)
 			} else {
 				d.Debugf("MEASUREMENT CFG KEY: %s VALUE %s | Connection [%s] %+v", val, mVal.Name, val, d.snmpClientMap[mVal.ID])
@@ -283,7 +327,7 @@ func (d *SnmpDevice) InitDevMeasurements() {
 		var mfilter *config.MeasFilterCfg
 		for _, f := range d.cfg.MeasFilters {
 			//we search if exist in the filter Database
-			if filter, ok := cfg.MFilters[f]; ok {
+			if filter, ok := d.dbcfg.MFilters[f]; ok {
 				if filter.IDMeasurementCfg == m.ID {
 					mfilter = filter
 					break
@@ -329,6 +373,9 @@ func (d *SnmpDevice) Init(c *config.SnmpDeviceCfg) error {
 		return fmt.Errorf("Error on initialice device, configuration struct is nil")
 	}
 	d.cfg = c
+	cfgMutex.RLock()
+	d.dbcfg = cfg
+	cfgMutex.RUnlock()
 	d.isStopped = make(chan bool)
 	//log.Infof("Initializing device %s\n", d.cfg.ID)
 
@@ -410,9 +457,7 @@ func (d *SnmpDevice) Init(c *config.SnmpDeviceCfg) error {
 		d.Gather = d.measSeqGatherAndSend
 		d.InitSnmpConnect = d.initSnmpConnectSequential
 	}
-	d.statsData.Lock()
-	d.Stats = d.getBasicStats()
-	d.statsData.Unlock()
+	d.updateStats()
 	return nil
 }
 
@@ -471,6 +516,10 @@ func (d *SnmpDevice) LeaveBus(b *bus.Bus) {
 // End The Opposite of Init() uninitialize all variables
 func (d *SnmpDevice) End() {
 	d.Node.Close()
+	promDelete(d.cfg.ID)
+	if d.lastValues != nil {
+		d.lastValues.DeleteDevice(d.cfg.ID)
+	}
 	for _, val := range d.snmpClientMap {
 		snmp.Release(val)
 	}
@@ -484,6 +533,43 @@ func (d *SnmpDevice) SetSelfMonitoring(cfg *selfmon.SelfMon) {
 	d.stats.SetSelfMonitoring(cfg)
 }
 
+// SetLastValues set the store where the last gathered values are kept
+func (d *SnmpDevice) SetLastValues(lv *cache.LastValues) {
+	d.lastValues = lv
+}
+
+// SetMaintWindows set the maintenance windows checked on each gather cycle
+func (d *SnmpDevice) SetMaintWindows(w *maint.Windows) {
+	d.maintWindows = w
+}
+
+// checkMaintenance updates the device maintenance state publishing the window
+// start and end, returns true if the gathering is paused by the window
+func (d *SnmpDevice) checkMaintenance(now time.Time) bool {
+	var st *maint.State
+	if d.maintWindows != nil {
+		st = d.maintWindows.Check(d.cfg, now)
+	}
+	prev := d.maint
+	d.maint = st
+	switch {
+	case st != nil && (prev == nil || prev.Window != st.Window || !prev.End.Equal(st.End)):
+		d.Infof("Maintenance window %s [%s] from %s until %s", st.Window, st.Mode, st.Start, st.End)
+		d.Node.Publish(bus.EventMaintenanceStart, st)
+	case st == nil && prev != nil:
+		d.Infof("Maintenance window %s finished", prev.Window)
+		d.Node.Publish(bus.EventMaintenanceEnd, prev)
+	}
+	return st != nil && st.Mode == maint.ModePause
+}
+
+// updateLastValues stores the measurement values built on the last influx points
+func (d *SnmpDevice) updateLastValues(m *measurement.Measurement) {
+	if d.lastValues != nil {
+		d.lastValues.Update(d.cfg.ID, m.ID, m.MName, m.GetLastValues())
+	}
+}
+
 // initSnmpConnectConcurrent does the  SNMP client connection and retrieve system info
 func (d *SnmpDevice) initSnmpConnectConcurrent(mkey string, debug bool, maxrep uint8) (*gosnmp.GoSNMP, error) {
 	//this will never happen if previously snmpClientMap has been released
@@ -550,6 +636,53 @@ func (d *SnmpDevice) CheckDeviceConnectivity() {
 	}
 }
 
+// probeConnectivity measures the device response time with a sysUpTime get
+func (d *SnmpDevice) probeConnectivity() (time.Duration, error) {
+	client, ok := d.snmpClientMap["init"]
+	if !ok || client == nil {
+		for _, c := range d.snmpClientMap {
+			if c != nil {
+				client = c
+				break
+			}
+		}
+	}
+	if client == nil {
+		return 0, fmt.Errorf("no snmp connection available")
+	}
+	start := time.Now()
+	if _, err := client.Get([]string{".1.3.6.1.2.1.1.3.0"}); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// checkAvailability registers the device connection state at the end of the
+// gather cycle and publishes the availability changes
+func (d *SnmpDevice) checkAvailability() {
+	var rtt time.Duration
+	up := d.DeviceConnected
+	if up {
+		var err error
+		// a not responding device is down even if its connection has not been reset yet
+		if rtt, err = d.probeConnectivity(); err != nil {
+			d.Warnf("Error on availability probe: %s", err)
+			up = false
+		}
+	}
+	ev := d.stats.AddAvailabilityCheck(time.Now(), up, rtt)
+	d.stats.SendAvailability(d.maint != nil)
+	if ev == nil {
+		return
+	}
+	d.Infof("Availability change: up [%t] flapping [%t] after [%f] seconds", ev.Up, ev.Flapping, ev.Duration)
+	if d.maint != nil {
+		d.Infof("Availability change not notified on maintenance window %s", d.maint.Window)
+		return
+	}
+	d.Node.Publish(bus.EventDeviceAvailability, ev)
+}
+
 func (d *SnmpDevice) snmpRelease() {
 	for _, v := range d.snmpClientMap {
 		if v != nil {
@@ -615,10 +748,44 @@ func (d *SnmpDevice) snmpReset(debug bool, maxrep uint8) {
 	}
 }
 
-func (d *SnmpDevice) gatherAndProcessData(t *time.Ticker, force bool) *time.Ticker {
+// checkGatherOverrun registers the gather cycle and applies the device overrun
+// policy if it took longer than the current gather period, returns the ticker
+// to wait for the next cycle and true if it should begin immediately
+func (d *SnmpDevice) checkGatherOverrun(t *time.Ticker, start time.Time) (*time.Ticker, bool) {
+	elapsed := time.Since(start)
+	freq := time.Duration(d.Freq) * time.Second
+	if !d.stats.AddGatherCycle(start, elapsed, freq) {
+		return t, false
+	}
+	d.Warnf("Gather cycle overrun: took [%s] with a gather period of [%s], applying [%s] policy", elapsed, freq, d.cfg.OverrunPolicy)
+	//discard the tick missed while gathering
+	select {
+	case <-t.C:
+	default:
+	}
+	switch d.cfg.OverrunPolicy {
+	case "immediate":
+		return t, true
+	case "autoraise":
+		//raise to the next multiple of the configured period (until next reload)
+		d.Freq = d.cfg.Freq * (int(elapsed/(time.Duration(d.cfg.Freq)*time.Second)) + 1)
+		d.Warnf("Raising gather period to [%d] seconds", d.Freq)
+		t.Stop()
+		return time.NewTicker(time.Duration(d.Freq) * time.Second), false
+	default:
+		//skip: wait for the next tick
+		return t, false
+	}
+}
+
+func (d *SnmpDevice) gatherAndProcessData(t *time.Ticker, force bool) (*time.Ticker, bool) {
+	var rerun bool
+	var gathered bool
 	d.rtData.Lock()
+	//forced gathers are done even on paused maintenance windows
+	paused := d.checkMaintenance(time.Now()) && !force
 	//if active
-	if d.DeviceActive || force {
+	if (d.DeviceActive || force) && !paused {
 	FORCEINIT:
 		//check if device has active snmp connections and Initialize if not
 		if d.DeviceConnected == false {
@@ -636,9 +803,9 @@ func (d *SnmpDevice) gatherAndProcessData(t *time.Ticker, force bool) *time.Tick
 					// Round collection to nearest interval by sleeping
 					//and reprogram the ticker to aligned starts
 					// only when no extra gather(forced from web-ui)
-					utils.WaitAlignForNextCycle(d.cfg.Freq, d.log)
+					utils.WaitAlignForNextCycle(d.Freq, d.log)
 					t.Stop()
-					t = time.NewTicker(time.Duration(d.cfg.Freq) * time.Second)
+					t = time.NewTicker(time.Duration(d.Freq) * time.Second)
 					//force one iteration now..after device has been connected  dont wait for next
 					//ticker (1 complete cycle)
 				}
@@ -647,6 +814,7 @@ func (d *SnmpDevice) gatherAndProcessData(t *time.Ticker, force bool) *time.Tick
 		} else {
 			//device active and connected
 			d.Infof("Init gather cycle mode Concurrent [ %t ]", d.cfg.ConcurrentGather)
+			startCycle := time.Now()
 			/*************************
 			 *
 			 * SNMP Gather data process
@@ -655,6 +823,7 @@ func (d *SnmpDevice) gatherAndProcessData(t *time.Ticker, force bool) *time.Tick
 			d.invalidateMetrics()
 			d.stats.ResetCounters()
 			d.Gather()
+			gathered = true
 
 			/*******************************************
 			 *
@@ -678,6 +847,11 @@ func (d *SnmpDevice) gatherAndProcessData(t *time.Ticker, force bool) *time.Tick
 					}
 					if changed {
 						m.InitBuildRuntime()
+						d.Node.Publish(bus.EventFilterChanged, map[string]interface{}{
+							"Measurement": m.ID,
+							"Rows":        len(m.CurIndexedLabels),
+							"AllRows":     len(m.AllIndexedLabels),
+						})
 					}
 				}
 
@@ -689,17 +863,29 @@ func (d *SnmpDevice) gatherAndProcessData(t *time.Ticker, force bool) *time.Tick
 			d.CheckDeviceConnectivity()
 
 			d.stats.Send()
+			for _, m := range d.Measurements {
+				d.stats.SendMeasStats(m.ID, m.GetStatsFields())
+			}
+			//forced gathers are out of the periodic cycles
+			if !force {
+				t, rerun = d.checkGatherOverrun(t, startCycle)
+			}
 		}
+		d.checkAvailability()
+	} else if paused {
+		d.Infof("Gather process paused by maintenance window %s until %s", d.maint.Window, d.maint.End)
 	} else {
 		d.Infof("Gather process is disabled")
 	}
 	//get Ready a copy of the stats to
 
-	d.statsData.Lock()
-	d.Stats = d.getBasicStats()
-	d.statsData.Unlock()
+	stats := d.updateStats()
+	//only when new values have been gathered (not disconnected, paused or disabled)
+	if gathered {
+		d.Node.Publish(bus.EventGatherDone, stats)
+	}
 	d.rtData.Unlock()
-	return t
+	return t, rerun
 }
 
 // StartGather Main GoRutine method to begin snmp data collecting
@@ -726,15 +912,22 @@ func (d *SnmpDevice) startGatherGo(wg *sync.WaitGroup) {
 
 	d.Infof("Beginning gather process for device on host (%s)", d.cfg.Host)
 
-	t := time.NewTicker(time.Duration(d.cfg.Freq) * time.Second)
+	t := time.NewTicker(time.Duration(d.Freq) * time.Second)
+	//ready channel to begin the next cycle without waiting for the ticker
+	immediately := make(chan time.Time)
+	close(immediately)
 	for {
-
-		t = d.gatherAndProcessData(t, false)
+		var rerun bool
+		t, rerun = d.gatherAndProcessData(t, false)
+		next := t.C
+		if rerun {
+			next = immediately
+		}
 
 	LOOP:
 		for {
 			select {
-			case <-t.C:
+			case <-next:
 				break LOOP
 			case val := <-d.Node.Read:
 				d.Infof("Received Message...%s: %+v", val.Type, val.Data)
@@ -794,11 +987,9 @@ func (d *SnmpDevice) startGatherGo(wg *sync.WaitGroup) {
 					d.CurLogLevel = d.log.Level.String()
 					d.rtData.Unlock()
 				}
+				//Some online actions can change Stats
+				val.Reply(d.updateStats(), nil)
 			}
-			//Some online actions can change Stats
-			d.statsData.Lock()
-			d.Stats = d.getBasicStats()
-			d.statsData.Unlock()
 		}
 	}
 }
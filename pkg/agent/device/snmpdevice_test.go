@@ -0,0 +1,117 @@
+package device
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+func TestGatherOverrunPolicy(t *testing.T) {
+	tests := []struct {
+		policy   string
+		elapsed  time.Duration
+		rerun    bool
+		freq     int
+		overruns int64
+	}{
+		{"skip", 500 * time.Millisecond, false, 1, 0},
+		{"skip", 1500 * time.Millisecond, false, 1, 1},
+		{"immediate", 1500 * time.Millisecond, true, 1, 1},
+		{"autoraise", 2500 * time.Millisecond, false, 3, 1},
+	}
+	for _, tt := range tests {
+		d := &SnmpDevice{cfg: &config.SnmpDeviceCfg{ID: "dev1", Freq: 1, OverrunPolicy: tt.policy}, Freq: 1, log: logrus.New()}
+		d.stats.Init("dev1", nil, d.log)
+		ticker := time.NewTicker(time.Second)
+		next, rerun := d.checkGatherOverrun(ticker, time.Now().Add(-tt.elapsed))
+		next.Stop()
+		ticker.Stop()
+		if rerun != tt.rerun || d.Freq != tt.freq || d.stats.GatherOverruns != tt.overruns {
+			t.Errorf("%s policy after %s: got rerun %t, freq %d, overruns %d", tt.policy, tt.elapsed, rerun, d.Freq, d.stats.GatherOverruns)
+		}
+	}
+}
+
+func TestChronicOverrun(t *testing.T) {
+	s := &DevStat{}
+	s.Init("dev1", nil, logrus.New())
+	start := time.Now()
+	for i := 0; i < OverrunWindow; i++ {
+		// overrun on even cycles
+		s.AddGatherCycle(start, time.Duration(i%2+1)*time.Second, 1500*time.Millisecond)
+	}
+	if s.GatherOverruns != 5 || s.RecentOverruns != 5 || !s.ChronicOverrun {
+		t.Errorf("expected chronic overrun: %+v", s.ThSafeCopy())
+	}
+	for i := 0; i < OverrunWindow; i++ {
+		s.AddGatherCycle(start, time.Second, 1500*time.Millisecond)
+	}
+	if s.GatherOverruns != 5 || s.RecentOverruns != 0 || s.ChronicOverrun || s.LastOverrunDuration != 2 {
+		t.Errorf("expected recovered device keeping the last overrun: %+v", s.ThSafeCopy())
+	}
+}
+
+func TestAvailabilityFlapping(t *testing.T) {
+	s := &DevStat{}
+	s.Init("dev1", nil, logrus.New())
+	start := time.Now().Add(-time.Hour)
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Minute) }
+
+	if ev := s.AddAvailabilityCheck(at(0), true, 20*time.Millisecond); ev != nil || s.LastRTT != 0.02 {
+		t.Errorf("unexpected event on first check %+v (%+v)", ev, s.ThSafeCopy())
+	}
+	ev := s.AddAvailabilityCheck(at(2), false, 0)
+	if ev == nil || ev.Up || ev.Duration != 120 || ev.Flapping || s.ConsecutiveFailures != 1 || !s.StateSince.Equal(at(2)) {
+		t.Fatalf("expected down event %+v (%+v)", ev, s.ThSafeCopy())
+	}
+	if ev = s.AddAvailabilityCheck(at(3), false, 0); ev != nil || s.ConsecutiveFailures != 2 {
+		t.Errorf("unexpected event without state change %+v", ev)
+	}
+	// up, down, up: flapping starts on the fourth state change
+	for i, up := range []bool{true, false} {
+		if ev = s.AddAvailabilityCheck(at(4+i), up, time.Millisecond); ev == nil || ev.Up != up || ev.Flapping {
+			t.Errorf("expected state %t event %+v", up, ev)
+		}
+	}
+	if ev = s.AddAvailabilityCheck(at(6), true, time.Millisecond); ev == nil || !ev.Flapping || !ev.Up {
+		t.Errorf("expected flapping start event %+v", ev)
+	}
+	// suppressed while flapping
+	if ev = s.AddAvailabilityCheck(at(7), false, 0); ev != nil || s.StateChanges != 5 {
+		t.Errorf("state change notified while flapping %+v", ev)
+	}
+	// stable until the state changes leave the flap window
+	i := 8
+	for ; ev == nil && i < 8+FlapWindow; i++ {
+		ev = s.AddAvailabilityCheck(at(i), false, 0)
+	}
+	if ev == nil || ev.Flapping || ev.Up || i != 7+FlapWindow {
+		t.Errorf("expected flapping end event after %d checks %+v", i-8, ev)
+	}
+	if len(s.StateHistory) != 5 || s.StateHistory[4].Up || s.StateHistory[4].Duration != 60 {
+		t.Errorf("unexpected state history %+v", s.StateHistory)
+	}
+
+	data, err := json.Marshal(s.ThSafeCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res map[string]interface{}
+	json.Unmarshal(data, &res)
+	if d, ok := res["StateDuration"].(float64); !ok || d <= 0 || res["StateChanges"] != 5.0 {
+		t.Errorf("unexpected state duration on device stats JSON %s", data)
+	}
+}
+
+func TestAvailabilityProbeFailure(t *testing.T) {
+	// connected device without any snmp client answering the probe
+	d := &SnmpDevice{cfg: &config.SnmpDeviceCfg{ID: "dev1"}, DeviceConnected: true, log: logrus.New()}
+	d.stats.Init("dev1", nil, d.log)
+	d.checkAvailability()
+	if d.stats.up || d.stats.ConsecutiveFailures != 1 || d.stats.LastRTT != 0 {
+		t.Errorf("failed probe not registered as a down check: %+v", d.stats.ThSafeCopy())
+	}
+}
@@ -1,10 +1,12 @@
 package device
 
 import (
+	"encoding/json"
 	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/maint"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/selfmon"
 )
 
@@ -79,8 +81,59 @@ type DevStat struct {
 	NumMeasurements int
 	SysDescription  string
 	NumMetrics      int
+	//gather cycle overruns (cycles taking longer than the device Freq)
+	GatherOverruns      int64     // overruns since the device start
+	LastOverrun         time.Time // start time of the last overrun cycle
+	LastOverrunDuration float64   // seconds taken by the last overrun cycle
+	RecentOverruns      int       // overruns on the last OverrunWindow cycles
+	ChronicOverrun      bool      // RecentOverruns reached ChronicOverrunThreshold
+	recentCycles        []bool
+	//availability (device connection state checked at the end of each gather cycle)
+	StateSince          time.Time      // last up/down state change (or first check)
+	StateChanges        int            // up/down state changes since the device start
+	ConsecutiveFailures int            // down checks since the last up one
+	LastRTT             float64        // seconds taken by the last connectivity probe
+	Flapping            bool           // state changes on the last FlapWindow checks reached FlapStartThreshold
+	StateHistory        []*StateChange // last StateHistorySize state changes
+	Maintenance         *maint.State   // active maintenance window, nil if none
+	up                  bool
+	availChecked        bool
+	recentChanges       []bool
 }
 
+// StateChange is an up/down state change of the device
+type StateChange struct {
+	Time     time.Time
+	Up       bool
+	Duration float64 // seconds on the previous state
+}
+
+// AvailabilityEvent is the device availability change notified to the bus subscribers
+type AvailabilityEvent struct {
+	Time                time.Time
+	Up                  bool
+	Flapping            bool
+	Duration            float64 // seconds on the previous state
+	ConsecutiveFailures int
+	StateChanges        int
+	RTT                 float64
+}
+
+const (
+	// OverrunWindow number of last gather cycles checked for chronic overruns
+	OverrunWindow = 10
+	// ChronicOverrunThreshold overruns on the last OverrunWindow cycles to mark the device as chronic overrunning
+	ChronicOverrunThreshold = 5
+	// FlapWindow number of last availability checks where the state changes are counted
+	FlapWindow = 10
+	// FlapStartThreshold state changes on the last FlapWindow checks to mark the device as flapping
+	FlapStartThreshold = 4
+	// FlapStopThreshold state changes on the last FlapWindow checks to unmark a flapping device
+	FlapStopThreshold = 1
+	// StateHistorySize number of last state changes kept
+	StateHistorySize = 20
+)
+
 // Init initializes the device stat object
 func (s *DevStat) Init(id string, tm map[string]string, l *logrus.Logger) {
 	s.mutex.Lock()
@@ -177,9 +230,34 @@ func (s *DevStat) ThSafeCopy() *DevStat {
 	for k, v := range s.Counters {
 		st.Counters[k] = v
 	}
+	st.GatherOverruns = s.GatherOverruns
+	st.LastOverrun = s.LastOverrun
+	st.LastOverrunDuration = s.LastOverrunDuration
+	st.RecentOverruns = s.RecentOverruns
+	st.ChronicOverrun = s.ChronicOverrun
+	st.StateSince = s.StateSince
+	st.StateChanges = s.StateChanges
+	st.ConsecutiveFailures = s.ConsecutiveFailures
+	st.LastRTT = s.LastRTT
+	st.Flapping = s.Flapping
+	st.StateHistory = append([]*StateChange(nil), s.StateHistory...)
 	return st
 }
 
+// MarshalJSON adds the seconds since the last up/down state change
+func (s *DevStat) MarshalJSON() ([]byte, error) {
+	type devStat DevStat
+	var duration float64
+	if !s.StateSince.IsZero() {
+		duration = time.Since(s.StateSince).Seconds()
+	}
+	return json.Marshal(&struct {
+		ID string
+		*devStat
+		StateDuration float64
+	}{s.id, (*devStat)(s), duration})
+}
+
 // Send send data to the selfmon device
 func (s *DevStat) Send() {
 	s.mutex.Lock()
@@ -187,11 +265,21 @@ func (s *DevStat) Send() {
 	s.log.Infof("STATS SNMP GET: snmp polling took [%f seconds] SNMP: Gets [%d] , Processed [%d], Errors [%d]", s.Counters[CycleGatherDuration], s.Counters[SnmpOIDGetAll], s.Counters[SnmpOIDGetProcessed], s.Counters[SnmpOIDGetErrors])
 	s.log.Infof("STATS SNMP FILTER: filter polling took [%f seconds] ", s.Counters[FilterDuration])
 	s.log.Infof("STATS INFLUX: influx send took [%f seconds]", s.Counters[BackEndSentDuration])
+	promAddCounters(s.id, s.Counters)
 	if s.selfmon != nil {
 		s.selfmon.AddDeviceMetrics(s.id, s.getMetricFields(), s.TagMap)
 	}
 }
 
+// SendMeasStats send the measurement stats to the selfmon device
+func (s *DevStat) SendMeasStats(measid string, fields map[string]interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.selfmon != nil {
+		s.selfmon.AddMeasurementMetrics(s.id, measid, fields, s.TagMap)
+	}
+}
+
 // ResetCounters initialize metric counters
 func (s *DevStat) ResetCounters() {
 	s.mutex.Lock()
@@ -225,12 +313,129 @@ func (s *DevStat) UpdateSnmpGetStats(g int64, p int64, e int64) {
 	s.Counters[SnmpOIDGetErrors] = s.Counters[SnmpOIDGetErrors].(int) + int(e)
 }
 
+// AddGatherCycle registers a complete gather cycle and returns true if it took
+// longer than the device gather period (an overrun)
+func (s *DevStat) AddGatherCycle(start time.Time, duration time.Duration, freq time.Duration) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	overrun := duration > freq
+	if overrun {
+		s.GatherOverruns++
+		s.LastOverrun = start
+		s.LastOverrunDuration = duration.Seconds()
+	}
+	s.recentCycles = append(s.recentCycles, overrun)
+	if len(s.recentCycles) > OverrunWindow {
+		s.recentCycles = s.recentCycles[1:]
+	}
+	s.RecentOverruns = 0
+	for _, o := range s.recentCycles {
+		if o {
+			s.RecentOverruns++
+		}
+	}
+	s.ChronicOverrun = s.RecentOverruns >= ChronicOverrunThreshold
+	return overrun
+}
+
+// AddAvailabilityCheck registers the device state at the end of a gather cycle
+// and returns the availability event to notify, if any. State changes are not
+// notified while the device is flapping, only when the flapping begins and ends
+func (s *DevStat) AddAvailabilityCheck(now time.Time, up bool, rtt time.Duration) *AvailabilityEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.availChecked {
+		s.availChecked = true
+		s.up = up
+		s.StateSince = now
+	}
+	changed := up != s.up
+	s.up = up
+	s.LastRTT = 0
+	if up {
+		s.ConsecutiveFailures = 0
+		s.LastRTT = rtt.Seconds()
+	} else {
+		s.ConsecutiveFailures++
+	}
+	var duration float64
+	if changed {
+		duration = now.Sub(s.StateSince).Seconds()
+		s.StateChanges++
+		s.StateSince = now
+		s.StateHistory = append(s.StateHistory, &StateChange{Time: now, Up: up, Duration: duration})
+		if len(s.StateHistory) > StateHistorySize {
+			s.StateHistory = s.StateHistory[1:]
+		}
+	}
+	s.recentChanges = append(s.recentChanges, changed)
+	if len(s.recentChanges) > FlapWindow {
+		s.recentChanges = s.recentChanges[1:]
+	}
+	recent := 0
+	for _, c := range s.recentChanges {
+		if c {
+			recent++
+		}
+	}
+	wasFlapping := s.Flapping
+	if !s.Flapping && recent >= FlapStartThreshold {
+		s.Flapping = true
+	} else if s.Flapping && recent <= FlapStopThreshold {
+		s.Flapping = false
+	}
+	promSetAvailability(s.id, up, s.LastRTT)
+	if wasFlapping == s.Flapping && (!changed || s.Flapping) {
+		return nil
+	}
+	return &AvailabilityEvent{
+		Time:                now,
+		Up:                  up,
+		Flapping:            s.Flapping,
+		Duration:            duration,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		StateChanges:        s.StateChanges,
+		RTT:                 s.LastRTT,
+	}
+}
+
+// SendAvailability send the availability state to the selfmon device, marked
+// if the device is on a maintenance window
+func (s *DevStat) SendAvailability(maintenance bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.selfmon == nil {
+		return
+	}
+	up, flapping, inmaint := 0, 0, 0
+	if s.up {
+		up = 1
+	}
+	if s.Flapping {
+		flapping = 1
+	}
+	if maintenance {
+		inmaint = 1
+	}
+	fields := map[string]interface{}{
+		"up":                   up,
+		"rtt":                  s.LastRTT,
+		"consecutive_failures": s.ConsecutiveFailures,
+		"state_changes":        s.StateChanges,
+		"state_duration":       time.Since(s.StateSince).Seconds(),
+		"flapping":             flapping,
+		"maintenance":          inmaint,
+	}
+	s.selfmon.AddAvailabilityMetrics(s.id, fields, s.TagMap)
+}
+
 // SetGatherDuration Update Gather Duration stats
 func (s *DevStat) SetGatherDuration(start time.Time, duration time.Duration) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	s.Counters[CycleGatherStartTime] = start.Unix()
 	s.Counters[CycleGatherDuration] = duration.Seconds()
+	promGatherDuration.WithLabelValues(s.id).Observe(duration.Seconds())
 }
 
 // AddSentDuration Update Sent Duration stats
@@ -242,6 +447,7 @@ func (s *DevStat) AddSentDuration(start time.Time, duration time.Duration) {
 		s.Counters[BackEndSentStartTime] = start.Unix()
 	}
 	s.Counters[BackEndSentDuration] = s.Counters[BackEndSentDuration].(float64) + duration.Seconds()
+	promSentDuration.WithLabelValues(s.id).Observe(duration.Seconds())
 }
 
 // SetFltUpdateStats Set Filter Stats
@@ -250,4 +456,5 @@ func (s *DevStat) SetFltUpdateStats(start time.Time, duration time.Duration) {
 	defer s.mutex.Unlock()
 	s.Counters[FilterStartTime] = start.Unix()
 	s.Counters[FilterDuration] = duration.Seconds()
+	promFilterDuration.WithLabelValues(s.id).Observe(duration.Seconds())
 }
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// OutputHealth is the sender state of an output database in use
+type OutputHealth struct {
+	Started    bool
+	BufferUsed int
+	BufferSize int
+}
+
+// Health is the agent state used by the liveness and readiness probes
+type Health struct {
+	Live             bool
+	Ready            bool
+	Reloading        bool
+	DatabaseError    string `json:",omitempty"`
+	Outputs          map[string]*OutputHealth
+	DevicesTotal     int
+	DevicesConnected int
+	Problems         []string `json:",omitempty"`
+}
+
+// GetHealth checks the agent state: it is live while the configuration database
+// is reachable and ready if not reloading, the outputs in use are sending without
+// exceeding the buffer fill threshold and enough devices are connected
+func GetHealth(cfg *config.HealthConfig) *Health {
+	h := &Health{Outputs: make(map[string]*OutputHealth)}
+	if CheckReloadProcess() {
+		h.Reloading = true
+		h.Live = true
+		h.Problems = append(h.Problems, "configuration reload in progress")
+		return h
+	}
+	if err := MainConfig.Database.Ping(); err != nil {
+		h.DatabaseError = err.Error()
+		h.Problems = append(h.Problems, fmt.Sprintf("configuration database unreachable: %s", err))
+		return h
+	}
+	h.Live = true
+	h.Ready = true
+
+	mutex.RLock()
+	for id, db := range influxdb {
+		if !db.IsInitialized() {
+			continue
+		}
+		o := &OutputHealth{Started: db.IsStarted()}
+		o.BufferUsed, o.BufferSize = db.BufferFill()
+		h.Outputs[id] = o
+		if !o.Started {
+			h.Ready = false
+			h.Problems = append(h.Problems, fmt.Sprintf("output %s sender not started", id))
+			continue
+		}
+		if cfg.MaxBufferFill > 0 && o.BufferSize > 0 && float64(o.BufferUsed)*100/float64(o.BufferSize) > cfg.MaxBufferFill {
+			h.Ready = false
+			h.Problems = append(h.Problems, fmt.Sprintf("output %s buffer fill %d/%d over %g%%", id, o.BufferUsed, o.BufferSize, cfg.MaxBufferFill))
+		}
+	}
+	for _, d := range devices {
+		h.DevicesTotal++
+		if d.GetBasicStats().DeviceConnected {
+			h.DevicesConnected++
+		}
+	}
+	mutex.RUnlock()
+
+	if cfg.MinDevicesConnected > 0 && h.DevicesTotal > 0 {
+		if share := float64(h.DevicesConnected) * 100 / float64(h.DevicesTotal); share < cfg.MinDevicesConnected {
+			h.Ready = false
+			h.Problems = append(h.Problems, fmt.Sprintf("only %d of %d devices connected, under %g%%", h.DevicesConnected, h.DevicesTotal, cfg.MinDevicesConnected))
+		}
+	}
+	return h
+}
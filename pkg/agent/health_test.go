@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+func TestGetHealth(t *testing.T) {
+	SetLogger(logrus.New())
+	config.SetLogger(logrus.New())
+	cfg := &config.HealthConfig{MinDevicesConnected: 50}
+
+	MainConfig.Database = config.DatabaseCfg{}
+	if h := GetHealth(cfg); h.Live || h.Ready || len(h.DatabaseError) == 0 {
+		t.Errorf("expected not live without database: %+v", h)
+	}
+
+	dir, err := ioutil.TempDir("", "snmpcollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetDirs(dir, dir, dir)
+	MainConfig.Database = config.DatabaseCfg{Type: "sqlite3", Name: "snmpcollector"}
+	MainConfig.Database.InitDB()
+
+	// unused outputs are not checked
+	influxdb = map[string]*output.InfluxDB{
+		"default": output.DummyDB,
+		"influx1": output.NewNotInitInfluxDB(&config.InfluxCfg{ID: "influx1"}),
+	}
+	devices = map[string]*device.SnmpDevice{
+		"dev1": {Stats: &device.DevStat{DeviceConnected: true}},
+		"dev2": {Stats: &device.DevStat{DeviceConnected: false}},
+	}
+	defer func() { devices, influxdb = nil, nil }()
+	if h := GetHealth(cfg); !h.Live || !h.Ready || len(h.Outputs) != 0 || h.DevicesConnected != 1 || h.DevicesTotal != 2 {
+		t.Errorf("expected ready: %+v", h)
+	}
+
+	devices["dev3"] = &device.SnmpDevice{Stats: &device.DevStat{}}
+	if h := GetHealth(cfg); !h.Live || h.Ready || len(h.Problems) != 1 {
+		t.Errorf("expected not ready with 1 of 3 devices connected: %+v", h)
+	}
+
+	CheckAndSetReloadProcess()
+	defer CheckAndUnSetReloadProcess()
+	if h := GetHealth(cfg); !h.Live || h.Ready || !h.Reloading {
+		t.Errorf("expected not ready while reloading: %+v", h)
+	}
+}
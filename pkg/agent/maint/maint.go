@@ -0,0 +1,225 @@
+package maint
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+var (
+	log *logrus.Logger
+)
+
+// SetLogger sets the current log output.
+func SetLogger(l *logrus.Logger) {
+	log = l
+}
+
+// Maintenance window modes
+const (
+	ModePause = "pause" // gathering paused while the window is active
+	ModeMark  = "mark"  // gathering goes on, the device state and availability points are marked
+)
+
+// State is the maintenance window active on a device
+type State struct {
+	Window string
+	Mode   string
+	Start  time.Time
+	End    time.Time
+}
+
+// WindowInfo is the runtime state of a maintenance window
+type WindowInfo struct {
+	ID      string
+	Mode    string
+	Active  bool      // active now
+	Start   time.Time // current ( or next ) window start, zero if there is no more windows
+	End     time.Time
+	Devices []string // selected devices
+}
+
+// window is the checked window config
+type window struct {
+	cfg   *config.MaintWindowCfg
+	sched cron.Schedule
+	tags  map[string]string
+}
+
+func newWindow(c *config.MaintWindowCfg) (*window, error) {
+	if err := c.Init(); err != nil {
+		return nil, err
+	}
+	sched, _ := c.GetSchedule()
+	tags, _ := c.GetTags()
+	return &window{cfg: c, sched: sched, tags: tags}, nil
+}
+
+func match(value string, pattern string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(value, strings.TrimSuffix(pattern, "*"))
+	}
+	return value == pattern
+}
+
+// matchDevice checks if the device is selected by its ID, its extra tags ( all of
+// the window tags should match ) or its measurement groups
+func (w *window) matchDevice(dev *config.SnmpDeviceCfg) bool {
+	for _, p := range w.cfg.GetDevices() {
+		if match(dev.ID, p) {
+			return true
+		}
+	}
+	if len(w.tags) > 0 {
+		devtags := make(map[string]string)
+		for _, t := range dev.ExtraTags {
+			if kv := strings.SplitN(t, "=", 2); len(kv) == 2 {
+				devtags[kv[0]] = kv[1]
+			}
+		}
+		matched := true
+		for k, p := range w.tags {
+			if v, ok := devtags[k]; !ok || !match(v, p) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	for _, g := range w.cfg.GetGroups() {
+		for _, dg := range dev.MeasurementGroups {
+			if dg == g {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// occurrence returns the window occurrence active at the time, or the next one,
+// ok is false if there is no more occurrences
+func (w *window) occurrence(now time.Time) (start time.Time, end time.Time, ok bool) {
+	c := w.cfg
+	if w.sched == nil {
+		return c.Start, c.End, now.Before(c.End)
+	}
+	d := time.Duration(c.Duration) * time.Second
+	from := now.Add(-d)
+	if !c.Start.IsZero() && from.Before(c.Start) {
+		// schedule activations are after the given time
+		from = c.Start.Add(-time.Second)
+	}
+	start = w.sched.Next(from)
+	if start.IsZero() {
+		return start, start, false
+	}
+	end = start.Add(d)
+	if !c.End.IsZero() {
+		if !start.Before(c.End) {
+			return start, end, false
+		}
+		if end.After(c.End) {
+			end = c.End
+		}
+	}
+	return start, end, true
+}
+
+// activeAt returns the window occurrence active at the time, if any
+func (w *window) activeAt(now time.Time) (time.Time, time.Time, bool) {
+	start, end, ok := w.occurrence(now)
+	return start, end, ok && !start.After(now) && now.Before(end)
+}
+
+// Windows is the set of active maintenance windows
+type Windows struct {
+	mutex   sync.RWMutex
+	windows []*window
+}
+
+// New creates an empty maintenance windows set
+func New() *Windows {
+	return &Windows{}
+}
+
+// Load sets the active windows from the configuration
+func (ws *Windows) Load(cfg map[string]*config.MaintWindowCfg) {
+	ids := make([]string, 0, len(cfg))
+	for id := range cfg {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var windows []*window
+	for _, id := range ids {
+		if !cfg[id].Active {
+			continue
+		}
+		w, err := newWindow(cfg[id])
+		if err != nil {
+			log.Errorf("MAINTENANCE: window %s disabled: %s", id, err)
+			continue
+		}
+		windows = append(windows, w)
+	}
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	ws.windows = windows
+	log.Infof("MAINTENANCE: loaded %d active windows", len(windows))
+}
+
+// Check returns the maintenance window active on the device, nil if none. Pause
+// windows take precedence over mark ones, and then the one ending later
+func (ws *Windows) Check(dev *config.SnmpDeviceCfg, now time.Time) *State {
+	ws.mutex.RLock()
+	defer ws.mutex.RUnlock()
+	var st *State
+	for _, w := range ws.windows {
+		start, end, ok := w.activeAt(now)
+		if !ok || !w.matchDevice(dev) {
+			continue
+		}
+		if st != nil {
+			if st.Mode == ModePause && w.cfg.Mode != ModePause {
+				continue
+			}
+			if st.Mode == w.cfg.Mode && !end.After(st.End) {
+				continue
+			}
+		}
+		st = &State{Window: w.cfg.ID, Mode: w.cfg.Mode, Start: start, End: end}
+	}
+	return st
+}
+
+// GetInfo returns the state of all windows with the devices they select
+func (ws *Windows) GetInfo(devices map[string]*config.SnmpDeviceCfg, now time.Time) []*WindowInfo {
+	ids := make([]string, 0, len(devices))
+	for id := range devices {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	ws.mutex.RLock()
+	defer ws.mutex.RUnlock()
+	info := make([]*WindowInfo, 0, len(ws.windows))
+	for _, w := range ws.windows {
+		wi := &WindowInfo{ID: w.cfg.ID, Mode: w.cfg.Mode, Devices: []string{}}
+		if start, end, ok := w.occurrence(now); ok {
+			wi.Start, wi.End = start, end
+			wi.Active = !start.After(now)
+		}
+		for _, id := range ids {
+			if w.matchDevice(devices[id]) {
+				wi.Devices = append(wi.Devices, id)
+			}
+		}
+		info = append(info, wi)
+	}
+	return info
+}
@@ -0,0 +1,79 @@
+package maint
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+func TestWindows(t *testing.T) {
+	SetLogger(logrus.New())
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC) // monday
+
+	ws := New()
+	ws.Load(map[string]*config.MaintWindowCfg{
+		// one-off upgrade of the core switches
+		"upgrade": {ID: "upgrade", Active: true, Mode: ModePause, Devices: "core*",
+			Start: day.Add(10 * time.Hour), End: day.Add(12 * time.Hour)},
+		// nightly backups at 02:00 during one hour on the dc1 site, only in march
+		"backup": {ID: "backup", Active: true, Mode: ModeMark, Tags: "site=dc1", Cron: "0 2 * * *", Duration: 3600,
+			Start: day, End: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		"storage": {ID: "storage", Active: true, Mode: ModeMark, Groups: "storage", Cron: "30 1 * * *", Duration: 7200},
+		"disabled": {ID: "disabled", Active: false, Mode: ModePause, Devices: "*",
+			Start: day, End: day.Add(24 * time.Hour)},
+		"invalid": {ID: "invalid", Active: true, Mode: ModePause, Devices: "*", Cron: "bad"},
+	})
+
+	core := &config.SnmpDeviceCfg{ID: "core01", ExtraTags: []string{"site=dc1"}}
+	nas := &config.SnmpDeviceCfg{ID: "nas01", ExtraTags: []string{"site=dc2"}, MeasurementGroups: []string{"storage"}}
+	edge := &config.SnmpDeviceCfg{ID: "edge01", ExtraTags: []string{"site=dc2"}}
+
+	for _, c := range []struct {
+		dev    *config.SnmpDeviceCfg
+		now    time.Time
+		window string
+		end    time.Time
+	}{
+		{core, day.Add(9 * time.Hour), "", time.Time{}},
+		{core, day.Add(10 * time.Hour), "upgrade", day.Add(12 * time.Hour)},
+		{core, day.Add(12 * time.Hour), "", time.Time{}},
+		{core, day.Add(26*time.Hour + 59*time.Minute), "backup", day.Add(27 * time.Hour)},
+		{core, day.Add(27 * time.Hour), "", time.Time{}},
+		{core, time.Date(2026, 4, 1, 2, 30, 0, 0, time.UTC), "", time.Time{}},
+		{nas, day.Add(2 * time.Hour), "storage", day.Add(3*time.Hour + 30*time.Minute)},
+		{edge, day.Add(2 * time.Hour), "", time.Time{}},
+	} {
+		st := ws.Check(c.dev, c.now)
+		if c.window == "" {
+			if st != nil {
+				t.Errorf("%s at %s: got window %s, want none", c.dev.ID, c.now, st.Window)
+			}
+			continue
+		}
+		if st == nil || st.Window != c.window || !st.End.Equal(c.end) {
+			t.Errorf("%s at %s: got %+v, want window %s ending at %s", c.dev.ID, c.now, st, c.window, c.end)
+		}
+	}
+
+	// pause windows take precedence over mark ones
+	ws.Load(map[string]*config.MaintWindowCfg{
+		"long":  {ID: "long", Active: true, Mode: ModeMark, Devices: "core01", Start: day, End: day.Add(48 * time.Hour)},
+		"short": {ID: "short", Active: true, Mode: ModePause, Devices: "core01", Start: day, End: day.Add(time.Hour)},
+	})
+	if st := ws.Check(core, day.Add(time.Minute)); st == nil || st.Window != "short" || st.Mode != ModePause {
+		t.Errorf("got %+v, want pause window short", st)
+	}
+	if st := ws.Check(core, day.Add(2*time.Hour)); st == nil || st.Window != "long" {
+		t.Errorf("got %+v, want mark window long", st)
+	}
+
+	info := ws.GetInfo(map[string]*config.SnmpDeviceCfg{"core01": core, "nas01": nas}, day.Add(2*time.Hour))
+	if len(info) != 2 || info[0].ID != "long" || !info[0].Active || info[1].Active || !info[1].Start.IsZero() {
+		t.Fatalf("got unexpected windows info %+v", info)
+	}
+	if len(info[0].Devices) != 1 || info[0].Devices[0] != "core01" {
+		t.Errorf("got window devices %v, want [core01]", info[0].Devices)
+	}
+}
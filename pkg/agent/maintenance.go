@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent/maint"
+)
+
+// MaintWindows is the set of maintenance windows checked by the devices on each gather cycle
+var MaintWindows = maint.New()
+
+// deviceInMaintenance checks if the running device is on a maintenance window
+func deviceInMaintenance(id string) bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if dev, ok := devices[id]; ok {
+		st := dev.GetBasicStats()
+		return st != nil && st.Maintenance != nil
+	}
+	return false
+}
+
+// GetMaintWindows returns the state of the active maintenance windows and the devices they select
+func GetMaintWindows() []*maint.WindowInfo {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return MaintWindows.GetInfo(DBConfig.SnmpDevice, time.Now())
+}
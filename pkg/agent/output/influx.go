@@ -9,12 +9,14 @@ import (
 
 	"github.com/influxdata/influxdb/client/v2"
 	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/bus"
 	"github.com/toni-moreno/snmpcollector/pkg/config"
 	"github.com/toni-moreno/snmpcollector/pkg/data/utils"
 )
 
 var (
-	log *logrus.Logger
+	log   *logrus.Logger
+	evBus *bus.Bus
 )
 
 // SetLogger adds a logger to this module
@@ -22,6 +24,11 @@ func SetLogger(l *logrus.Logger) {
 	log = l
 }
 
+// SetBus sets the bus where the output write errors are published
+func SetBus(b *bus.Bus) {
+	evBus = b
+}
+
 /*InfluxDB database export */
 type InfluxDB struct {
 	cfg         *config.InfluxCfg
@@ -182,6 +189,21 @@ func (db *InfluxDB) CheckAndUnSetInitialized() bool {
 	return retval
 }
 
+// IsInitialized check if the output has been initialized ( used by any device )
+func (db *InfluxDB) IsInitialized() bool {
+	db.imutex.Lock()
+	defer db.imutex.Unlock()
+	return db.initialized
+}
+
+// BufferFill returns the number of batchpoints waiting on the sender buffer and its size
+func (db *InfluxDB) BufferFill() (int, int) {
+	if db.dummy == true || !db.IsInitialized() {
+		return 0, 0
+	}
+	return len(db.iChan), cap(db.iChan)
+}
+
 // NewNotInitInfluxDB Create Object in memory but not initialized until ready connection needed
 func NewNotInitInfluxDB(c *config.InfluxCfg) *InfluxDB {
 	return &InfluxDB{
@@ -296,9 +318,17 @@ func (db *InfluxDB) sendBatchPoint(data *client.BatchPoints, enqueueonerror bool
 	elapsedSend := time.Since(startSend)
 
 	bufferPercent = (float32(len(db.iChan)) * 100.0) / float32(db.cfg.BufferSize)
+	promWriteUpdate(db.cfg.ID, int64(np), int64(nf), elapsedSend, err)
 	if err != nil {
 		db.stats.WriteErrUpdate(elapsedSend, bufferPercent)
 		log.Errorf("ERROR on Write batchPoint in DB %s (%d points) | elapsed : %s | Error: %s ", db.cfg.ID, np, elapsedSend.String(), err)
+		if evBus != nil {
+			evBus.Publish(&bus.Event{Type: bus.EventOutputError, Data: map[string]interface{}{
+				"OutDB":  db.cfg.ID,
+				"Points": np,
+				"Error":  err.Error(),
+			}})
+		}
 		// If the queue is not full we will resend after a while
 		if enqueueonerror {
 			log.Debug("queing data again...")
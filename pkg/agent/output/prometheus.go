@@ -0,0 +1,59 @@
+package output
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus metrics of the output databases, unlike InfluxStats they are never reset
+var (
+	promWrites = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "snmpcollector",
+		Subsystem: "output",
+		Name:      "writes_total",
+		Help:      "Batchpoints written to the output database.",
+	}, []string{"outdb"})
+	promWriteErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "snmpcollector",
+		Subsystem: "output",
+		Name:      "write_errors_total",
+		Help:      "Batchpoints with errors on write to the output database.",
+	}, []string{"outdb"})
+	promPointsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "snmpcollector",
+		Subsystem: "output",
+		Name:      "points_sent_total",
+		Help:      "Points sent to the output database.",
+	}, []string{"outdb"})
+	promFieldsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "snmpcollector",
+		Subsystem: "output",
+		Name:      "fields_sent_total",
+		Help:      "Fields sent to the output database.",
+	}, []string{"outdb"})
+	promWriteDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "snmpcollector",
+		Subsystem: "output",
+		Name:      "write_duration_seconds",
+		Help:      "Time taken to write a batchpoint to the output database (with or without errors).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outdb"})
+)
+
+// PrometheusCollectors returns the output metrics to register
+func PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{promWrites, promWriteErrors, promPointsSent, promFieldsSent, promWriteDuration}
+}
+
+// promWriteUpdate updates the output metrics after a batchpoint write
+func promWriteUpdate(id string, ps int64, fs int64, wt time.Duration, err error) {
+	promWriteDuration.WithLabelValues(id).Observe(wt.Seconds())
+	if err != nil {
+		promWriteErrors.WithLabelValues(id).Inc()
+		return
+	}
+	promWrites.WithLabelValues(id).Inc()
+	promPointsSent.WithLabelValues(id).Add(float64(ps))
+	promFieldsSent.WithLabelValues(id).Add(float64(fs))
+}
@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/selfmon"
+)
+
+var (
+	promRegistry     *prometheus.Registry
+	promRegistryOnce sync.Once
+)
+
+// GetPrometheusRegistry returns the registry with all the agent metrics
+func GetPrometheusRegistry() *prometheus.Registry {
+	promRegistryOnce.Do(func() {
+		promRegistry = prometheus.NewRegistry()
+		promRegistry.MustRegister(newStateCollector())
+		promRegistry.MustRegister(selfmon.PrometheusCollectors()...)
+		promRegistry.MustRegister(output.PrometheusCollectors()...)
+		promRegistry.MustRegister(device.PrometheusCollectors()...)
+	})
+	return promRegistry
+}
+
+// stateCollector gets the agent, output and device state metrics on each scrape
+type stateCollector struct {
+	info            *prometheus.Desc
+	reloading       *prometheus.Desc
+	outStarted      *prometheus.Desc
+	outBufferUsed   *prometheus.Desc
+	outBufferSize   *prometheus.Desc
+	devActive       *prometheus.Desc
+	devConnected    *prometheus.Desc
+	devMeasurements *prometheus.Desc
+	devMetrics      *prometheus.Desc
+	devOverruns     *prometheus.Desc
+}
+
+func newStateCollector() *stateCollector {
+	return &stateCollector{
+		info:            prometheus.NewDesc("snmpcollector_info", "Agent build info (always 1).", []string{"instance_id", "version", "commit"}, nil),
+		reloading:       prometheus.NewDesc("snmpcollector_reloading", "1 while the configuration is being reloaded.", nil, nil),
+		outStarted:      prometheus.NewDesc("snmpcollector_output_started", "1 if the output database sender is running.", []string{"outdb"}, nil),
+		outBufferUsed:   prometheus.NewDesc("snmpcollector_output_buffer_used", "Batchpoints waiting on the output database sender buffer.", []string{"outdb"}, nil),
+		outBufferSize:   prometheus.NewDesc("snmpcollector_output_buffer_size", "Output database sender buffer size.", []string{"outdb"}, nil),
+		devActive:       prometheus.NewDesc("snmpcollector_device_active", "1 if the device gathering is active.", []string{"device"}, nil),
+		devConnected:    prometheus.NewDesc("snmpcollector_device_connected", "1 if the device SNMP connection is established.", []string{"device"}, nil),
+		devMeasurements: prometheus.NewDesc("snmpcollector_device_measurements", "Measurements gathered from the device.", []string{"device"}, nil),
+		devMetrics:      prometheus.NewDesc("snmpcollector_device_metrics", "Metrics gathered from the device.", []string{"device"}, nil),
+		devOverruns:     prometheus.NewDesc("snmpcollector_device_gather_overruns_total", "Gather cycles taking longer than the device gather period.", []string{"device"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.info
+	ch <- c.reloading
+	ch <- c.outStarted
+	ch <- c.outBufferUsed
+	ch <- c.outBufferSize
+	ch <- c.devActive
+	ch <- c.devConnected
+	ch <- c.devMeasurements
+	ch <- c.devMetrics
+	ch <- c.devOverruns
+}
+
+func promBool(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Collect implements prometheus.Collector, outputs and devices are not
+// available while reloading
+func (c *stateCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, MainConfig.General.InstanceID, Version, Commit)
+	reloading := CheckReloadProcess()
+	ch <- prometheus.MustNewConstMetric(c.reloading, prometheus.GaugeValue, promBool(reloading))
+	if reloading {
+		return
+	}
+	mutex.RLock()
+	for id, db := range influxdb {
+		if !db.IsInitialized() {
+			continue
+		}
+		used, size := db.BufferFill()
+		ch <- prometheus.MustNewConstMetric(c.outStarted, prometheus.GaugeValue, promBool(db.IsStarted()), id)
+		ch <- prometheus.MustNewConstMetric(c.outBufferUsed, prometheus.GaugeValue, float64(used), id)
+		ch <- prometheus.MustNewConstMetric(c.outBufferSize, prometheus.GaugeValue, float64(size), id)
+	}
+	mutex.RUnlock()
+	for id, s := range GetDevStats() {
+		ch <- prometheus.MustNewConstMetric(c.devActive, prometheus.GaugeValue, promBool(s.DeviceActive), id)
+		ch <- prometheus.MustNewConstMetric(c.devConnected, prometheus.GaugeValue, promBool(s.DeviceConnected), id)
+		ch <- prometheus.MustNewConstMetric(c.devMeasurements, prometheus.GaugeValue, float64(s.NumMeasurements), id)
+		ch <- prometheus.MustNewConstMetric(c.devMetrics, prometheus.GaugeValue, float64(s.NumMetrics), id)
+		ch <- prometheus.MustNewConstMetric(c.devOverruns, prometheus.CounterValue, float64(s.GatherOverruns), id)
+	}
+}
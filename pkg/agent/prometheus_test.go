@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
+)
+
+func TestPrometheusRegistry(t *testing.T) {
+	// device metrics are package globals, a new device on each run keeps them from previous runs
+	id := fmt.Sprintf("promdev%d", time.Now().UnixNano())
+	stat := &device.DevStat{}
+	stat.Init(id, nil, logrus.New())
+	stat.UpdateSnmpGetStats(10, 8, 2)
+	stat.SetGatherDuration(time.Now(), 300*time.Millisecond)
+	stat.Send()
+	// counters are reset on each cycle but the metrics keep growing
+	stat.ResetCounters()
+	stat.UpdateSnmpGetStats(5, 5, 0)
+	stat.SetGatherDuration(time.Now(), 2*time.Second)
+	stat.Send()
+
+	devices = map[string]*device.SnmpDevice{
+		id: {Stats: &device.DevStat{DeviceActive: true, DeviceConnected: true, NumMeasurements: 2}},
+	}
+	defer func() { devices = nil }()
+
+	families, err := GetPrometheusRegistry().Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]float64)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			if l := m.GetLabel(); len(l) > 0 && l[0].GetName() == "device" && l[0].GetValue() != id {
+				continue
+			}
+			switch {
+			case m.Gauge != nil:
+				values[f.GetName()] = m.GetGauge().GetValue()
+			case m.Counter != nil:
+				values[f.GetName()] = m.GetCounter().GetValue()
+			case m.Histogram != nil:
+				values[f.GetName()] = float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	for name, want := range map[string]float64{
+		"snmpcollector_info":                             1,
+		"snmpcollector_device_connected":                 1,
+		"snmpcollector_device_measurements":              2,
+		"snmpcollector_device_snmp_oid_get_all_total":    15,
+		"snmpcollector_device_snmp_oid_get_errors_total": 2,
+		"snmpcollector_device_gather_duration_seconds":   2,
+	} {
+		if got, ok := values[name]; !ok || got != want {
+			t.Errorf("metric %s: got %v (found %t), want %v", name, got, ok, want)
+		}
+	}
+	if _, ok := values["go_goroutines"]; !ok {
+		t.Error("runtime metrics not registered")
+	}
+}
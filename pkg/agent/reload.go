@@ -0,0 +1,234 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/Knetic/govaluate"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+var (
+	// deviceSignatures is the checksum of each runtime device configuration and its dependencies
+	deviceSignatures map[string]string
+	// outputSignatures is the checksum of each runtime output configuration
+	outputSignatures map[string]string
+)
+
+// deviceCfgDeps contains a device configuration and all the configuration objects it depends on
+type deviceCfgDeps struct {
+	Device        *config.SnmpDeviceCfg
+	Output        *config.InfluxCfg
+	Groups        []*config.MGroupsCfg
+	Measurements  []*config.MeasurementCfg
+	Metrics       []*config.SnmpMetricCfg
+	Filters       []*config.MeasFilterCfg
+	OidConditions []*config.OidConditionCfg
+	CustomFilters []*config.CustomFilterCfg
+}
+
+func signature(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// objects that can not be compared are always taken as changed
+		log.Warnf("Error on get configuration signature: %s", err)
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// addOidCondition adds the condition and its subconditions (if multiple) to the dependencies
+func (d *deviceCfgDeps) addOidCondition(id string, seen map[string]bool) {
+	if seen[id] {
+		return
+	}
+	seen[id] = true
+	cond, err := MainConfig.Database.GetOidConditionCfgByID(id)
+	if err != nil {
+		return
+	}
+	d.OidConditions = append(d.OidConditions, &cond)
+	if !cond.IsMultiple {
+		return
+	}
+	if expression, err := govaluate.NewEvaluableExpression(cond.OIDCond); err == nil {
+		for _, par := range expression.Vars() {
+			d.addOidCondition(par, seen)
+		}
+	}
+}
+
+// deviceSignature returns the checksum of the device configuration and all the objects
+// it depends on: output, measurement groups, measurements, metrics and filters (with their
+// oid conditions and custom filters read from the database). File filters contents are not checked.
+func deviceSignature(c *config.DBConfig, dev *config.SnmpDeviceCfg) string {
+	d := &deviceCfgDeps{Device: dev}
+	// same output selection done by the device
+	if out, ok := c.Influxdb[dev.OutDB]; ok {
+		d.Output = out
+	} else {
+		d.Output = c.Influxdb["default"]
+	}
+	conds := make(map[string]bool)
+	meas := make(map[string]bool)
+	metrics := make(map[string]bool)
+	for _, gid := range dev.MeasurementGroups {
+		g, ok := c.GetGroups[gid]
+		if !ok {
+			continue
+		}
+		d.Groups = append(d.Groups, g)
+		for _, mid := range g.Measurements {
+			m, ok := c.Measurements[mid]
+			if !ok || meas[mid] {
+				continue
+			}
+			meas[mid] = true
+			d.Measurements = append(d.Measurements, m)
+			for _, f := range m.Fields {
+				metric, ok := c.Metrics[f.ID]
+				if !ok || metrics[f.ID] {
+					continue
+				}
+				metrics[f.ID] = true
+				d.Metrics = append(d.Metrics, metric)
+				if metric.DataSrcType == "CONDITIONEVAL" {
+					d.addOidCondition(metric.ExtraData, conds)
+				}
+			}
+		}
+	}
+	for _, fid := range dev.MeasFilters {
+		f, ok := c.MFilters[fid]
+		if !ok {
+			continue
+		}
+		d.Filters = append(d.Filters, f)
+		switch f.FType {
+		case "OIDCondition":
+			d.addOidCondition(f.FilterName, conds)
+		case "CustomFilter":
+			if cf, err := MainConfig.Database.GetCustomFilterCfgByID(f.FilterName); err == nil {
+				d.CustomFilters = append(d.CustomFilters, &cf)
+			}
+		}
+	}
+	return signature(d)
+}
+
+// cfgSignatures returns the signatures of all devices and outputs of the configuration
+func cfgSignatures(c *config.DBConfig) (map[string]string, map[string]string) {
+	devs := make(map[string]string, len(c.SnmpDevice))
+	for k, dev := range c.SnmpDevice {
+		devs[k] = deviceSignature(c, dev)
+	}
+	outs := make(map[string]string, len(c.Influxdb))
+	for k, out := range c.Influxdb {
+		outs[k] = signature(out)
+	}
+	return devs, outs
+}
+
+// ReloadPlan contains the runtime changes needed to apply a new configuration
+type ReloadPlan struct {
+	Full           bool   // all devices and outputs should be restarted
+	Reason         string // why a full reload is needed
+	AddedDevices   []string
+	ChangedDevices []string
+	RemovedDevices []string
+	Unchanged      int
+	ChangedOutputs []string // changed or removed outputs
+	devSigs        map[string]string
+	outSigs        map[string]string
+}
+
+// diffKeys returns the sorted keys added, changed and removed from a to b
+func diffKeys(a, b map[string]string) (added []string, changed []string, removed []string) {
+	for k, v := range b {
+		if old, ok := a[k]; !ok {
+			added = append(added, k)
+		} else if old != v || len(v) == 0 {
+			changed = append(changed, k)
+		}
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return
+}
+
+// planReload compares the runtime configuration with the new one
+func planReload(newcfg *config.DBConfig) *ReloadPlan {
+	p := &ReloadPlan{}
+	p.devSigs, p.outSigs = cfgSignatures(newcfg)
+	switch {
+	case deviceSignatures == nil:
+		p.Full, p.Reason = true, "no previous runtime configuration"
+	case signature(DBConfig.VarCatalog) != signature(newcfg.VarCatalog):
+		p.Full, p.Reason = true, "global variables changed"
+	case MainConfig.Selfmon.Enabled && outputSignatures["default"] != p.outSigs["default"]:
+		p.Full, p.Reason = true, "self monitoring output changed"
+	}
+	// devices added to the runtime outside a reload (without signature) are restarted
+	runtime := make(map[string]string)
+	mutex.RLock()
+	for k := range devices {
+		runtime[k] = deviceSignatures[k]
+	}
+	mutex.RUnlock()
+	p.AddedDevices, p.ChangedDevices, p.RemovedDevices = diffKeys(runtime, p.devSigs)
+	p.Unchanged = len(p.devSigs) - len(p.AddedDevices) - len(p.ChangedDevices)
+	_, changed, removed := diffKeys(outputSignatures, p.outSigs)
+	p.ChangedOutputs = append(changed, removed...)
+	return p
+}
+
+// applyReload restarts only the devices and outputs changed on the plan
+func applyReload(p *ReloadPlan, newcfg *config.DBConfig) {
+	for _, k := range append(append([]string{}, p.RemovedDevices...), p.ChangedDevices...) {
+		log.Infof("RELOADCONF: stopping device %s", k)
+		DeleteDeviceInRuntime(k)
+	}
+	// changed outputs are not used by any running device now
+	newdb := make(map[string]*output.InfluxDB, len(influxdb))
+	for k, v := range influxdb {
+		newdb[k] = v
+	}
+	for _, k := range p.ChangedOutputs {
+		if old, ok := newdb[k]; ok {
+			log.Infof("RELOADCONF: stopping output %s", k)
+			old.StopSender()
+			old.End()
+			delete(newdb, k)
+		}
+	}
+	for k, c := range newcfg.Influxdb {
+		if sig, ok := outputSignatures[k]; !ok || sig != p.outSigs[k] {
+			newdb[k] = output.NewNotInitInfluxDB(c)
+		}
+	}
+	if _, ok := newdb["default"]; !ok {
+		newdb["default"] = output.DummyDB
+	}
+	mutex.Lock()
+	influxdb = newdb
+	setDBConfig(newcfg)
+	mutex.Unlock()
+	if MainConfig.Selfmon.Enabled && selfmonProc.IsInitialized() {
+		selfmonProc.SetOutDB(newdb)
+	}
+	for _, k := range append(append([]string{}, p.ChangedDevices...), p.AddedDevices...) {
+		log.Infof("RELOADCONF: starting device %s", k)
+		AddDeviceInRuntime(k, DBConfig.SnmpDevice[k])
+	}
+	deviceSignatures, outputSignatures = p.devSigs, p.outSigs
+}
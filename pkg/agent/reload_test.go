@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+func testReloadCfg() *config.DBConfig {
+	return &config.DBConfig{
+		Metrics: map[string]*config.SnmpMetricCfg{
+			"uptime": {ID: "uptime", FieldName: "uptime", BaseOID: ".1.3.6.1.2.1.1.3.0", DataSrcType: "TimeTicks"},
+			"ifin":   {ID: "ifin", FieldName: "in", BaseOID: ".1.3.6.1.2.1.2.2.1.10", DataSrcType: "Counter32"},
+		},
+		Measurements: map[string]*config.MeasurementCfg{
+			"sys": {ID: "sys", Name: "sys", GetMode: "value", Fields: []config.MeasurementFieldReport{{ID: "uptime", Report: 1}}},
+			"if":  {ID: "if", Name: "if", GetMode: "indexed", Fields: []config.MeasurementFieldReport{{ID: "ifin", Report: 1}}},
+		},
+		GetGroups: map[string]*config.MGroupsCfg{
+			"base":  {ID: "base", Measurements: []string{"sys"}},
+			"iface": {ID: "iface", Measurements: []string{"sys", "if"}},
+		},
+		MFilters: map[string]*config.MeasFilterCfg{},
+		SnmpDevice: map[string]*config.SnmpDeviceCfg{
+			"dev1": {ID: "dev1", Host: "10.0.0.1", OutDB: "influx1", MeasurementGroups: []string{"base"}},
+			"dev2": {ID: "dev2", Host: "10.0.0.2", OutDB: "influx1", MeasurementGroups: []string{"iface"}},
+			"dev3": {ID: "dev3", Host: "10.0.0.3", OutDB: "influx2", MeasurementGroups: []string{"base"}},
+		},
+		Influxdb: map[string]*config.InfluxCfg{
+			"influx1": {ID: "influx1", Host: "127.0.0.1", Port: 8086},
+			"influx2": {ID: "influx2", Host: "127.0.0.2", Port: 8086},
+		},
+		VarCatalog: map[string]interface{}{"factor": 8},
+	}
+}
+
+func TestPlanReload(t *testing.T) {
+	DBConfig = *testReloadCfg()
+	devices = map[string]*device.SnmpDevice{"dev1": nil, "dev2": nil, "dev3": nil}
+	deviceSignatures, outputSignatures = cfgSignatures(&DBConfig)
+	defer func() {
+		DBConfig = config.DBConfig{}
+		devices = nil
+		deviceSignatures, outputSignatures = nil, nil
+	}()
+
+	if p := planReload(testReloadCfg()); p.Full || p.Unchanged != 3 || len(p.ChangedDevices)+len(p.AddedDevices)+len(p.RemovedDevices)+len(p.ChangedOutputs) != 0 {
+		t.Errorf("unexpected plan for the same configuration %+v", p)
+	}
+
+	// metric only used by dev2, a new device and dev3 removed
+	c := testReloadCfg()
+	c.Metrics["ifin"].DataSrcType = "Counter64"
+	c.SnmpDevice["dev4"] = &config.SnmpDeviceCfg{ID: "dev4", Host: "10.0.0.4", OutDB: "influx1"}
+	delete(c.SnmpDevice, "dev3")
+	p := planReload(c)
+	if p.Full || p.Unchanged != 1 || !reflect.DeepEqual(p.ChangedDevices, []string{"dev2"}) ||
+		!reflect.DeepEqual(p.AddedDevices, []string{"dev4"}) || !reflect.DeepEqual(p.RemovedDevices, []string{"dev3"}) {
+		t.Errorf("unexpected plan on metric change %+v", p)
+	}
+
+	// output change restarts the devices using it
+	c = testReloadCfg()
+	c.Influxdb["influx1"].Port = 8087
+	p = planReload(c)
+	if p.Full || !reflect.DeepEqual(p.ChangedDevices, []string{"dev1", "dev2"}) || !reflect.DeepEqual(p.ChangedOutputs, []string{"influx1"}) {
+		t.Errorf("unexpected plan on output change %+v", p)
+	}
+
+	// global variables could be used by any measurement
+	c = testReloadCfg()
+	c.VarCatalog["factor"] = 16
+	if p = planReload(c); !p.Full {
+		t.Errorf("full reload expected on global variables change %+v", p)
+	}
+}
+
+func TestReloadRunningDevice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snmpcollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// device without snmp connections to init
+	runtimeCfg := func() *config.DBConfig {
+		c := testReloadCfg()
+		c.SnmpDevice = map[string]*config.SnmpDeviceCfg{
+			"dev1": {ID: "dev1", Host: "10.0.0.1", OutDB: "influx1", MeasurementGroups: []string{"nogroup"}, LogFile: filepath.Join(dir, "dev1.log")},
+		}
+		return c
+	}
+	c := runtimeCfg()
+	setDBConfig(c)
+	devcfg := *c.SnmpDevice["dev1"] // devices set their config defaults
+	dev := device.New(&devcfg)
+	devices = map[string]*device.SnmpDevice{"dev1": dev}
+	deviceSignatures, outputSignatures = cfgSignatures(&DBConfig)
+	defer func() {
+		DBConfig = config.DBConfig{}
+		device.SetDBConfig(nil)
+		devices, influxdb = nil, nil
+		deviceSignatures, outputSignatures = nil, nil
+	}()
+
+	// the running device reinitializes its measurements ( as on reconnect ) while reloading
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			dev.InitDevMeasurements()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		c := runtimeCfg()
+		p := planReload(c)
+		if p.Full || p.Unchanged != 1 {
+			t.Fatalf("unexpected plan %+v", p)
+		}
+		applyReload(p, c)
+	}
+	<-done
+}
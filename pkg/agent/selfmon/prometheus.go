@@ -0,0 +1,16 @@
+package selfmon
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusCollectors returns the agent runtime metrics to register, the go
+// collector exposes the same goroutines, memory and GC stats of getRuntimeStats
+// (go_goroutines, go_memstats_*, go_gc_duration_seconds) and the process one
+// the CPU, memory and file descriptors used by the agent
+func PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	}
+}
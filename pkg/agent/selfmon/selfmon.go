@@ -33,6 +33,8 @@ type SelfMon struct {
 	chExit              chan bool
 	mutex               sync.Mutex
 	RtMeasName          string //devices measurement name
+	MeasMeasName        string //devices measurements stats measurement name
+	AvailMeasName       string //devices availability measurement name
 	GvmMeasName         string //Self agent GoVirtualMachine measurement name
 	OutMeasName         string //Output DB's measurement name
 	initialized         bool
@@ -74,11 +76,15 @@ func (sm *SelfMon) Init() {
 
 	// Measurement Names
 	sm.RtMeasName = "selfmon_device_stats"
+	sm.MeasMeasName = "selfmon_measurement_stats"
+	sm.AvailMeasName = "selfmon_device_availability"
 	sm.GvmMeasName = "selfmon_gvm"
 	sm.OutMeasName = "selfmon_outdb_stats"
 
 	if len(sm.cfg.Prefix) > 0 {
 		sm.RtMeasName = fmt.Sprintf("%sselfmon_device_stats", sm.cfg.Prefix)
+		sm.MeasMeasName = fmt.Sprintf("%sselfmon_measurement_stats", sm.cfg.Prefix)
+		sm.AvailMeasName = fmt.Sprintf("%sselfmon_device_availability", sm.cfg.Prefix)
 		sm.GvmMeasName = fmt.Sprintf("%sselfmon_gvm", sm.cfg.Prefix)
 		sm.OutMeasName = fmt.Sprintf("%sselfmon_outdb_stats", sm.cfg.Prefix)
 	}
@@ -144,6 +150,20 @@ func (sm *SelfMon) addDataPoint(pt *client.Point) {
 
 // AddDeviceMetrics add data from devices
 func (sm *SelfMon) AddDeviceMetrics(deviceid string, fields map[string]interface{}, devtags map[string]string) {
+	sm.addDevicePoint(sm.RtMeasName, deviceid, nil, fields, devtags)
+}
+
+// AddMeasurementMetrics add data from device measurements
+func (sm *SelfMon) AddMeasurementMetrics(deviceid string, measid string, fields map[string]interface{}, devtags map[string]string) {
+	sm.addDevicePoint(sm.MeasMeasName, deviceid, map[string]string{"measurement": measid}, fields, devtags)
+}
+
+// AddAvailabilityMetrics add the device availability state
+func (sm *SelfMon) AddAvailabilityMetrics(deviceid string, fields map[string]interface{}, devtags map[string]string) {
+	sm.addDevicePoint(sm.AvailMeasName, deviceid, nil, fields, devtags)
+}
+
+func (sm *SelfMon) addDevicePoint(measname string, deviceid string, tags map[string]string, fields map[string]interface{}, devtags map[string]string) {
 	if !sm.IsInitialized() {
 		return
 	}
@@ -160,10 +180,13 @@ func (sm *SelfMon) AddDeviceMetrics(deviceid string, fields map[string]interface
 		}
 	}
 
+	for k, v := range tags {
+		tagMap[k] = v
+	}
 	tagMap["device"] = deviceid
 	now := time.Now()
 	pt, err := client.NewPoint(
-		sm.RtMeasName,
+		measname,
 		tagMap,
 		fields,
 		now)
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// AlertReceiverCfg webhook where the alert state changes are posted as JSON
+type AlertReceiverCfg struct {
+	ID           string `xorm:"'id' unique" binding:"Required"`
+	URL          string `xorm:"url" binding:"Required"`
+	Timeout      int    `xorm:"timeout"` // seconds, 5 if not set
+	SendResolved bool   `xorm:"send_resolved"`
+	Description  string `xorm:"description"`
+}
+
+// Init checks the receiver config
+func (r *AlertReceiverCfg) Init() error {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return fmt.Errorf("Error on alert receiver %s URL %s: %s", r.ID, r.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("Error on alert receiver %s URL %s: only http or https webhooks allowed", r.ID, r.URL)
+	}
+	return nil
+}
+
+/***************************
+Alert Receivers
+	-GetAlertReceiverCfgByID(struct)
+	-GetAlertReceiverCfgMap (map - for interna config use
+	-GetAlertReceiverCfgArray(Array - for web ui use )
+	-AddAlertReceiverCfg
+	-DelAlertReceiverCfg
+	-UpdateAlertReceiverCfg
+	-GetAlertReceiverCfgAffectOnDel
+***********************************/
+
+/*GetAlertReceiverCfgByID get receiver data by id*/
+func (dbc *DatabaseCfg) GetAlertReceiverCfgByID(id string) (AlertReceiverCfg, error) {
+	cfgarray, err := dbc.GetAlertReceiverCfgArray(FilterEq("id", id))
+	if err != nil {
+		return AlertReceiverCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return AlertReceiverCfg{}, fmt.Errorf("Error %d results on get AlertReceiverCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return AlertReceiverCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the alert receiver table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetAlertReceiverCfgMap  return data in map format*/
+func (dbc *DatabaseCfg) GetAlertReceiverCfgMap(filter *Filter) (map[string]*AlertReceiverCfg, error) {
+	cfgarray, err := dbc.GetAlertReceiverCfgArray(filter)
+	cfgmap := make(map[string]*AlertReceiverCfg)
+	for _, val := range cfgarray {
+		cfgmap[val.ID] = val
+		log.Debugf("%+v", *val)
+	}
+	return cfgmap, err
+}
+
+/*GetAlertReceiverCfgArray generate an array of receivers with all its information */
+func (dbc *DatabaseCfg) GetAlertReceiverCfgArray(filter *Filter) ([]*AlertReceiverCfg, error) {
+	var err error
+	var receivers []*AlertReceiverCfg
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&receivers); err != nil {
+			log.Warnf("Fail to get AlertReceiverCfg data filtered with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.db().Find(&receivers); err != nil {
+			log.Warnf("Fail to get AlertReceiverCfg data: %v\n", err)
+			return nil, err
+		}
+	}
+	return receivers, nil
+}
+
+/*AddAlertReceiverCfg for adding new alert receivers*/
+func (dbc *DatabaseCfg) AddAlertReceiverCfg(dev AlertReceiverCfg) (int64, error) {
+	if err := dev.Init(); err != nil {
+		return 0, err
+	}
+	session := dbc.newSession()
+	defer session.Close()
+
+	affected, err := session.Insert(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	if err = session.Commit(); err != nil {
+		return 0, err
+	}
+	log.Infof("Added new AlertReceiverCfg Successfully with id %s ", dev.ID)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+// replaceAlertRuleReceiver changes ( or removes if newid is empty ) the receiver on all rules using it
+func replaceAlertRuleReceiver(rules []*AlertRuleCfg, id string, newid string) []*AlertRuleCfg {
+	var changed []*AlertRuleCfg
+	for _, r := range rules {
+		var receivers []string
+		found := false
+		for _, v := range r.Receivers {
+			if v != id {
+				receivers = append(receivers, v)
+				continue
+			}
+			found = true
+			if len(newid) > 0 {
+				receivers = append(receivers, newid)
+			}
+		}
+		if found {
+			r.Receivers = receivers
+			changed = append(changed, r)
+		}
+	}
+	return changed
+}
+
+// updateAlertRuleReceiver changes the receiver id ( or removes it ) on the rules using it
+func (dbc *DatabaseCfg) updateAlertRuleReceiver(session *dbSession, id string, newid string) (int64, error) {
+	rules, err := dbc.GetAlertRuleCfgArray(nil)
+	if err != nil {
+		return 0, err
+	}
+	var affected int64
+	for _, r := range replaceAlertRuleReceiver(rules, id, newid) {
+		n, err := session.Where("id=?", r.ID).Cols("receivers").Update(&AlertRuleCfg{Receivers: r.Receivers})
+		if err != nil {
+			return 0, fmt.Errorf("Error on update receivers on alert rule %s: %s", r.ID, err)
+		}
+		affected += n
+	}
+	return affected, nil
+}
+
+/*DelAlertReceiverCfg for deleting alert receivers from ID*/
+func (dbc *DatabaseCfg) DelAlertReceiverCfg(id string) (int64, error) {
+	session := dbc.newSession()
+	defer session.Close()
+
+	affecteddev, err := dbc.updateAlertRuleReceiver(session, id, "")
+	if err != nil {
+		session.Rollback()
+		return 0, fmt.Errorf("Error on Delete alert receiver on alert rules with id: %s, error: %s", id, err)
+	}
+	affected, err := session.Where("id=?", id).Delete(&AlertReceiverCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	if err = session.Commit(); err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully alert receiver with ID %s [ %d Items Affected  ]", id, affecteddev)
+	dbc.addChanges(affected + affecteddev)
+	return affected, nil
+}
+
+/*UpdateAlertReceiverCfg for updating alert receivers*/
+func (dbc *DatabaseCfg) UpdateAlertReceiverCfg(id string, dev AlertReceiverCfg) (int64, error) {
+	var affecteddev int64
+	if err := dev.Init(); err != nil {
+		return 0, err
+	}
+	session := dbc.newSession()
+	defer session.Close()
+
+	if id != dev.ID { //ID has been changed
+		var err error
+		affecteddev, err = dbc.updateAlertRuleReceiver(session, id, dev.ID)
+		if err != nil {
+			session.Rollback()
+			return 0, fmt.Errorf("Error on Update alert rules on update alert receiver id(old) %s with (new): %s, error: %s", id, dev.ID, err)
+		}
+	}
+	affected, err := session.Where("id=?", id).UseBool().AllCols().Update(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	if err = session.Commit(); err != nil {
+		return 0, err
+	}
+	log.Infof("Updated AlertReceiverCfg Successfully with id %s [ %d id changed]", dev.ID, affecteddev)
+	dbc.addChanges(affected + affecteddev)
+	return affected, nil
+}
+
+/*GetAlertReceiverCfgAffectOnDel for deleting alert receivers from ID*/
+func (dbc *DatabaseCfg) GetAlertReceiverCfgAffectOnDel(id string) ([]*DbObjAction, error) {
+	rules, err := dbc.GetAlertRuleCfgArray(nil)
+	if err != nil {
+		return nil, err
+	}
+	var obj []*DbObjAction
+	for _, r := range replaceAlertRuleReceiver(rules, id, "") {
+		obj = append(obj, &DbObjAction{
+			Type:     "alertrulecfg",
+			TypeDesc: "Alert Rules",
+			ObID:     r.ID,
+			Action:   "Delete alert receiver from the rule",
+		})
+	}
+	return obj, nil
+}
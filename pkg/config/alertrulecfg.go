@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Knetic/govaluate"
+)
+
+// AlertRuleCfg threshold rule evaluated on each device gather cycle over the
+// last values of the measurement points ( one alert per matching point )
+type AlertRuleCfg struct {
+	ID              string   `xorm:"'id' unique" binding:"Required"`
+	Active          bool     `xorm:"active"`
+	Measurement     string   `xorm:"measurement" binding:"Required"` // measurement ID or name
+	Devices         string   `xorm:"devices"`                        // comma separated device IDs ("*" suffix to match by prefix), all if empty
+	TagSelector     string   `xorm:"tag_selector"`                   // comma separated tag=value filters ("*" suffix to match by prefix)
+	Expression      string   `xorm:"expression" binding:"Required"`  // fires when true, evaluated with the point fields and tags
+	ClearExpression string   `xorm:"clear_expression"`               // resolves when true (hysteresis), when the expression is false if empty
+	For             int      `xorm:"for_duration"`                   // seconds the expression should be true before firing
+	Severity        string   `xorm:"severity" binding:"Default(warning);In(info,warning,critical)"`
+	Receivers       []string `xorm:"receivers"`
+	Description     string   `xorm:"description"`
+}
+
+// splitCSV returns the trimmed not empty values of a comma separated list
+func splitCSV(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// GetDevices returns the device IDs ( or ID prefixes ) selected by the rule
+func (r *AlertRuleCfg) GetDevices() []string {
+	return splitCSV(r.Devices)
+}
+
+// GetTagSelector returns the tag values selected by the rule
+func (r *AlertRuleCfg) GetTagSelector() (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, v := range splitCSV(r.TagSelector) {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || len(strings.TrimSpace(kv[0])) == 0 {
+			return nil, fmt.Errorf("Error on alert rule %s tag selector %q: tag=value expected", r.ID, v)
+		}
+		tags[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return tags, nil
+}
+
+// Init checks the rule expressions and tag selector
+func (r *AlertRuleCfg) Init() error {
+	if _, err := govaluate.NewEvaluableExpression(r.Expression); err != nil {
+		return fmt.Errorf("Error on alert rule %s expression %s: %s", r.ID, r.Expression, err)
+	}
+	if len(r.ClearExpression) > 0 {
+		if _, err := govaluate.NewEvaluableExpression(r.ClearExpression); err != nil {
+			return fmt.Errorf("Error on alert rule %s clear expression %s: %s", r.ID, r.ClearExpression, err)
+		}
+	}
+	if r.For < 0 {
+		return fmt.Errorf("Error on alert rule %s: negative for duration %d", r.ID, r.For)
+	}
+	_, err := r.GetTagSelector()
+	return err
+}
+
+/***************************
+Alert Rules
+	-GetAlertRuleCfgByID(struct)
+	-GetAlertRuleCfgMap (map - for interna config use
+	-GetAlertRuleCfgArray(Array - for web ui use )
+	-AddAlertRuleCfg
+	-DelAlertRuleCfg
+	-UpdateAlertRuleCfg
+***********************************/
+
+/*GetAlertRuleCfgByID get rule data by id*/
+func (dbc *DatabaseCfg) GetAlertRuleCfgByID(id string) (AlertRuleCfg, error) {
+	cfgarray, err := dbc.GetAlertRuleCfgArray(FilterEq("id", id))
+	if err != nil {
+		return AlertRuleCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return AlertRuleCfg{}, fmt.Errorf("Error %d results on get AlertRuleCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return AlertRuleCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the alert rule table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetAlertRuleCfgMap  return data in map format*/
+func (dbc *DatabaseCfg) GetAlertRuleCfgMap(filter *Filter) (map[string]*AlertRuleCfg, error) {
+	cfgarray, err := dbc.GetAlertRuleCfgArray(filter)
+	cfgmap := make(map[string]*AlertRuleCfg)
+	for _, val := range cfgarray {
+		cfgmap[val.ID] = val
+		log.Debugf("%+v", *val)
+	}
+	return cfgmap, err
+}
+
+/*GetAlertRuleCfgArray generate an array of rules with all its information */
+func (dbc *DatabaseCfg) GetAlertRuleCfgArray(filter *Filter) ([]*AlertRuleCfg, error) {
+	var err error
+	var rules []*AlertRuleCfg
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&rules); err != nil {
+			log.Warnf("Fail to get AlertRuleCfg data filtered with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.db().Find(&rules); err != nil {
+			log.Warnf("Fail to get AlertRuleCfg data: %v\n", err)
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// checkAlertRuleReceivers checks all the rule receivers exist
+func (dbc *DatabaseCfg) checkAlertRuleReceivers(dev *AlertRuleCfg) error {
+	for _, id := range dev.Receivers {
+		if _, err := dbc.GetAlertReceiverCfgByID(id); err != nil {
+			return fmt.Errorf("Error on alert rule %s receiver %s: %s", dev.ID, id, err)
+		}
+	}
+	return nil
+}
+
+/*AddAlertRuleCfg for adding new alert rules*/
+func (dbc *DatabaseCfg) AddAlertRuleCfg(dev AlertRuleCfg) (int64, error) {
+	if err := dev.Init(); err != nil {
+		return 0, err
+	}
+	if err := dbc.checkAlertRuleReceivers(&dev); err != nil {
+		return 0, err
+	}
+	session := dbc.newSession()
+	defer session.Close()
+
+	affected, err := session.Insert(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	if err = session.Commit(); err != nil {
+		return 0, err
+	}
+	log.Infof("Added new AlertRuleCfg Successfully with id %s ", dev.ID)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*DelAlertRuleCfg for deleting alert rules from ID*/
+func (dbc *DatabaseCfg) DelAlertRuleCfg(id string) (int64, error) {
+	session := dbc.newSession()
+	defer session.Close()
+
+	affected, err := session.Where("id=?", id).Delete(&AlertRuleCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	if err = session.Commit(); err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully alert rule with ID %s", id)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*UpdateAlertRuleCfg for updating alert rules*/
+func (dbc *DatabaseCfg) UpdateAlertRuleCfg(id string, dev AlertRuleCfg) (int64, error) {
+	if err := dev.Init(); err != nil {
+		return 0, err
+	}
+	if err := dbc.checkAlertRuleReceivers(&dev); err != nil {
+		return 0, err
+	}
+	session := dbc.newSession()
+	defer session.Close()
+
+	affected, err := session.Where("id=?", id).UseBool().AllCols().Update(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	if err = session.Commit(); err != nil {
+		return 0, err
+	}
+	log.Infof("Updated AlertRuleCfg Successfully with id %s", dev.ID)
+	dbc.addChanges(affected)
+	return affected, nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAlertRuleInit(t *testing.T) {
+	r := &AlertRuleCfg{ID: "r", Expression: "load > 90", TagSelector: "cpu=1, host = sw1"}
+	if err := r.Init(); err != nil {
+		t.Fatal(err)
+	}
+	sel, err := r.GetTagSelector()
+	if want := map[string]string{"cpu": "1", "host": "sw1"}; err != nil || !reflect.DeepEqual(sel, want) {
+		t.Errorf("got tag selector %v (%v), want %v", sel, err, want)
+	}
+	for _, bad := range []*AlertRuleCfg{
+		{ID: "r"},
+		{ID: "r", Expression: "load >"},
+		{ID: "r", Expression: "load > 90", ClearExpression: "(load"},
+		{ID: "r", Expression: "load > 90", TagSelector: "cpu"},
+	} {
+		if err := bad.Init(); err == nil {
+			t.Errorf("invalid rule %+v accepted", bad)
+		}
+	}
+}
+
+func TestAlertReceiverRename(t *testing.T) {
+	dbc, release := newTestSQLiteDB(t)
+	defer release()
+
+	if _, err := dbc.AddAlertRuleCfg(AlertRuleCfg{ID: "highcpu", Expression: "load > 90", Receivers: []string{"ops"}}); err == nil {
+		t.Error("rule with unknown receiver accepted")
+	}
+	if _, err := dbc.AddAlertReceiverCfg(AlertReceiverCfg{ID: "ops", URL: "http://localhost/hook"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbc.AddAlertRuleCfg(AlertRuleCfg{ID: "highcpu", Expression: "load > 90", Receivers: []string{"ops"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dbc.UpdateAlertReceiverCfg("ops", AlertReceiverCfg{ID: "noc", URL: "http://localhost/hook"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := dbc.GetAlertRuleCfgByID("highcpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.Receivers, []string{"noc"}) {
+		t.Errorf("rule receivers not renamed: %v", r.Receivers)
+	}
+
+	if _, err := dbc.DelAlertReceiverCfg("noc"); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ = dbc.GetAlertRuleCfgByID("highcpu"); len(r.Receivers) != 0 {
+		t.Errorf("deleted receiver kept on rule: %v", r.Receivers)
+	}
+}
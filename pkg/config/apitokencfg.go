@@ -0,0 +1,197 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// apiTokenPrefix is prepended to the generated token secrets to identify them
+const apiTokenPrefix = "snmpc_"
+
+// APITokenCfg is a long-lived API token for automation clients, only the hash
+// of the token secret is stored
+type APITokenCfg struct {
+	ID          string    `xorm:"'id' unique" binding:"Required"`
+	TokenHash   string    `xorm:"'token_hash' index" json:"-"`
+	Role        string    `xorm:"role" binding:"Required;In(viewer,operator,admin)"`
+	Scope       string    `xorm:"scope"` // optional object ID (or ID prefix ending with "*") allowed
+	Owner       string    `xorm:"owner"` // user who created the token, token role is limited by the owner one
+	Description string    `xorm:"description"`
+	CreatedAt   time.Time `xorm:"created_at"`
+	ExpiresAt   time.Time `xorm:"expires_at"` // zero value means never
+	LastUsedAt  time.Time `xorm:"last_used_at"`
+	Revoked     bool      `xorm:"revoked"`
+}
+
+/***************************
+API Tokens
+	-GetAPITokenCfgByID(struct)
+	-GetAPITokenCfgBySecret(struct)
+	-GetAPITokenCfgArray(Array - for web ui use )
+	-AddAPITokenCfg
+	-DelAPITokenCfg
+	-RevokeAPITokenCfg
+	-UpdateAPITokenCfgLastUsed
+***********************************/
+
+// hashAPIToken returns the stored hash for the token secret (secrets are random
+// enough to not need a slow hash)
+func hashAPIToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckValid returns an error if the token has been revoked or has expired
+func (t *APITokenCfg) CheckValid(now time.Time) error {
+	if t.Revoked {
+		return fmt.Errorf("API token %s has been revoked", t.ID)
+	}
+	if !t.ExpiresAt.IsZero() && now.After(t.ExpiresAt) {
+		return fmt.Errorf("API token %s expired at %s", t.ID, t.ExpiresAt)
+	}
+	return nil
+}
+
+// InScope returns true if the token is allowed to access the object id (device or
+// any other config object), an empty id is only allowed to tokens without scope
+func (t *APITokenCfg) InScope(id string) bool {
+	if len(t.Scope) == 0 {
+		return true
+	}
+	if len(id) == 0 {
+		return false
+	}
+	if strings.HasSuffix(t.Scope, "*") {
+		return strings.HasPrefix(id, strings.TrimSuffix(t.Scope, "*"))
+	}
+	return id == t.Scope
+}
+
+/*GetAPITokenCfgByID get token data by id*/
+func (dbc *DatabaseCfg) GetAPITokenCfgByID(id string) (APITokenCfg, error) {
+	cfgarray, err := dbc.GetAPITokenCfgArray(FilterEq("id", id))
+	if err != nil {
+		return APITokenCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return APITokenCfg{}, fmt.Errorf("Error %d results on get APITokenCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return APITokenCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the API token table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetAPITokenCfgBySecret get token data by its secret*/
+func (dbc *DatabaseCfg) GetAPITokenCfgBySecret(secret string) (APITokenCfg, error) {
+	if !strings.HasPrefix(secret, apiTokenPrefix) {
+		return APITokenCfg{}, fmt.Errorf("Error invalid API token format")
+	}
+	cfgarray, err := dbc.GetAPITokenCfgArray(FilterEq("token_hash", hashAPIToken(secret)))
+	if err != nil {
+		return APITokenCfg{}, err
+	}
+	if len(cfgarray) != 1 {
+		return APITokenCfg{}, fmt.Errorf("Error API token not found")
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetAPITokenCfgArray generate an array of tokens with all its information */
+func (dbc *DatabaseCfg) GetAPITokenCfgArray(filter *Filter) ([]*APITokenCfg, error) {
+	var err error
+	var tokens []*APITokenCfg
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&tokens); err != nil {
+			log.Warnf("Fail to get APITokenCfg data filtered with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.db().Find(&tokens); err != nil {
+			log.Warnf("Fail to get APITokenCfg data: %v\n", err)
+			return nil, err
+		}
+	}
+	return tokens, nil
+}
+
+/*AddAPITokenCfg creates a new token with a random secret, the secret is only returned here*/
+func (dbc *DatabaseCfg) AddAPITokenCfg(dev APITokenCfg) (string, error) {
+	if !ValidRole(dev.Role) {
+		return "", fmt.Errorf("Error on add API token %s: unknown role %q", dev.ID, dev.Role)
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("Error on generate API token %s: %s", dev.ID, err)
+	}
+	secret := apiTokenPrefix + hex.EncodeToString(buf)
+	dev.TokenHash = hashAPIToken(secret)
+	dev.CreatedAt = time.Now()
+	dev.LastUsedAt = time.Time{}
+	dev.Revoked = false
+
+	session := dbc.newSession()
+	defer session.Close()
+
+	if _, err := session.Insert(dev); err != nil {
+		session.Rollback()
+		return "", err
+	}
+	if err := session.Commit(); err != nil {
+		return "", err
+	}
+	log.Infof("Added new API token Successfully with id %s, role %s and scope %q for user %s", dev.ID, dev.Role, dev.Scope, dev.Owner)
+	return secret, nil
+}
+
+/*DelAPITokenCfg for deleting tokens from ID*/
+func (dbc *DatabaseCfg) DelAPITokenCfg(id string) (int64, error) {
+	session := dbc.newSession()
+	defer session.Close()
+
+	affected, err := session.Where("id=?", id).Delete(&APITokenCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	if affected == 0 {
+		session.Rollback()
+		return 0, fmt.Errorf("Error no API token found with id %s", id)
+	}
+	if err = session.Commit(); err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully API token with ID %s", id)
+	return affected, nil
+}
+
+/*RevokeAPITokenCfg disables the token, it is kept to know when it was used*/
+func (dbc *DatabaseCfg) RevokeAPITokenCfg(id string) (int64, error) {
+	session := dbc.newSession()
+	defer session.Close()
+
+	affected, err := session.Where("id=?", id).Cols("revoked").UseBool().Update(&APITokenCfg{Revoked: true})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	if affected == 0 {
+		session.Rollback()
+		return 0, fmt.Errorf("Error no API token found with id %s", id)
+	}
+	if err = session.Commit(); err != nil {
+		return 0, err
+	}
+	log.Infof("Revoked Successfully API token with ID %s", id)
+	return affected, nil
+}
+
+/*UpdateAPITokenCfgLastUsed records the last time the token has been used*/
+func (dbc *DatabaseCfg) UpdateAPITokenCfgLastUsed(id string, t time.Time) error {
+	_, err := dbc.db().Where("id=?", id).Cols("last_used_at").Update(&APITokenCfg{LastUsedAt: t})
+	return err
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAPITokenScope(t *testing.T) {
+	tests := []struct {
+		scope string
+		id    string
+		want  bool
+	}{
+		{"", "", true},
+		{"", "sw1", true},
+		{"sw1", "sw1", true},
+		{"sw1", "sw10", false},
+		{"sw1", "", false},
+		{"mad_*", "mad_sw1", true},
+		{"mad_*", "bcn_sw1", false},
+	}
+	for _, tt := range tests {
+		tok := &APITokenCfg{Scope: tt.scope}
+		if got := tok.InScope(tt.id); got != tt.want {
+			t.Errorf("scope %q InScope(%q) = %v, want %v", tt.scope, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestAPITokenLifecycle(t *testing.T) {
+	dbc, release := newTestSQLiteDB(t)
+	defer release()
+
+	secret, err := dbc.AddAPITokenCfg(APITokenCfg{ID: "provisioning", Role: RoleOperator, Owner: "admin", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbc.GetAPITokenCfgBySecret(secret + "x"); err == nil {
+		t.Error("wrong token secret accepted")
+	}
+	tok, err := dbc.GetAPITokenCfgBySecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.ID != "provisioning" || tok.CheckValid(time.Now()) != nil {
+		t.Errorf("unexpected token %+v", tok)
+	}
+	if tok.CheckValid(time.Now().Add(2*time.Hour)) == nil {
+		t.Error("expired token is valid")
+	}
+
+	used := time.Now()
+	if err := dbc.UpdateAPITokenCfgLastUsed("provisioning", used); err != nil {
+		t.Fatal(err)
+	}
+	if tok, _ = dbc.GetAPITokenCfgByID("provisioning"); tok.LastUsedAt.Unix() != used.Unix() || tok.Role != RoleOperator {
+		t.Errorf("last used time not updated %+v", tok)
+	}
+
+	if _, err := dbc.RevokeAPITokenCfg("provisioning"); err != nil {
+		t.Fatal(err)
+	}
+	if tok, _ = dbc.GetAPITokenCfgBySecret(secret); tok.CheckValid(time.Now()) == nil {
+		t.Error("revoked token is valid")
+	}
+	if _, err := dbc.DelAPITokenCfg("provisioning"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbc.GetAPITokenCfgBySecret(secret); err == nil {
+		t.Error("deleted token found")
+	}
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditActionAdd    = "add"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// AuditFieldDiff a changed field between before and after object states
+type AuditFieldDiff struct {
+	Field  string
+	Before interface{}
+	After  interface{}
+}
+
+// AuditCfg records a configuration change
+type AuditCfg struct {
+	ID        int64                  `xorm:"'id' pk autoincr"`
+	Timestamp time.Time              `xorm:"'audit_time' index"`
+	User      string                 `xorm:"'username' index"`
+	ObjType   string                 `xorm:"'obj_type' index"`
+	ObjID     string                 `xorm:"'obj_id' index"`
+	Action    string                 `xorm:"action"`
+	Before    map[string]interface{} `xorm:"'before_state' text"`
+	After     map[string]interface{} `xorm:"'after_state' text"`
+	Diff      []AuditFieldDiff       `xorm:"'diff' text"`
+	RevertOf  int64                  `xorm:"'revert_of' default 0"` // ID of the reverted change if any
+}
+
+// AuditQuery filters for the audit log
+type AuditQuery struct {
+	User    string
+	ObjType string
+	ObjID   string
+	Action  string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
+
+/***************************
+	Audit Log
+	-AddAuditCfg
+	-GetAuditCfgByID
+	-GetAuditCfgArray
+	-RevertAuditCfg
+***********************************/
+
+// cfgObject2Map converts any config object to a generic JSON map
+func cfgObject2Map(obj interface{}) (map[string]interface{}, error) {
+	if obj == nil || reflect.ValueOf(obj).IsNil() {
+		return nil, nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{})
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// map2CfgObject converts a generic JSON map into a new objtype config object
+func map2CfgObject(objtype string, m map[string]interface{}) (interface{}, error) {
+	obj, err := NewCfgObject(objtype)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, obj)
+	return obj, err
+}
+
+// CfgMapDiff returns the sorted list of fields with different values between two
+// config objects converted to generic JSON maps
+func CfgMapDiff(before, after map[string]interface{}) []AuditFieldDiff {
+	keys := make(map[string]bool)
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+	var fields []string
+	for k := range keys {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	diff := []AuditFieldDiff{}
+	for _, k := range fields {
+		if !reflect.DeepEqual(before[k], after[k]) {
+			diff = append(diff, AuditFieldDiff{Field: k, Before: before[k], After: after[k]})
+		}
+	}
+	return diff
+}
+
+/*AddAuditCfg record a change for the objtype/id object, after state is read back from the database*/
+func (dbc *DatabaseCfg) AddAuditCfg(user string, objtype string, action string, id string, before interface{}) (*AuditCfg, error) {
+	var err error
+	a := &AuditCfg{
+		Timestamp: time.Now(),
+		User:      user,
+		ObjType:   objtype,
+		ObjID:     id,
+		Action:    action,
+	}
+	if a.Before, err = cfgObject2Map(before); err != nil {
+		return nil, fmt.Errorf("Error on audit %s %s/%s: %s", action, objtype, id, err)
+	}
+	if action != AuditActionDelete {
+		after, err := dbc.GetCfgObjectByID(objtype, id)
+		if err != nil {
+			return nil, fmt.Errorf("Error on audit %s %s/%s: %s", action, objtype, id, err)
+		}
+		if a.After, err = cfgObject2Map(after); err != nil {
+			return nil, fmt.Errorf("Error on audit %s %s/%s: %s", action, objtype, id, err)
+		}
+	}
+	a.Diff = CfgMapDiff(a.Before, a.After)
+	if _, err = dbc.db().Insert(a); err != nil {
+		return nil, fmt.Errorf("Error on audit %s %s/%s: %s", action, objtype, id, err)
+	}
+	log.Infof("Audit: user %s %s %s with id %s [audit id %d]", user, action, objtype, id, a.ID)
+	return a, nil
+}
+
+/*GetAuditCfgByID get audit log entry by id*/
+func (dbc *DatabaseCfg) GetAuditCfgByID(id int64) (*AuditCfg, error) {
+	a := &AuditCfg{}
+	found, err := dbc.db().ID(id).Get(a)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("Error no values have been returned with this id %d in the audit table", id)
+	}
+	return a, nil
+}
+
+/*GetAuditCfgArray get audit log entries (newest first) filtered by query*/
+func (dbc *DatabaseCfg) GetAuditCfgArray(q *AuditQuery) ([]*AuditCfg, error) {
+	var filter *Filter
+	if len(q.User) > 0 {
+		filter = filter.And(FilterEq("username", q.User))
+	}
+	if len(q.ObjType) > 0 {
+		filter = filter.And(FilterEq("obj_type", q.ObjType))
+	}
+	if len(q.ObjID) > 0 {
+		filter = filter.And(FilterEq("obj_id", q.ObjID))
+	}
+	if len(q.Action) > 0 {
+		filter = filter.And(FilterEq("action", q.Action))
+	}
+	// timestamps are compared with the same format xorm stores them
+	if !q.From.IsZero() {
+		filter = filter.And(FilterGte("audit_time", q.From.In(dbc.x.TZLocation).Format("2006-01-02 15:04:05")))
+	}
+	if !q.To.IsZero() {
+		filter = filter.And(FilterLte("audit_time", q.To.In(dbc.x.TZLocation).Format("2006-01-02 15:04:05")))
+	}
+	session := dbc.newSession()
+	defer session.Close()
+	if filter != nil {
+		session.Where(filter.cond)
+	}
+	if q.Limit > 0 {
+		session.Limit(q.Limit)
+	}
+	var audit []*AuditCfg
+	if err := session.Desc("id").Find(&audit); err != nil {
+		log.Warnf("Fail to get AuditCfg data filtered with %s : %v\n", filter, err)
+		return nil, err
+	}
+	return audit, nil
+}
+
+func (dbc *DatabaseCfg) checkAuditCurrentState(a *AuditCfg) error {
+	id, _ := a.After["ID"].(string)
+	current, err := dbc.GetCfgObjectByID(a.ObjType, id)
+	if err != nil {
+		return fmt.Errorf("Error on revert audit %d: object %s/%s not found: %s", a.ID, a.ObjType, id, err)
+	}
+	cm, err := cfgObject2Map(current)
+	if err != nil {
+		return err
+	}
+	if len(CfgMapDiff(cm, a.After)) > 0 {
+		return fmt.Errorf("Error on revert audit %d: object %s/%s has been changed after this change", a.ID, a.ObjType, id)
+	}
+	return nil
+}
+
+/*RevertAuditCfg undo a single audited change, only if the object has not been changed after it*/
+func (dbc *DatabaseCfg) RevertAuditCfg(id int64, user string) (*AuditCfg, error) {
+	a, err := dbc.GetAuditCfgByID(id)
+	if err != nil {
+		return nil, err
+	}
+	var r *AuditCfg
+	var obj interface{}
+	switch a.Action {
+	case AuditActionAdd:
+		if err = dbc.checkAuditCurrentState(a); err != nil {
+			return nil, err
+		}
+		before, _ := dbc.GetCfgObjectByID(a.ObjType, a.ObjID)
+		if _, err = dbc.DelCfgObject(a.ObjType, a.ObjID); err != nil {
+			return nil, fmt.Errorf("Error on revert audit %d: %s", a.ID, err)
+		}
+		r, err = dbc.AddAuditCfg(user, a.ObjType, AuditActionDelete, a.ObjID, before)
+	case AuditActionUpdate:
+		if err = dbc.checkAuditCurrentState(a); err != nil {
+			return nil, err
+		}
+		curid, _ := a.After["ID"].(string)
+		before, _ := dbc.GetCfgObjectByID(a.ObjType, curid)
+		if obj, err = map2CfgObject(a.ObjType, a.Before); err != nil {
+			return nil, fmt.Errorf("Error on revert audit %d: %s", a.ID, err)
+		}
+		oldid, _ := a.Before["ID"].(string)
+		if _, err = dbc.UpdateCfgObject(a.ObjType, curid, obj); err != nil {
+			return nil, fmt.Errorf("Error on revert audit %d: %s", a.ID, err)
+		}
+		r, err = dbc.AddAuditCfg(user, a.ObjType, AuditActionUpdate, oldid, before)
+	case AuditActionDelete:
+		if _, err = dbc.GetCfgObjectByID(a.ObjType, a.ObjID); err == nil {
+			return nil, fmt.Errorf("Error on revert audit %d: object %s/%s already exist", a.ID, a.ObjType, a.ObjID)
+		}
+		if obj, err = map2CfgObject(a.ObjType, a.Before); err != nil {
+			return nil, fmt.Errorf("Error on revert audit %d: %s", a.ID, err)
+		}
+		if _, err = dbc.AddCfgObject(a.ObjType, obj); err != nil {
+			return nil, fmt.Errorf("Error on revert audit %d: %s", a.ID, err)
+		}
+		r, err = dbc.AddAuditCfg(user, a.ObjType, AuditActionAdd, a.ObjID, nil)
+	default:
+		return nil, fmt.Errorf("Error on revert audit %d: unknown action %s", a.ID, a.Action)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.RevertOf = a.ID
+	if _, err = dbc.db().ID(r.ID).Cols("revert_of").Update(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuditRevert(t *testing.T) {
+	dbc, release := newTestSQLiteDB(t)
+	defer release()
+
+	start := time.Now().Add(-time.Second)
+	influx := InfluxCfg{ID: "influx1", Host: "127.0.0.1", Port: 8086, DB: "snmp", Retention: "autogen"}
+	if _, err := dbc.AddInfluxCfg(influx); err != nil {
+		t.Fatal(err)
+	}
+	add, err := dbc.AddAuditCfg("admin", "influxcfg", AuditActionAdd, "influx1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, _ := dbc.GetCfgObjectByID("influxcfg", "influx1")
+	updated := influx
+	updated.ID = "influx2"
+	updated.DB = "snmp2"
+	if _, err := dbc.UpdateInfluxCfg("influx1", updated); err != nil {
+		t.Fatal(err)
+	}
+	upd, err := dbc.AddAuditCfg("operator", "influxcfg", AuditActionUpdate, "influx2", before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := map[string]bool{}
+	for _, d := range upd.Diff {
+		diff[d.Field] = true
+	}
+	if len(diff) != 2 || !diff["ID"] || !diff["DB"] {
+		t.Errorf("unexpected update diff %+v", upd.Diff)
+	}
+
+	// add can not be reverted while object has been changed later
+	if _, err := dbc.RevertAuditCfg(add.ID, "admin"); err == nil {
+		t.Errorf("expected error on revert of a later changed object")
+	}
+
+	// revert the update: rename back and restore db
+	rev, err := dbc.RevertAuditCfg(upd.ID, "admin")
+	if err != nil {
+		t.Fatalf("RevertAuditCfg error: %s", err)
+	}
+	if rev.RevertOf != upd.ID || rev.ObjID != "influx1" {
+		t.Errorf("unexpected revert audit entry %+v", rev)
+	}
+	got, err := dbc.GetInfluxCfgByID("influx1")
+	if err != nil || got.DB != "snmp" {
+		t.Errorf("update not reverted: %+v (%v)", got, err)
+	}
+
+	// query by user and time range
+	audit, err := dbc.GetAuditCfgArray(&AuditQuery{User: "operator", From: start, To: time.Now().Add(time.Second)})
+	if err != nil || len(audit) != 1 || audit[0].ID != upd.ID {
+		t.Errorf("unexpected audit query result %+v (%v)", audit, err)
+	}
+	audit, _ = dbc.GetAuditCfgArray(&AuditQuery{ObjType: "influxcfg"})
+	if len(audit) != 3 || audit[0].ID != rev.ID {
+		t.Errorf("expected 3 audit entries newest first got %d", len(audit))
+	}
+	audit, _ = dbc.GetAuditCfgArray(&AuditQuery{From: time.Now().Add(time.Hour)})
+	if len(audit) != 0 {
+		t.Errorf("expected no audit entries in the future got %d", len(audit))
+	}
+
+	// delete and revert the delete
+	before, _ = dbc.GetCfgObjectByID("influxcfg", "influx1")
+	if _, err := dbc.DelInfluxCfg("influx1"); err != nil {
+		t.Fatal(err)
+	}
+	del, err := dbc.AddAuditCfg("admin", "influxcfg", AuditActionDelete, "influx1", before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbc.RevertAuditCfg(del.ID, "admin"); err != nil {
+		t.Fatalf("RevertAuditCfg on delete error: %s", err)
+	}
+	if _, err := dbc.GetInfluxCfgByID("influx1"); err != nil {
+		t.Errorf("deleted object not restored: %s", err)
+	}
+}
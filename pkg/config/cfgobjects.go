@@ -0,0 +1,307 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+/***************************
+	Generic Config Object access
+	(object type names are the same used in import/export)
+	-GetCfgObjectTypes
+	-NewCfgObject
+	-GetCfgObjectByID
+	-GetCfgObjectIDs
+	-AddCfgObject
+	-UpdateCfgObject
+	-DelCfgObject
+***********************************/
+
+type cfgObjectType struct {
+	new    func() interface{}
+	get    func(dbc *DatabaseCfg, id string) (interface{}, error)
+	list   func(dbc *DatabaseCfg, filter *Filter) (interface{}, error)
+	add    func(dbc *DatabaseCfg, obj interface{}) (int64, error)
+	update func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error)
+	del    func(dbc *DatabaseCfg, id string) (int64, error)
+}
+
+var cfgObjectTypes = map[string]*cfgObjectType{
+	"snmpdevicecfg": {
+		new: func() interface{} { return &SnmpDeviceCfg{} },
+		get: func(dbc *DatabaseCfg, id string) (interface{}, error) {
+			o, err := dbc.GetSnmpDeviceCfgByID(id)
+			return &o, err
+		},
+		list: func(dbc *DatabaseCfg, filter *Filter) (interface{}, error) {
+			return dbc.GetSnmpDeviceCfgArray(filter)
+		},
+		add: func(dbc *DatabaseCfg, obj interface{}) (int64, error) {
+			return dbc.AddSnmpDeviceCfg(*obj.(*SnmpDeviceCfg))
+		},
+		update: func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error) {
+			return dbc.UpdateSnmpDeviceCfg(id, *obj.(*SnmpDeviceCfg))
+		},
+		del: (*DatabaseCfg).DelSnmpDeviceCfg,
+	},
+	"influxcfg": {
+		new: func() interface{} { return &InfluxCfg{} },
+		get: func(dbc *DatabaseCfg, id string) (interface{}, error) {
+			o, err := dbc.GetInfluxCfgByID(id)
+			return &o, err
+		},
+		list: func(dbc *DatabaseCfg, filter *Filter) (interface{}, error) {
+			return dbc.GetInfluxCfgArray(filter)
+		},
+		add: func(dbc *DatabaseCfg, obj interface{}) (int64, error) { return dbc.AddInfluxCfg(*obj.(*InfluxCfg)) },
+		update: func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error) {
+			return dbc.UpdateInfluxCfg(id, *obj.(*InfluxCfg))
+		},
+		del: (*DatabaseCfg).DelInfluxCfg,
+	},
+	"measfiltercfg": {
+		new: func() interface{} { return &MeasFilterCfg{} },
+		get: func(dbc *DatabaseCfg, id string) (interface{}, error) {
+			o, err := dbc.GetMeasFilterCfgByID(id)
+			return &o, err
+		},
+		list: func(dbc *DatabaseCfg, filter *Filter) (interface{}, error) {
+			return dbc.GetMeasFilterCfgArray(filter)
+		},
+		add: func(dbc *DatabaseCfg, obj interface{}) (int64, error) {
+			return dbc.AddMeasFilterCfg(*obj.(*MeasFilterCfg))
+		},
+		update: func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error) {
+			return dbc.UpdateMeasFilterCfg(id, *obj.(*MeasFilterCfg))
+		},
+		del: (*DatabaseCfg).DelMeasFilterCfg,
+	},
+	"customfiltercfg": {
+		new: func() interface{} { return &CustomFilterCfg{} },
+		get: func(dbc *DatabaseCfg, id string) (interface{}, error) {
+			o, err := dbc.GetCustomFilterCfgByID(id)
+			return &o, err
+		},
+		list: func(dbc *DatabaseCfg, filter *Filter) (interface{}, error) {
+			return dbc.GetCustomFilterCfgArray(filter)
+		},
+		add: func(dbc *DatabaseCfg, obj interface{}) (int64, error) {
+			return dbc.AddCustomFilterCfg(*obj.(*CustomFilterCfg))
+		},
+		update: func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error) {
+			return dbc.UpdateCustomFilterCfg(id, *obj.(*CustomFilterCfg))
+		},
+		del: (*DatabaseCfg).DelCustomFilterCfg,
+	},
+	"oidconditioncfg": {
+		new: func() interface{} { return &OidConditionCfg{} },
+		get: func(dbc *DatabaseCfg, id string) (interface{}, error) {
+			o, err := dbc.GetOidConditionCfgByID(id)
+			return &o, err
+		},
+		list: func(dbc *DatabaseCfg, filter *Filter) (interface{}, error) {
+			return dbc.GetOidConditionCfgArray(filter)
+		},
+		add: func(dbc *DatabaseCfg, obj interface{}) (int64, error) {
+			return dbc.AddOidConditionCfg(*obj.(*OidConditionCfg))
+		},
+		update: func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error) {
+			return dbc.UpdateOidConditionCfg(id, *obj.(*OidConditionCfg))
+		},
+		del: (*DatabaseCfg).DelOidConditionCfg,
+	},
+	"measurementcfg": {
+		new: func() interface{} { return &MeasurementCfg{} },
+		get: func(dbc *DatabaseCfg, id string) (interface{}, error) {
+			o, err := dbc.GetMeasurementCfgByID(id)
+			return &o, err
+		},
+		list: func(dbc *DatabaseCfg, filter *Filter) (interface{}, error) {
+			return dbc.GetMeasurementCfgArray(filter)
+		},
+		add: func(dbc *DatabaseCfg, obj interface{}) (int64, error) {
+			return dbc.AddMeasurementCfg(*obj.(*MeasurementCfg))
+		},
+		update: func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error) {
+			return dbc.UpdateMeasurementCfg(id, *obj.(*MeasurementCfg))
+		},
+		del: (*DatabaseCfg).DelMeasurementCfg,
+	},
+	"snmpmetriccfg": {
+		new: func() interface{} { return &SnmpMetricCfg{} },
+		get: func(dbc *DatabaseCfg, id string) (interface{}, error) {
+			o, err := dbc.GetSnmpMetricCfgByID(id)
+			return &o, err
+		},
+		list: func(dbc *DatabaseCfg, filter *Filter) (interface{}, error) {
+			return dbc.GetSnmpMetricCfgArray(filter)
+		},
+		add: func(dbc *DatabaseCfg, obj interface{}) (int64, error) {
+			return dbc.AddSnmpMetricCfg(*obj.(*SnmpMetricCfg))
+		},
+		update: func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error) {
+			return dbc.UpdateSnmpMetricCfg(id, *obj.(*SnmpMetricCfg))
+		},
+		del: (*DatabaseCfg).DelSnmpMetricCfg,
+	},
+	"measgroupcfg": {
+		new: func() interface{} { return &MGroupsCfg{} },
+		get: func(dbc *DatabaseCfg, id string) (interface{}, error) {
+			o, err := dbc.GetMGroupsCfgByID(id)
+			return &o, err
+		},
+		list: func(dbc *DatabaseCfg, filter *Filter) (interface{}, error) {
+			return dbc.GetMGroupsCfgArray(filter)
+		},
+		add: func(dbc *DatabaseCfg, obj interface{}) (int64, error) { return dbc.AddMGroupsCfg(*obj.(*MGroupsCfg)) },
+		update: func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error) {
+			return dbc.UpdateMGroupsCfg(id, *obj.(*MGroupsCfg))
+		},
+		del: (*DatabaseCfg).DelMGroupsCfg,
+	},
+	"varcatalogcfg": {
+		new: func() interface{} { return &VarCatalogCfg{} },
+		get: func(dbc *DatabaseCfg, id string) (interface{}, error) {
+			o, err := dbc.GetVarCatalogCfgByID(id)
+			return &o, err
+		},
+		list: func(dbc *DatabaseCfg, filter *Filter) (interface{}, error) {
+			return dbc.GetVarCatalogCfgArray(filter)
+		},
+		add: func(dbc *DatabaseCfg, obj interface{}) (int64, error) {
+			return dbc.AddVarCatalogCfg(*obj.(*VarCatalogCfg))
+		},
+		update: func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error) {
+			return dbc.UpdateVarCatalogCfg(id, *obj.(*VarCatalogCfg))
+		},
+		del: (*DatabaseCfg).DelVarCatalogCfg,
+	},
+	"alertrulecfg": {
+		new: func() interface{} { return &AlertRuleCfg{} },
+		get: func(dbc *DatabaseCfg, id string) (interface{}, error) {
+			o, err := dbc.GetAlertRuleCfgByID(id)
+			return &o, err
+		},
+		list: func(dbc *DatabaseCfg, filter *Filter) (interface{}, error) {
+			return dbc.GetAlertRuleCfgArray(filter)
+		},
+		add: func(dbc *DatabaseCfg, obj interface{}) (int64, error) {
+			return dbc.AddAlertRuleCfg(*obj.(*AlertRuleCfg))
+		},
+		update: func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error) {
+			return dbc.UpdateAlertRuleCfg(id, *obj.(*AlertRuleCfg))
+		},
+		del: (*DatabaseCfg).DelAlertRuleCfg,
+	},
+	"alertreceivercfg": {
+		new: func() interface{} { return &AlertReceiverCfg{} },
+		get: func(dbc *DatabaseCfg, id string) (interface{}, error) {
+			o, err := dbc.GetAlertReceiverCfgByID(id)
+			return &o, err
+		},
+		list: func(dbc *DatabaseCfg, filter *Filter) (interface{}, error) {
+			return dbc.GetAlertReceiverCfgArray(filter)
+		},
+		add: func(dbc *DatabaseCfg, obj interface{}) (int64, error) {
+			return dbc.AddAlertReceiverCfg(*obj.(*AlertReceiverCfg))
+		},
+		update: func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error) {
+			return dbc.UpdateAlertReceiverCfg(id, *obj.(*AlertReceiverCfg))
+		},
+		del: (*DatabaseCfg).DelAlertReceiverCfg,
+	},
+	"maintwindowcfg": {
+		new: func() interface{} { return &MaintWindowCfg{} },
+		get: func(dbc *DatabaseCfg, id string) (interface{}, error) {
+			o, err := dbc.GetMaintWindowCfgByID(id)
+			return &o, err
+		},
+		list: func(dbc *DatabaseCfg, filter *Filter) (interface{}, error) {
+			return dbc.GetMaintWindowCfgArray(filter)
+		},
+		add: func(dbc *DatabaseCfg, obj interface{}) (int64, error) {
+			return dbc.AddMaintWindowCfg(*obj.(*MaintWindowCfg))
+		},
+		update: func(dbc *DatabaseCfg, id string, obj interface{}) (int64, error) {
+			return dbc.UpdateMaintWindowCfg(id, *obj.(*MaintWindowCfg))
+		},
+		del: (*DatabaseCfg).DelMaintWindowCfg,
+	},
+}
+
+func getCfgObjectType(objtype string) (*cfgObjectType, error) {
+	t, ok := cfgObjectTypes[objtype]
+	if !ok {
+		return nil, fmt.Errorf("Unknown configuration object type %s", objtype)
+	}
+	return t, nil
+}
+
+// GetCfgObjectTypes returns the sorted list of known configuration object types
+func GetCfgObjectTypes() []string {
+	var types []string
+	for k := range cfgObjectTypes {
+		types = append(types, k)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// NewCfgObject returns a pointer to a new empty object of the objtype
+func NewCfgObject(objtype string) (interface{}, error) {
+	t, err := getCfgObjectType(objtype)
+	if err != nil {
+		return nil, err
+	}
+	return t.new(), nil
+}
+
+/*GetCfgObjectByID get a pointer to any configuration object by type and id*/
+func (dbc *DatabaseCfg) GetCfgObjectByID(objtype string, id string) (interface{}, error) {
+	t, err := getCfgObjectType(objtype)
+	if err != nil {
+		return nil, err
+	}
+	return t.get(dbc, id)
+}
+
+/*GetCfgObjectIDs get the sorted ID list of all objtype configuration objects*/
+func (dbc *DatabaseCfg) GetCfgObjectIDs(objtype string) ([]string, error) {
+	t, err := getCfgObjectType(objtype)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	if err = dbc.db().Table(t.new()).Cols("id").Asc("id").Find(&ids); err != nil {
+		log.Warnf("Fail to get %s ID list : %v\n", objtype, err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+/*AddCfgObject add any configuration object (obj should be a pointer got from NewCfgObject)*/
+func (dbc *DatabaseCfg) AddCfgObject(objtype string, obj interface{}) (int64, error) {
+	t, err := getCfgObjectType(objtype)
+	if err != nil {
+		return 0, err
+	}
+	return t.add(dbc, obj)
+}
+
+/*UpdateCfgObject update any configuration object (obj should be a pointer got from NewCfgObject)*/
+func (dbc *DatabaseCfg) UpdateCfgObject(objtype string, id string, obj interface{}) (int64, error) {
+	t, err := getCfgObjectType(objtype)
+	if err != nil {
+		return 0, err
+	}
+	return t.update(dbc, id, obj)
+}
+
+/*DelCfgObject delete any configuration object by type and id*/
+func (dbc *DatabaseCfg) DelCfgObject(objtype string, id string) (int64, error) {
+	t, err := getCfgObjectType(objtype)
+	if err != nil {
+		return 0, err
+	}
+	return t.del(dbc, id)
+}
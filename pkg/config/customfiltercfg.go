@@ -15,7 +15,7 @@ Custom Filter Cfg
 
 /*GetCustomFilterCfgByID get metric data by id*/
 func (dbc *DatabaseCfg) GetCustomFilterCfgByID(id string) (CustomFilterCfg, error) {
-	cfgarray, err := dbc.GetCustomFilterCfgArray("id='" + id + "'")
+	cfgarray, err := dbc.GetCustomFilterCfgArray(FilterEq("id", id))
 	if err != nil {
 		return CustomFilterCfg{}, err
 	}
@@ -29,7 +29,7 @@ func (dbc *DatabaseCfg) GetCustomFilterCfgByID(id string) (CustomFilterCfg, erro
 }
 
 /*GetCustomFilterCfgMap  return data in map format*/
-func (dbc *DatabaseCfg) GetCustomFilterCfgMap(filter string) (map[string]*CustomFilterCfg, error) {
+func (dbc *DatabaseCfg) GetCustomFilterCfgMap(filter *Filter) (map[string]*CustomFilterCfg, error) {
 	cfgarray, err := dbc.GetCustomFilterCfgArray(filter)
 	cfgmap := make(map[string]*CustomFilterCfg)
 	for _, val := range cfgarray {
@@ -40,24 +40,24 @@ func (dbc *DatabaseCfg) GetCustomFilterCfgMap(filter string) (map[string]*Custom
 }
 
 /*GetCustomFilterCfgArray generate an array of metrics with all its information */
-func (dbc *DatabaseCfg) GetCustomFilterCfgArray(filter string) ([]*CustomFilterCfg, error) {
+func (dbc *DatabaseCfg) GetCustomFilterCfgArray(filter *Filter) ([]*CustomFilterCfg, error) {
 	var err error
 	var filters []*CustomFilterCfg
 	//Get Only data for selected metrics
-	if len(filter) > 0 {
-		if err = dbc.x.Where(filter).Find(&filters); err != nil {
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&filters); err != nil {
 			log.Warnf("Fail to get CustomFilterCfg  data filteter with %s : %v\n", filter, err)
 			return nil, err
 		}
 	} else {
-		if err = dbc.x.Find(&filters); err != nil {
+		if err = dbc.db().Find(&filters); err != nil {
 			log.Warnf("Fail to get CustomFilterCfg   data: %v\n", err)
 			return nil, err
 		}
 	}
 	for k, vf := range filters {
 		var item []*CustomFilterItems
-		if err = dbc.x.Where("customid = '" + vf.ID + "'").Find(&item); err != nil {
+		if err = dbc.db().Where("customid=?", vf.ID).Find(&item); err != nil {
 			log.Warnf("Fail to get CustomFilterItems  data filtered with ID %s : %v\n", vf.ID, err)
 			continue
 		}
@@ -82,7 +82,7 @@ func (dbc *DatabaseCfg) AddCustomFilterCfg(dev CustomFilterCfg) (int64, error) {
 	var affected int64
 	// create CustomFilterCfg to check if any configuration issue found before persist to database.
 	// initialize data persistence
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	affected, err = session.Insert(dev)
@@ -91,7 +91,7 @@ func (dbc *DatabaseCfg) AddCustomFilterCfg(dev CustomFilterCfg) (int64, error) {
 		return 0, err
 	}
 	// first we will remove all previous entries
-	affected, err = session.Where("customid='" + dev.ID + "'").Delete(&CustomFilterItems{})
+	affected, err = session.Where("customid=?", dev.ID).Delete(&CustomFilterItems{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Addig new filter config inputs with id on add MeasurementFieldCfg with id: %s, error: %s", dev.ID, err)
@@ -120,23 +120,23 @@ func (dbc *DatabaseCfg) DelCustomFilterCfg(id string) (int64, error) {
 	var affecteddev, affected int64
 	var err error
 
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 	// deleting references in Measurements
 
-	affecteddev, err = session.Where("filter_name='" + id + "'").Cols("filter_name").Update(&MeasFilterCfg{})
+	affecteddev, err = session.Where("filter_name=?", id).Cols("filter_name").Update(&MeasFilterCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Custom Filter on Measurement Filter table with id:  %s , error: %s", id, err)
 	}
 
-	affecteddev, err = session.Where("customid='" + id + "'").Delete(&CustomFilterItems{})
+	affecteddev, err = session.Where("customid=?", id).Delete(&CustomFilterItems{})
 	if err != nil {
 		session.Rollback()
 		return 0, err
 	}
 
-	affected, err = session.Where("id='" + id + "'").Delete(&CustomFilterCfg{})
+	affected, err = session.Where("id=?", id).Delete(&CustomFilterCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -157,18 +157,18 @@ func (dbc *DatabaseCfg) UpdateCustomFilterCfg(id string, dev CustomFilterCfg) (i
 	var affected, affecteddev int64
 	// create CustomFilterCfg to check if any configuration issue found before persist to database.
 	// initialize data persistence
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	if id != dev.ID { //ID has been changed so we need to update Related MeasurementCfg
-		affecteddev, err = session.Where("filter_name='" + id + "'").Cols("filter_name").Update(&MeasFilterCfg{FilterName: dev.ID})
+		affecteddev, err = session.Where("filter_name=?", id).Cols("filter_name").Update(&MeasFilterCfg{FilterName: dev.ID})
 		if err != nil {
 			session.Rollback()
 			return 0, fmt.Errorf("Error on Update Custom Filter on update id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
 		}
 	}
 	// first we will remove all previous entries
-	affected, err = session.Where("customid='" + dev.ID + "'").Delete(&CustomFilterItems{})
+	affected, err = session.Where("customid=?", dev.ID).Delete(&CustomFilterItems{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Addig new filter config inputs with id on add MeasurementFieldCfg with id: %s, error: %s", dev.ID, err)
@@ -181,7 +181,7 @@ func (dbc *DatabaseCfg) UpdateCustomFilterCfg(id string, dev CustomFilterCfg) (i
 			return 0, err
 		}
 	}
-	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	affected, err = session.Where("id=?", id).UseBool().AllCols().Update(dev)
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -200,7 +200,7 @@ func (dbc *DatabaseCfg) UpdateCustomFilterCfg(id string, dev CustomFilterCfg) (i
 func (dbc *DatabaseCfg) GetCustomFilterCfgAffectOnDel(id string) ([]*DbObjAction, error) {
 	var filters []*MeasFilterCfg
 	var obj []*DbObjAction
-	if err := dbc.x.Where("filter_name='" + id + "'").Find(&filters); err != nil {
+	if err := dbc.db().Where("filter_name=?", id).Find(&filters); err != nil {
 		log.Warnf("Error on Get CustomID  id %s for Measurement Filters , error: %s", id, err)
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -9,6 +10,8 @@ import (
 
 	// _ needed to mysql
 	_ "github.com/go-sql-driver/mysql"
+	// _ needed to postgres
+	_ "github.com/lib/pq"
 
 	"github.com/go-xorm/core"
 	"github.com/go-xorm/xorm"
@@ -28,6 +31,75 @@ func (dbc *DatabaseCfg) getChanges() int64 {
 	return atomic.LoadInt64(&dbc.numChanges)
 }
 
+// dbSession is the session used on each database operation, inside a Transaction
+// all operations share the transaction session and their own Commit, Rollback and
+// Close calls are ignored (the transaction is committed or rolled back at its end)
+type dbSession struct {
+	*xorm.Session
+	inTx bool
+}
+
+func (s *dbSession) Commit() error {
+	if s.inTx {
+		return nil
+	}
+	return s.Session.Commit()
+}
+
+func (s *dbSession) Rollback() error {
+	if s.inTx {
+		return nil
+	}
+	return s.Session.Rollback()
+}
+
+func (s *dbSession) Close() {
+	if !s.inTx {
+		s.Session.Close()
+	}
+}
+
+func (dbc *DatabaseCfg) newSession() *dbSession {
+	if dbc.tx != nil {
+		return &dbSession{Session: dbc.tx, inTx: true}
+	}
+	return &dbSession{Session: dbc.x.NewSession()}
+}
+
+// db returns the transaction session if any or the engine otherwise
+func (dbc *DatabaseCfg) db() xorm.Interface {
+	if dbc.tx != nil {
+		return dbc.tx
+	}
+	return dbc.x
+}
+
+// Transaction runs f with a DatabaseCfg whose operations (reads included) are done
+// in a single transaction, committed if f returns nil and rolled back on any error
+func (dbc *DatabaseCfg) Transaction(f func(tx *DatabaseCfg) error) error {
+	if dbc.tx != nil {
+		// already in a transaction
+		return f(dbc)
+	}
+	session := dbc.x.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	tx := &DatabaseCfg{Type: dbc.Type, Name: dbc.Name, x: dbc.x, tx: session}
+	if err := f(tx); err != nil {
+		if rerr := session.Rollback(); rerr != nil {
+			log.Errorf("Error on rollback transaction: %s", rerr)
+		}
+		return err
+	}
+	if err := session.Commit(); err != nil {
+		return fmt.Errorf("Error on commit transaction: %s", err)
+	}
+	dbc.addChanges(tx.getChanges())
+	return nil
+}
+
 //DbObjAction measurement groups to assign to devices
 type DbObjAction struct {
 	Type     string
@@ -36,8 +108,52 @@ type DbObjAction struct {
 	Action   string
 }
 
-//InitDB initialize de BD configuration
-func (dbc *DatabaseCfg) InitDB() {
+// pgQuote escapes a value for a libpq key=value connection string
+func pgQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `'`, `\'`, -1)
+	return "'" + s + "'"
+}
+
+// postgresDataSource builds the lib/pq connection string, Host could be
+// "host", "host:port" or an unix socket directory like "/var/run/postgresql"
+func (dbc *DatabaseCfg) postgresDataSource() string {
+	host := dbc.Host
+	port := "5432"
+	if !strings.HasPrefix(host, "/") {
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			host, port = h, p
+		}
+	}
+	if len(host) == 0 {
+		host = "localhost"
+	}
+	sslmode := "disable"
+	if len(dbc.SSLMode) > 0 {
+		sslmode = dbc.SSLMode
+	}
+	opts := []string{
+		"host=" + pgQuote(host),
+		"port=" + pgQuote(port),
+		"dbname=" + pgQuote(dbc.Name),
+		"user=" + pgQuote(dbc.User),
+		"password=" + pgQuote(dbc.Password),
+		"sslmode=" + pgQuote(sslmode),
+	}
+	if len(dbc.SSLCert) > 0 {
+		opts = append(opts, "sslcert="+pgQuote(dbc.SSLCert))
+	}
+	if len(dbc.SSLKey) > 0 {
+		opts = append(opts, "sslkey="+pgQuote(dbc.SSLKey))
+	}
+	if len(dbc.SSLRootCert) > 0 {
+		opts = append(opts, "sslrootcert="+pgQuote(dbc.SSLRootCert))
+	}
+	return strings.Join(opts, " ")
+}
+
+//OpenDB create the ORM engine for the configured database (without schema changes)
+func (dbc *DatabaseCfg) OpenDB() error {
 	// Create ORM engine and database
 	var err error
 	var dbtype string
@@ -57,9 +173,11 @@ func (dbc *DatabaseCfg) InitDB() {
 		}
 		datasource = fmt.Sprintf("%s:%s@%s(%s)/%s?charset=utf8", dbc.User, dbc.Password, protocol, dbc.Host, dbc.Name)
 		//datasource = dbc.User + ":" + dbc.Pass + "@" + dbc.Host + "/" + dbc.Name + "?charset=utf8"
+	case "postgres":
+		dbtype = "postgres"
+		datasource = dbc.postgresDataSource()
 	default:
-		log.Errorf("unknown db  type %s", dbc.Type)
-		return
+		return fmt.Errorf("unknown db  type %s", dbc.Type)
 	}
 
 	dbc.x, err = xorm.NewEngine(dbtype, datasource)
@@ -89,50 +207,26 @@ func (dbc *DatabaseCfg) InitDB() {
 	if dbc.Debug == "true" {
 		dbc.x.Logger().SetLevel(core.LOG_DEBUG)
 	}
+	return nil
+}
 
-	// Sync tables
-	if err = dbc.x.Sync(new(VarCatalogCfg)); err != nil {
-		log.Fatalf("Fail to sync database VarCatalogCfg: %v\n", err)
-	}
-	if err = dbc.x.Sync(new(InfluxCfg)); err != nil {
-		log.Fatalf("Fail to sync database InfluxCfg: %v\n", err)
-	}
-	if err = dbc.x.Sync(new(SnmpDeviceCfg)); err != nil {
-		log.Fatalf("Fail to sync database SnmpDeviceCfg: %v\n", err)
-	}
-	if err = dbc.x.Sync(new(SnmpMetricCfg)); err != nil {
-		log.Fatalf("Fail to sync database SnmpMetricCfg: %v\n", err)
-	}
-	if err = dbc.x.Sync(new(MeasurementCfg)); err != nil {
-		log.Fatalf("Fail to sync database MeasurementCfg: %v\n", err)
-	}
-	if err = dbc.x.Sync(new(MeasFilterCfg)); err != nil {
-		log.Fatalf("Fail to sync database MeasurementFilterCfg : %v\n", err)
-	}
-	if err = dbc.x.Sync(new(MeasurementFieldCfg)); err != nil {
-		log.Fatalf("Fail to sync database MeasurementFieldCfg: %v\n", err)
-	}
-	if err = dbc.x.Sync(new(MGroupsCfg)); err != nil {
-		log.Fatalf("Fail to sync database MGroupCfg: %v\n", err)
-	}
-	if err = dbc.x.Sync(new(MGroupsMeasurements)); err != nil {
-		log.Fatalf("Fail to sync database MGroupsMeasurements: %v\n", err)
-	}
-	if err = dbc.x.Sync(new(SnmpDevMGroups)); err != nil {
-		log.Fatalf("Fail to sync database SnmpDevMGroups: %v\n", err)
-	}
-	if err = dbc.x.Sync(new(SnmpDevFilters)); err != nil {
-		log.Fatalf("Fail to sync database SnmpDevFilters: %v\n", err)
-	}
-	if err = dbc.x.Sync(new(CustomFilterCfg)); err != nil {
-		log.Fatalf("Fail to sync database CustomFilterCfg: %v\n", err)
+//InitDB initialize de BD configuration and apply pending schema migrations
+func (dbc *DatabaseCfg) InitDB() {
+	if err := dbc.OpenDB(); err != nil {
+		log.Errorf("%s", err)
+		return
 	}
-	if err = dbc.x.Sync(new(CustomFilterItems)); err != nil {
-		log.Fatalf("Fail to sync database CustomFilterItems: %v\n", err)
+	if err := dbc.Migrate(); err != nil {
+		log.Fatalf("Fail to migrate database: %v\n", err)
 	}
-	if err = dbc.x.Sync(new(OidConditionCfg)); err != nil {
-		log.Fatalf("Fail to sync database OidConditionCfg: %v\n", err)
+}
+
+//Ping checks the configuration database connection
+func (dbc *DatabaseCfg) Ping() error {
+	if dbc.x == nil {
+		return fmt.Errorf("Error on ping database: not opened")
 	}
+	return dbc.x.Ping()
 }
 
 // CatalogVar2Map return interface map from variable table
@@ -166,7 +260,7 @@ func (dbc *DatabaseCfg) LoadDbConfig(cfg *DBConfig) {
 	var err error
 	//Load Global Variables
 	VarCatalog := make(map[string]*VarCatalogCfg)
-	VarCatalog, err = dbc.GetVarCatalogCfgMap("")
+	VarCatalog, err = dbc.GetVarCatalogCfgMap(nil)
 	if err != nil {
 		log.Warningf("Some errors on get Global variables :%v", err)
 	}
@@ -174,41 +268,59 @@ func (dbc *DatabaseCfg) LoadDbConfig(cfg *DBConfig) {
 	cfg.VarCatalog = CatalogVar2Map(VarCatalog)
 
 	//Load Influxdb databases
-	cfg.Influxdb, err = dbc.GetInfluxCfgMap("")
+	cfg.Influxdb, err = dbc.GetInfluxCfgMap(nil)
 	if err != nil {
 		log.Warningf("Some errors on get Influx db's :%v", err)
 	}
 
 	//Load metrics
-	cfg.Metrics, err = dbc.GetSnmpMetricCfgMap("")
+	cfg.Metrics, err = dbc.GetSnmpMetricCfgMap(nil)
 	if err != nil {
 		log.Warningf("Some errors on get Metrics  :%v", err)
 	}
 
 	//Load Measurements
-	cfg.Measurements, err = dbc.GetMeasurementCfgMap("")
+	cfg.Measurements, err = dbc.GetMeasurementCfgMap(nil)
 	if err != nil {
 		log.Warningf("Some errors on get Measurements  :%v", err)
 	}
 
 	//Load Measurement Filters
-	cfg.MFilters, err = dbc.GetMeasFilterCfgMap("")
+	cfg.MFilters, err = dbc.GetMeasFilterCfgMap(nil)
 	if err != nil {
 		log.Warningf("Some errors on get Measurement Filters  :%v", err)
 	}
 
 	//Load measourement Groups
 
-	cfg.GetGroups, err = dbc.GetMGroupsCfgMap("")
+	cfg.GetGroups, err = dbc.GetMGroupsCfgMap(nil)
 	if err != nil {
 		log.Warningf("Some errors on get Measurements Groups  :%v", err)
 	}
 
 	//Device
 
-	cfg.SnmpDevice, err = dbc.GetSnmpDeviceCfgMap("")
+	cfg.SnmpDevice, err = dbc.GetSnmpDeviceCfgMap(nil)
 	if err != nil {
 		log.Warningf("Some errors on get SnmpDeviceConf :%v", err)
 	}
+
+	//Alerting
+
+	cfg.AlertRules, err = dbc.GetAlertRuleCfgMap(nil)
+	if err != nil {
+		log.Warningf("Some errors on get Alert Rules :%v", err)
+	}
+	cfg.AlertReceivers, err = dbc.GetAlertReceiverCfgMap(nil)
+	if err != nil {
+		log.Warningf("Some errors on get Alert Receivers :%v", err)
+	}
+
+	//Maintenance windows
+
+	cfg.MaintWindows, err = dbc.GetMaintWindowCfgMap(nil)
+	if err != nil {
+		log.Warningf("Some errors on get Maintenance Windows :%v", err)
+	}
 	dbc.resetChanges()
 }
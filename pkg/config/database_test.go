@@ -0,0 +1,155 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func Test_postgresDataSource(t *testing.T) {
+	tests := []struct {
+		name string
+		dbc  DatabaseCfg
+		want string
+	}{
+		{
+			name: "host and port with defaults",
+			dbc:  DatabaseCfg{Host: "127.0.0.1:5433", Name: "snmpcollector", User: "snmpcoluser", Password: "snmpcolpass"},
+			want: "host='127.0.0.1' port='5433' dbname='snmpcollector' user='snmpcoluser' password='snmpcolpass' sslmode='disable'",
+		},
+		{
+			name: "unix socket directory",
+			dbc:  DatabaseCfg{Host: "/var/run/postgresql", Name: "snmpcollector", User: "snmpcoluser"},
+			want: "host='/var/run/postgresql' port='5432' dbname='snmpcollector' user='snmpcoluser' password='' sslmode='disable'",
+		},
+		{
+			name: "ssl options and quoted password",
+			dbc: DatabaseCfg{Host: "db.example.com", Name: "snmpcollector", User: "snmpcoluser", Password: `it's\secret`,
+				SSLMode: "verify-full", SSLCert: "/etc/ssl/client.crt", SSLKey: "/etc/ssl/client.key", SSLRootCert: "/etc/ssl/ca.crt"},
+			want: `host='db.example.com' port='5432' dbname='snmpcollector' user='snmpcoluser' password='it\'s\\secret' sslmode='verify-full' sslcert='/etc/ssl/client.crt' sslkey='/etc/ssl/client.key' sslrootcert='/etc/ssl/ca.crt'`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dbc.postgresDataSource(); got != tt.want {
+				t.Errorf("postgresDataSource() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// testDBBackend runs schema sync and the basic object life cycle (which relies on
+// the Where filters) against a configured backend
+func testDBBackend(t *testing.T, dbc *DatabaseCfg) {
+	log = logrus.New()
+	log.Out = ioutil.Discard
+	dbc.InitDB()
+	if dbc.x == nil {
+		t.Fatalf("database engine not initialized for type %s", dbc.Type)
+	}
+	defer dbc.x.Close()
+	// leave backend clean if reused
+	dbc.DelSnmpDeviceCfg("test'device")
+	dbc.DelInfluxCfg("test_influx")
+	dbc.DelInfluxCfg("test_influx_renamed")
+
+	if _, err := dbc.AddInfluxCfg(InfluxCfg{ID: "test_influx", Host: "127.0.0.1", Port: 8086, DB: "snmp", User: "user", Password: "pass", Retention: "autogen"}); err != nil {
+		t.Fatalf("AddInfluxCfg error: %s", err)
+	}
+	dev := SnmpDeviceCfg{ID: "test'device", Host: "127.0.0.1", Port: 161, SnmpVersion: "2c", Community: "public", Active: true, Freq: 60, OutDB: "test_influx", ExtraTags: []string{"site=bcn"}}
+	if _, err := dbc.AddSnmpDeviceCfg(dev); err != nil {
+		t.Fatalf("AddSnmpDeviceCfg error: %s", err)
+	}
+	got, err := dbc.GetSnmpDeviceCfgByID("test'device")
+	if err != nil {
+		t.Fatalf("GetSnmpDeviceCfgByID error: %s", err)
+	}
+	if !got.Active || got.DisableBulk || got.OutDB != "test_influx" || len(got.ExtraTags) != 1 {
+		t.Errorf("unexpected device read back: %+v", got)
+	}
+	// values are bound parameters, never part of the SQL sentence
+	if devs, err := dbc.GetSnmpDeviceCfgArray(FilterEq("id", "x' OR '1'='1")); err != nil || len(devs) != 0 {
+		t.Errorf("unexpected result on injected filter: %d devices, error %v", len(devs), err)
+	}
+	if devs, err := dbc.GetSnmpDeviceCfgArray(FilterLike("host", "127.0.").And(FilterEq("active", true))); err != nil || len(devs) != 1 {
+		t.Errorf("unexpected result on like filter: %d devices, error %v", len(devs), err)
+	}
+	// renaming the output should cascade to the device
+	if _, err := dbc.UpdateInfluxCfg("test_influx", InfluxCfg{ID: "test_influx_renamed", Host: "127.0.0.1", Port: 8086, DB: "snmp", User: "user", Password: "pass", Retention: "autogen"}); err != nil {
+		t.Fatalf("UpdateInfluxCfg error: %s", err)
+	}
+	got, err = dbc.GetSnmpDeviceCfgByID("test'device")
+	if err != nil {
+		t.Fatalf("GetSnmpDeviceCfgByID error: %s", err)
+	}
+	if got.OutDB != "test_influx_renamed" {
+		t.Errorf("device outdb not updated on rename got %s", got.OutDB)
+	}
+	if _, err := dbc.DelSnmpDeviceCfg("test'device"); err != nil {
+		t.Errorf("DelSnmpDeviceCfg error: %s", err)
+	}
+	if _, err := dbc.DelInfluxCfg("test_influx_renamed"); err != nil {
+		t.Errorf("DelInfluxCfg error: %s", err)
+	}
+}
+
+func TestDatabaseSQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snmpcollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	SetDirs(dir, dir, dir)
+	testDBBackend(t, &DatabaseCfg{Type: "sqlite3", Name: "snmpcollector"})
+}
+
+// newTestSQLiteDB returns an initialized sqlite database on a temporary dir
+// and the function to release it
+func newTestSQLiteDB(t *testing.T) (*DatabaseCfg, func()) {
+	log = logrus.New()
+	log.Out = ioutil.Discard
+	dir, err := ioutil.TempDir("", "snmpcollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDirs(dir, dir, dir)
+	dbc := &DatabaseCfg{Type: "sqlite3", Name: "snmpcollector"}
+	dbc.InitDB()
+	if dbc.x == nil {
+		os.RemoveAll(dir)
+		t.Fatal("database engine not initialized")
+	}
+	return dbc, func() {
+		dbc.x.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// TestDatabasePostgres needs a reachable postgres server set with
+// SNMPCOL_TEST_POSTGRES_HOST (and optionally _NAME, _USER, _PASSWORD, _SSL_MODE)
+func TestDatabasePostgres(t *testing.T) {
+	host := os.Getenv("SNMPCOL_TEST_POSTGRES_HOST")
+	if len(host) == 0 {
+		t.Skip("SNMPCOL_TEST_POSTGRES_HOST not set, skipping postgres test")
+	}
+	dbc := &DatabaseCfg{
+		Type:     "postgres",
+		Host:     host,
+		Name:     "snmpcollector",
+		User:     "snmpcoluser",
+		Password: "snmpcolpass",
+		SSLMode:  os.Getenv("SNMPCOL_TEST_POSTGRES_SSL_MODE"),
+	}
+	if v := os.Getenv("SNMPCOL_TEST_POSTGRES_NAME"); len(v) > 0 {
+		dbc.Name = v
+	}
+	if v := os.Getenv("SNMPCOL_TEST_POSTGRES_USER"); len(v) > 0 {
+		dbc.User = v
+	}
+	if v := os.Getenv("SNMPCOL_TEST_POSTGRES_PASSWORD"); len(v) > 0 {
+		dbc.Password = v
+	}
+	testDBBackend(t, dbc)
+}
@@ -10,7 +10,7 @@ type SnmpDeviceCfg struct {
 	Retries    int      `xorm:"retries"`
 	Timeout    int      `xorm:"timeout"`
 	Repeat     int      `xorm:"repeat"`
-	Active     bool     `xorm:"'active' default 1"`
+	Active     bool     `xorm:"'active' default true"`
 	//snmp auth  config
 	SnmpVersion       string `xorm:"snmpversion" binding:"Required;In(1,2c,3)"`
 	Community         string `xorm:"community"`
@@ -23,18 +23,20 @@ type SnmpDeviceCfg struct {
 	V3ContextEngineID string `xorm:"v3contextengineid"`
 	V3ContextName     string `xorm:"v3contextname"`
 	//snmp workarround for some devices
-	DisableBulk    bool  `xorm:"'disablebulk' default 0"`
+	DisableBulk    bool  `xorm:"'disablebulk' default false"`
 	MaxRepetitions uint8 `xorm:"'maxrepetitions' default 50" binding:"Default(50);IntegerNotZero"`
 	//snmp runtime config
 	Freq             int  `xorm:"'freq' default 60" binding:"Default(60);IntegerNotZero"`
 	UpdateFltFreq    int  `xorm:"'update_flt_freq' default 60" binding:"Default(60);UIntegerAndLessOne"`
-	ConcurrentGather bool `xorm:"'concurrent_gather' default 1"`
+	ConcurrentGather bool `xorm:"'concurrent_gather' default true"`
+	//what to do when a gather cycle takes longer than Freq (skip/immediate/autoraise)
+	OverrunPolicy string `xorm:"'overrun_policy' default 'skip'" binding:"Default(skip);In(skip,immediate,autoraise)"`
 
 	OutDB    string `xorm:"outdb"`
 	LogLevel string `xorm:"loglevel" binding:"Default(info)"`
 	LogFile  string `xorm:"logfile"`
 
-	SnmpDebug bool `xorm:"'snmpdebug' default 0"`
+	SnmpDebug bool `xorm:"'snmpdebug' default false"`
 	//influx tags
 	DeviceTagName  string   `xorm:"devicetagname" binding:"Default(hostname)"`
 	DeviceTagValue string   `xorm:"devicetagvalue" binding:"Default(id)"`
@@ -132,13 +134,16 @@ type SnmpDevMGroups struct {
 
 // DBConfig read from DB
 type DBConfig struct {
-	Metrics      map[string]*SnmpMetricCfg
-	Measurements map[string]*MeasurementCfg
-	MFilters     map[string]*MeasFilterCfg
-	GetGroups    map[string]*MGroupsCfg
-	SnmpDevice   map[string]*SnmpDeviceCfg
-	Influxdb     map[string]*InfluxCfg
-	VarCatalog   map[string]interface{}
+	Metrics        map[string]*SnmpMetricCfg
+	Measurements   map[string]*MeasurementCfg
+	MFilters       map[string]*MeasFilterCfg
+	GetGroups      map[string]*MGroupsCfg
+	SnmpDevice     map[string]*SnmpDeviceCfg
+	Influxdb       map[string]*InfluxCfg
+	VarCatalog     map[string]interface{}
+	AlertRules     map[string]*AlertRuleCfg
+	AlertReceivers map[string]*AlertReceiverCfg
+	MaintWindows   map[string]*MaintWindowCfg
 }
 
 /*
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-xorm/builder"
+)
+
+/***************************
+	Query Filters
+	-FilterEq
+	-FilterLike
+	-FilterIn
+	-FilterGte / FilterLte
+	-Filter.And / Filter.Or
+***********************************/
+
+// Filter is a typed condition to select configuration objects from the database,
+// values are never concatenated into the SQL sentence but sent as bound parameters.
+// Column names should always be constant table column names (never user input).
+// A nil *Filter selects all objects.
+type Filter struct {
+	cond builder.Cond
+}
+
+// FilterEq selects objects with column equal to value
+func FilterEq(column string, value interface{}) *Filter {
+	return &Filter{cond: builder.Eq{column: value}}
+}
+
+// likeEscaper escapes the LIKE wildcards to match them as literal characters
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// FilterLike selects objects with column containing the value string ( "%" and "_" are
+// not wildcards ). The escape character is also a bound parameter, as sqlite and mysql
+// parse backslashes in string literals in a different way.
+func FilterLike(column string, value string) *Filter {
+	return &Filter{cond: builder.Expr(column+" LIKE ? ESCAPE ?", "%"+likeEscaper.Replace(value)+"%", `\`)}
+}
+
+// FilterIn selects objects with column equal to any of the values
+func FilterIn(column string, values ...interface{}) *Filter {
+	return &Filter{cond: builder.In(column, values...)}
+}
+
+// FilterGte selects objects with column greater or equal than value
+func FilterGte(column string, value interface{}) *Filter {
+	return &Filter{cond: builder.Gte{column: value}}
+}
+
+// FilterLte selects objects with column less or equal than value
+func FilterLte(column string, value interface{}) *Filter {
+	return &Filter{cond: builder.Lte{column: value}}
+}
+
+// And returns a new filter matching both filters
+func (f *Filter) And(o *Filter) *Filter {
+	switch {
+	case f == nil:
+		return o
+	case o == nil:
+		return f
+	}
+	return &Filter{cond: builder.And(f.cond, o.cond)}
+}
+
+// Or returns a new filter matching any of both filters
+func (f *Filter) Or(o *Filter) *Filter {
+	switch {
+	case f == nil, o == nil:
+		return nil
+	}
+	return &Filter{cond: builder.Or(f.cond, o.cond)}
+}
+
+// String returns the filter as SQL with its parameters (only for logging purposes)
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	sql, args, err := builder.ToSQL(f.cond)
+	if err != nil {
+		return fmt.Sprintf("invalid filter: %s", err)
+	}
+	return fmt.Sprintf("%s %v", sql, args)
+}
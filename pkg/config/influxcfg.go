@@ -15,7 +15,7 @@ import "fmt"
 
 /*GetInfluxCfgByID get device data by id*/
 func (dbc *DatabaseCfg) GetInfluxCfgByID(id string) (InfluxCfg, error) {
-	cfgarray, err := dbc.GetInfluxCfgArray("id='" + id + "'")
+	cfgarray, err := dbc.GetInfluxCfgArray(FilterEq("id", id))
 	if err != nil {
 		return InfluxCfg{}, err
 	}
@@ -29,7 +29,7 @@ func (dbc *DatabaseCfg) GetInfluxCfgByID(id string) (InfluxCfg, error) {
 }
 
 /*GetInfluxCfgMap  return data in map format*/
-func (dbc *DatabaseCfg) GetInfluxCfgMap(filter string) (map[string]*InfluxCfg, error) {
+func (dbc *DatabaseCfg) GetInfluxCfgMap(filter *Filter) (map[string]*InfluxCfg, error) {
 	cfgarray, err := dbc.GetInfluxCfgArray(filter)
 	cfgmap := make(map[string]*InfluxCfg)
 	for _, val := range cfgarray {
@@ -40,17 +40,17 @@ func (dbc *DatabaseCfg) GetInfluxCfgMap(filter string) (map[string]*InfluxCfg, e
 }
 
 /*GetInfluxCfgArray generate an array of devices with all its information */
-func (dbc *DatabaseCfg) GetInfluxCfgArray(filter string) ([]*InfluxCfg, error) {
+func (dbc *DatabaseCfg) GetInfluxCfgArray(filter *Filter) ([]*InfluxCfg, error) {
 	var err error
 	var devices []*InfluxCfg
 	//Get Only data for selected devices
-	if len(filter) > 0 {
-		if err = dbc.x.Where(filter).Find(&devices); err != nil {
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&devices); err != nil {
 			log.Warnf("Fail to get InfluxCfg  data filteter with %s : %v\n", filter, err)
 			return nil, err
 		}
 	} else {
-		if err = dbc.x.Find(&devices); err != nil {
+		if err = dbc.db().Find(&devices); err != nil {
 			log.Warnf("Fail to get influxcfg   data: %v\n", err)
 			return nil, err
 		}
@@ -62,7 +62,7 @@ func (dbc *DatabaseCfg) GetInfluxCfgArray(filter string) ([]*InfluxCfg, error) {
 func (dbc *DatabaseCfg) AddInfluxCfg(dev InfluxCfg) (int64, error) {
 	var err error
 	var affected int64
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	affected, err = session.Insert(dev)
@@ -85,17 +85,17 @@ func (dbc *DatabaseCfg) DelInfluxCfg(id string) (int64, error) {
 	var affecteddev, affected int64
 	var err error
 
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 	// deleting references in SnmpDevCfg
 
-	affecteddev, err = session.Where("outdb='" + id + "'").Cols("outdb").Update(&SnmpDeviceCfg{})
+	affecteddev, err = session.Where("outdb=?", id).Cols("outdb").Update(&SnmpDeviceCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevCfg with id: %s, error: %s", id, err)
 	}
 
-	affected, err = session.Where("id='" + id + "'").Delete(&InfluxCfg{})
+	affected, err = session.Where("id=?", id).Delete(&InfluxCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -114,10 +114,10 @@ func (dbc *DatabaseCfg) DelInfluxCfg(id string) (int64, error) {
 func (dbc *DatabaseCfg) UpdateInfluxCfg(id string, dev InfluxCfg) (int64, error) {
 	var affecteddev, affected int64
 	var err error
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 	if id != dev.ID { //ID has been changed
-		affecteddev, err = session.Where("outdb='" + id + "'").Cols("outdb").Update(&SnmpDeviceCfg{OutDB: dev.ID})
+		affecteddev, err = session.Where("outdb=?", id).Cols("outdb").Update(&SnmpDeviceCfg{OutDB: dev.ID})
 		if err != nil {
 			session.Rollback()
 			return 0, fmt.Errorf("Error on Update InfluxConfig on update id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
@@ -125,7 +125,7 @@ func (dbc *DatabaseCfg) UpdateInfluxCfg(id string, dev InfluxCfg) (int64, error)
 		log.Infof("Updated Influx Config to %d devices ", affecteddev)
 	}
 
-	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	affected, err = session.Where("id=?", id).UseBool().AllCols().Update(dev)
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -144,7 +144,7 @@ func (dbc *DatabaseCfg) UpdateInfluxCfg(id string, dev InfluxCfg) (int64, error)
 func (dbc *DatabaseCfg) GetInfluxCfgAffectOnDel(id string) ([]*DbObjAction, error) {
 	var devices []*SnmpDeviceCfg
 	var obj []*DbObjAction
-	if err := dbc.x.Where("outdb='" + id + "'").Find(&devices); err != nil {
+	if err := dbc.db().Where("outdb=?", id).Find(&devices); err != nil {
 		log.Warnf("Error on Get Outout db id %s for devices , error: %s", id, err)
 		return nil, err
 	}
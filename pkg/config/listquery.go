@@ -0,0 +1,237 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/***************************
+	Paginated Lists
+	-ListQuery
+	-GetCfgObjectPage
+	-GetUserCfgPage
+	-GetAPITokenCfgPage
+***********************************/
+
+// ErrInvalidListQuery is wrapped by the errors caused by unknown fields or invalid values
+var ErrInvalidListQuery = errors.New("invalid list query")
+
+// ListQuery selects a sorted page of objects from a configuration table.
+// Field names could be the JSON (struct field) or the table column names, both
+// case insensitive, and are always checked against the table columns.
+type ListQuery struct {
+	Fields map[string]string // field filters: text fields contain the value, others are equal
+	Sort   []string          // sort fields, with "-" prefix for descending order
+	Limit  int               // 0 means no limit
+	Offset int
+}
+
+// listColumn is a table column which could be used in list queries
+type listColumn struct {
+	name string
+	kind reflect.Kind
+}
+
+// listColumns returns the bean table columns by lower case field and column name,
+// skipping the hidden ones and those never sent to the clients (json "-")
+func (dbc *DatabaseCfg) listColumns(bean interface{}, hidden ...string) map[string]*listColumn {
+	st := reflect.Indirect(reflect.ValueOf(bean)).Type()
+	cols := make(map[string]*listColumn)
+	for _, c := range dbc.x.TableInfo(bean).Columns() {
+		f, ok := st.FieldByName(c.FieldName)
+		if !ok || f.Tag.Get("json") == "-" {
+			continue
+		}
+		skip := false
+		for _, h := range hidden {
+			if h == c.Name {
+				skip = true
+			}
+		}
+		if skip {
+			continue
+		}
+		lc := &listColumn{name: c.Name, kind: f.Type.Kind()}
+		cols[strings.ToLower(c.Name)] = lc
+		cols[strings.ToLower(c.FieldName)] = lc
+	}
+	return cols
+}
+
+// filter returns the field filters as a Filter on the given columns
+func (q *ListQuery) filter(cols map[string]*listColumn) (*Filter, error) {
+	var filter *Filter
+	// sorted to always build the same SQL sentence
+	var fields []string
+	for k := range q.Fields {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		value := q.Fields[field]
+		c, ok := cols[strings.ToLower(field)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown filter field %s", ErrInvalidListQuery, field)
+		}
+		var v interface{}
+		var err error
+		switch c.kind {
+		case reflect.String:
+			filter = filter.And(FilterLike(c.name, value))
+			continue
+		case reflect.Bool:
+			v, err = strconv.ParseBool(value)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v, err = strconv.ParseInt(value, 10, 64)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v, err = strconv.ParseUint(value, 10, 64)
+		case reflect.Float32, reflect.Float64:
+			v, err = strconv.ParseFloat(value, 64)
+		default:
+			return nil, fmt.Errorf("%w: field %s can not be filtered", ErrInvalidListQuery, field)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid value %q for field %s", ErrInvalidListQuery, value, field)
+		}
+		filter = filter.And(FilterEq(c.name, v))
+	}
+	return filter, nil
+}
+
+// orderBy returns the ORDER BY clause, always ending by id to get stable pages
+func (q *ListQuery) orderBy(cols map[string]*listColumn) (string, error) {
+	var order []string
+	for _, s := range q.Sort {
+		dir := "ASC"
+		if strings.HasPrefix(s, "-") {
+			s, dir = s[1:], "DESC"
+		}
+		c, ok := cols[strings.ToLower(strings.TrimPrefix(s, "+"))]
+		if !ok {
+			return "", fmt.Errorf("%w: unknown sort field %s", ErrInvalidListQuery, s)
+		}
+		order = append(order, c.name+" "+dir)
+	}
+	return strings.Join(append(order, "id ASC"), ", "), nil
+}
+
+// listIDChunk is the max number of IDs loaded on each query (sqlite allows up to 999 variables)
+const listIDChunk = 500
+
+// getPage gets the IDs of the bean table page selected by the base filter and the
+// query, loads them with the list function and returns them in page order with the
+// total count of selected objects
+func (dbc *DatabaseCfg) getPage(bean interface{}, base *Filter, q *ListQuery, list func(*Filter) (interface{}, error), hidden ...string) (interface{}, int64, error) {
+	cols := dbc.listColumns(bean, hidden...)
+	filter, err := q.filter(cols)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter = base.And(filter)
+	order, err := q.orderBy(cols)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	session := dbc.newSession()
+	defer session.Close()
+	if filter != nil {
+		session.Where(filter.cond)
+	}
+	total, err := session.Count(bean)
+	if err != nil {
+		log.Warnf("Fail to count %s list objects filtered with %s : %v\n", dbc.x.TableName(bean), filter, err)
+		return nil, 0, err
+	}
+
+	if filter != nil {
+		session.Where(filter.cond)
+	}
+	if q.Limit > 0 {
+		session.Limit(q.Limit, q.Offset)
+	} else if q.Offset > 0 {
+		// offset without limit is not supported by all databases
+		session.Limit(int(total), q.Offset)
+	}
+	var ids []string
+	if err = session.Table(bean).Cols("id").OrderBy(order).Find(&ids); err != nil {
+		log.Warnf("Fail to get %s list page : %v\n", dbc.x.TableName(bean), err)
+		return nil, 0, err
+	}
+
+	if len(ids) == 0 {
+		// empty typed list
+		l, err := list(FilterIn("id", ""))
+		return l, total, err
+	}
+	// loaded by chunks to keep the query variables under the database limits
+	var l reflect.Value
+	for i := 0; i < len(ids); i += listIDChunk {
+		end := i + listIDChunk
+		if end > len(ids) {
+			end = len(ids)
+		}
+		idvals := make([]interface{}, 0, end-i)
+		for _, id := range ids[i:end] {
+			idvals = append(idvals, id)
+		}
+		chunk, err := list(FilterIn("id", idvals...))
+		if err != nil {
+			return nil, 0, err
+		}
+		if !l.IsValid() {
+			l = reflect.ValueOf(chunk)
+			continue
+		}
+		l = reflect.AppendSlice(l, reflect.ValueOf(chunk))
+	}
+	sortByIDs(l.Interface(), ids)
+	return l.Interface(), total, nil
+}
+
+// sortByIDs sorts a slice of object pointers (with ID field) in the ids order
+func sortByIDs(list interface{}, ids []string) {
+	pos := make(map[string]int, len(ids))
+	for i, id := range ids {
+		pos[id] = i
+	}
+	v := reflect.ValueOf(list)
+	sort.SliceStable(list, func(i, j int) bool {
+		return pos[v.Index(i).Elem().FieldByName("ID").String()] < pos[v.Index(j).Elem().FieldByName("ID").String()]
+	})
+}
+
+/*GetCfgObjectPage get the page of objtype objects selected by the query (typed as GetXxxCfgArray) and the total count*/
+func (dbc *DatabaseCfg) GetCfgObjectPage(objtype string, q *ListQuery) (interface{}, int64, error) {
+	t, err := getCfgObjectType(objtype)
+	if err != nil {
+		return nil, 0, err
+	}
+	return dbc.getPage(t.new(), nil, q, func(f *Filter) (interface{}, error) { return t.list(dbc, f) })
+}
+
+/*GetUserCfgPage get the page of users selected by the query and the total selected count*/
+func (dbc *DatabaseCfg) GetUserCfgPage(q *ListQuery) ([]*UserCfg, int64, error) {
+	l, total, err := dbc.getPage(&UserCfg{}, nil, q, func(f *Filter) (interface{}, error) {
+		return dbc.GetUserCfgArray(f)
+	}, "password")
+	if err != nil {
+		return nil, 0, err
+	}
+	return l.([]*UserCfg), total, nil
+}
+
+/*GetAPITokenCfgPage get the page of API tokens selected by filter and query and the total selected count*/
+func (dbc *DatabaseCfg) GetAPITokenCfgPage(filter *Filter, q *ListQuery) ([]*APITokenCfg, int64, error) {
+	l, total, err := dbc.getPage(&APITokenCfg{}, filter, q, func(f *Filter) (interface{}, error) {
+		return dbc.GetAPITokenCfgArray(f)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return l.([]*APITokenCfg), total, nil
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCfgObjectPage(t *testing.T) {
+	dbc, release := newTestSQLiteDB(t)
+	defer release()
+
+	dbc.AddMGroupsCfg(MGroupsCfg{ID: "mg1"})
+	for i := 1; i <= 5; i++ {
+		dev := SnmpDeviceCfg{
+			ID:                fmt.Sprintf("dev%d", i),
+			Host:              fmt.Sprintf("host%d.example.org", 6-i),
+			Port:              161,
+			Active:            i%2 == 1,
+			SnmpVersion:       "2c",
+			MeasurementGroups: []string{"mg1"},
+		}
+		if i == 5 {
+			dev.Host = "router_01.example.net"
+		}
+		if _, err := dbc.AddSnmpDeviceCfg(dev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := func(l interface{}) string {
+		var r []string
+		for _, d := range l.([]*SnmpDeviceCfg) {
+			r = append(r, d.ID)
+		}
+		return fmt.Sprint(r)
+	}
+	tests := []struct {
+		name  string
+		q     ListQuery
+		want  string
+		total int64
+	}{
+		{"all", ListQuery{}, "[dev1 dev2 dev3 dev4 dev5]", 5},
+		{"page", ListQuery{Limit: 2, Offset: 1}, "[dev2 dev3]", 5},
+		{"offset", ListQuery{Offset: 3}, "[dev4 dev5]", 5},
+		{"sort desc", ListQuery{Sort: []string{"-id"}, Limit: 2}, "[dev5 dev4]", 5},
+		{"sort field name", ListQuery{Sort: []string{"Host"}}, "[dev4 dev3 dev2 dev1 dev5]", 5},
+		{"multiple sort", ListQuery{Sort: []string{"-active", "host"}}, "[dev3 dev1 dev5 dev4 dev2]", 5},
+		{"text filter", ListQuery{Fields: map[string]string{"Host": "example.org"}, Sort: []string{"-id"}}, "[dev4 dev3 dev2 dev1]", 4},
+		{"bool filter", ListQuery{Fields: map[string]string{"active": "true"}, Limit: 1}, "[dev1]", 3},
+		{"combined filters", ListQuery{Fields: map[string]string{"active": "false", "host": "host4"}}, "[dev2]", 1},
+		{"number filter", ListQuery{Fields: map[string]string{"Port": "162"}}, "[]", 0},
+		// LIKE wildcards are literal characters as on the runtime lists
+		{"underscore filter", ListQuery{Fields: map[string]string{"host": "r_01"}}, "[dev5]", 1},
+		{"underscore not wildcard", ListQuery{Fields: map[string]string{"host": "host_"}}, "[]", 0},
+		{"percent not wildcard", ListQuery{Fields: map[string]string{"host": "host%org"}}, "[]", 0},
+	}
+	for _, tt := range tests {
+		l, total, err := dbc.GetCfgObjectPage("snmpdevicecfg", &tt.q)
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if got := ids(l); got != tt.want || total != tt.total {
+			t.Errorf("%s: got %s total %d, want %s total %d", tt.name, got, total, tt.want, tt.total)
+		}
+	}
+	l, _, _ := dbc.GetCfgObjectPage("snmpdevicecfg", &ListQuery{Limit: 1})
+	if devs := l.([]*SnmpDeviceCfg); len(devs[0].MeasurementGroups) != 1 {
+		t.Errorf("device relations not loaded %+v", devs[0])
+	}
+
+	for _, q := range []ListQuery{
+		{Fields: map[string]string{"unknown": "x"}},
+		{Fields: map[string]string{"port": "x"}},
+		{Fields: map[string]string{"systemoids": "x"}},
+		{Sort: []string{"-unknown"}},
+		{Sort: []string{"id; DROP TABLE snmp_device_cfg"}},
+	} {
+		if _, _, err := dbc.GetCfgObjectPage("snmpdevicecfg", &q); !errors.Is(err, ErrInvalidListQuery) {
+			t.Errorf("query %+v: expected invalid list query, got %v", q, err)
+		}
+	}
+}
+
+func TestCfgObjectPageManyObjects(t *testing.T) {
+	dbc, release := newTestSQLiteDB(t)
+	defer release()
+
+	n := 1200
+	vars := make([]*VarCatalogCfg, 0, n)
+	for i := 0; i < n; i++ {
+		vars = append(vars, &VarCatalogCfg{ID: fmt.Sprintf("var%04d", i), Type: "string", Value: "x"})
+	}
+	for i := 0; i < n; i += 200 {
+		chunk := vars[i : i+200]
+		if _, err := dbc.x.Insert(&chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range []struct {
+		q     ListQuery
+		len   int
+		first string
+	}{
+		{ListQuery{}, n, "var0000"},
+		{ListQuery{Sort: []string{"-id"}}, n, "var1199"},
+		{ListQuery{Offset: 100}, n - 100, "var0100"},
+	} {
+		l, total, err := dbc.GetCfgObjectPage("varcatalogcfg", &tt.q)
+		if err != nil {
+			t.Fatalf("query %+v: %s", tt.q, err)
+		}
+		list := l.([]*VarCatalogCfg)
+		if total != int64(n) || len(list) != tt.len || list[0].ID != tt.first {
+			t.Errorf("query %+v: got %d objects starting by %s total %d, want %d starting by %s", tt.q, len(list), list[0].ID, total, tt.len, tt.first)
+		}
+		for i := 1; i < len(list); i++ {
+			if (list[i].ID > list[i-1].ID) != (len(tt.q.Sort) == 0) {
+				t.Errorf("query %+v: object %s out of order at %d", tt.q, list[i].ID, i)
+				break
+			}
+		}
+	}
+}
+
+func TestUserCfgPageHiddenFields(t *testing.T) {
+	dbc, release := newTestSQLiteDB(t)
+	defer release()
+
+	if _, err := dbc.AddUserCfg(UserCfg{ID: "alice", Password: "secret", Role: RoleViewer}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := dbc.GetUserCfgPage(&ListQuery{Fields: map[string]string{"password": "$2a"}}); !errors.Is(err, ErrInvalidListQuery) {
+		t.Errorf("users filtered by password hash, error %v", err)
+	}
+	if _, _, err := dbc.GetUserCfgPage(&ListQuery{Sort: []string{"Password"}}); !errors.Is(err, ErrInvalidListQuery) {
+		t.Errorf("users sorted by password hash, error %v", err)
+	}
+	users, total, err := dbc.GetUserCfgPage(&ListQuery{Fields: map[string]string{"role": "viewer"}})
+	if err != nil || total != 1 || len(users) != 1 || users[0].ID != "alice" {
+		t.Errorf("unexpected users %v total %d error %v", users, total, err)
+	}
+	if _, _, err := dbc.GetAPITokenCfgPage(nil, &ListQuery{Fields: map[string]string{"TokenHash": "0"}}); !errors.Is(err, ErrInvalidListQuery) {
+		t.Errorf("tokens filtered by hash, error %v", err)
+	}
+}
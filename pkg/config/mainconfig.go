@@ -25,7 +25,13 @@ type DatabaseCfg struct {
 	SQLLogFile string `mapstructure:"sqllogfile" envconfig:"SNMPCOL_DATABASE_SQL_LOG_FILE"`
 	Debug      string `mapstructure:"debug" envconfig:"SNMPCOL_DATABASE_SQL_DEBUG"`
 	LogMode    string `mapstructure:"log_mode" envconfig:"SNMPCOL_DATABASE_LOG_MODE"`
-	x          *xorm.Engine
+	//SSL options (apply only to postgres)
+	SSLMode     string `mapstructure:"sslmode" envconfig:"SNMPCOL_DATABASE_SSL_MODE"`
+	SSLCert     string `mapstructure:"sslcert" envconfig:"SNMPCOL_DATABASE_SSL_CERT"`
+	SSLKey      string `mapstructure:"sslkey" envconfig:"SNMPCOL_DATABASE_SSL_KEY"`
+	SSLRootCert string `mapstructure:"sslrootcert" envconfig:"SNMPCOL_DATABASE_SSL_ROOT_CERT"`
+	x           *xorm.Engine
+	tx          *xorm.Session //only set on the DatabaseCfg passed to Transaction functions
 }
 
 //SelfMonConfig configuration for self monitoring
@@ -40,22 +46,89 @@ type SelfMonConfig struct {
 //HTTPConfig has webserver config options
 // Port should be deprecated from version >= 0.8.1
 type HTTPConfig struct {
-	Port          int    `mapstructure:"port"  envconfig:"SNMPCOL_HTTP_PORT"`
-	Listen        string `mapstructure:"listen"  envconfig:"SNMPCOL_HTTP_LISTEN"`
-	Protocol      string `mapstructure:"protocol" envconfig:"SNMPCOL_HTTP_PROTOCOL"`
-	CertKey       string `mapstructure:"cert_key" envconfig:"SNMPCOL_HTTP_CERT_KEY"`
-	CertFile      string `mapstructure:"cert_file" envconfig:"SNMPCOL_HTTP_CERT_FILE"`
-	AdminUser     string `mapstructure:"adminuser" envconfig:"SNMPCOL_HTTP_ADMIN_USER"`
-	AdminPassword string `mapstructure:"adminpassword" envconfig:"SNMPCOL_HTTP_ADMIN_PASSWORD"`
-	CookieID      string `mapstructure:"cookieid" envconfig:"SNMPCOL_HTTP_COOKIE_ID"`
+	Port          int        `mapstructure:"port"  envconfig:"SNMPCOL_HTTP_PORT"`
+	Listen        string     `mapstructure:"listen"  envconfig:"SNMPCOL_HTTP_LISTEN"`
+	Protocol      string     `mapstructure:"protocol" envconfig:"SNMPCOL_HTTP_PROTOCOL"`
+	CertKey       string     `mapstructure:"cert_key" envconfig:"SNMPCOL_HTTP_CERT_KEY"`
+	CertFile      string     `mapstructure:"cert_file" envconfig:"SNMPCOL_HTTP_CERT_FILE"`
+	AdminUser     string     `mapstructure:"adminuser" envconfig:"SNMPCOL_HTTP_ADMIN_USER"`
+	AdminPassword string     `mapstructure:"adminpassword" envconfig:"SNMPCOL_HTTP_ADMIN_PASSWORD"`
+	CookieID      string     `mapstructure:"cookieid" envconfig:"SNMPCOL_HTTP_COOKIE_ID"`
+	LDAP          LDAPConfig `mapstructure:"ldap"`
+	OIDC          OIDCConfig `mapstructure:"oidc"`
+}
+
+//LDAPConfig LDAP bind authentication for the web UI
+// the user DN is searched with the bind_dn account (or built from the user_dn template)
+// and its groups are mapped to roles with "GROUP_DN=role" group_roles entries
+type LDAPConfig struct {
+	Enabled            bool     `mapstructure:"enabled" envconfig:"SNMPCOL_HTTP_LDAP_ENABLED"`
+	URL                string   `mapstructure:"url" envconfig:"SNMPCOL_HTTP_LDAP_URL"`
+	StartTLS           bool     `mapstructure:"starttls" envconfig:"SNMPCOL_HTTP_LDAP_STARTTLS"`
+	InsecureSkipVerify bool     `mapstructure:"insecure_skip_verify" envconfig:"SNMPCOL_HTTP_LDAP_INSECURE_SKIP_VERIFY"`
+	BindDN             string   `mapstructure:"bind_dn" envconfig:"SNMPCOL_HTTP_LDAP_BIND_DN"`
+	BindPassword       string   `mapstructure:"bind_password" envconfig:"SNMPCOL_HTTP_LDAP_BIND_PASSWORD"`
+	UserDN             string   `mapstructure:"user_dn" envconfig:"SNMPCOL_HTTP_LDAP_USER_DN"`
+	BaseDN             string   `mapstructure:"base_dn" envconfig:"SNMPCOL_HTTP_LDAP_BASE_DN"`
+	UserFilter         string   `mapstructure:"user_filter" envconfig:"SNMPCOL_HTTP_LDAP_USER_FILTER"`
+	GroupAttribute     string   `mapstructure:"group_attribute" envconfig:"SNMPCOL_HTTP_LDAP_GROUP_ATTRIBUTE"`
+	GroupBaseDN        string   `mapstructure:"group_base_dn" envconfig:"SNMPCOL_HTTP_LDAP_GROUP_BASE_DN"`
+	GroupFilter        string   `mapstructure:"group_filter" envconfig:"SNMPCOL_HTTP_LDAP_GROUP_FILTER"`
+	GroupRoles         []string `mapstructure:"group_roles" envconfig:"SNMPCOL_HTTP_LDAP_GROUP_ROLES"`
+	DefaultRole        string   `mapstructure:"default_role" envconfig:"SNMPCOL_HTTP_LDAP_DEFAULT_ROLE"`
+}
+
+//OIDCConfig OpenID Connect authorization code flow login for the web UI
+// role_claim values are mapped to roles with "VALUE=role" claim_roles entries
+type OIDCConfig struct {
+	Enabled       bool     `mapstructure:"enabled" envconfig:"SNMPCOL_HTTP_OIDC_ENABLED"`
+	IssuerURL     string   `mapstructure:"issuer_url" envconfig:"SNMPCOL_HTTP_OIDC_ISSUER_URL"`
+	ClientID      string   `mapstructure:"client_id" envconfig:"SNMPCOL_HTTP_OIDC_CLIENT_ID"`
+	ClientSecret  string   `mapstructure:"client_secret" envconfig:"SNMPCOL_HTTP_OIDC_CLIENT_SECRET"`
+	RedirectURL   string   `mapstructure:"redirect_url" envconfig:"SNMPCOL_HTTP_OIDC_REDIRECT_URL"`
+	Scopes        []string `mapstructure:"scopes" envconfig:"SNMPCOL_HTTP_OIDC_SCOPES"`
+	UsernameClaim string   `mapstructure:"username_claim" envconfig:"SNMPCOL_HTTP_OIDC_USERNAME_CLAIM"`
+	RoleClaim     string   `mapstructure:"role_claim" envconfig:"SNMPCOL_HTTP_OIDC_ROLE_CLAIM"`
+	ClaimRoles    []string `mapstructure:"claim_roles" envconfig:"SNMPCOL_HTTP_OIDC_CLAIM_ROLES"`
+	DefaultRole   string   `mapstructure:"default_role" envconfig:"SNMPCOL_HTTP_OIDC_DEFAULT_ROLE"`
+}
+
+//CfgSyncConfig declarative configuration sync from a directory of YAML/TOML files
+type CfgSyncConfig struct {
+	Dir     string `mapstructure:"dir" envconfig:"SNMPCOL_CFGSYNC_DIR"`
+	Prune   bool   `mapstructure:"prune" envconfig:"SNMPCOL_CFGSYNC_PRUNE"`
+	OnStart bool   `mapstructure:"onstart" envconfig:"SNMPCOL_CFGSYNC_ON_START"`
+}
+
+//HealthConfig has the /readyz readiness thresholds
+type HealthConfig struct {
+	MinDevicesConnected float64 `mapstructure:"min_devices_connected" envconfig:"SNMPCOL_HEALTH_MIN_DEVICES_CONNECTED"`
+	MaxBufferFill       float64 `mapstructure:"max_buffer_fill" envconfig:"SNMPCOL_HEALTH_MAX_BUFFER_FILL"`
+}
+
+//PrometheusConfig has the Prometheus metrics endpoint options
+type PrometheusConfig struct {
+	Enabled     bool   `mapstructure:"enabled" envconfig:"SNMPCOL_PROMETHEUS_ENABLED"`
+	Path        string `mapstructure:"path" envconfig:"SNMPCOL_PROMETHEUS_PATH"`
+	RequireAuth bool   `mapstructure:"require_auth" envconfig:"SNMPCOL_PROMETHEUS_REQUIRE_AUTH"`
+}
+
+//AlertingConfig has the alert rules engine options
+type AlertingConfig struct {
+	Disabled         bool   `mapstructure:"disabled" envconfig:"SNMPCOL_ALERTING_DISABLED"`
+	EventMeasurement string `mapstructure:"event_measurement" envconfig:"SNMPCOL_ALERTING_EVENT_MEASUREMENT"`
 }
 
 //Config Main Configuration struct
 type Config struct {
-	General  GeneralConfig `mapstructure:"general"`
-	Database DatabaseCfg   `mapstructure:"database"`
-	Selfmon  SelfMonConfig `mapstructure:"selfmon"`
-	HTTP     HTTPConfig    `mapstructure:"http"`
+	General    GeneralConfig    `mapstructure:"general"`
+	Database   DatabaseCfg      `mapstructure:"database"`
+	Selfmon    SelfMonConfig    `mapstructure:"selfmon"`
+	HTTP       HTTPConfig       `mapstructure:"http"`
+	CfgSync    CfgSyncConfig    `mapstructure:"cfgsync"`
+	Health     HealthConfig     `mapstructure:"health"`
+	Prometheus PrometheusConfig `mapstructure:"prometheus"`
+	Alerting   AlertingConfig   `mapstructure:"alerting"`
 }
 
 //var MainConfig Config
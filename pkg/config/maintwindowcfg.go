@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// MaintWindowCfg maintenance window where the selected devices gathering is paused
+// (or only marked) and their connectivity and alert events are suppressed. It could
+// be one-off ( from Start to End ) or recurring ( begins on each Cron schedule and
+// lasts Duration seconds, only between Start and End if set )
+type MaintWindowCfg struct {
+	ID          string    `xorm:"'id' unique" binding:"Required"`
+	Active      bool      `xorm:"active"`
+	Mode        string    `xorm:"mode" binding:"Default(pause);In(pause,mark)"`
+	Devices     string    `xorm:"devices"` // comma separated device IDs ("*" suffix to match by prefix)
+	Tags        string    `xorm:"tags"`    // comma separated device extra tags tag=value ("*" suffix to match by prefix)
+	Groups      string    `xorm:"groups"`  // comma separated measurement group IDs
+	Start       time.Time `xorm:"start_time"`
+	End         time.Time `xorm:"end_time"`
+	Cron        string    `xorm:"cron"`     // standard cron spec ( with optional CRON_TZ= prefix ) for recurring windows
+	Duration    int       `xorm:"duration"` // seconds of each recurring window
+	Description string    `xorm:"description"`
+}
+
+// GetDevices returns the device IDs ( or ID prefixes ) selected by the window
+func (w *MaintWindowCfg) GetDevices() []string {
+	return splitCSV(w.Devices)
+}
+
+// GetGroups returns the measurement groups whose devices are selected by the window
+func (w *MaintWindowCfg) GetGroups() []string {
+	return splitCSV(w.Groups)
+}
+
+// GetTags returns the device tag values selected by the window
+func (w *MaintWindowCfg) GetTags() (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, v := range splitCSV(w.Tags) {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || len(strings.TrimSpace(kv[0])) == 0 {
+			return nil, fmt.Errorf("Error on maintenance window %s tag %q: tag=value expected", w.ID, v)
+		}
+		tags[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return tags, nil
+}
+
+// GetSchedule returns the recurring window schedule, nil on one-off windows
+func (w *MaintWindowCfg) GetSchedule() (cron.Schedule, error) {
+	if len(w.Cron) == 0 {
+		return nil, nil
+	}
+	s, err := cron.ParseStandard(w.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("Error on maintenance window %s cron %q: %s", w.ID, w.Cron, err)
+	}
+	return s, nil
+}
+
+// Init checks the window selectors and schedule
+func (w *MaintWindowCfg) Init() error {
+	if len(w.GetDevices()) == 0 && len(w.GetGroups()) == 0 && len(splitCSV(w.Tags)) == 0 {
+		return fmt.Errorf("Error on maintenance window %s: no devices, tags or groups selected", w.ID)
+	}
+	if _, err := w.GetTags(); err != nil {
+		return err
+	}
+	s, err := w.GetSchedule()
+	if err != nil {
+		return err
+	}
+	if s == nil && (w.Start.IsZero() || w.End.IsZero()) {
+		return fmt.Errorf("Error on maintenance window %s: start and end needed on one-off windows", w.ID)
+	}
+	if s != nil && w.Duration <= 0 {
+		return fmt.Errorf("Error on maintenance window %s: duration needed on recurring windows", w.ID)
+	}
+	if !w.Start.IsZero() && !w.End.IsZero() && !w.End.After(w.Start) {
+		return fmt.Errorf("Error on maintenance window %s: end %s before start %s", w.ID, w.End, w.Start)
+	}
+	return nil
+}
+
+/***************************
+Maintenance Windows
+	-GetMaintWindowCfgByID(struct)
+	-GetMaintWindowCfgMap (map - for interna config use
+	-GetMaintWindowCfgArray(Array - for web ui use )
+	-AddMaintWindowCfg
+	-DelMaintWindowCfg
+	-UpdateMaintWindowCfg
+***********************************/
+
+/*GetMaintWindowCfgByID get maintenance window data by id*/
+func (dbc *DatabaseCfg) GetMaintWindowCfgByID(id string) (MaintWindowCfg, error) {
+	cfgarray, err := dbc.GetMaintWindowCfgArray(FilterEq("id", id))
+	if err != nil {
+		return MaintWindowCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return MaintWindowCfg{}, fmt.Errorf("Error %d results on get MaintWindowCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return MaintWindowCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the maintenance window table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetMaintWindowCfgMap  return data in map format*/
+func (dbc *DatabaseCfg) GetMaintWindowCfgMap(filter *Filter) (map[string]*MaintWindowCfg, error) {
+	cfgarray, err := dbc.GetMaintWindowCfgArray(filter)
+	cfgmap := make(map[string]*MaintWindowCfg)
+	for _, val := range cfgarray {
+		cfgmap[val.ID] = val
+		log.Debugf("%+v", *val)
+	}
+	return cfgmap, err
+}
+
+/*GetMaintWindowCfgArray generate an array of maintenance windows with all its information */
+func (dbc *DatabaseCfg) GetMaintWindowCfgArray(filter *Filter) ([]*MaintWindowCfg, error) {
+	var err error
+	var windows []*MaintWindowCfg
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&windows); err != nil {
+			log.Warnf("Fail to get MaintWindowCfg data filtered with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.db().Find(&windows); err != nil {
+			log.Warnf("Fail to get MaintWindowCfg data: %v\n", err)
+			return nil, err
+		}
+	}
+	return windows, nil
+}
+
+/*AddMaintWindowCfg for adding new maintenance windows*/
+func (dbc *DatabaseCfg) AddMaintWindowCfg(dev MaintWindowCfg) (int64, error) {
+	if err := dev.Init(); err != nil {
+		return 0, err
+	}
+	session := dbc.newSession()
+	defer session.Close()
+
+	affected, err := session.Insert(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	if err = session.Commit(); err != nil {
+		return 0, err
+	}
+	log.Infof("Added new MaintWindowCfg Successfully with id %s ", dev.ID)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*DelMaintWindowCfg for deleting maintenance windows from ID*/
+func (dbc *DatabaseCfg) DelMaintWindowCfg(id string) (int64, error) {
+	session := dbc.newSession()
+	defer session.Close()
+
+	affected, err := session.Where("id=?", id).Delete(&MaintWindowCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	if err = session.Commit(); err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully maintenance window with ID %s", id)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*UpdateMaintWindowCfg for updating maintenance windows*/
+func (dbc *DatabaseCfg) UpdateMaintWindowCfg(id string, dev MaintWindowCfg) (int64, error) {
+	if err := dev.Init(); err != nil {
+		return 0, err
+	}
+	session := dbc.newSession()
+	defer session.Close()
+
+	affected, err := session.Where("id=?", id).UseBool().AllCols().Update(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	if err = session.Commit(); err != nil {
+		return 0, err
+	}
+	log.Infof("Updated MaintWindowCfg Successfully with id %s", dev.ID)
+	dbc.addChanges(affected)
+	return affected, nil
+}
@@ -15,7 +15,7 @@ import "fmt"
 
 /*GetMeasFilterCfgByID get metric data by id*/
 func (dbc *DatabaseCfg) GetMeasFilterCfgByID(id string) (MeasFilterCfg, error) {
-	cfgarray, err := dbc.GetMeasFilterCfgArray("id='" + id + "'")
+	cfgarray, err := dbc.GetMeasFilterCfgArray(FilterEq("id", id))
 	if err != nil {
 		return MeasFilterCfg{}, err
 	}
@@ -29,7 +29,7 @@ func (dbc *DatabaseCfg) GetMeasFilterCfgByID(id string) (MeasFilterCfg, error) {
 }
 
 /*GetMeasFilterCfgMap  return data in map format*/
-func (dbc *DatabaseCfg) GetMeasFilterCfgMap(filter string) (map[string]*MeasFilterCfg, error) {
+func (dbc *DatabaseCfg) GetMeasFilterCfgMap(filter *Filter) (map[string]*MeasFilterCfg, error) {
 	cfgarray, err := dbc.GetMeasFilterCfgArray(filter)
 	cfgmap := make(map[string]*MeasFilterCfg)
 	for _, val := range cfgarray {
@@ -40,17 +40,17 @@ func (dbc *DatabaseCfg) GetMeasFilterCfgMap(filter string) (map[string]*MeasFilt
 }
 
 /*GetMeasFilterCfgArray generate an array of measurements with all its information */
-func (dbc *DatabaseCfg) GetMeasFilterCfgArray(filter string) ([]*MeasFilterCfg, error) {
+func (dbc *DatabaseCfg) GetMeasFilterCfgArray(filter *Filter) ([]*MeasFilterCfg, error) {
 	var err error
 	var devices []*MeasFilterCfg
 	//Get Only data for selected measurements
-	if len(filter) > 0 {
-		if err = dbc.x.Where(filter).Find(&devices); err != nil {
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&devices); err != nil {
 			log.Warnf("Fail to get MeasFilterCfg  data filteter with %s : %v\n", filter, err)
 			return nil, err
 		}
 	} else {
-		if err = dbc.x.Find(&devices); err != nil {
+		if err = dbc.db().Find(&devices); err != nil {
 			log.Warnf("Fail to get MeasFilterCfg   data: %v\n", err)
 			return nil, err
 		}
@@ -62,7 +62,7 @@ func (dbc *DatabaseCfg) GetMeasFilterCfgArray(filter string) ([]*MeasFilterCfg,
 func (dbc *DatabaseCfg) AddMeasFilterCfg(dev MeasFilterCfg) (int64, error) {
 	var err error
 	var affected int64
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	affected, err = session.Insert(dev)
@@ -86,16 +86,16 @@ func (dbc *DatabaseCfg) DelMeasFilterCfg(id string) (int64, error) {
 	var affectedfl, affected int64
 	var err error
 
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 	// deleting references in SnmpDeviceCfg
-	affectedfl, err = session.Where("id_filter='" + id + "'").Delete(&SnmpDevFilters{})
+	affectedfl, err = session.Where("id_filter=?", id).Delete(&SnmpDevFilters{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Filter on SnmpDeviceFilter table with id: %s, error: %s", id, err)
 	}
 
-	affected, err = session.Where("id='" + id + "'").Delete(&MeasFilterCfg{})
+	affected, err = session.Where("id=?", id).Delete(&MeasFilterCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -114,11 +114,11 @@ func (dbc *DatabaseCfg) DelMeasFilterCfg(id string) (int64, error) {
 func (dbc *DatabaseCfg) UpdateMeasFilterCfg(id string, dev MeasFilterCfg) (int64, error) {
 	var affecteddev, newmf, affected int64
 	var err error
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	if id != dev.ID { //ID has been changed only need change id's in snsmpdev
-		affecteddev, err = session.Where("id_filter='" + id + "'").Cols("id_filter").Update(&SnmpDevFilters{IDFilter: dev.ID})
+		affecteddev, err = session.Where("id_filter=?", id).Cols("id_filter").Update(&SnmpDevFilters{IDFilter: dev.ID})
 		if err != nil {
 			session.Rollback()
 			return 0, fmt.Errorf("Error Update Filter id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
@@ -127,7 +127,7 @@ func (dbc *DatabaseCfg) UpdateMeasFilterCfg(id string, dev MeasFilterCfg) (int64
 	}
 
 	//update data
-	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	affected, err = session.Where("id=?", id).UseBool().AllCols().Update(dev)
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -147,7 +147,7 @@ func (dbc *DatabaseCfg) GetMeasFilterCfgAffectOnDel(id string) ([]*DbObjAction,
 	var mf []*SnmpDevFilters
 	var obj []*DbObjAction
 	var err error
-	err = dbc.x.Where("id_filter='" + id + "'").Find(&mf)
+	err = dbc.db().Where("id_filter=?", id).Find(&mf)
 	if err != nil {
 		return nil, fmt.Errorf("Error on Delete Measurement filter with id: %s, error: %s", id, err)
 	}
@@ -22,7 +22,7 @@ type MeasurementCfg struct {
 	TagOID         string                   `xorm:"tagoid"`                                         //only valid if inderecta TAG indexeded
 	IndexTag       string                   `xorm:"indextag"`
 	IndexTagFormat string                   `xorm:"indextagformat"`
-	IndexAsValue   bool                     `xorm:"'indexasvalue' default 0"`
+	IndexAsValue   bool                     `xorm:"'indexasvalue' default false"`
 	Fields         []MeasurementFieldReport `xorm:"-"` //Got from MeasurementFieldCfg table
 	FieldMetric    []*SnmpMetricCfg         `xorm:"-" json:"-"`
 	EvalMetric     []*SnmpMetricCfg         `xorm:"-" json:"-"`
@@ -299,7 +299,7 @@ func (mc *MeasurementCfg) Init(MetricCfg *map[string]*SnmpMetricCfg, varmap map[
 
 /*GetMeasurementCfgByID get metric data by id*/
 func (dbc *DatabaseCfg) GetMeasurementCfgByID(id string) (MeasurementCfg, error) {
-	cfgarray, err := dbc.GetMeasurementCfgArray("id='" + id + "'")
+	cfgarray, err := dbc.GetMeasurementCfgArray(FilterEq("id", id))
 	if err != nil {
 		return MeasurementCfg{}, err
 	}
@@ -313,7 +313,7 @@ func (dbc *DatabaseCfg) GetMeasurementCfgByID(id string) (MeasurementCfg, error)
 }
 
 /*GetMeasurementCfgMap  return data in map format*/
-func (dbc *DatabaseCfg) GetMeasurementCfgMap(filter string) (map[string]*MeasurementCfg, error) {
+func (dbc *DatabaseCfg) GetMeasurementCfgMap(filter *Filter) (map[string]*MeasurementCfg, error) {
 	cfgarray, err := dbc.GetMeasurementCfgArray(filter)
 	cfgmap := make(map[string]*MeasurementCfg)
 	for _, val := range cfgarray {
@@ -324,24 +324,24 @@ func (dbc *DatabaseCfg) GetMeasurementCfgMap(filter string) (map[string]*Measure
 }
 
 /*GetMeasurementCfgArray generate an array of measurements with all its information */
-func (dbc *DatabaseCfg) GetMeasurementCfgArray(filter string) ([]*MeasurementCfg, error) {
+func (dbc *DatabaseCfg) GetMeasurementCfgArray(filter *Filter) ([]*MeasurementCfg, error) {
 	var err error
 	var devices []*MeasurementCfg
 	//Get Only data for selected measurements
-	if len(filter) > 0 {
-		if err = dbc.x.Where(filter).Find(&devices); err != nil {
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&devices); err != nil {
 			log.Warnf("Fail to get MeasurementCfg  data filteter with %s : %v\n", filter, err)
 			return nil, err
 		}
 	} else {
-		if err = dbc.x.Find(&devices); err != nil {
+		if err = dbc.db().Find(&devices); err != nil {
 			log.Warnf("Fail to get MeasurementCfg   data: %v\n", err)
 			return nil, err
 		}
 	}
 
 	var MeasureMetric []*MeasurementFieldCfg
-	if err = dbc.x.Find(&MeasureMetric); err != nil {
+	if err = dbc.db().Find(&MeasureMetric); err != nil {
 		log.Warnf("Fail to get Measurements Metric relationship data: %v\n", err)
 	}
 
@@ -371,15 +371,15 @@ func (dbc *DatabaseCfg) AddMeasurementCfg(dev MeasurementCfg) (int64, error) {
 
 	// create SnmpMetricCfg to check if any configuration issue found before persist to database
 	// We need to get data from database
-	cfg, _ := dbc.GetSnmpMetricCfgMap("")
-	gv, _ := dbc.GetVarCatalogCfgMap("")
+	cfg, _ := dbc.GetSnmpMetricCfgMap(nil)
+	gv, _ := dbc.GetVarCatalogCfgMap(nil)
 
 	err = dev.Init(&cfg, CatalogVar2Map(gv))
 	if err != nil {
 		return 0, err
 	}
 	// initialize data persistence
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	affected, err = session.Insert(dev)
@@ -416,35 +416,35 @@ func (dbc *DatabaseCfg) DelMeasurementCfg(id string) (int64, error) {
 	var affectedfl, affectedmg, affectedft, affectedcf, affected int64
 	var err error
 
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 	// deleting references in MeasurementFieldCfg
-	affectedfl, err = session.Where("id_measurement_cfg='" + id + "'").Delete(&MeasurementFieldCfg{})
+	affectedfl, err = session.Where("id_measurement_cfg=?", id).Delete(&MeasurementFieldCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Measurement on MeasurementFieldCfg with id: %s, error: %s", id, err)
 	}
 
-	affectedmg, err = session.Where("id_measurement_cfg='" + id + "'").Delete(&MGroupsMeasurements{})
+	affectedmg, err = session.Where("id_measurement_cfg=?", id).Delete(&MGroupsMeasurements{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Measurement on MGroupsMeasurements with id: %s, error: %s", id, err)
 	}
 
-	affectedft, err = session.Where("id_measurement_cfg='" + id + "'").Cols("id_measurement_cfg").Update(&MeasFilterCfg{})
+	affectedft, err = session.Where("id_measurement_cfg=?", id).Cols("id_measurement_cfg").Update(&MeasFilterCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Update FilterMeasurement on with id: %s, error: %s", id, err)
 	}
 
 	//CustomFilter Related Dev
-	affectedcf, err = session.Where("related_meas='" + id + "'").Cols("related_meas").Update(&CustomFilterCfg{})
+	affectedcf, err = session.Where("related_meas=?", id).Cols("related_meas").Update(&CustomFilterCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Measurement with id on delete CustomFilter with id: %s, error: %s", id, err)
 	}
 
-	affected, err = session.Where("id='" + id + "'").Delete(&MeasurementCfg{})
+	affected, err = session.Where("id=?", id).Delete(&MeasurementCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -466,31 +466,31 @@ func (dbc *DatabaseCfg) UpdateMeasurementCfg(id string, dev MeasurementCfg) (int
 	// create SnmpMetricCfg to check if any configuration issue found before persist to database.
 	// config should be got from database
 	// TODO: filter only metrics in Measurement to test if measurement was well defined
-	cfg, _ := dbc.GetSnmpMetricCfgMap("")
-	gv, _ := dbc.GetVarCatalogCfgMap("")
+	cfg, _ := dbc.GetSnmpMetricCfgMap(nil)
+	gv, _ := dbc.GetVarCatalogCfgMap(nil)
 
 	err = dev.Init(&cfg, CatalogVar2Map(gv))
 	if err != nil {
 		return 0, err
 	}
 	// initialize data persistence
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	if id != dev.ID { //ID has been changed
 		log.Infof("Updated Measurement Config to %d devices ", affecteddev)
 
-		affecteddev, err = session.Where("id_measurement_cfg='" + id + "'").Cols("id_measurement_cfg").Update(&MGroupsMeasurements{IDMeasurementCfg: dev.ID})
+		affecteddev, err = session.Where("id_measurement_cfg=?", id).Cols("id_measurement_cfg").Update(&MGroupsMeasurements{IDMeasurementCfg: dev.ID})
 		if err != nil {
 			session.Rollback()
 			return 0, fmt.Errorf("Error Update Measurement id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
 		}
-		affecteddev, err = session.Where("id_measurement_cfg='" + id + "'").Cols("id_measurement_cfg").Update(&MeasFilterCfg{IDMeasurementCfg: dev.ID})
+		affecteddev, err = session.Where("id_measurement_cfg=?", id).Cols("id_measurement_cfg").Update(&MeasFilterCfg{IDMeasurementCfg: dev.ID})
 		if err != nil {
 			session.Rollback()
 			return 0, fmt.Errorf("Error Update Measurement id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
 		}
-		affecteddev, err = session.Where("related_meas='" + id + "'").Cols("related_meas").Update(&CustomFilterCfg{RelatedMeas: dev.ID})
+		affecteddev, err = session.Where("related_meas=?", id).Cols("related_meas").Update(&CustomFilterCfg{RelatedMeas: dev.ID})
 		if err != nil {
 			session.Rollback()
 			return 0, fmt.Errorf("Error Update Measurement id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
@@ -499,7 +499,7 @@ func (dbc *DatabaseCfg) UpdateMeasurementCfg(id string, dev MeasurementCfg) (int
 		log.Infof("Updated Measurement config to %d devices ", affecteddev)
 	}
 	//delete all previous values
-	affecteddev, err = session.Where("id_measurement_cfg='" + id + "'").Delete(&MeasurementFieldCfg{})
+	affecteddev, err = session.Where("id_measurement_cfg=?", id).Delete(&MeasurementFieldCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Measurement on MGroupsMeasurements with id: %s, error: %s", id, err)
@@ -520,7 +520,7 @@ func (dbc *DatabaseCfg) UpdateMeasurementCfg(id string, dev MeasurementCfg) (int
 		}
 	}
 	//update data
-	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	affected, err = session.Where("id=?", id).UseBool().AllCols().Update(dev)
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -542,7 +542,7 @@ func (dbc *DatabaseCfg) GetMeasurementCfgAffectOnDel(id string) ([]*DbObjAction,
 	var cf []*CustomFilterCfg
 	var obj []*DbObjAction
 	var err error
-	err = dbc.x.Where("id_measurement_cfg='" + id + "'").Find(&mf)
+	err = dbc.db().Where("id_measurement_cfg=?", id).Find(&mf)
 	if err != nil {
 		return nil, fmt.Errorf("Error on Delete Measurement on MeasurementFieldCfg with id: %s, error: %s", id, err)
 	}
@@ -555,7 +555,7 @@ func (dbc *DatabaseCfg) GetMeasurementCfgAffectOnDel(id string) ([]*DbObjAction,
 		})
 	}
 
-	err = dbc.x.Where("id_measurement_cfg='" + id + "'").Find(&mg)
+	err = dbc.db().Where("id_measurement_cfg=?", id).Find(&mg)
 	if err != nil {
 		return nil, fmt.Errorf("Error on Delete Measurement on MGroupsMeasurements with id: %s, error: %s", id, err)
 	}
@@ -569,7 +569,7 @@ func (dbc *DatabaseCfg) GetMeasurementCfgAffectOnDel(id string) ([]*DbObjAction,
 		})
 	}
 
-	err = dbc.x.Where("related_meas='" + id + "'").Find(&cf)
+	err = dbc.db().Where("related_meas=?", id).Find(&cf)
 	if err != nil {
 		return nil, fmt.Errorf("Error on Delete Measurement on MeasurementFieldCfg with id: %s, error: %s", id, err)
 	}
@@ -15,7 +15,7 @@ MEASUREMENT GROUPS
 
 /*GetMGroupsCfgByID get metric data by id*/
 func (dbc *DatabaseCfg) GetMGroupsCfgByID(id string) (MGroupsCfg, error) {
-	cfgarray, err := dbc.GetMGroupsCfgArray("id='" + id + "'")
+	cfgarray, err := dbc.GetMGroupsCfgArray(FilterEq("id", id))
 	if err != nil {
 		return MGroupsCfg{}, err
 	}
@@ -29,7 +29,7 @@ func (dbc *DatabaseCfg) GetMGroupsCfgByID(id string) (MGroupsCfg, error) {
 }
 
 /*GetMGroupsCfgMap  return data in map format*/
-func (dbc *DatabaseCfg) GetMGroupsCfgMap(filter string) (map[string]*MGroupsCfg, error) {
+func (dbc *DatabaseCfg) GetMGroupsCfgMap(filter *Filter) (map[string]*MGroupsCfg, error) {
 	cfgarray, err := dbc.GetMGroupsCfgArray(filter)
 	cfgmap := make(map[string]*MGroupsCfg)
 	for _, val := range cfgarray {
@@ -40,17 +40,17 @@ func (dbc *DatabaseCfg) GetMGroupsCfgMap(filter string) (map[string]*MGroupsCfg,
 }
 
 /*GetMGroupsCfgArray generate an array of metrics with all its information */
-func (dbc *DatabaseCfg) GetMGroupsCfgArray(filter string) ([]*MGroupsCfg, error) {
+func (dbc *DatabaseCfg) GetMGroupsCfgArray(filter *Filter) ([]*MGroupsCfg, error) {
 	var err error
 	var devices []*MGroupsCfg
 	//Get Only data for selected metrics
-	if len(filter) > 0 {
-		if err = dbc.x.Where(filter).Find(&devices); err != nil {
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&devices); err != nil {
 			log.Warnf("Fail to get MGroupsCfg  data filteter with %s : %v\n", filter, err)
 			return nil, err
 		}
 	} else {
-		if err = dbc.x.Find(&devices); err != nil {
+		if err = dbc.db().Find(&devices); err != nil {
 			log.Warnf("Fail to get MGroupsCfg   data: %v\n", err)
 			return nil, err
 		}
@@ -58,7 +58,7 @@ func (dbc *DatabaseCfg) GetMGroupsCfgArray(filter string) ([]*MGroupsCfg, error)
 
 	//Load measurement for each groups
 	var mgroupsmeas []*MGroupsMeasurements
-	if err = dbc.x.Find(&mgroupsmeas); err != nil {
+	if err = dbc.db().Find(&mgroupsmeas); err != nil {
 		log.Warnf("Fail to get MGroup Measurements relationship  data: %v\n", err)
 	}
 
@@ -76,7 +76,7 @@ func (dbc *DatabaseCfg) GetMGroupsCfgArray(filter string) ([]*MGroupsCfg, error)
 func (dbc *DatabaseCfg) AddMGroupsCfg(dev MGroupsCfg) (int64, error) {
 	var err error
 	var affected, newmf int64
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	affected, err = session.Insert(dev)
@@ -112,23 +112,23 @@ func (dbc *DatabaseCfg) DelMGroupsCfg(id string) (int64, error) {
 	var affecteddev, affected int64
 	var err error
 
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 	// deleting references in Measurements tables
-	affecteddev, err = session.Where("id_mgroup_cfg='" + id + "'").Delete(&MGroupsMeasurements{})
+	affecteddev, err = session.Where("id_mgroup_cfg=?", id).Delete(&MGroupsMeasurements{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Metric with id on delete MeasurementFieldCfg with id: %s, error: %s", id, err)
 	}
 
 	//deleting all references in devices (snmpdevfilters)
-	affecteddev, err = session.Where("id_mgroup_cfg='" + id + "'").Delete(&SnmpDevMGroups{})
+	affecteddev, err = session.Where("id_mgroup_cfg=?", id).Delete(&SnmpDevMGroups{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Filter on SnmpDeviceFilter table with id: %s, error: %s", id, err)
 	}
 
-	affected, err = session.Where("id='" + id + "'").Delete(&MGroupsCfg{})
+	affected, err = session.Where("id=?", id).Delete(&MGroupsCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -147,11 +147,11 @@ func (dbc *DatabaseCfg) DelMGroupsCfg(id string) (int64, error) {
 func (dbc *DatabaseCfg) UpdateMGroupsCfg(id string, dev MGroupsCfg) (int64, error) {
 	var affecteddev, newmg, affected int64
 	var err error
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	if id != dev.ID { //ID has been changed
-		affecteddev, err = session.Where("id_mgroup_cfg='" + id + "'").Cols("id_mgroup_cfg").Update(&SnmpDevMGroups{IDMGroupCfg: dev.ID})
+		affecteddev, err = session.Where("id_mgroup_cfg=?", id).Cols("id_mgroup_cfg").Update(&SnmpDevMGroups{IDMGroupCfg: dev.ID})
 		if err != nil {
 			session.Rollback()
 			return 0, fmt.Errorf("Error Update Metric id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
@@ -159,7 +159,7 @@ func (dbc *DatabaseCfg) UpdateMGroupsCfg(id string, dev MGroupsCfg) (int64, erro
 		log.Infof("Updated Measurement Group Config to %d devices ", affecteddev)
 	}
 	//Remove all measurements in group.
-	_, err = session.Where("id_mgroup_cfg='" + id + "'").Delete(&MGroupsMeasurements{})
+	_, err = session.Where("id_mgroup_cfg=?", id).Delete(&MGroupsMeasurements{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Metric with id on delete MeasurementFieldCfg with id: %s, error: %s", id, err)
@@ -178,7 +178,7 @@ func (dbc *DatabaseCfg) UpdateMGroupsCfg(id string, dev MGroupsCfg) (int64, erro
 		}
 	}
 
-	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	affected, err = session.Where("id=?", id).UseBool().AllCols().Update(dev)
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -197,7 +197,7 @@ func (dbc *DatabaseCfg) UpdateMGroupsCfg(id string, dev MGroupsCfg) (int64, erro
 func (dbc *DatabaseCfg) GetMGroupsCfgAffectOnDel(id string) ([]*DbObjAction, error) {
 	var devices []*SnmpDevMGroups
 	var obj []*DbObjAction
-	if err := dbc.x.Where("id_mgroup_cfg='" + id + "'").Find(&devices); err != nil {
+	if err := dbc.db().Where("id_mgroup_cfg=?", id).Find(&devices); err != nil {
 		log.Warnf("Error on Get Measrument groups id %s for devices , error: %s", id, err)
 		return nil, err
 	}
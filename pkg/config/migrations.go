@@ -0,0 +1,233 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/go-xorm/xorm"
+)
+
+/***************************
+	Schema Migrations
+	-GetSchemaVersion
+	-GetMigrationPlan (dry-run)
+	-Migrate
+***********************************/
+
+// SchemaVersion keeps track of the applied schema migrations
+type SchemaVersion struct {
+	Version     int64     `xorm:"'version' pk"`
+	Description string    `xorm:"description"`
+	AppliedAt   time.Time `xorm:"applied_at"`
+}
+
+// Migration is a numbered schema up-step, all the steps are executed in
+// version order inside its own transaction (when the backend allows it)
+type Migration struct {
+	Version     int64
+	Description string
+	Up          func(session *xorm.Session) error
+}
+
+// migrations list, new steps should be always appended with a greater version
+// and never modified once released
+var migrations = []*Migration{
+	{
+		Version:     1,
+		Description: "initial schema (snmpcollector <= 0.8.1)",
+		Up: func(session *xorm.Session) error {
+			return session.Sync2(
+				new(VarCatalogCfg),
+				new(InfluxCfg),
+				new(SnmpDeviceCfg),
+				new(SnmpMetricCfg),
+				new(MeasurementCfg),
+				new(MeasFilterCfg),
+				new(MeasurementFieldCfg),
+				new(MGroupsCfg),
+				new(MGroupsMeasurements),
+				new(SnmpDevMGroups),
+				new(SnmpDevFilters),
+				new(CustomFilterCfg),
+				new(CustomFilterItems),
+				new(OidConditionCfg),
+			)
+		},
+	},
+	{
+		Version:     2,
+		Description: "configuration audit log",
+		Up: func(session *xorm.Session) error {
+			return session.Sync2(new(AuditCfg))
+		},
+	},
+	{
+		Version:     3,
+		Description: "configuration snapshots",
+		Up: func(session *xorm.Session) error {
+			return session.Sync2(new(SnapshotCfg))
+		},
+	},
+	{
+		Version:     4,
+		Description: "web ui users with roles",
+		Up: func(session *xorm.Session) error {
+			return session.Sync2(new(UserCfg))
+		},
+	},
+	{
+		Version:     5,
+		Description: "api tokens",
+		Up: func(session *xorm.Session) error {
+			return session.Sync2(new(APITokenCfg))
+		},
+	},
+	{
+		Version:     6,
+		Description: "snmp device gather overrun policy",
+		Up: func(session *xorm.Session) error {
+			return session.Sync2(new(SnmpDeviceCfg))
+		},
+	},
+	{
+		Version:     7,
+		Description: "alert rules and receivers",
+		Up: func(session *xorm.Session) error {
+			return session.Sync2(new(AlertRuleCfg), new(AlertReceiverCfg))
+		},
+	},
+	{
+		Version:     8,
+		Description: "maintenance windows",
+		Up: func(session *xorm.Session) error {
+			return session.Sync2(new(MaintWindowCfg))
+		},
+	},
+	{
+		Version:     9,
+		Description: "OCTETSTRING metrics conversion from FLOAT to STRING (snmpcollector < 0.8 upgrades)",
+		Up: func(session *xorm.Session) error {
+			// FLOAT was the default conversion before 0.8 but it is not valid for
+			// OCTETSTRING metrics, this was a manual SQL upgrade step
+			res, err := session.Exec("UPDATE snmp_metric_cfg SET conversion = ? WHERE datasrctype = ? AND conversion = ?", int(STRING), "OCTETSTRING", int(FLOAT))
+			if err != nil {
+				return err
+			}
+			if n, err := res.RowsAffected(); err == nil && n > 0 {
+				log.Infof("Updated conversion mode to STRING on %d OCTETSTRING metrics", n)
+			}
+			return nil
+		},
+	},
+}
+
+/*GetSchemaVersion get the last applied migration version (0 if none)*/
+func (dbc *DatabaseCfg) GetSchemaVersion() (int64, error) {
+	exist, err := dbc.x.IsTableExist(new(SchemaVersion))
+	if err != nil {
+		return 0, fmt.Errorf("Error on check schema version table: %s", err)
+	}
+	if !exist {
+		return 0, nil
+	}
+	sv := SchemaVersion{}
+	found, err := dbc.x.Desc("version").Limit(1).Get(&sv)
+	if err != nil {
+		return 0, fmt.Errorf("Error on get schema version: %s", err)
+	}
+	if !found {
+		return 0, nil
+	}
+	return sv.Version, nil
+}
+
+/*GetMigrationPlan get current schema version and the pending migrations without applying them*/
+func (dbc *DatabaseCfg) GetMigrationPlan() (int64, []*Migration, error) {
+	current, err := dbc.GetSchemaVersion()
+	if err != nil {
+		return 0, nil, err
+	}
+	var pending []*Migration
+	for _, m := range migrations {
+		if m.Version > current {
+			pending = append(pending, m)
+		}
+	}
+	return current, pending, nil
+}
+
+// backupSQLite copies the sqlite database file before applying migrations
+func (dbc *DatabaseCfg) backupSQLite(version int64) (string, error) {
+	dbfile := dataDir + "/" + dbc.Name + ".db"
+	// nothing to save on new databases
+	if fi, err := os.Stat(dbfile); err != nil || fi.Size() == 0 {
+		return "", nil
+	}
+	src, err := os.Open(dbfile)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	backup := fmt.Sprintf("%s.backup-v%d-%s", dbfile, version, time.Now().Format("20060102150405"))
+	dst, err := os.OpenFile(backup, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", err
+	}
+	return backup, dst.Close()
+}
+
+func (dbc *DatabaseCfg) applyMigration(m *Migration) error {
+	session := dbc.x.NewSession()
+	defer session.Close()
+
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if err := m.Up(session); err != nil {
+		session.Rollback()
+		return err
+	}
+	if _, err := session.Insert(&SchemaVersion{Version: m.Version, Description: m.Description, AppliedAt: time.Now()}); err != nil {
+		session.Rollback()
+		return err
+	}
+	return session.Commit()
+}
+
+/*Migrate apply all pending migrations, sqlite databases are backed up before*/
+func (dbc *DatabaseCfg) Migrate() error {
+	current, pending, err := dbc.GetMigrationPlan()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		log.Infof("Database schema is up to date (version %d)", current)
+		return nil
+	}
+	if dbc.Type == "sqlite3" {
+		backup, err := dbc.backupSQLite(current)
+		if err != nil {
+			return fmt.Errorf("Error on backup sqlite database before migrate: %s", err)
+		}
+		if len(backup) > 0 {
+			log.Infof("Database backup before migration written to %s", backup)
+		}
+	}
+	if err := dbc.x.Sync2(new(SchemaVersion)); err != nil {
+		return fmt.Errorf("Error on create schema version table: %s", err)
+	}
+	for _, m := range pending {
+		log.Infof("Applying database migration %d: %s", m.Version, m.Description)
+		if err := dbc.applyMigration(m); err != nil {
+			return fmt.Errorf("Error on database migration %d (%s): %s", m.Version, m.Description, err)
+		}
+	}
+	log.Infof("Database schema migrated from version %d to %d", current, pending[len(pending)-1].Version)
+	return nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMigrateFromUnversionedSQLite(t *testing.T) {
+	log = logrus.New()
+	log.Out = ioutil.Discard
+	dir, err := ioutil.TempDir("", "snmpcollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	SetDirs(dir, dir, dir)
+
+	// database created by older releases (plain sync without version table)
+	dbc := &DatabaseCfg{Type: "sqlite3", Name: "snmpcollector"}
+	if err := dbc.OpenDB(); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbc.x.Sync(new(InfluxCfg), new(SnmpMetricCfg)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbc.x.Insert(&InfluxCfg{ID: "old_influx", Host: "127.0.0.1", Port: 8086, DB: "snmp"}); err != nil {
+		t.Fatal(err)
+	}
+	// metrics from releases < 0.8 (without conversion, FLOAT by default)
+	if _, err := dbc.x.Insert([]*SnmpMetricCfg{
+		{ID: "sysdescr", FieldName: "sysDescr", BaseOID: ".1.3.6.1.2.1.1.1.0", DataSrcType: "OCTETSTRING", Conversion: FLOAT},
+		{ID: "hexcounter", FieldName: "hexCounter", BaseOID: ".1.3.6.1.4.1.1.1.0", DataSrcType: "OCTETSTRING", Conversion: INTEGER},
+		{ID: "ifspeed", FieldName: "ifSpeed", BaseOID: ".1.3.6.1.2.1.2.2.1.5", DataSrcType: "Gauge32", Conversion: FLOAT},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	current, pending, err := dbc.GetMigrationPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current != 0 || len(pending) != len(migrations) {
+		t.Fatalf("unexpected plan: version %d pending %d", current, len(pending))
+	}
+
+	if err := dbc.Migrate(); err != nil {
+		t.Fatalf("Migrate error: %s", err)
+	}
+	last := migrations[len(migrations)-1].Version
+	if v, _ := dbc.GetSchemaVersion(); v != last {
+		t.Errorf("schema version got %d want %d", v, last)
+	}
+	if _, err := dbc.GetInfluxCfgByID("old_influx"); err != nil {
+		t.Errorf("data lost on migration: %s", err)
+	}
+	for id, want := range map[string]ConversionMode{"sysdescr": STRING, "hexcounter": INTEGER, "ifspeed": FLOAT} {
+		m, err := dbc.GetSnmpMetricCfgByID(id)
+		if err != nil {
+			t.Errorf("metric %s lost on migration: %s", id, err)
+			continue
+		}
+		if m.Conversion != want {
+			t.Errorf("metric %s conversion got %s want %s", id, m.Conversion.GetString(), want.GetString())
+		}
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "snmpcollector.db.backup-v0-*"))
+	if len(backups) != 1 {
+		t.Errorf("expected one sqlite backup got %v", backups)
+	}
+
+	// nothing pending on next start
+	if _, pending, _ := dbc.GetMigrationPlan(); len(pending) != 0 {
+		t.Errorf("unexpected pending migrations after migrate: %d", len(pending))
+	}
+	if err := dbc.Migrate(); err != nil {
+		t.Errorf("Migrate on up to date schema error: %s", err)
+	}
+}
@@ -22,15 +22,26 @@ type OidConditionCfg struct {
 
 // Init Initialize a OIDConditionCfg
 func (oid *OidConditionCfg) Init(dbc *DatabaseCfg) error {
+	var ids []string
 	if oid.IsMultiple {
-		//check if OIDCond expression  is good
 		// First get all conditions ID's
-		oids, err := dbc.GetOidConditionCfgMap("")
+		oids, err := dbc.GetOidConditionCfgMap(nil)
 		if err != nil {
 			return err
 		}
-		OidsMap := make(map[string]interface{})
 		for k := range oids {
+			ids = append(ids, k)
+		}
+	}
+	return oid.InitWithIDs(ids)
+}
+
+// InitWithIDs Initialize a OIDConditionCfg, multiple conditions can only use the condition ids
+func (oid *OidConditionCfg) InitWithIDs(ids []string) error {
+	if oid.IsMultiple {
+		//check if OIDCond expression  is good
+		OidsMap := make(map[string]interface{})
+		for _, k := range ids {
 			OidsMap[k] = bool(true)
 		}
 		//check
@@ -100,7 +111,7 @@ Oid Condition Cfg
 
 /*GetOidConditionCfgByID get metric data by id*/
 func (dbc *DatabaseCfg) GetOidConditionCfgByID(id string) (OidConditionCfg, error) {
-	cfgarray, err := dbc.GetOidConditionCfgArray("id='" + id + "'")
+	cfgarray, err := dbc.GetOidConditionCfgArray(FilterEq("id", id))
 	if err != nil {
 		return OidConditionCfg{}, err
 	}
@@ -114,7 +125,7 @@ func (dbc *DatabaseCfg) GetOidConditionCfgByID(id string) (OidConditionCfg, erro
 }
 
 /*GetOidConditionCfgMap  return data in map format*/
-func (dbc *DatabaseCfg) GetOidConditionCfgMap(filter string) (map[string]*OidConditionCfg, error) {
+func (dbc *DatabaseCfg) GetOidConditionCfgMap(filter *Filter) (map[string]*OidConditionCfg, error) {
 	cfgarray, err := dbc.GetOidConditionCfgArray(filter)
 	cfgmap := make(map[string]*OidConditionCfg)
 	for _, val := range cfgarray {
@@ -125,17 +136,17 @@ func (dbc *DatabaseCfg) GetOidConditionCfgMap(filter string) (map[string]*OidCon
 }
 
 /*GetOidConditionCfgArray generate an array of metrics with all its information */
-func (dbc *DatabaseCfg) GetOidConditionCfgArray(filter string) ([]*OidConditionCfg, error) {
+func (dbc *DatabaseCfg) GetOidConditionCfgArray(filter *Filter) ([]*OidConditionCfg, error) {
 	var err error
 	var filters []*OidConditionCfg
 	//Get Only data for selected metrics
-	if len(filter) > 0 {
-		if err = dbc.x.Where(filter).Find(&filters); err != nil {
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&filters); err != nil {
 			log.Warnf("Fail to get OidConditionCfg  data filteter with %s : %v\n", filter, err)
 			return nil, err
 		}
 	} else {
-		if err = dbc.x.Find(&filters); err != nil {
+		if err = dbc.db().Find(&filters); err != nil {
 			log.Warnf("Fail to get OidConditionCfg   data: %v\n", err)
 			return nil, err
 		}
@@ -155,7 +166,7 @@ func (dbc *DatabaseCfg) AddOidConditionCfg(dev OidConditionCfg) (int64, error) {
 
 	// create OidConditionCfg to check if any configuration issue found before persist to database.
 	// initialize data persistence
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	affected, err = session.Insert(dev)
@@ -178,23 +189,23 @@ func (dbc *DatabaseCfg) DelOidConditionCfg(id string) (int64, error) {
 	var affecteddev, affected int64
 	var err error
 
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 	// deleting references filter_name on Measurement Filters
-	affecteddev, err = session.Where("filter_name='" + id + "'").Cols("filter_name").Update(&MeasFilterCfg{})
+	affecteddev, err = session.Where("filter_name=?", id).Cols("filter_name").Update(&MeasFilterCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete OIDCondition on Measurement Filter table with id:  %s , error: %s", id, err)
 	}
 
 	// deleting references extrada on SNMP Metric on related ConditionEval
-	affecteddev, err = session.Where("extradata='" + id + "' and datasrctype = 'CONDITIONEVAL'").Cols("extradata").Update(&SnmpMetricCfg{})
+	affecteddev, err = session.Where("extradata=? and datasrctype=?", id, "CONDITIONEVAL").Cols("extradata").Update(&SnmpMetricCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete OIDCondition on Metric table with id:  %s , error: %s", id, err)
 	}
 
-	affected, err = session.Where("id='" + id + "'").Delete(&OidConditionCfg{})
+	affected, err = session.Where("id=?", id).Delete(&OidConditionCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -219,25 +230,25 @@ func (dbc *DatabaseCfg) UpdateOidConditionCfg(id string, dev OidConditionCfg) (i
 	}
 	// create OidConditionCfg to check if any configuration issue found before persist to database.
 	// initialize data persistence
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	if id != dev.ID { //ID has been changed
 		//SnmpMetricCfg
-		affecteddev, err = session.Where("extradata='" + id + "' and datasrctype = 'CONDITIONEVAL'").Cols("extradata").Update(&SnmpMetricCfg{ExtraData: dev.ID})
+		affecteddev, err = session.Where("extradata=? and datasrctype=?", id, "CONDITIONEVAL").Cols("extradata").Update(&SnmpMetricCfg{ExtraData: dev.ID})
 		if err != nil {
 			session.Rollback()
 			return 0, fmt.Errorf("Error on Update SnmpMetricCfg on update OID Condition id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
 		}
 		//MeasFilterCfg
-		affecteddev, err = session.Where("filter_name='" + id + "'").Cols("filter_name").Update(&MeasFilterCfg{FilterName: dev.ID})
+		affecteddev, err = session.Where("filter_name=?", id).Cols("filter_name").Update(&MeasFilterCfg{FilterName: dev.ID})
 		if err != nil {
 			session.Rollback()
 			return 0, fmt.Errorf("Error on Update Custom Filter on update id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
 		}
 	}
 
-	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	affected, err = session.Where("id=?", id).UseBool().AllCols().Update(dev)
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -258,7 +269,7 @@ func (dbc *DatabaseCfg) GetOidConditionCfgAffectOnDel(id string) ([]*DbObjAction
 	var measf []*MeasFilterCfg
 	var obj []*DbObjAction
 	var err error
-	if err = dbc.x.Where("extradata='" + id + "' and datasrctype = 'CONDITIONEVAL'").Find(&metrics); err != nil {
+	if err = dbc.db().Where("extradata=? and datasrctype=?", id, "CONDITIONEVAL").Find(&metrics); err != nil {
 		log.Warnf("Error on Get CustomID  id %s for Measurement Filters , error: %s", id, err)
 		return nil, err
 	}
@@ -272,7 +283,7 @@ func (dbc *DatabaseCfg) GetOidConditionCfgAffectOnDel(id string) ([]*DbObjAction
 		})
 	}
 
-	if err = dbc.x.Where("filter_name='" + id + "'").Find(&measf); err != nil {
+	if err = dbc.db().Where("filter_name=?", id).Find(&measf); err != nil {
 		log.Warnf("Error on Get CustomID  id %s for Measurement Filters , error: %s", id, err)
 		return nil, err
 	}
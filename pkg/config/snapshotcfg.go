@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// SnapshotCfg a named copy of the whole configuration,
+// Data contains the JSON encoded export (see impexp.ExportData)
+type SnapshotCfg struct {
+	ID          int64     `xorm:"'id' pk autoincr"`
+	Name        string    `xorm:"'name' index"`
+	Description string    `xorm:"description"`
+	Auto        bool      `xorm:"'auto' default false"` // taken automatically (on reload/restore)
+	User        string    `xorm:"'username'"`
+	Timestamp   time.Time `xorm:"'snapshot_time' index"`
+	Checksum    string    `xorm:"checksum"`
+	NumObjects  int       `xorm:"'num_objects' default 0"`
+	Data        string    `xorm:"'data' longtext" json:",omitempty"`
+}
+
+/***************************
+	Configuration Snapshots
+	-AddSnapshotCfg
+	-GetSnapshotCfgByID
+	-GetLastSnapshotCfg
+	-GetSnapshotCfgArray
+	-DelSnapshotCfg
+***********************************/
+
+/*AddSnapshotCfg store a new configuration snapshot*/
+func (dbc *DatabaseCfg) AddSnapshotCfg(s *SnapshotCfg) error {
+	if s.Timestamp.IsZero() {
+		s.Timestamp = time.Now()
+	}
+	if _, err := dbc.db().Insert(s); err != nil {
+		return fmt.Errorf("Error on add snapshot %s: %s", s.Name, err)
+	}
+	log.Infof("Added new configuration snapshot %s [id %d] with %d objects", s.Name, s.ID, s.NumObjects)
+	return nil
+}
+
+/*GetSnapshotCfgByID get snapshot (with data) by id*/
+func (dbc *DatabaseCfg) GetSnapshotCfgByID(id int64) (*SnapshotCfg, error) {
+	s := &SnapshotCfg{}
+	found, err := dbc.db().ID(id).Get(s)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("Error no values have been returned with this id %d in the snapshot table", id)
+	}
+	return s, nil
+}
+
+/*GetLastSnapshotCfg get the newest snapshot without data, nil if there is no one*/
+func (dbc *DatabaseCfg) GetLastSnapshotCfg() (*SnapshotCfg, error) {
+	s := &SnapshotCfg{}
+	found, err := dbc.db().Omit("data").Desc("id").Get(s)
+	if err != nil || !found {
+		return nil, err
+	}
+	return s, nil
+}
+
+/*GetSnapshotCfgArray get all snapshots (newest first) without data*/
+func (dbc *DatabaseCfg) GetSnapshotCfgArray(filter *Filter) ([]*SnapshotCfg, error) {
+	session := dbc.newSession()
+	defer session.Close()
+	if filter != nil {
+		session.Where(filter.cond)
+	}
+	var snaps []*SnapshotCfg
+	if err := session.Omit("data").Desc("id").Find(&snaps); err != nil {
+		log.Warnf("Fail to get SnapshotCfg data filtered with %s : %v\n", filter, err)
+		return nil, err
+	}
+	return snaps, nil
+}
+
+/*DelSnapshotCfg delete a snapshot*/
+func (dbc *DatabaseCfg) DelSnapshotCfg(id int64) (int64, error) {
+	affected, err := dbc.db().ID(id).Delete(&SnapshotCfg{})
+	if err != nil {
+		return 0, err
+	}
+	if affected == 0 {
+		return 0, fmt.Errorf("Error no snapshot with id %d has been deleted", id)
+	}
+	log.Infof("Deleted Successfully configuration snapshot with ID %d", id)
+	return affected, nil
+}
@@ -15,7 +15,7 @@ import "fmt"
 
 /*GetSnmpDeviceCfgByID get device data by id*/
 func (dbc *DatabaseCfg) GetSnmpDeviceCfgByID(id string) (SnmpDeviceCfg, error) {
-	devcfgarray, err := dbc.GetSnmpDeviceCfgArray("id='" + id + "'")
+	devcfgarray, err := dbc.GetSnmpDeviceCfgArray(FilterEq("id", id))
 	if err != nil {
 		return SnmpDeviceCfg{}, err
 	}
@@ -29,7 +29,7 @@ func (dbc *DatabaseCfg) GetSnmpDeviceCfgByID(id string) (SnmpDeviceCfg, error) {
 }
 
 /*GetSnmpDeviceCfgMap  return data in map format*/
-func (dbc *DatabaseCfg) GetSnmpDeviceCfgMap(filter string) (map[string]*SnmpDeviceCfg, error) {
+func (dbc *DatabaseCfg) GetSnmpDeviceCfgMap(filter *Filter) (map[string]*SnmpDeviceCfg, error) {
 	devcfgarray, err := dbc.GetSnmpDeviceCfgArray(filter)
 	devcfgmap := make(map[string]*SnmpDeviceCfg)
 	for _, val := range devcfgarray {
@@ -40,17 +40,17 @@ func (dbc *DatabaseCfg) GetSnmpDeviceCfgMap(filter string) (map[string]*SnmpDevi
 }
 
 /*GetSnmpDeviceCfgArray generate an array of devices with all its information */
-func (dbc *DatabaseCfg) GetSnmpDeviceCfgArray(filter string) ([]*SnmpDeviceCfg, error) {
+func (dbc *DatabaseCfg) GetSnmpDeviceCfgArray(filter *Filter) ([]*SnmpDeviceCfg, error) {
 	var err error
 	var devices []*SnmpDeviceCfg
 	//Get Only data for selected devices
-	if len(filter) > 0 {
-		if err = dbc.x.Where(filter).Find(&devices); err != nil {
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&devices); err != nil {
 			log.Warnf("Fail to get SnmpDevicesCfg  data filteter with %s : %v\n", filter, err)
 			return nil, err
 		}
 	} else {
-		if err = dbc.x.Find(&devices); err != nil {
+		if err = dbc.db().Find(&devices); err != nil {
 			log.Warnf("Fail to get SnmpDevicesCfg   data: %v\n", err)
 			return nil, err
 		}
@@ -58,7 +58,7 @@ func (dbc *DatabaseCfg) GetSnmpDeviceCfgArray(filter string) ([]*SnmpDeviceCfg,
 
 	//Asign Groups to devices.
 	var snmpdevmgroups []*SnmpDevMGroups
-	if err = dbc.x.Find(&snmpdevmgroups); err != nil {
+	if err = dbc.db().Find(&snmpdevmgroups); err != nil {
 		log.Warnf("Fail to get SnmpDevices and Measurement groups relationship data: %v\n", err)
 		return devices, err
 	}
@@ -75,7 +75,7 @@ func (dbc *DatabaseCfg) GetSnmpDeviceCfgArray(filter string) ([]*SnmpDeviceCfg,
 
 	//Asign Filters to devices.
 	var snmpdevfilters []*SnmpDevFilters
-	if err = dbc.x.Find(&snmpdevfilters); err != nil {
+	if err = dbc.db().Find(&snmpdevfilters); err != nil {
 		log.Warnf("Fail to get SnmpDevices and Filter relationship data: %v\n", err)
 		return devices, err
 	}
@@ -96,7 +96,7 @@ func (dbc *DatabaseCfg) GetSnmpDeviceCfgArray(filter string) ([]*SnmpDeviceCfg,
 func (dbc *DatabaseCfg) AddSnmpDeviceCfg(dev SnmpDeviceCfg) (int64, error) {
 	var err error
 	var affected, newmg, newft int64
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	affected, err = session.Insert(dev)
@@ -143,29 +143,29 @@ func (dbc *DatabaseCfg) DelSnmpDeviceCfg(id string) (int64, error) {
 	var affectedmg, affectedft, affectedcf, affected int64
 	var err error
 
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 	//first deleting references in SnmpDevMGroups SnmpDevFilters
 	// Measurement Groups
-	affectedmg, err = session.Where("id_snmpdev='" + id + "'").Delete(&SnmpDevMGroups{})
+	affectedmg, err = session.Where("id_snmpdev=?", id).Delete(&SnmpDevMGroups{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevMGroups with id: %s, error: %s", id, err)
 	}
 	//Filters{}
-	affectedft, err = session.Where("id_snmpdev='" + id + "'").Delete(&SnmpDevFilters{})
+	affectedft, err = session.Where("id_snmpdev=?", id).Delete(&SnmpDevFilters{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevFilters with id: %s, error: %s", id, err)
 	}
 	//CustomFilter Reladed Dev
-	affectedcf, err = session.Where("related_dev='" + id + "'").Cols("related_dev").Update(&CustomFilterCfg{})
+	affectedcf, err = session.Where("related_dev=?", id).Cols("related_dev").Update(&CustomFilterCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevCfg with id: %s, error: %s", id, err)
 	}
 
-	affected, err = session.Where("id='" + id + "'").Delete(&SnmpDeviceCfg{})
+	affected, err = session.Where("id=?", id).Delete(&SnmpDeviceCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -184,22 +184,22 @@ func (dbc *DatabaseCfg) DelSnmpDeviceCfg(id string) (int64, error) {
 func (dbc *DatabaseCfg) UpdateSnmpDeviceCfg(id string, dev SnmpDeviceCfg) (int64, error) {
 	var deletemg, newmg, deleteft, newft, affectedcf, affected int64
 	var err error
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 	//Deleting first all relations
-	deletemg, err = session.Where("id_snmpdev='" + id + "'").Delete(&SnmpDevMGroups{})
+	deletemg, err = session.Where("id_snmpdev=?", id).Delete(&SnmpDevMGroups{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevMGroups with id: %s, error: %s", id, err)
 	}
 	//Filters{}
-	deleteft, err = session.Where("id_snmpdev='" + id + "'").Delete(&SnmpDevFilters{})
+	deleteft, err = session.Where("id_snmpdev=?", id).Delete(&SnmpDevFilters{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevFilters with id: %s, error: %s", id, err)
 	}
 
-	affectedcf, err = session.Where("related_dev='" + id + "'").Cols("related_dev").Update(&CustomFilterCfg{RelatedDev: dev.ID})
+	affectedcf, err = session.Where("related_dev=?", id).Cols("related_dev").Update(&CustomFilterCfg{RelatedDev: dev.ID})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error Update SnmpDevice id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
@@ -221,7 +221,7 @@ func (dbc *DatabaseCfg) UpdateSnmpDeviceCfg(id string, dev SnmpDeviceCfg) (int64
 		}
 		newft, err = session.Insert(&mfstruct)
 	}
-	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	affected, err = session.Where("id=?", id).UseBool().AllCols().Update(dev)
 
 	if err != nil {
 		session.Rollback()
@@ -242,7 +242,7 @@ func (dbc *DatabaseCfg) UpdateSnmpDeviceCfg(id string, dev SnmpDeviceCfg) (int64
 func (dbc *DatabaseCfg) GeSnmpDeviceCfgAffectOnDel(id string) ([]*DbObjAction, error) {
 	var devices []*CustomFilterCfg
 	var obj []*DbObjAction
-	if err := dbc.x.Where("related_dev='" + id + "'").Find(&devices); err != nil {
+	if err := dbc.db().Where("related_dev=?", id).Find(&devices); err != nil {
 		log.Warnf("Error on Get Custotm Filter id %s for devices , error: %s", id, err)
 		return nil, err
 	}
@@ -65,7 +65,7 @@ type SnmpMetricCfg struct {
 	GetRate     bool           `xorm:"getrate"` //ony Valid with COUNTERS
 	Scale       float64        `xorm:"scale"`
 	Shift       float64        `xorm:"shift"`
-	IsTag       bool           `xorm:"'istag' default 0"`      //Not Valid on  MULTISTRINGPARSER
+	IsTag       bool           `xorm:"'istag' default false"`  //Not Valid on  MULTISTRINGPARSER
 	ExtraData   string         `xorm:"extradata"`              //Only Valid with STRINGPARSER, MULTISTRINGPARSER, STRINGEVAL , BITS , BITSCHK, ENUM
 	Conversion  ConversionMode `xorm:"'conversion' default 0"` //Conversion will be always float for
 	Names       map[int]string `xorm:"-" json:"-"`             //BitString Name array
@@ -432,7 +432,7 @@ SNMP Metric
 
 /*GetSnmpMetricCfgByID get metric data by id*/
 func (dbc *DatabaseCfg) GetSnmpMetricCfgByID(id string) (SnmpMetricCfg, error) {
-	cfgarray, err := dbc.GetSnmpMetricCfgArray("id='" + id + "'")
+	cfgarray, err := dbc.GetSnmpMetricCfgArray(FilterEq("id", id))
 	if err != nil {
 		return SnmpMetricCfg{}, err
 	}
@@ -446,7 +446,7 @@ func (dbc *DatabaseCfg) GetSnmpMetricCfgByID(id string) (SnmpMetricCfg, error) {
 }
 
 /*GetSnmpMetricCfgMap  return data in map format*/
-func (dbc *DatabaseCfg) GetSnmpMetricCfgMap(filter string) (map[string]*SnmpMetricCfg, error) {
+func (dbc *DatabaseCfg) GetSnmpMetricCfgMap(filter *Filter) (map[string]*SnmpMetricCfg, error) {
 	cfgarray, err := dbc.GetSnmpMetricCfgArray(filter)
 	cfgmap := make(map[string]*SnmpMetricCfg)
 	for _, val := range cfgarray {
@@ -457,17 +457,17 @@ func (dbc *DatabaseCfg) GetSnmpMetricCfgMap(filter string) (map[string]*SnmpMetr
 }
 
 /*GetSnmpMetricCfgArray generate an array of metrics with all its information */
-func (dbc *DatabaseCfg) GetSnmpMetricCfgArray(filter string) ([]*SnmpMetricCfg, error) {
+func (dbc *DatabaseCfg) GetSnmpMetricCfgArray(filter *Filter) ([]*SnmpMetricCfg, error) {
 	var err error
 	var devices []*SnmpMetricCfg
 	//Get Only data for selected metrics
-	if len(filter) > 0 {
-		if err = dbc.x.Where(filter).Find(&devices); err != nil {
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&devices); err != nil {
 			log.Warnf("Fail to get SnmpMetricCfg  data filteter with %s : %v\n", filter, err)
 			return nil, err
 		}
 	} else {
-		if err = dbc.x.Find(&devices); err != nil {
+		if err = dbc.db().Find(&devices); err != nil {
 			log.Warnf("Fail to get SnmpMetricCfg   data: %v\n", err)
 			return nil, err
 		}
@@ -485,7 +485,7 @@ func (dbc *DatabaseCfg) AddSnmpMetricCfg(dev SnmpMetricCfg) (int64, error) {
 		return 0, err
 	}
 	// initialize data persistence
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	affected, err = session.Insert(dev)
@@ -508,17 +508,17 @@ func (dbc *DatabaseCfg) DelSnmpMetricCfg(id string) (int64, error) {
 	var affecteddev, affected int64
 	var err error
 
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 	// deleting references in Measurements
 
-	affecteddev, err = session.Where("id_metric_cfg='" + id + "'").Delete(&MeasurementFieldCfg{})
+	affecteddev, err = session.Where("id_metric_cfg=?", id).Delete(&MeasurementFieldCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Metric with id on delete MeasurementFieldCfg with id: %s, error: %s", id, err)
 	}
 
-	affected, err = session.Where("id='" + id + "'").Delete(&SnmpMetricCfg{})
+	affected, err = session.Where("id=?", id).Delete(&SnmpMetricCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -543,11 +543,11 @@ func (dbc *DatabaseCfg) UpdateSnmpMetricCfg(id string, dev SnmpMetricCfg) (int64
 		return 0, err
 	}
 	// initialize data persistence
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	if id != dev.ID { //ID has been changed
-		affecteddev, err = session.Where("id_metric_cfg='" + id + "'").Cols("id_metric_cfg").Update(&MeasurementFieldCfg{IDMetricCfg: dev.ID})
+		affecteddev, err = session.Where("id_metric_cfg=?", id).Cols("id_metric_cfg").Update(&MeasurementFieldCfg{IDMetricCfg: dev.ID})
 		if err != nil {
 			session.Rollback()
 			return 0, fmt.Errorf("Error Update Metric id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
@@ -555,7 +555,7 @@ func (dbc *DatabaseCfg) UpdateSnmpMetricCfg(id string, dev SnmpMetricCfg) (int64
 		log.Infof("Updated SnmpMetric Config to %d devices ", affecteddev)
 	}
 
-	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	affected, err = session.Where("id=?", id).UseBool().AllCols().Update(dev)
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -574,7 +574,7 @@ func (dbc *DatabaseCfg) UpdateSnmpMetricCfg(id string, dev SnmpMetricCfg) (int64
 func (dbc *DatabaseCfg) GetSnmpMetricCfgAffectOnDel(id string) ([]*DbObjAction, error) {
 	var devices []*MeasurementFieldCfg
 	var obj []*DbObjAction
-	if err := dbc.x.Where("id_metric_cfg='" + id + "'").Find(&devices); err != nil {
+	if err := dbc.db().Where("id_metric_cfg=?", id).Find(&devices); err != nil {
 		log.Warnf("Error on Get Snmp Metric Cfg id %s for devices , error: %s", id, err)
 		return nil, err
 	}
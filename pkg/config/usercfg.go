@@ -0,0 +1,195 @@
+package config
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User roles, each role has also all the permissions of the previous ones
+const (
+	RoleViewer   = "viewer"   // read only access to configuration and runtime info
+	RoleOperator = "operator" // runtime actions (forcegather, snmpreset, activate...)
+	RoleAdmin    = "admin"    // configuration changes, imports and user management
+)
+
+var roleLevels = map[string]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ValidRole returns true if role is a known user role
+func ValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// RoleAllows returns true if role has the permissions of the required role
+func RoleAllows(role string, required string) bool {
+	return ValidRole(role) && roleLevels[role] >= roleLevels[required]
+}
+
+// UserCfg is a local web UI user
+type UserCfg struct {
+	ID          string `xorm:"'id' unique" binding:"Required"`
+	Password    string `xorm:"password" json:",omitempty"` // bcrypt hash on database, plain text on add/update
+	Role        string `xorm:"role" binding:"Required;In(viewer,operator,admin)"`
+	FullName    string `xorm:"fullname"`
+	Email       string `xorm:"email"`
+	Description string `xorm:"description"`
+}
+
+/***************************
+Web UI Users
+	-GetUserCfgByID(struct)
+	-GetUserCfgArray(Array - for web ui use )
+	-AddUserCfg
+	-DelUserCfg
+	-UpdateUserCfg
+	-CheckUserCfgPassword
+***********************************/
+
+// hashPassword returns the bcrypt hash of the plain text user password
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("Error on hash user password: %s", err)
+	}
+	return string(hash), nil
+}
+
+/*GetUserCfgByID get user data by id*/
+func (dbc *DatabaseCfg) GetUserCfgByID(id string) (UserCfg, error) {
+	cfgarray, err := dbc.GetUserCfgArray(FilterEq("id", id))
+	if err != nil {
+		return UserCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return UserCfg{}, fmt.Errorf("Error %d results on get UserCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return UserCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the user config table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetUserCfgArray generate an array of users with all its information */
+func (dbc *DatabaseCfg) GetUserCfgArray(filter *Filter) ([]*UserCfg, error) {
+	var err error
+	var users []*UserCfg
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&users); err != nil {
+			log.Warnf("Fail to get UserCfg data filtered with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.db().Find(&users); err != nil {
+			log.Warnf("Fail to get UserCfg data: %v\n", err)
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+/*AddUserCfg for adding a new user, password is stored hashed*/
+func (dbc *DatabaseCfg) AddUserCfg(dev UserCfg) (int64, error) {
+	var err error
+	var affected int64
+
+	if !ValidRole(dev.Role) {
+		return 0, fmt.Errorf("Error on add user %s: unknown role %q", dev.ID, dev.Role)
+	}
+	if len(dev.Password) == 0 {
+		return 0, fmt.Errorf("Error on add user %s: password is required", dev.ID)
+	}
+	if dev.Password, err = hashPassword(dev.Password); err != nil {
+		return 0, err
+	}
+
+	session := dbc.newSession()
+	defer session.Close()
+
+	affected, err = session.Insert(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Added new User Successfully with id %s and role %s", dev.ID, dev.Role)
+	return affected, nil
+}
+
+/*DelUserCfg for deleting users from ID*/
+func (dbc *DatabaseCfg) DelUserCfg(id string) (int64, error) {
+	var affected int64
+	var err error
+
+	session := dbc.newSession()
+	defer session.Close()
+
+	affected, err = session.Where("id=?", id).Delete(&UserCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	if affected == 0 {
+		session.Rollback()
+		return 0, fmt.Errorf("Error no user found with id %s", id)
+	}
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully User with ID %s", id)
+	return affected, nil
+}
+
+/*UpdateUserCfg for updating users, the current password is kept if no new one is set*/
+func (dbc *DatabaseCfg) UpdateUserCfg(id string, dev UserCfg) (int64, error) {
+	var affected int64
+	var err error
+
+	if !ValidRole(dev.Role) {
+		return 0, fmt.Errorf("Error on update user %s: unknown role %q", id, dev.Role)
+	}
+	if len(dev.Password) == 0 {
+		old, err := dbc.GetUserCfgByID(id)
+		if err != nil {
+			return 0, err
+		}
+		dev.Password = old.Password
+	} else if dev.Password, err = hashPassword(dev.Password); err != nil {
+		return 0, err
+	}
+
+	session := dbc.newSession()
+	defer session.Close()
+
+	affected, err = session.Where("id=?", id).AllCols().Update(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Updated User Successfully with id %s and role %s", id, dev.Role)
+	return affected, nil
+}
+
+/*CheckUserCfgPassword returns the user if the password matches the stored one*/
+func (dbc *DatabaseCfg) CheckUserCfgPassword(id string, password string) (UserCfg, error) {
+	user, err := dbc.GetUserCfgByID(id)
+	if err != nil {
+		return UserCfg{}, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return UserCfg{}, fmt.Errorf("Error on check password for user %s: password does not match", id)
+	}
+	return user, nil
+}
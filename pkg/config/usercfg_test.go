@@ -0,0 +1,75 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role     string
+		required string
+		want     bool
+	}{
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleOperator, false},
+		{RoleOperator, RoleViewer, true},
+		{RoleOperator, RoleAdmin, false},
+		{RoleAdmin, RoleOperator, true},
+		{"", RoleViewer, false},
+		{"root", RoleViewer, false},
+	}
+	for _, tt := range tests {
+		if got := RoleAllows(tt.role, tt.required); got != tt.want {
+			t.Errorf("RoleAllows(%q, %q) = %v, want %v", tt.role, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestUserPassword(t *testing.T) {
+	dbc, release := newTestSQLiteDB(t)
+	defer release()
+
+	if _, err := dbc.AddUserCfg(UserCfg{ID: "noc", Password: "s3cret", Role: "superuser"}); err == nil {
+		t.Error("user with unknown role added")
+	}
+	if _, err := dbc.AddUserCfg(UserCfg{ID: "noc", Role: RoleOperator}); err == nil {
+		t.Error("user without password added")
+	}
+	if _, err := dbc.AddUserCfg(UserCfg{ID: "noc", Password: "s3cret", Role: RoleOperator, FullName: "NOC"}); err != nil {
+		t.Fatal(err)
+	}
+	u, err := dbc.GetUserCfgByID("noc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Password == "s3cret" {
+		t.Error("password stored in plain text")
+	}
+	if _, err := dbc.CheckUserCfgPassword("noc", "bad"); err == nil {
+		t.Error("wrong password accepted")
+	}
+	if u, err := dbc.CheckUserCfgPassword("noc", "s3cret"); err != nil || u.Role != RoleOperator {
+		t.Errorf("unexpected login user %+v, error %v", u, err)
+	}
+
+	// password is kept when not set on update
+	if _, err := dbc.UpdateUserCfg("noc", UserCfg{ID: "noc", Role: RoleViewer}); err != nil {
+		t.Fatal(err)
+	}
+	if u, err := dbc.CheckUserCfgPassword("noc", "s3cret"); err != nil || u.Role != RoleViewer || len(u.FullName) != 0 {
+		t.Errorf("unexpected updated user %+v, error %v", u, err)
+	}
+	if _, err := dbc.UpdateUserCfg("noc", UserCfg{ID: "noc", Password: "n3w", Role: RoleViewer}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbc.CheckUserCfgPassword("noc", "s3cret"); err == nil {
+		t.Error("old password accepted after change")
+	}
+
+	if _, err := dbc.DelUserCfg("noc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbc.CheckUserCfgPassword("noc", "n3w"); err == nil {
+		t.Error("deleted user accepted")
+	}
+}
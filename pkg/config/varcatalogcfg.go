@@ -27,7 +27,7 @@ Global Var
 
 /*GetVarCatalogCfgByID get metric data by id*/
 func (dbc *DatabaseCfg) GetVarCatalogCfgByID(id string) (VarCatalogCfg, error) {
-	cfgarray, err := dbc.GetVarCatalogCfgArray("id='" + id + "'")
+	cfgarray, err := dbc.GetVarCatalogCfgArray(FilterEq("id", id))
 	if err != nil {
 		return VarCatalogCfg{}, err
 	}
@@ -41,7 +41,7 @@ func (dbc *DatabaseCfg) GetVarCatalogCfgByID(id string) (VarCatalogCfg, error) {
 }
 
 /*GetVarCatalogCfgMap  return data in map format*/
-func (dbc *DatabaseCfg) GetVarCatalogCfgMap(filter string) (map[string]*VarCatalogCfg, error) {
+func (dbc *DatabaseCfg) GetVarCatalogCfgMap(filter *Filter) (map[string]*VarCatalogCfg, error) {
 	cfgarray, err := dbc.GetVarCatalogCfgArray(filter)
 	cfgmap := make(map[string]*VarCatalogCfg)
 	for _, val := range cfgarray {
@@ -52,17 +52,17 @@ func (dbc *DatabaseCfg) GetVarCatalogCfgMap(filter string) (map[string]*VarCatal
 }
 
 /*GetVarCatalogCfgArray generate an array of metrics with all its information */
-func (dbc *DatabaseCfg) GetVarCatalogCfgArray(filter string) ([]*VarCatalogCfg, error) {
+func (dbc *DatabaseCfg) GetVarCatalogCfgArray(filter *Filter) ([]*VarCatalogCfg, error) {
 	var err error
 	var devices []*VarCatalogCfg
 	//Get Only data for selected metrics
-	if len(filter) > 0 {
-		if err = dbc.x.Where(filter).Find(&devices); err != nil {
+	if filter != nil {
+		if err = dbc.db().Where(filter.cond).Find(&devices); err != nil {
 			log.Warnf("Fail to get VarCatalogCfg  data filteter with %s : %v\n", filter, err)
 			return nil, err
 		}
 	} else {
-		if err = dbc.x.Find(&devices); err != nil {
+		if err = dbc.db().Find(&devices); err != nil {
 			log.Warnf("Fail to get VarCatalogCfg   data: %v\n", err)
 			return nil, err
 		}
@@ -76,7 +76,7 @@ func (dbc *DatabaseCfg) AddVarCatalogCfg(dev VarCatalogCfg) (int64, error) {
 	var affected int64
 
 	// initialize data persistence
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	affected, err = session.Insert(dev)
@@ -99,11 +99,11 @@ func (dbc *DatabaseCfg) DelVarCatalogCfg(id string) (int64, error) {
 	var affecteddev, affected int64
 	var err error
 
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 	// deleting references in Measurements
 
-	affected, err = session.Where("id='" + id + "'").Delete(&VarCatalogCfg{})
+	affected, err = session.Where("id=?", id).Delete(&VarCatalogCfg{})
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -124,15 +124,15 @@ func (dbc *DatabaseCfg) UpdateVarCatalogCfg(id string, dev VarCatalogCfg) (int64
 	var err error
 	// create VarCatalogCfg to check if any configuration issue found before persist to database.
 
-	session := dbc.x.NewSession()
+	session := dbc.newSession()
 	defer session.Close()
 
 	if id != dev.ID { //ID has been changed
 		var metrics []*SnmpMetricCfg
-		session.Where("datasrctype = 'STRINGEVAL' and extradata like '%" + id + "%'").Find(&metrics)
+		session.Where("datasrctype=? and extradata like ?", "STRINGEVAL", "%"+id+"%").Find(&metrics)
 		for _, v := range metrics {
 			v.ExtraData = strings.Replace(v.ExtraData, id, dev.ID, -1)
-			_, err = session.Where("id='" + v.ID + "'").UseBool().AllCols().Update(v)
+			_, err = session.Where("id=?", v.ID).UseBool().AllCols().Update(v)
 			if err != nil {
 				session.Rollback()
 				return 0, err
@@ -142,7 +142,7 @@ func (dbc *DatabaseCfg) UpdateVarCatalogCfg(id string, dev VarCatalogCfg) (int64
 		log.Infof("Updated VarCatalogiableConfig to %d devices ", affecteddev)
 	}
 
-	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	affected, err = session.Where("id=?", id).UseBool().AllCols().Update(dev)
 	if err != nil {
 		session.Rollback()
 		return 0, err
@@ -162,7 +162,7 @@ func (dbc *DatabaseCfg) GetVarCatalogCfgAffectOnDel(id string) ([]*DbObjAction,
 	//var devices []*MeasurementFieldCfg
 	var obj []*DbObjAction
 	/*
-		if err := dbc.x.Where("id_metric_cfg='" + id + "'").Find(&devices); err != nil {
+		if err := dbc.db().Where("id_metric_cfg=?", id).Find(&devices); err != nil {
 			log.Warnf("Error on Get Snmp Metric Cfg id %d for devices , error: %s", id, err)
 			return nil, err
 		}
@@ -0,0 +1,385 @@
+package impexp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-macaron/binding"
+	"github.com/pelletier/go-toml"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/yaml.v2"
+)
+
+/***************************
+	Declarative Configuration Sync
+	configuration objects are read from a directory of YAML/TOML files with
+	the object type names as keys and a list of objects as values:
+
+	snmpdevicecfg:
+	  - id: router1
+	    host: 10.0.0.1
+	    ...
+	influxcfg:
+	  - id: influx1
+	    ...
+
+	field names are the same used in import/export (case insensitive)
+	-LoadCfgDir
+	-PlanCfgSync
+	-ApplyCfgSync
+***********************************/
+
+// CfgSyncPlan the changes needed to get the database as described in a config directory
+type CfgSyncPlan struct {
+	Dir          string
+	Files        []string
+	Prune        bool
+	ManagedTypes []string // object types found in the directory (only these ones are pruned)
+	NumObjects   int
+	Changes      []*SnapshotObjectDiff
+	desired      map[string]*ExportObject
+}
+
+// CfgSyncError is returned when the directory objects are not valid
+type CfgSyncError struct {
+	Errors []string
+}
+
+func (e *CfgSyncError) Error() string {
+	return fmt.Sprintf("There is %d errors in the configuration directory: %s", len(e.Errors), strings.Join(e.Errors, "; "))
+}
+
+var bindingDefaultRe = regexp.MustCompile(`Default\(([^)]*)\)`)
+
+// applyBindingDefaults set the `binding:"Default(x)"` value on empty struct fields
+// as the web API does on bind
+func applyBindingDefaults(obj interface{}) {
+	v := reflect.ValueOf(obj).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		m := bindingDefaultRe.FindStringSubmatch(t.Field(i).Tag.Get("binding"))
+		f := v.Field(i)
+		if m == nil || !f.CanSet() || !reflect.DeepEqual(f.Interface(), reflect.Zero(f.Type()).Interface()) {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(m[1])
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if n, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+				f.SetInt(n)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if n, err := strconv.ParseUint(m[1], 10, 64); err == nil {
+				f.SetUint(n)
+			}
+		}
+	}
+}
+
+// yaml2JSON converts yaml generic maps (with interface{} keys) to JSON compatible ones
+func yaml2JSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for k, val := range t {
+			m[fmt.Sprintf("%v", k)] = yaml2JSON(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range t {
+			t[i] = yaml2JSON(val)
+		}
+	}
+	return v
+}
+
+// readCfgFile returns the object lists by object type contained in a YAML/TOML file
+func readCfgFile(file string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".toml":
+		tree, err := toml.LoadBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		return tree.ToMap(), nil
+	default:
+		var raw interface{}
+		if err = yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			return nil, nil
+		}
+		m, ok := yaml2JSON(raw).(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("bad format, should be a map of object types")
+		}
+		return m, nil
+	}
+}
+
+// LoadCfgDir reads all YAML/TOML files in dir (recursively) and returns the decoded objects
+// sorted in the import order and the file list. All object errors are returned in a *CfgSyncError
+func LoadCfgDir(dir string) (*ExportData, []string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".toml":
+			if !info.IsDir() {
+				files = append(files, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error on read configuration directory %s: %s", dir, err)
+	}
+	sort.Strings(files)
+
+	serr := &CfgSyncError{}
+	bytype := make(map[string][]*ExportObject)
+	found := make(map[string]string)
+	for _, file := range files {
+		data, err := readCfgFile(file)
+		if err != nil {
+			serr.Errors = append(serr.Errors, fmt.Sprintf("%s: %s", file, err))
+			continue
+		}
+		for objtype, list := range data {
+			objs, ok := list.([]interface{})
+			if !ok {
+				serr.Errors = append(serr.Errors, fmt.Sprintf("%s: %s should be an object list", file, objtype))
+				continue
+			}
+			for i, raw := range objs {
+				obj, err := config.NewCfgObject(objtype)
+				if err != nil {
+					serr.Errors = append(serr.Errors, fmt.Sprintf("%s: %s", file, err))
+					break
+				}
+				// JSON decoding matches field names case insensitive
+				data, err := json.Marshal(raw)
+				if err == nil {
+					err = json.Unmarshal(data, obj)
+				}
+				if err != nil {
+					serr.Errors = append(serr.Errors, fmt.Sprintf("%s: %s[%d]: %s", file, objtype, i, err))
+					continue
+				}
+				applyBindingDefaults(obj)
+				m := make(map[string]interface{})
+				if data, err = json.Marshal(obj); err == nil {
+					err = json.Unmarshal(data, &m)
+				}
+				if err != nil {
+					serr.Errors = append(serr.Errors, fmt.Sprintf("%s: %s[%d]: %s", file, objtype, i, err))
+					continue
+				}
+				id, _ := m["ID"].(string)
+				if prev, ok := found[objtype+"/"+id]; ok {
+					serr.Errors = append(serr.Errors, fmt.Sprintf("%s: %s %s already defined in %s", file, objtype, id, prev))
+					continue
+				}
+				found[objtype+"/"+id] = file
+				if ers := binding.RawValidate(reflect.ValueOf(obj).Elem().Interface()); ers.Len() > 0 {
+					e, _ := json.Marshal(ers)
+					serr.Errors = append(serr.Errors, fmt.Sprintf("%s: %s %s: %s", file, objtype, id, e))
+					continue
+				}
+				bytype[objtype] = append(bytype[objtype], &ExportObject{ObjectTypeID: objtype, ObjectID: id, ObjectCfg: m})
+			}
+		}
+	}
+	if len(serr.Errors) > 0 {
+		return nil, files, serr
+	}
+	e := &ExportData{Info: &ExportInfo{FileName: dir, Description: "configuration directory"}}
+	for _, t := range snapshotObjectTypes {
+		sort.Slice(bytype[t], func(i, j int) bool { return bytype[t][i].ObjectID < bytype[t][j].ObjectID })
+		e.Objects = append(e.Objects, bytype[t]...)
+	}
+	return e, files, nil
+}
+
+// validateCfgSync checks the objects as they will be in the database after the sync
+// with each type Init and the references between objects
+func validateCfgSync(objs map[string]*ExportObject, desired *ExportData) error {
+	serr := &CfgSyncError{}
+	decoded := make(map[string]interface{})
+	ids := make(map[string][]string)
+	for k, o := range objs {
+		obj, err := config.NewCfgObject(o.ObjectTypeID)
+		if err == nil {
+			var data []byte
+			if data, err = json.Marshal(o.ObjectCfg); err == nil {
+				err = json.Unmarshal(data, obj)
+			}
+		}
+		if err != nil {
+			serr.Errors = append(serr.Errors, fmt.Sprintf("%s: %s", k, err))
+			continue
+		}
+		decoded[k] = obj
+		ids[o.ObjectTypeID] = append(ids[o.ObjectTypeID], o.ObjectID)
+	}
+	metrics := make(map[string]*config.SnmpMetricCfg)
+	vars := make(map[string]*config.VarCatalogCfg)
+	for _, obj := range decoded {
+		switch v := obj.(type) {
+		case *config.SnmpMetricCfg:
+			metrics[v.ID] = v
+		case *config.VarCatalogCfg:
+			vars[v.ID] = v
+		}
+	}
+	exists := func(objtype, id string) bool {
+		_, ok := objs[objtype+"/"+id]
+		return ok
+	}
+	check := func(o *ExportObject, objtype, id string) {
+		if len(id) > 0 && !exists(objtype, id) {
+			serr.Errors = append(serr.Errors, fmt.Sprintf("%s %s: references unknown %s %s", o.ObjectTypeID, o.ObjectID, objtype, id))
+		}
+	}
+	// only the objects in the directory are checked
+	for _, o := range desired.Objects {
+		var err error
+		switch v := decoded[o.ObjectTypeID+"/"+o.ObjectID].(type) {
+		case *config.SnmpMetricCfg:
+			err = v.Init()
+			if v.DataSrcType == "CONDITIONEVAL" {
+				check(o, "oidconditioncfg", v.ExtraData)
+			}
+		case *config.OidConditionCfg:
+			err = v.InitWithIDs(ids["oidconditioncfg"])
+		case *config.MeasurementCfg:
+			err = v.Init(&metrics, config.CatalogVar2Map(vars))
+			for _, f := range v.Fields {
+				check(o, "snmpmetriccfg", f.ID)
+			}
+		case *config.MeasFilterCfg:
+			check(o, "measurementcfg", v.IDMeasurementCfg)
+			switch v.FType {
+			case "OIDCondition":
+				check(o, "oidconditioncfg", v.FilterName)
+			case "CustomFilter":
+				check(o, "customfiltercfg", v.FilterName)
+			}
+		case *config.MGroupsCfg:
+			for _, m := range v.Measurements {
+				check(o, "measurementcfg", m)
+			}
+		case *config.SnmpDeviceCfg:
+			check(o, "influxcfg", v.OutDB)
+			for _, g := range v.MeasurementGroups {
+				check(o, "measgroupcfg", g)
+			}
+			for _, f := range v.MeasFilters {
+				check(o, "measfiltercfg", f)
+			}
+		case *config.MaintWindowCfg:
+			err = v.Init()
+		case *config.AlertRuleCfg:
+			err = v.Init()
+			for _, r := range v.Receivers {
+				check(o, "alertreceivercfg", r)
+			}
+		case *config.AlertReceiverCfg:
+			err = v.Init()
+		}
+		if err != nil {
+			serr.Errors = append(serr.Errors, fmt.Sprintf("%s %s: %s", o.ObjectTypeID, o.ObjectID, err))
+		}
+	}
+	if len(serr.Errors) > 0 {
+		return serr
+	}
+	return nil
+}
+
+// PlanCfgSync returns the changes needed to get the database configuration as described in dir,
+// objects not in dir are only removed with prune, and only for object types found in dir
+func PlanCfgSync(dir string, prune bool) (*CfgSyncPlan, error) {
+	desired, files, err := LoadCfgDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	current, _, err := currentExport("current")
+	if err != nil {
+		return nil, err
+	}
+	plan := &CfgSyncPlan{Dir: dir, Files: files, Prune: prune, NumObjects: len(desired.Objects), desired: make(map[string]*ExportObject)}
+	managed := make(map[string]bool)
+	for _, o := range desired.Objects {
+		plan.desired[objectKey(o)] = o
+		if !managed[o.ObjectTypeID] {
+			managed[o.ObjectTypeID] = true
+			plan.ManagedTypes = append(plan.ManagedTypes, o.ObjectTypeID)
+		}
+	}
+	// final state: current objects overwritten by the desired ones, less the pruned ones
+	final := make(map[string]*ExportObject)
+	for _, o := range current.Objects {
+		final[objectKey(o)] = o
+	}
+	plan.Changes = []*SnapshotObjectDiff{}
+	for _, d := range diffExports(current, desired) {
+		if d.Status == SnapshotObjRemoved {
+			if !prune || !managed[d.ObjectTypeID] {
+				continue
+			}
+			delete(final, d.ObjectTypeID+"/"+d.ObjectID)
+		}
+		plan.Changes = append(plan.Changes, d)
+	}
+	for k, o := range plan.desired {
+		final[k] = o
+	}
+	if err = validateCfgSync(final, desired); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// ApplyCfgSync writes the plan changes into the database in a single transaction rolled back on
+// any error (all of them recorded in the audit log with user), an automatic snapshot is taken before.
+// Returns the applied changes.
+func ApplyCfgSync(plan *CfgSyncPlan, user string) ([]*SnapshotObjectDiff, error) {
+	if len(plan.Changes) == 0 {
+		log.Infof("Configuration sync from %s: nothing to change", plan.Dir)
+		return plan.Changes, nil
+	}
+	if _, err := TakeSnapshot(fmt.Sprintf("auto sync %s", time.Now().Format("2006-01-02 15:04:05")), "automatic snapshot taken before configuration sync from "+plan.Dir, user, true); err != nil {
+		return nil, err
+	}
+	log.Infof("Configuration sync from %s by user %s: %d objects to change", plan.Dir, user, len(plan.Changes))
+	err := dbc.Transaction(func(tx *config.DatabaseCfg) error {
+		for _, d := range plan.Changes {
+			if err := applyObjectDiff(tx, d, plan.desired[d.ObjectTypeID+"/"+d.ObjectID], user); err != nil {
+				return fmt.Errorf("Error on configuration sync, object %s %s (%s): %s", d.ObjectTypeID, d.ObjectID, d.Status, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan.Changes, nil
+}
@@ -0,0 +1,169 @@
+package impexp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+const testSyncOutputs = `
+[[influxcfg]]
+id = "influx1"
+host = "127.0.0.1"
+port = 8086
+db = "snmp"
+user = "user"
+password = "pass"
+retention = "autogen"
+`
+
+const testSyncDevices = `
+snmpmetriccfg:
+  - id: sysuptime
+    fieldname: sysUpTime
+    baseoid: .1.3.6.1.2.1.1.3.0
+    datasrctype: TimeTicks
+measurementcfg:
+  - id: sys
+    name: system
+    getmode: value
+    fields:
+      - id: sysuptime
+        report: 1
+measgroupcfg:
+  - id: base
+    measurements: [sys]
+snmpdevicecfg:
+  - id: router1
+    host: 10.0.0.1
+    port: 161
+    snmpversion: 2c
+    community: public
+    active: true
+    outdb: influx1
+    measurementgroups: [base]
+`
+
+const testSyncRouter2 = `  - id: router2
+    host: 10.0.0.2
+    port: 161
+    snmpversion: 2c
+    community: public
+    outdb: influx1
+    measurementgroups: [base]
+`
+
+const testSyncAlerts = `
+alertreceivercfg:
+  - id: ops
+    url: http://127.0.0.1:9000/hook
+alertrulecfg:
+  - id: uptime
+    measurement: sys
+    expression: sysUpTime < 300
+    receivers: [ops]
+`
+
+func writeSyncFile(t *testing.T, dir string, name string, data string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCfgSync(t *testing.T) {
+	db, release := newTestDB(t)
+	defer release()
+	dir, err := ioutil.TempDir("", "cfgsync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeSyncFile(t, dir, "outputs.toml", testSyncOutputs)
+	writeSyncFile(t, dir, "devices.yaml", testSyncDevices+testSyncRouter2)
+
+	plan, err := PlanCfgSync(dir, false)
+	if err != nil {
+		t.Fatalf("PlanCfgSync error: %s", err)
+	}
+	if plan.NumObjects != 6 || len(plan.Changes) != 6 {
+		t.Fatalf("unexpected plan with %d objects and %d changes: %+v", plan.NumObjects, len(plan.Changes), plan.Changes)
+	}
+	// a failed object rolls back all the previous changes
+	plan.desired["snmpdevicecfg/router2"] = &ExportObject{ObjectTypeID: "snmpdevicecfg", ObjectID: "router2", ObjectCfg: map[string]interface{}{"ID": "router2", "Port": "bad"}}
+	if changes, err := ApplyCfgSync(plan, "admin"); err == nil || changes != nil {
+		t.Fatalf("invalid sync applied %+v, error %v", changes, err)
+	}
+	if _, err := db.GetInfluxCfgByID("influx1"); err == nil {
+		t.Error("output created on a failed sync")
+	}
+	if a, err := db.GetAuditCfgArray(&config.AuditQuery{User: "admin"}); err != nil || len(a) != 0 {
+		t.Errorf("audit records not rolled back: %d, error %v", len(a), err)
+	}
+	if plan, err = PlanCfgSync(dir, false); err != nil || len(plan.Changes) != 6 {
+		t.Fatalf("unexpected plan after a failed sync %+v, error %v", plan, err)
+	}
+	if _, err := ApplyCfgSync(plan, "admin"); err != nil {
+		t.Fatalf("ApplyCfgSync error: %s", err)
+	}
+	dev, err := db.GetSnmpDeviceCfgByID("router2")
+	if err != nil {
+		t.Fatalf("device not created: %s", err)
+	}
+	// binding defaults are set as the web API does
+	if dev.Freq != 60 || dev.MaxRepetitions != 50 || dev.DeviceTagName != "hostname" || len(dev.MeasurementGroups) != 1 {
+		t.Errorf("unexpected device created %+v", dev)
+	}
+	if plan, err = PlanCfgSync(dir, true); err != nil || len(plan.Changes) != 0 {
+		t.Fatalf("unexpected changes after apply %+v, error %v", plan, err)
+	}
+
+	// router2 removed and router1 updated, only pruned when asked
+	writeSyncFile(t, dir, "devices.yaml", testSyncDevices+"    freq: 30\n")
+	if plan, err = PlanCfgSync(dir, false); err != nil || len(plan.Changes) != 1 || plan.Changes[0].Status != SnapshotObjChanged {
+		t.Fatalf("unexpected plan without prune %+v, error %v", plan, err)
+	}
+	if plan, err = PlanCfgSync(dir, true); err != nil || len(plan.Changes) != 2 || plan.Changes[1].Status != SnapshotObjRemoved {
+		t.Fatalf("unexpected plan with prune %+v, error %v", plan, err)
+	}
+	if _, err := ApplyCfgSync(plan, "admin"); err != nil {
+		t.Fatalf("ApplyCfgSync error: %s", err)
+	}
+	if _, err := db.GetSnmpDeviceCfgByID("router2"); err == nil {
+		t.Error("device not pruned")
+	}
+	// pruning only applies to object types in the directory
+	if _, err := db.GetInfluxCfgByID("influx1"); err != nil {
+		t.Errorf("output removed: %s", err)
+	}
+
+	// invalid objects and references are reported before any change
+	writeSyncFile(t, dir, "bad.yml", "measgroupcfg:\n  - id: bad\n    measurements: [unknown]\nsnmpmetriccfg:\n  - id: nofield\n    baseoid: .1.3\n")
+	if _, err := PlanCfgSync(dir, false); err == nil {
+		t.Error("invalid configuration directory accepted")
+	} else if serr, ok := err.(*CfgSyncError); !ok || len(serr.Errors) != 1 {
+		t.Errorf("unexpected error %s", err)
+	}
+	os.Remove(filepath.Join(dir, "bad.yml"))
+
+	// alert rules and their receivers
+	writeSyncFile(t, dir, "alerts.yaml", testSyncAlerts)
+	if plan, err = PlanCfgSync(dir, false); err != nil || len(plan.Changes) != 2 {
+		t.Fatalf("unexpected alerts plan %+v, error %v", plan, err)
+	}
+	if _, err := ApplyCfgSync(plan, "admin"); err != nil {
+		t.Fatalf("ApplyCfgSync error: %s", err)
+	}
+	if r, err := db.GetAlertRuleCfgByID("uptime"); err != nil || len(r.Receivers) != 1 || r.Receivers[0] != "ops" {
+		t.Errorf("unexpected alert rule %+v, error %v", r, err)
+	}
+	writeSyncFile(t, dir, "alerts.yaml", strings.Replace(testSyncAlerts, "[ops]", "[unknown]", 1))
+	if _, err := PlanCfgSync(dir, false); err == nil {
+		t.Error("alert rule with unknown receiver accepted")
+	} else if serr, ok := err.(*CfgSyncError); !ok || len(serr.Errors) != 1 || !strings.Contains(serr.Errors[0], "alertreceivercfg unknown") {
+		t.Errorf("unexpected error %s", err)
+	}
+}
@@ -0,0 +1,103 @@
+package impexp
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+/***************************
+	Foreign SNMP configuration converters
+	translate other collectors SNMP definitions into SnmpMetricCfg, MeasurementCfg
+	and MGroupsCfg objects to be imported with ImportCheck/Import
+	-ConvertTelegrafSnmp  (telegraf.go)
+	-ConvertSnmpExporter  (snmpexporter.go)
+***********************************/
+
+var (
+	numericOIDRe = regexp.MustCompile(`^\.?[0-9]+(\.[0-9]+)*$`)
+	badIDCharsRe = regexp.MustCompile(`[^a-zA-Z0-9_\-.]+`)
+)
+
+// normalizeOID returns the OID with leading dot, or an error if it is not numeric
+// (MIB names can not be resolved without the MIB files)
+func normalizeOID(oid string) (string, error) {
+	oid = strings.TrimSpace(oid)
+	if !numericOIDRe.MatchString(oid) {
+		return "", fmt.Errorf("OID %q is not numeric (MIB names are not supported)", oid)
+	}
+	if !strings.HasPrefix(oid, ".") {
+		oid = "." + oid
+	}
+	return oid, nil
+}
+
+// convertID builds a valid object ID from parts
+func convertID(parts ...string) string {
+	return badIDCharsRe.ReplaceAllString(strings.Join(parts, "_"), "_")
+}
+
+// converter collects the converted objects in import order (metrics, measurements, groups)
+type converter struct {
+	metrics      []*ExportObject
+	measurements []*ExportObject
+	groups       []*ExportObject
+	ids          map[string]interface{}
+	warnings     []string
+}
+
+func newConverter() *converter {
+	return &converter{ids: make(map[string]interface{})}
+}
+
+func (c *converter) warnf(format string, args ...interface{}) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+// add appends an object, same type and ID objects are only added once (and a warning is
+// added if they are different). Returns false if the object has not been added
+func (c *converter) add(objtype string, id string, obj interface{}) bool {
+	key := objtype + "/" + id
+	if prev, ok := c.ids[key]; ok {
+		if !reflect.DeepEqual(prev, obj) {
+			c.warnf("%s %s defined more than once with different values, only the first one will be imported", objtype, id)
+		}
+		return false
+	}
+	c.ids[key] = obj
+	o := &ExportObject{ObjectTypeID: objtype, ObjectID: id, ObjectCfg: obj}
+	switch objtype {
+	case "snmpmetriccfg":
+		c.metrics = append(c.metrics, o)
+	case "measurementcfg":
+		c.measurements = append(c.measurements, o)
+	case "measgroupcfg":
+		c.groups = append(c.groups, o)
+	}
+	return true
+}
+
+// addMeasurement adds the measurement only if it has fields, returns false if skipped
+func (c *converter) addMeasurement(m *config.MeasurementCfg) bool {
+	if len(m.Fields) == 0 {
+		c.warnf("measurement %s has no valid fields, skipped", m.ID)
+		return false
+	}
+	c.add("measurementcfg", m.ID, m)
+	return true
+}
+
+// export returns the ExportData to import and the conversion warnings
+func (c *converter) export(info *ExportInfo) (*ExportData, []string, error) {
+	e := NewExport(info)
+	e.Objects = append(e.Objects, c.metrics...)
+	e.Objects = append(e.Objects, c.measurements...)
+	e.Objects = append(e.Objects, c.groups...)
+	if len(e.Objects) == 0 {
+		return nil, c.warnings, fmt.Errorf("No objects found to import")
+	}
+	return e, c.warnings, nil
+}
@@ -0,0 +1,183 @@
+package impexp
+
+import (
+	"testing"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+const testTelegrafConf = `
+[agent]
+  interval = "60s"
+
+[[inputs.snmp]]
+  agents = ["udp://127.0.0.1:161"]
+  version = 2
+  community = "public"
+
+  [[inputs.snmp.field]]
+    name = "hostname"
+    oid = ".1.3.6.1.2.1.1.5.0"
+    is_tag = true
+  [[inputs.snmp.field]]
+    name = "uptime"
+    oid = "1.3.6.1.2.1.1.3.0"
+    conversion = "float(2)"
+  [[inputs.snmp.field]]
+    name = "location"
+    oid = "SNMPv2-MIB::sysLocation.0"
+
+  [[inputs.snmp.table]]
+    name = "interface"
+    [[inputs.snmp.table.field]]
+      name = "ifDescr"
+      oid = ".1.3.6.1.2.1.2.2.1.2"
+      is_tag = true
+    [[inputs.snmp.table.field]]
+      name = "ifInOctets"
+      oid = ".1.3.6.1.2.1.2.2.1.10"
+    [[inputs.snmp.table.field]]
+      name = "ifPhysAddress"
+      oid = ".1.3.6.1.2.1.2.2.1.6"
+      conversion = "hwaddr"
+
+  [[inputs.snmp.table]]
+    name = "ifXTable"
+    oid = "IF-MIB::ifXTable"
+
+[[outputs.influxdb]]
+  urls = ["http://127.0.0.1:8086"]
+`
+
+const testSnmpExporterYml = `
+auths:
+  public_v2:
+    community: public
+    version: 2
+modules:
+  if_mib:
+    walk:
+    - 1.3.6.1.2.1.1.3
+    - 1.3.6.1.2.1.2
+    metrics:
+    - name: sysUpTime
+      oid: 1.3.6.1.2.1.1.3
+      type: gauge
+    - name: ifInOctets
+      oid: 1.3.6.1.2.1.2.2.1.10
+      type: counter
+      indexes:
+      - labelname: ifIndex
+        type: gauge
+      lookups:
+      - labels: [ifIndex]
+        labelname: ifDescr
+        oid: 1.3.6.1.2.1.2.2.1.2
+        type: DisplayString
+      - labels: [ifIndex]
+        labelname: ifAlias
+        oid: 1.3.6.1.2.1.31.1.1.1.18
+        type: DisplayString
+    - name: ifOperStatus
+      oid: 1.3.6.1.2.1.2.2.1.8
+      type: gauge
+      indexes:
+      - labelname: ifIndex
+        type: gauge
+      enum_values:
+        1: up
+        2: down
+  generator_only:
+    walk: [ifTable, 1.3.6.1.2.1.25.3.3.1.2]
+`
+
+// checkConvertedImport imports the converted objects into an empty database
+func checkConvertedImport(t *testing.T, e *ExportData) {
+	if _, err := e.ImportCheck(); err != nil {
+		t.Fatalf("ImportCheck error: %s", err)
+	}
+	if err := e.Import(false, false, "test"); err != nil {
+		t.Fatalf("Import error: %s", err)
+	}
+	audit, err := dbc.GetAuditCfgArray(&config.AuditQuery{User: "test", Action: config.AuditActionAdd})
+	if err != nil || len(audit) != len(e.Objects) {
+		t.Errorf("got %d import audit entries (error %v), want %d", len(audit), err, len(e.Objects))
+	}
+	// a second import should report all objects as duplicated
+	if dup, err := e.ImportCheck(); err == nil || len(dup.Objects) != len(e.Objects) {
+		t.Errorf("unexpected duplicates check %+v, error %v", dup, err)
+	}
+}
+
+func TestConvertTelegrafSnmp(t *testing.T) {
+	db, release := newTestDB(t)
+	defer release()
+
+	e, warnings, err := ConvertTelegrafSnmp([]byte(testTelegrafConf))
+	if err != nil {
+		t.Fatalf("ConvertTelegrafSnmp error: %s", err)
+	}
+	// sysLocation MIB name and empty ifXTable table
+	if len(warnings) != 2 {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+	// 2 scalar + 2 table metrics, 2 measurements and 1 group
+	if len(e.Objects) != 7 {
+		t.Fatalf("unexpected converted objects %d", len(e.Objects))
+	}
+	checkConvertedImport(t, e)
+
+	m, err := db.GetMeasurementCfgByID("interface")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.GetMode != "indexed" || m.IndexOID != ".1.3.6.1.2.1.2.2.1.2" || m.IndexTag != "ifDescr" || len(m.Fields) != 2 {
+		t.Errorf("unexpected table measurement %+v", m)
+	}
+	up, err := db.GetSnmpMetricCfgByID("snmp_uptime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up.BaseOID != ".1.3.6.1.2.1.1.3.0" || up.Scale != 0.01 || up.Conversion != config.FLOAT {
+		t.Errorf("unexpected scalar metric %+v", up)
+	}
+	if g, err := db.GetMGroupsCfgByID("telegraf_snmp_1"); err != nil || len(g.Measurements) != 2 {
+		t.Errorf("unexpected measurement group %+v, error %v", g, err)
+	}
+}
+
+func TestConvertSnmpExporter(t *testing.T) {
+	db, release := newTestDB(t)
+	defer release()
+
+	e, warnings, err := ConvertSnmpExporter([]byte(testSnmpExporterYml))
+	if err != nil {
+		t.Fatalf("ConvertSnmpExporter error: %s", err)
+	}
+	// ifTable MIB name in generator module
+	if len(warnings) != 1 {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+	checkConvertedImport(t, e)
+
+	m, err := db.GetMeasurementCfgByID("if_mib_ifIndex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ifInOctets, ifAlias (tag) and ifOperStatus with ifDescr as index tag
+	if m.IndexOID != ".1.3.6.1.2.1.2.2.1.2" || m.IndexTag != "ifDescr" || m.IndexAsValue || len(m.Fields) != 3 {
+		t.Errorf("unexpected indexed measurement %+v", m)
+	}
+	if up, err := db.GetSnmpMetricCfgByID("if_mib_sysUpTime"); err != nil || up.BaseOID != ".1.3.6.1.2.1.1.3.0" {
+		t.Errorf("unexpected scalar metric %+v, error %v", up, err)
+	}
+	if st, err := db.GetSnmpMetricCfgByID("if_mib_ifOperStatus"); err != nil || st.DataSrcType != "INTEGER" {
+		t.Errorf("unexpected enum metric %+v, error %v", st, err)
+	}
+	if g, err := db.GetMGroupsCfgByID("snmp_exporter_if_mib"); err != nil || len(g.Measurements) != 2 {
+		t.Errorf("unexpected measurement group %+v, error %v", g, err)
+	}
+	if w, err := db.GetMeasurementCfgByID("generator_only_walk"); err != nil || !w.IndexAsValue || len(w.Fields) != 1 {
+		t.Errorf("unexpected generator measurement %+v, error %v", w, err)
+	}
+}
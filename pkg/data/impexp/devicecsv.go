@@ -0,0 +1,255 @@
+package impexp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+/***************************
+	Bulk Device Onboarding
+	devices are created from a CSV file with a header line and columns:
+	host,id,site,tags,credential,template
+
+	-host: device host or host:port (required)
+	-id: device ID (default host)
+	-site: added as "site=<site>" extra tag
+	-tags: extra tags as TAG=VALUE separated by ";"
+	-credential: ID of a device to copy the SNMP version and credentials from
+	-template: ID of the device to copy all the other fields from (measurement
+	 groups and filters included), default the one given on upload
+***********************************/
+
+var deviceCSVColumns = []string{"host", "id", "site", "tags", "credential", "template"}
+
+// DeviceCSVRow a device to onboard from a CSV file line
+type DeviceCSVRow struct {
+	Line       int // record number (header is 1)
+	ID         string
+	Host       string
+	Site       string
+	Tags       []string
+	Credential string
+	Template   string
+	Errors     []string              `json:",omitempty"`
+	Device     *config.SnmpDeviceCfg `json:",omitempty"`
+}
+
+// DeviceOnboardReport the result of the check (dry run) or the onboarding of the CSV devices
+type DeviceOnboardReport struct {
+	DryRun  bool
+	Rows    []*DeviceCSVRow
+	Valid   int
+	Invalid int
+	Added   int
+}
+
+// ReadDeviceCSV reads the devices to onboard from a CSV file
+func ReadDeviceCSV(r io.Reader) ([]*DeviceCSVRow, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Error on read CSV header: %s", err)
+	}
+	cols := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		known := false
+		for _, c := range deviceCSVColumns {
+			known = known || c == h
+		}
+		if !known {
+			return nil, fmt.Errorf("Unknown CSV column %q, valid ones are: %s", h, strings.Join(deviceCSVColumns, ","))
+		}
+		cols[h] = i
+	}
+	if _, ok := cols["host"]; !ok {
+		return nil, fmt.Errorf("CSV column \"host\" is required")
+	}
+	var rows []*DeviceCSVRow
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error on read CSV: %s", err)
+		}
+		get := func(c string) string {
+			if i, ok := cols[c]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		row := &DeviceCSVRow{Line: line, Host: get("host"), ID: get("id"), Site: get("site"), Credential: get("credential"), Template: get("template")}
+		for _, t := range strings.Split(get("tags"), ";") {
+			if t = strings.TrimSpace(t); len(t) > 0 {
+				row.Tags = append(row.Tags, t)
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("No devices found in the CSV file")
+	}
+	return rows, nil
+}
+
+// mergeTags returns the template TAG=VALUE tags with the row ones (same TAG values are replaced)
+func mergeTags(base []string, tags []string) []string {
+	var merged []string
+	index := make(map[string]int)
+	for _, t := range append(append([]string{}, base...), tags...) {
+		key := strings.SplitN(t, "=", 2)[0]
+		if i, ok := index[key]; ok {
+			merged[i] = t
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, t)
+	}
+	return merged
+}
+
+// buildDevice sets the row device from its template and credential devices
+func (row *DeviceCSVRow) buildDevice(db *config.DatabaseCfg, defTemplate string) {
+	errorf := func(format string, args ...interface{}) {
+		row.Errors = append(row.Errors, fmt.Sprintf(format, args...))
+	}
+	if len(row.Template) == 0 {
+		row.Template = defTemplate
+	}
+	host, port := row.Host, 0
+	if h, p, err := net.SplitHostPort(row.Host); err == nil {
+		host = h
+		if port, err = strconv.Atoi(p); err != nil {
+			errorf("invalid port in host %s", row.Host)
+		}
+	}
+	if len(row.ID) == 0 {
+		row.ID = host
+	}
+	if len(host) == 0 {
+		errorf("host is required")
+	}
+	tags := row.Tags
+	if len(row.Site) > 0 {
+		tags = append([]string{"site=" + row.Site}, tags...)
+	}
+	for _, t := range tags {
+		if kv := strings.SplitN(t, "=", 2); len(kv) != 2 || len(kv[0]) == 0 || strings.Contains(kv[1], "=") {
+			errorf("invalid tag %q, should be TAG=VALUE", t)
+		}
+	}
+	var cred *config.SnmpDeviceCfg
+	if len(row.Credential) > 0 {
+		c, err := db.GetSnmpDeviceCfgByID(row.Credential)
+		if err != nil {
+			errorf("credential device %s not found", row.Credential)
+		}
+		cred = &c
+	}
+	if len(row.Template) == 0 {
+		errorf("template is required")
+		return
+	}
+	tmpl, err := db.GetSnmpDeviceCfgByID(row.Template)
+	if err != nil {
+		errorf("template device %s not found", row.Template)
+		return
+	}
+	dev := tmpl
+	dev.ID = row.ID
+	dev.Host = host
+	if port > 0 {
+		dev.Port = port
+	}
+	// template devices are usually inactive
+	dev.Active = true
+	dev.ExtraTags = mergeTags(tmpl.ExtraTags, tags)
+	if cred != nil {
+		dev.SnmpVersion = cred.SnmpVersion
+		dev.Community = cred.Community
+		dev.V3SecLevel = cred.V3SecLevel
+		dev.V3AuthUser = cred.V3AuthUser
+		dev.V3AuthPass = cred.V3AuthPass
+		dev.V3AuthProt = cred.V3AuthProt
+		dev.V3PrivPass = cred.V3PrivPass
+		dev.V3PrivProt = cred.V3PrivProt
+		dev.V3ContextEngineID = cred.V3ContextEngineID
+		dev.V3ContextName = cred.V3ContextName
+	}
+	applyBindingDefaults(&dev)
+	if ers := binding.RawValidate(dev); ers.Len() > 0 {
+		e, _ := json.Marshal(ers)
+		errorf("invalid device: %s", e)
+	}
+	row.Device = &dev
+}
+
+// checkDeviceRows builds and validates the devices of all rows
+func checkDeviceRows(db *config.DatabaseCfg, rows []*DeviceCSVRow, template string) *DeviceOnboardReport {
+	report := &DeviceOnboardReport{Rows: rows}
+	ids := make(map[string]int)
+	for _, row := range rows {
+		row.buildDevice(db, template)
+		if line, ok := ids[row.ID]; ok {
+			row.Errors = append(row.Errors, fmt.Sprintf("duplicated device ID %s (line %d)", row.ID, line))
+		} else if _, err := db.GetSnmpDeviceCfgByID(row.ID); err == nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("device %s already exists", row.ID))
+		}
+		ids[row.ID] = row.Line
+		if len(row.Errors) > 0 {
+			report.Invalid++
+			continue
+		}
+		report.Valid++
+	}
+	return report
+}
+
+// OnboardDevices creates the devices of the CSV data from their templates, template is the
+// default one for rows without it. All devices are added in a single transaction and only
+// if all rows are valid. On dry run the rows are only checked.
+// Returns the report of each row (devices added are in the row Device field)
+func OnboardDevices(data []byte, template string, dryRun bool, user string) (*DeviceOnboardReport, error) {
+	rows, err := ReadDeviceCSV(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	report := checkDeviceRows(dbc, rows, template)
+	report.DryRun = dryRun
+	if dryRun {
+		return report, nil
+	}
+	if report.Invalid > 0 {
+		return report, fmt.Errorf("There is %d invalid rows in the CSV file, no device has been added", report.Invalid)
+	}
+	err = dbc.Transaction(func(tx *config.DatabaseCfg) error {
+		for _, row := range rows {
+			if _, err := tx.AddSnmpDeviceCfg(*row.Device); err != nil {
+				return fmt.Errorf("Error on add device %s (line %d): %s", row.ID, row.Line, err)
+			}
+			if _, err := tx.AddAuditCfg(user, "snmpdevicecfg", config.AuditActionAdd, row.ID, nil); err != nil {
+				return fmt.Errorf("Error on record audit log for device %s: %s", row.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Added = len(rows)
+	log.Infof("Onboarded %d devices from CSV by user %s", report.Added, user)
+	return report, nil
+}
@@ -0,0 +1,74 @@
+package impexp
+
+import (
+	"testing"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+const testDeviceCSV = `host,id,site,tags,credential,template
+10.0.0.1,,mad1,role=access;vendor=acme,v3cred,
+10.0.0.2:1161,sw2,bcn1,role=core,,
+10.0.0.3,sw2,,,,
+10.0.0.4,,,badtag,unknown,notemplate
+`
+
+func TestOnboardDevices(t *testing.T) {
+	db, release := newTestDB(t)
+	defer release()
+
+	if _, err := db.AddMGroupsCfg(config.MGroupsCfg{ID: "base"}); err != nil {
+		t.Fatal(err)
+	}
+	tmpl := config.SnmpDeviceCfg{ID: "tmpl_switch", Host: "template", Port: 161, SnmpVersion: "2c", Community: "public", Freq: 60, MaxRepetitions: 50,
+		UpdateFltFreq: 60, ExtraTags: []string{"role=switch", "env=prod"}, MeasurementGroups: []string{"base"}}
+	cred := config.SnmpDeviceCfg{ID: "v3cred", Host: "none", Port: 161, SnmpVersion: "3", V3SecLevel: "AuthPriv", V3AuthUser: "snmpuser", V3AuthPass: "secret", V3AuthProt: "SHA",
+		V3PrivPass: "secret", V3PrivProt: "AES", Freq: 60, MaxRepetitions: 50, UpdateFltFreq: 60}
+	for _, d := range []config.SnmpDeviceCfg{tmpl, cred} {
+		if _, err := db.AddSnmpDeviceCfg(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := OnboardDevices([]byte(testDeviceCSV), "tmpl_switch", true, "admin")
+	if err != nil {
+		t.Fatalf("OnboardDevices dry run error: %s", err)
+	}
+	if report.Valid != 2 || report.Invalid != 2 {
+		t.Fatalf("unexpected dry run report %+v", report)
+	}
+	// duplicated ID
+	if r := report.Rows[2]; len(r.Errors) != 1 {
+		t.Errorf("unexpected row errors %+v", r)
+	}
+	// tag, credential and template errors
+	if r := report.Rows[3]; len(r.Errors) != 3 {
+		t.Errorf("unexpected row errors %+v", r)
+	}
+	// invalid rows abort the onboarding
+	if _, err := OnboardDevices([]byte(testDeviceCSV), "tmpl_switch", false, "admin"); err == nil {
+		t.Error("invalid CSV file accepted")
+	}
+	if ids, _ := db.GetCfgObjectIDs("snmpdevicecfg"); len(ids) != 2 {
+		t.Fatalf("devices added from an invalid CSV file: %v", ids)
+	}
+
+	report, err = OnboardDevices([]byte("host,id,site,tags,credential,template\n10.0.0.1,,mad1,role=access;vendor=acme,v3cred,\n10.0.0.2:1161,sw2,bcn1,role=core,,\n"), "tmpl_switch", false, "admin")
+	if err != nil || report.Added != 2 {
+		t.Fatalf("unexpected onboarding report %+v, error %v", report, err)
+	}
+	d1, err := db.GetSnmpDeviceCfgByID("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d1.Active || d1.SnmpVersion != "3" || d1.V3AuthUser != "snmpuser" || len(d1.MeasurementGroups) != 1 || len(d1.ExtraTags) != 4 || d1.ExtraTags[0] != "role=access" {
+		t.Errorf("unexpected device created %+v", d1)
+	}
+	d2, err := db.GetSnmpDeviceCfgByID("sw2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d2.Host != "10.0.0.2" || d2.Port != 1161 || d2.Community != "public" || d2.ExtraTags[2] != "site=bcn1" {
+		t.Errorf("unexpected device created %+v", d2)
+	}
+}
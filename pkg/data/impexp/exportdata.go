@@ -209,8 +209,8 @@ func (e *ExportData) Export(ObjType string, id string, recursive bool, level int
 		//--------------------
 		// we need to initialice the measurment first to get Variables.
 
-		cfg, _ := dbc.GetSnmpMetricCfgMap("")
-		gv, _ := dbc.GetVarCatalogCfgMap("")
+		cfg, _ := dbc.GetSnmpMetricCfgMap(nil)
+		gv, _ := dbc.GetVarCatalogCfgMap(nil)
 
 		err = v.Init(&cfg, config.CatalogVar2Map(gv))
 		if err != nil {
@@ -263,6 +263,30 @@ func (e *ExportData) Export(ObjType string, id string, recursive bool, level int
 			return err
 		}
 		e.PrependObject(&ExportObject{ObjectTypeID: "varcatalogcfg", ObjectID: id, ObjectCfg: v})
+	case "maintwindowcfg":
+		v, err := dbc.GetMaintWindowCfgByID(id)
+		if err != nil {
+			return err
+		}
+		e.PrependObject(&ExportObject{ObjectTypeID: "maintwindowcfg", ObjectID: id, ObjectCfg: v})
+	case "alertrulecfg":
+		v, err := dbc.GetAlertRuleCfgByID(id)
+		if err != nil {
+			return err
+		}
+		e.PrependObject(&ExportObject{ObjectTypeID: "alertrulecfg", ObjectID: id, ObjectCfg: v})
+		if !recursive {
+			break
+		}
+		for _, val := range v.Receivers {
+			e.Export("alertreceivercfg", val, recursive, level+1)
+		}
+	case "alertreceivercfg":
+		v, err := dbc.GetAlertReceiverCfgByID(id)
+		if err != nil {
+			return err
+		}
+		e.PrependObject(&ExportObject{ObjectTypeID: "alertreceivercfg", ObjectID: id, ObjectCfg: v})
 	default:
 		return fmt.Errorf("Unknown type object type %s ", ObjType)
 	}
@@ -164,6 +164,51 @@ func (e *ExportData) ImportCheck() (*ExportData, error) {
 				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
 				duplicated = append(duplicated, o)
 			}
+		case "maintwindowcfg":
+			data := config.MaintWindowCfg{}
+			json.Unmarshal(raw, &data)
+			ers := binding.RawValidate(data)
+			if ers.Len() > 0 {
+				e, _ := json.Marshal(ers)
+				o.Error = string(e)
+				duplicated = append(duplicated, o)
+				break
+			}
+			_, err := dbc.GetMaintWindowCfgByID(o.ObjectID)
+			if err == nil {
+				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
+				duplicated = append(duplicated, o)
+			}
+		case "alertrulecfg":
+			data := config.AlertRuleCfg{}
+			json.Unmarshal(raw, &data)
+			ers := binding.RawValidate(data)
+			if ers.Len() > 0 {
+				e, _ := json.Marshal(ers)
+				o.Error = string(e)
+				duplicated = append(duplicated, o)
+				break
+			}
+			_, err := dbc.GetAlertRuleCfgByID(o.ObjectID)
+			if err == nil {
+				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
+				duplicated = append(duplicated, o)
+			}
+		case "alertreceivercfg":
+			data := config.AlertReceiverCfg{}
+			json.Unmarshal(raw, &data)
+			ers := binding.RawValidate(data)
+			if ers.Len() > 0 {
+				e, _ := json.Marshal(ers)
+				o.Error = string(e)
+				duplicated = append(duplicated, o)
+				break
+			}
+			_, err := dbc.GetAlertReceiverCfgByID(o.ObjectID)
+			if err == nil {
+				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
+				duplicated = append(duplicated, o)
+			}
 		default:
 			return &ExportData{Info: e.Info, Objects: duplicated}, fmt.Errorf("Unknown type object type %s ", o.ObjectTypeID)
 		}
@@ -176,8 +221,9 @@ func (e *ExportData) ImportCheck() (*ExportData, error) {
 	return &ExportData{Info: e.Info, Objects: duplicated}, nil
 }
 
-// Import import into the config database data contained in the ExportData struct
-func (e *ExportData) Import(overwrite bool, autorename bool) error {
+// Import import into the config database data contained in the ExportData struct, each
+// added or overwritten object is recorded in the audit log as changed by user
+func (e *ExportData) Import(overwrite bool, autorename bool, user string) error {
 
 	var suffix string
 	if autorename == true {
@@ -198,6 +244,8 @@ func (e *ExportData) Import(overwrite bool, autorename bool) error {
 			o.Error = fmt.Sprintf("error on reformating object %s: error: %s ", o.ObjectID, err)
 			return errors.New(o.Error)
 		}
+		before, err := dbc.GetCfgObjectByID(o.ObjectTypeID, o.ObjectID)
+		exists := err == nil
 		switch o.ObjectTypeID {
 		case "snmpdevicecfg":
 			log.Debugf("Importing snmpdevicecfg : %+v", o.ObjectCfg)
@@ -397,10 +445,85 @@ func (e *ExportData) Import(overwrite bool, autorename bool) error {
 			if err != nil {
 				return err
 			}
+		case "maintwindowcfg":
+			log.Debugf("Importing maintwindowcfg : %+v", o.ObjectCfg)
+			data := config.MaintWindowCfg{}
+			json.Unmarshal(raw, &data)
+			var err error
+			_, err = dbc.GetMaintWindowCfgByID(o.ObjectID)
+			if err == nil { //value exist already in the database
+				if overwrite == true {
+					_, err2 := dbc.UpdateMaintWindowCfg(o.ObjectID, data)
+					if err2 != nil {
+						return fmt.Errorf("Error on overwrite object [%s] %s : %s", o.ObjectTypeID, o.ObjectID, err2)
+					}
+					break
+				}
+			}
+			if autorename == true {
+				data.ID = data.ID + suffix
+			}
+			_, err = dbc.AddMaintWindowCfg(data)
+			if err != nil {
+				return err
+			}
+		case "alertrulecfg":
+			log.Debugf("Importing alertrulecfg : %+v", o.ObjectCfg)
+			data := config.AlertRuleCfg{}
+			json.Unmarshal(raw, &data)
+			var err error
+			_, err = dbc.GetAlertRuleCfgByID(o.ObjectID)
+			if err == nil { //value exist already in the database
+				if overwrite == true {
+					_, err2 := dbc.UpdateAlertRuleCfg(o.ObjectID, data)
+					if err2 != nil {
+						return fmt.Errorf("Error on overwrite object [%s] %s : %s", o.ObjectTypeID, o.ObjectID, err2)
+					}
+					break
+				}
+			}
+			if autorename == true {
+				data.ID = data.ID + suffix
+			}
+			_, err = dbc.AddAlertRuleCfg(data)
+			if err != nil {
+				return err
+			}
+		case "alertreceivercfg":
+			log.Debugf("Importing alertreceivercfg : %+v", o.ObjectCfg)
+			data := config.AlertReceiverCfg{}
+			json.Unmarshal(raw, &data)
+			var err error
+			_, err = dbc.GetAlertReceiverCfgByID(o.ObjectID)
+			if err == nil { //value exist already in the database
+				if overwrite == true {
+					_, err2 := dbc.UpdateAlertReceiverCfg(o.ObjectID, data)
+					if err2 != nil {
+						return fmt.Errorf("Error on overwrite object [%s] %s : %s", o.ObjectTypeID, o.ObjectID, err2)
+					}
+					break
+				}
+			}
+			if autorename == true {
+				data.ID = data.ID + suffix
+			}
+			_, err = dbc.AddAlertReceiverCfg(data)
+			if err != nil {
+				return err
+			}
 
 		default:
 			return fmt.Errorf("Unknown type object type %s ", o.ObjectTypeID)
 		}
+		action, id := config.AuditActionAdd, o.ObjectID+suffix
+		if exists && overwrite {
+			action, id = config.AuditActionUpdate, o.ObjectID
+		} else {
+			before = nil
+		}
+		if _, err := dbc.AddAuditCfg(user, o.ObjectTypeID, action, id, before); err != nil {
+			log.Warningf("Error on record audit log for imported object [%s] %s: %s", o.ObjectTypeID, id, err)
+		}
 	}
 	return nil
 }
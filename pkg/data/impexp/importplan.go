@@ -0,0 +1,462 @@
+package impexp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Knetic/govaluate"
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+/***************************
+	Import Preview and Apply
+	-PreviewImport: object status against the database, field diffs and dependency tree
+	-ApplyImport: import with a conflict resolution strategy for each object,
+	 all changes are done in a single transaction
+***********************************/
+
+// Import preview object status
+const (
+	ImportObjNew      = "new"      // not in the database
+	ImportObjEqual    = "equal"    // in the database with the same values
+	ImportObjConflict = "conflict" // in the database with different values
+)
+
+// Import strategies
+const (
+	// ImportAdd adds a new object (only for new objects)
+	ImportAdd = "add"
+	// ImportSkip the object is not imported, neither the imported objects depending on it
+	ImportSkip = "skip"
+	// ImportOverwrite the database object is updated with the imported one
+	ImportOverwrite = "overwrite"
+	// ImportRename the object is added with a new ID, the imported objects depending on it are changed to use it
+	ImportRename = "rename"
+	// ImportKeepExisting the database object is not changed and the imported objects depending on it will use it
+	ImportKeepExisting = "keep-existing"
+)
+
+// ImportStrategy the strategy chosen to import an object
+type ImportStrategy struct {
+	ObjectTypeID string
+	ObjectID     string
+	Strategy     string
+	NewID        string // only for rename, default the object AlternateID option or the ID with a timestamp suffix
+}
+
+// ImportPreviewObject the import status of an object
+type ImportPreviewObject struct {
+	ObjectTypeID string
+	ObjectID     string
+	Status       string
+	Strategy     string                  // default strategy on preview (empty for conflicts) or the applied one
+	NewID        string                  `json:",omitempty"`
+	Reason       string                  `json:",omitempty"` // why the strategy has been changed on apply
+	Diff         []config.AuditFieldDiff `json:",omitempty"` // changes to the database object (conflicts only)
+	Deps         []string                // referenced objects as "objtype/id"
+	Missing      []string                `json:",omitempty"` // referenced objects neither imported nor in the database
+	Error        string                  `json:",omitempty"`
+}
+
+// ImportTreeNode an imported object with the imported objects it depends on
+type ImportTreeNode struct {
+	ObjectTypeID string
+	ObjectID     string
+	Status       string
+	Deps         []*ImportTreeNode `json:",omitempty"`
+}
+
+// ImportPreview the result of checking an import against the database
+type ImportPreview struct {
+	Info      *ExportInfo
+	Objects   []*ImportPreviewObject
+	Tree      []*ImportTreeNode // objects not referenced by other imported objects as roots
+	Conflicts int
+	Errors    int
+}
+
+// cfgRef a reference from a configuration object to other one, set changes it
+type cfgRef struct {
+	objtype  string
+	id       string
+	optional bool // selectors could reference not existing objects
+	set      func(id string)
+}
+
+func (r *cfgRef) key() string {
+	return r.objtype + "/" + r.id
+}
+
+// replaceCondVar replaces a variable name in an oid condition expression
+func replaceCondVar(expr string, old string, new string) string {
+	re := regexp.MustCompile(`(^|[^\w.\-])` + regexp.QuoteMeta(old) + `([^\w.\-]|$)`)
+	// matches could share the separator, repeat until all are replaced
+	for s := re.ReplaceAllString(expr, "${1}"+new+"${2}"); s != expr; s = re.ReplaceAllString(expr, "${1}"+new+"${2}") {
+		expr = s
+	}
+	return expr
+}
+
+// cfgObjectRefs returns the references from a decoded object to other configuration objects
+func cfgObjectRefs(objtype string, obj interface{}) []*cfgRef {
+	var refs []*cfgRef
+	add := func(t string, p *string) {
+		if len(*p) > 0 {
+			refs = append(refs, &cfgRef{objtype: t, id: *p, set: func(id string) { *p = id }})
+		}
+	}
+	// comma separated selectors, "*" suffixed prefixes are not references
+	addSelectors := func(t string, p *string) {
+		list := strings.Split(*p, ",")
+		for i := range list {
+			id := strings.TrimSpace(list[i])
+			if len(id) == 0 || strings.HasSuffix(id, "*") {
+				continue
+			}
+			i := i
+			refs = append(refs, &cfgRef{objtype: t, id: id, optional: true, set: func(id string) {
+				list[i] = id
+				*p = strings.Join(list, ",")
+			}})
+		}
+	}
+	switch v := obj.(type) {
+	case *config.SnmpDeviceCfg:
+		add("influxcfg", &v.OutDB)
+		for i := range v.MeasurementGroups {
+			add("measgroupcfg", &v.MeasurementGroups[i])
+		}
+		for i := range v.MeasFilters {
+			add("measfiltercfg", &v.MeasFilters[i])
+		}
+	case *config.MeasFilterCfg:
+		add("measurementcfg", &v.IDMeasurementCfg)
+		switch v.FType {
+		case "OIDCondition":
+			add("oidconditioncfg", &v.FilterName)
+		case "CustomFilter":
+			add("customfiltercfg", &v.FilterName)
+		}
+	case *config.OidConditionCfg:
+		if !v.IsMultiple {
+			break
+		}
+		expression, err := govaluate.NewEvaluableExpression(v.OIDCond)
+		if err != nil {
+			break
+		}
+		for _, par := range expression.Vars() {
+			old := par
+			refs = append(refs, &cfgRef{objtype: "oidconditioncfg", id: par, set: func(id string) { v.OIDCond = replaceCondVar(v.OIDCond, old, id) }})
+		}
+	case *config.SnmpMetricCfg:
+		if v.DataSrcType == "CONDITIONEVAL" {
+			add("oidconditioncfg", &v.ExtraData)
+		}
+	case *config.MeasurementCfg:
+		for i := range v.Fields {
+			add("snmpmetriccfg", &v.Fields[i].ID)
+		}
+	case *config.MGroupsCfg:
+		for i := range v.Measurements {
+			add("measurementcfg", &v.Measurements[i])
+		}
+	case *config.MaintWindowCfg:
+		addSelectors("snmpdevicecfg", &v.Devices)
+		addSelectors("measgroupcfg", &v.Groups)
+	case *config.AlertRuleCfg:
+		for i := range v.Receivers {
+			add("alertreceivercfg", &v.Receivers[i])
+		}
+		addSelectors("snmpdevicecfg", &v.Devices)
+	}
+	return refs
+}
+
+// decodeObject returns the ObjectCfg as a pointer to its configuration type
+func decodeObject(o *ExportObject) (interface{}, error) {
+	if o.ObjectCfg == nil {
+		return nil, fmt.Errorf("Error inconsistent data not ObjectCfg found on Imported data for id: %s", o.ObjectID)
+	}
+	obj, err := config.NewCfgObject(o.ObjectTypeID)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(o.ObjectCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error on reformating object %s: error: %s ", o.ObjectID, err)
+	}
+	if err = json.Unmarshal(raw, obj); err != nil {
+		return nil, fmt.Errorf("error on decode object %s: error: %s ", o.ObjectID, err)
+	}
+	return obj, nil
+}
+
+// cfgObjectMap returns the object as a generic JSON map to compare it
+func cfgObjectMap(obj interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	raw, err := json.Marshal(obj)
+	if err == nil {
+		json.Unmarshal(raw, &m)
+	}
+	return m
+}
+
+// importItem an imported object with its preview status
+type importItem struct {
+	o       *ExportObject
+	obj     interface{}
+	refs    []*cfgRef
+	preview *ImportPreviewObject
+}
+
+// previewImport decodes and checks all objects against the database (db could be a transaction)
+func previewImport(db *config.DatabaseCfg, e *ExportData) (*ImportPreview, []*importItem, error) {
+	p := &ImportPreview{Info: e.Info}
+	items := make(map[string]*importItem)
+	var list []*importItem
+	for _, o := range e.Objects {
+		if _, ok := items[objectKey(o)]; ok {
+			return nil, nil, fmt.Errorf("Duplicated object %s in the imported data", objectKey(o))
+		}
+		obj, err := decodeObject(o)
+		if err != nil {
+			return nil, nil, err
+		}
+		it := &importItem{o: o, obj: obj, refs: cfgObjectRefs(o.ObjectTypeID, obj)}
+		it.preview = &ImportPreviewObject{ObjectTypeID: o.ObjectTypeID, ObjectID: o.ObjectID, Status: ImportObjNew, Strategy: ImportAdd, Deps: []string{}}
+		if ers := binding.RawValidate(reflect.ValueOf(obj).Elem().Interface()); ers.Len() > 0 {
+			e, _ := json.Marshal(ers)
+			it.preview.Error = string(e)
+		}
+		if current, err := db.GetCfgObjectByID(o.ObjectTypeID, o.ObjectID); err == nil {
+			it.preview.Diff = config.CfgMapDiff(cfgObjectMap(current), cfgObjectMap(obj))
+			it.preview.Status = ImportObjEqual
+			it.preview.Strategy = ImportKeepExisting
+			if len(it.preview.Diff) > 0 {
+				it.preview.Status = ImportObjConflict
+				it.preview.Strategy = ""
+				p.Conflicts++
+			}
+		}
+		items[objectKey(o)] = it
+		list = append(list, it)
+	}
+	referenced := make(map[string]bool)
+	for _, it := range list {
+		for _, r := range it.refs {
+			it.preview.Deps = append(it.preview.Deps, r.key())
+			if _, ok := items[r.key()]; ok {
+				referenced[r.key()] = true
+				continue
+			}
+			if _, err := db.GetCfgObjectByID(r.objtype, r.id); err != nil && !r.optional {
+				it.preview.Missing = append(it.preview.Missing, r.key())
+			}
+		}
+		if len(it.preview.Error) > 0 {
+			p.Errors++
+		}
+		p.Objects = append(p.Objects, it.preview)
+	}
+	var node func(it *importItem, path map[string]bool) *ImportTreeNode
+	node = func(it *importItem, path map[string]bool) *ImportTreeNode {
+		n := &ImportTreeNode{ObjectTypeID: it.o.ObjectTypeID, ObjectID: it.o.ObjectID, Status: it.preview.Status}
+		path[objectKey(it.o)] = true
+		for _, r := range it.refs {
+			if dep, ok := items[r.key()]; ok && !path[r.key()] {
+				n.Deps = append(n.Deps, node(dep, path))
+			}
+		}
+		delete(path, objectKey(it.o))
+		return n
+	}
+	for _, it := range list {
+		if !referenced[objectKey(it.o)] {
+			p.Tree = append(p.Tree, node(it, make(map[string]bool)))
+		}
+	}
+	return p, list, nil
+}
+
+// PreviewImport returns the status of each imported object against the database
+// (new, equal or conflict with the field differences) and the dependency tree
+func PreviewImport(e *ExportData) (*ImportPreview, error) {
+	p, _, err := previewImport(dbc, e)
+	return p, err
+}
+
+// typeOrder returns the position of the object type in the dependency order
+func typeOrder(objtype string) int {
+	for i, t := range snapshotObjectTypes {
+		if t == objtype {
+			return i
+		}
+	}
+	return len(snapshotObjectTypes)
+}
+
+// setImportStrategies sets and checks the strategy of each object, skipped objects are
+// propagated to the objects depending on them. Returns the renamed objects new IDs
+func setImportStrategies(db *config.DatabaseCfg, list []*importItem, strategies []*ImportStrategy) (map[string]string, error) {
+	items := make(map[string]*importItem)
+	for _, it := range list {
+		items[objectKey(it.o)] = it
+	}
+	suffix := "_" + strconv.FormatInt(time.Now().Unix(), 10)
+	renamed := make(map[string]string)
+	for _, s := range strategies {
+		it, ok := items[s.ObjectTypeID+"/"+s.ObjectID]
+		if !ok {
+			return nil, fmt.Errorf("Strategy for object %s/%s not found in the imported data", s.ObjectTypeID, s.ObjectID)
+		}
+		p := it.preview
+		switch s.Strategy {
+		case ImportSkip:
+		case ImportAdd:
+			if p.Status != ImportObjNew {
+				return nil, fmt.Errorf("Object %s already exists, strategy %s not allowed", objectKey(it.o), s.Strategy)
+			}
+		case ImportOverwrite, ImportKeepExisting:
+			if p.Status == ImportObjNew {
+				return nil, fmt.Errorf("Object %s does not exist, strategy %s not allowed", objectKey(it.o), s.Strategy)
+			}
+		case ImportRename:
+			p.NewID = s.NewID
+			if len(p.NewID) == 0 && it.o.Options != nil {
+				p.NewID = it.o.Options.AlternateID
+			}
+			if len(p.NewID) == 0 {
+				p.NewID = it.o.ObjectID + suffix
+			}
+			if _, ok := items[it.o.ObjectTypeID+"/"+p.NewID]; ok {
+				return nil, fmt.Errorf("Object %s can not be renamed to %s: there is other imported object with this ID", objectKey(it.o), p.NewID)
+			}
+			if _, err := db.GetCfgObjectByID(it.o.ObjectTypeID, p.NewID); err == nil {
+				return nil, fmt.Errorf("Object %s can not be renamed to %s: already exists in the database", objectKey(it.o), p.NewID)
+			}
+			renamed[objectKey(it.o)] = p.NewID
+		default:
+			return nil, fmt.Errorf("Unknown import strategy %q for object %s", s.Strategy, objectKey(it.o))
+		}
+		p.Strategy = s.Strategy
+	}
+	// objects depending on skipped ones are also skipped
+	for changed := true; changed; {
+		changed = false
+		for _, it := range list {
+			if it.preview.Strategy == ImportSkip {
+				continue
+			}
+			for _, r := range it.refs {
+				if dep, ok := items[r.key()]; ok && dep.preview.Strategy == ImportSkip && !r.optional {
+					it.preview.Strategy = ImportSkip
+					it.preview.Reason = "depends on skipped object " + r.key()
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	for _, it := range list {
+		p := it.preview
+		if p.Strategy == ImportSkip {
+			continue
+		}
+		if len(p.Strategy) == 0 {
+			return nil, fmt.Errorf("No strategy chosen for conflicting object %s", objectKey(it.o))
+		}
+		if len(p.Error) > 0 {
+			return nil, fmt.Errorf("Invalid object %s: %s", objectKey(it.o), p.Error)
+		}
+		if len(p.Missing) > 0 {
+			return nil, fmt.Errorf("Object %s references not found objects: %v", objectKey(it.o), p.Missing)
+		}
+	}
+	return renamed, nil
+}
+
+// ApplyImport imports the objects with the strategies chosen for them (objects without
+// strategy get the preview default: add the new ones and keep the equal ones, conflicts
+// should have one). All changes are done in a single transaction, any error rolls back all of
+// them. Returns the preview objects with the applied strategies.
+func ApplyImport(e *ExportData, strategies []*ImportStrategy, user string) ([]*ImportPreviewObject, error) {
+	var result []*ImportPreviewObject
+	err := dbc.Transaction(func(tx *config.DatabaseCfg) error {
+		p, list, err := previewImport(tx, e)
+		if err != nil {
+			return err
+		}
+		result = p.Objects
+		renamed, err := setImportStrategies(tx, list, strategies)
+		if err != nil {
+			return err
+		}
+		sorted := make([]*importItem, len(list))
+		copy(sorted, list)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return typeOrder(sorted[i].o.ObjectTypeID) < typeOrder(sorted[j].o.ObjectTypeID)
+		})
+		for _, it := range sorted {
+			p := it.preview
+			if p.Strategy == ImportSkip || p.Strategy == ImportKeepExisting {
+				continue
+			}
+			for _, r := range it.refs {
+				if id, ok := renamed[r.key()]; ok {
+					r.set(id)
+				}
+			}
+			var before interface{}
+			action := config.AuditActionAdd
+			id := it.o.ObjectID
+			switch p.Strategy {
+			case ImportOverwrite:
+				before, _ = tx.GetCfgObjectByID(it.o.ObjectTypeID, id)
+				action = config.AuditActionUpdate
+				_, err = tx.UpdateCfgObject(it.o.ObjectTypeID, id, it.obj)
+			case ImportRename:
+				id = p.NewID
+				if err = setCfgObjectID(it.obj, id); err == nil {
+					_, err = tx.AddCfgObject(it.o.ObjectTypeID, it.obj)
+				}
+			default:
+				_, err = tx.AddCfgObject(it.o.ObjectTypeID, it.obj)
+			}
+			if err != nil {
+				return fmt.Errorf("Error on import object %s (%s): %s", objectKey(it.o), p.Strategy, err)
+			}
+			if _, err = tx.AddAuditCfg(user, it.o.ObjectTypeID, action, id, before); err != nil {
+				return fmt.Errorf("Error on record audit log for %s: %s", objectKey(it.o), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Import rolled back: %s", err)
+		return result, err
+	}
+	log.Infof("Imported %d objects by user %s", len(result), user)
+	return result, nil
+}
+
+// setCfgObjectID changes the ID field of a decoded configuration object
+func setCfgObjectID(obj interface{}, id string) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Unknown configuration object %T", obj)
+	}
+	f := v.Elem().FieldByName("ID")
+	if !f.IsValid() || f.Kind() != reflect.String || !f.CanSet() {
+		return fmt.Errorf("Unknown configuration object %T", obj)
+	}
+	f.SetString(id)
+	return nil
+}
@@ -0,0 +1,206 @@
+package impexp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+func testImportData() *ExportData {
+	return &ExportData{
+		Info: &ExportInfo{FileName: "vendorpack.json"},
+		Objects: []*ExportObject{
+			{ObjectTypeID: "snmpmetriccfg", ObjectID: "sysuptime", ObjectCfg: &config.SnmpMetricCfg{ID: "sysuptime", FieldName: "uptime", BaseOID: ".1.3.6.1.2.1.1.3.0", DataSrcType: "TimeTicks"}},
+			{ObjectTypeID: "snmpmetriccfg", ObjectID: "syscontact", ObjectCfg: &config.SnmpMetricCfg{ID: "syscontact", FieldName: "contact", BaseOID: ".1.3.6.1.2.1.1.4.0", DataSrcType: "OCTETSTRING"}},
+			{ObjectTypeID: "measurementcfg", ObjectID: "sys", ObjectCfg: &config.MeasurementCfg{ID: "sys", Name: "system", GetMode: "value", Fields: []config.MeasurementFieldReport{{ID: "sysuptime", Report: 1}, {ID: "syscontact", Report: 1}}}},
+			{ObjectTypeID: "measgroupcfg", ObjectID: "vendor", ObjectCfg: &config.MGroupsCfg{ID: "vendor", Measurements: []string{"sys"}}},
+		},
+	}
+}
+
+func TestImportPreviewApply(t *testing.T) {
+	db, release := newTestDB(t)
+	defer release()
+
+	if _, err := db.AddSnmpMetricCfg(config.SnmpMetricCfg{ID: "sysuptime", FieldName: "sysUpTime", BaseOID: ".1.3.6.1.2.1.1.3.0", DataSrcType: "TimeTicks"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddMeasurementCfg(config.MeasurementCfg{ID: "sys", Name: "system", GetMode: "value", Fields: []config.MeasurementFieldReport{{ID: "sysuptime", Report: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := PreviewImport(testImportData())
+	if err != nil {
+		t.Fatalf("PreviewImport error: %s", err)
+	}
+	if p.Conflicts != 2 || p.Errors != 0 || len(p.Objects) != 4 {
+		t.Fatalf("unexpected preview %+v", p)
+	}
+	if o := p.Objects[0]; o.Status != ImportObjConflict || len(o.Diff) != 1 || o.Diff[0].Field != "FieldName" {
+		t.Errorf("unexpected metric preview %+v", o)
+	}
+	if o := p.Objects[1]; o.Status != ImportObjNew || o.Strategy != ImportAdd {
+		t.Errorf("unexpected new metric preview %+v", o)
+	}
+	// vendor -> sys -> sysuptime, syscontact
+	if len(p.Tree) != 1 || p.Tree[0].ObjectID != "vendor" || len(p.Tree[0].Deps) != 1 || len(p.Tree[0].Deps[0].Deps) != 2 {
+		t.Errorf("unexpected dependency tree %+v", p.Tree)
+	}
+
+	// conflicts need a strategy
+	if _, err := ApplyImport(testImportData(), nil, "admin"); err == nil {
+		t.Error("import with unresolved conflicts accepted")
+	}
+
+	strategies := []*ImportStrategy{
+		{ObjectTypeID: "snmpmetriccfg", ObjectID: "sysuptime", Strategy: ImportKeepExisting},
+		{ObjectTypeID: "measurementcfg", ObjectID: "sys", Strategy: ImportRename, NewID: "vendor_sys"},
+	}
+	if _, err := ApplyImport(testImportData(), strategies, "admin"); err != nil {
+		t.Fatalf("ApplyImport error: %s", err)
+	}
+	if m, err := db.GetSnmpMetricCfgByID("sysuptime"); err != nil || m.FieldName != "sysUpTime" {
+		t.Errorf("kept metric changed %+v, error %v", m, err)
+	}
+	if m, err := db.GetMeasurementCfgByID("sys"); err != nil || len(m.Fields) != 1 {
+		t.Errorf("renamed measurement changed %+v, error %v", m, err)
+	}
+	if m, err := db.GetMeasurementCfgByID("vendor_sys"); err != nil || len(m.Fields) != 2 {
+		t.Errorf("unexpected renamed measurement %+v, error %v", m, err)
+	}
+	if g, err := db.GetMGroupsCfgByID("vendor"); err != nil || len(g.Measurements) != 1 || g.Measurements[0] != "vendor_sys" {
+		t.Errorf("group references not renamed %+v, error %v", g, err)
+	}
+	if a, err := db.GetAuditCfgArray(&config.AuditQuery{User: "admin"}); err != nil || len(a) != 3 {
+		t.Errorf("unexpected audit records %d, error %v", len(a), err)
+	}
+
+	// any error rolls back all changes
+	e := testImportData()
+	e.Objects = append(e.Objects, &ExportObject{ObjectTypeID: "snmpmetriccfg", ObjectID: "bad", ObjectCfg: &config.SnmpMetricCfg{ID: "bad", FieldName: "bad", BaseOID: ".1.3", DataSrcType: "UNKNOWN"}})
+	strategies = []*ImportStrategy{
+		{ObjectTypeID: "snmpmetriccfg", ObjectID: "sysuptime", Strategy: ImportOverwrite},
+		{ObjectTypeID: "snmpmetriccfg", ObjectID: "syscontact", Strategy: ImportSkip},
+		{ObjectTypeID: "measurementcfg", ObjectID: "sys", Strategy: ImportOverwrite},
+	}
+	objs, err := ApplyImport(e, strategies, "admin")
+	if err == nil {
+		t.Fatal("invalid import applied")
+	}
+	// skipped measurement dependencies
+	if objs[2].Strategy != ImportSkip || objs[3].Strategy != ImportSkip {
+		t.Errorf("dependencies of skipped object not skipped: %+v %+v", objs[2], objs[3])
+	}
+	if m, err := db.GetSnmpMetricCfgByID("sysuptime"); err != nil || m.FieldName != "sysUpTime" {
+		t.Errorf("overwritten metric not rolled back %+v, error %v", m, err)
+	}
+	if a, _ := db.GetAuditCfgArray(&config.AuditQuery{User: "admin"}); len(a) != 3 {
+		t.Errorf("audit records not rolled back: %d", len(a))
+	}
+}
+
+func TestImportRenameMaintWindow(t *testing.T) {
+	db, release := newTestDB(t)
+	defer release()
+
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	if _, err := db.AddInfluxCfg(config.InfluxCfg{ID: "influx1", Host: "127.0.0.1", Port: 8086, DB: "snmp", Retention: "autogen"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddSnmpDeviceCfg(config.SnmpDeviceCfg{ID: "sw1", Host: "127.0.0.1", Port: 161, SnmpVersion: "2c", Community: "public", Freq: 60, OutDB: "influx1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddMaintWindowCfg(config.MaintWindowCfg{ID: "upgrade", Active: true, Mode: "pause", Devices: "sw1", Start: start, End: start.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	e := &ExportData{
+		Info: &ExportInfo{FileName: "site2.json"},
+		Objects: []*ExportObject{
+			{ObjectTypeID: "snmpdevicecfg", ObjectID: "sw1", ObjectCfg: &config.SnmpDeviceCfg{ID: "sw1", Host: "10.0.0.1", Port: 161, SnmpVersion: "2c", Community: "public", Freq: 60, OutDB: "influx1",
+				MaxRepetitions: 50, UpdateFltFreq: 60, OverrunPolicy: "skip", LogLevel: "info", DeviceTagName: "hostname", DeviceTagValue: "id"}},
+			// selectors with prefixes or not existing devices are not import errors
+			{ObjectTypeID: "maintwindowcfg", ObjectID: "upgrade", ObjectCfg: &config.MaintWindowCfg{ID: "upgrade", Active: true, Mode: "pause", Devices: "sw1,core*,gone", Start: start, End: start.Add(2 * time.Hour)}},
+		},
+	}
+	p, err := PreviewImport(e)
+	if err != nil {
+		t.Fatalf("PreviewImport error: %s", err)
+	}
+	if p.Conflicts != 2 || p.Errors != 0 {
+		t.Fatalf("unexpected preview %+v", p)
+	}
+	strategies := []*ImportStrategy{
+		{ObjectTypeID: "snmpdevicecfg", ObjectID: "sw1", Strategy: ImportRename, NewID: "site2_sw1"},
+		{ObjectTypeID: "maintwindowcfg", ObjectID: "upgrade", Strategy: ImportRename, NewID: "site2_upgrade"},
+	}
+	if _, err := ApplyImport(e, strategies, "admin"); err != nil {
+		t.Fatalf("ApplyImport error: %s", err)
+	}
+	if d, err := db.GetSnmpDeviceCfgByID("site2_sw1"); err != nil || d.Host != "10.0.0.1" {
+		t.Errorf("unexpected renamed device %+v, error %v", d, err)
+	}
+	if w, err := db.GetMaintWindowCfgByID("site2_upgrade"); err != nil || w.Devices != "site2_sw1,core*,gone" {
+		t.Errorf("window device references not renamed %+v, error %v", w, err)
+	}
+	if w, err := db.GetMaintWindowCfgByID("upgrade"); err != nil || w.Devices != "sw1" {
+		t.Errorf("renamed window changed %+v, error %v", w, err)
+	}
+}
+
+func TestImportExportAlertRules(t *testing.T) {
+	db, release := newTestDB(t)
+	defer release()
+
+	if _, err := db.AddAlertReceiverCfg(config.AlertReceiverCfg{ID: "ops", URL: "http://127.0.0.1:9000/hook"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddAlertRuleCfg(config.AlertRuleCfg{ID: "cpu", Measurement: "sys", Devices: "sw1,core*", Expression: "cpu > 90", Severity: "warning", Receivers: []string{"ops"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// receivers are exported with the rules
+	e := NewExport(&ExportInfo{FileName: "alerts.json"})
+	if err := e.Export("alertrulecfg", "cpu", true, 0); err != nil {
+		t.Fatalf("Export error: %s", err)
+	}
+	if len(e.Objects) != 2 || e.Objects[0].ObjectTypeID != "alertreceivercfg" || e.Objects[1].ObjectTypeID != "alertrulecfg" {
+		t.Fatalf("unexpected exported objects %+v", e.Objects)
+	}
+	s, err := TakeSnapshot("alerts", "", "admin", false)
+	if err != nil || s.NumObjects != 2 {
+		t.Fatalf("unexpected snapshot %+v, error %v", s, err)
+	}
+
+	strategies := []*ImportStrategy{
+		{ObjectTypeID: "alertreceivercfg", ObjectID: "ops", Strategy: ImportRename, NewID: "site2_ops"},
+		{ObjectTypeID: "alertrulecfg", ObjectID: "cpu", Strategy: ImportRename, NewID: "site2_cpu"},
+	}
+	if _, err := ApplyImport(e, strategies, "admin"); err != nil {
+		t.Fatalf("ApplyImport error: %s", err)
+	}
+	if _, err := db.GetAlertReceiverCfgByID("site2_ops"); err != nil {
+		t.Errorf("renamed receiver not imported: %s", err)
+	}
+	if r, err := db.GetAlertRuleCfgByID("site2_cpu"); err != nil || len(r.Receivers) != 1 || r.Receivers[0] != "site2_ops" || r.Devices != "sw1,core*" {
+		t.Errorf("rule receiver references not renamed %+v, error %v", r, err)
+	}
+	if r, err := db.GetAlertRuleCfgByID("cpu"); err != nil || len(r.Receivers) != 1 || r.Receivers[0] != "ops" {
+		t.Errorf("renamed rule changed %+v, error %v", r, err)
+	}
+
+	// rules can not be imported without their receivers
+	e = &ExportData{
+		Info: &ExportInfo{FileName: "alerts.json"},
+		Objects: []*ExportObject{
+			{ObjectTypeID: "alertrulecfg", ObjectID: "mem", ObjectCfg: &config.AlertRuleCfg{ID: "mem", Measurement: "sys", Expression: "mem > 90", Severity: "warning", Receivers: []string{"pager"}}},
+		},
+	}
+	if p, err := PreviewImport(e); err != nil || len(p.Objects[0].Missing) != 1 || p.Objects[0].Missing[0] != "alertreceivercfg/pager" {
+		t.Errorf("unexpected missing receiver preview %+v, error %v", p, err)
+	}
+	if _, err := ApplyImport(e, nil, "admin"); err == nil {
+		t.Error("rule with missing receiver imported")
+	}
+}
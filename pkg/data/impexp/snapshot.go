@@ -0,0 +1,283 @@
+package impexp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// Snapshot diff object status
+const (
+	SnapshotObjAdded   = "added"
+	SnapshotObjRemoved = "removed"
+	SnapshotObjChanged = "changed"
+)
+
+// snapshotObjectTypes is the order objects are exported to snapshots, any
+// object is placed after all the objects it depends on
+var snapshotObjectTypes = []string{
+	"varcatalogcfg",
+	"oidconditioncfg",
+	"customfiltercfg",
+	"measfiltercfg",
+	"snmpmetriccfg",
+	"measurementcfg",
+	"measgroupcfg",
+	"influxcfg",
+	"snmpdevicecfg",
+	"maintwindowcfg",
+	"alertreceivercfg",
+	"alertrulecfg",
+}
+
+// SnapshotObjectDiff an object difference between two configurations
+type SnapshotObjectDiff struct {
+	ObjectTypeID string
+	ObjectID     string
+	Status       string
+	Diff         []config.AuditFieldDiff
+}
+
+// ExportAll exports recursively all configuration objects
+func ExportAll(info *ExportInfo) (*ExportData, error) {
+	exp := NewExport(info)
+	for _, t := range snapshotObjectTypes {
+		ids, err := dbc.GetCfgObjectIDs(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if err := exp.Export(t, id, true, 0); err != nil {
+				return nil, fmt.Errorf("Error on export %s %s: %s", t, id, err)
+			}
+		}
+	}
+	return exp, nil
+}
+
+// normalizeExport returns a copy of the export with all ObjectCfg as generic JSON maps
+// and the checksum of its objects
+func normalizeExport(e *ExportData) (*ExportData, string, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, "", err
+	}
+	n := &ExportData{}
+	if err = json.Unmarshal(data, n); err != nil {
+		return nil, "", err
+	}
+	// maps are marshalled with sorted keys, so the same objects get the same checksum
+	objs, err := json.Marshal(n.Objects)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(objs)
+	return n, hex.EncodeToString(sum[:]), nil
+}
+
+// currentExport returns the normalized export of the current configuration
+func currentExport(name string) (*ExportData, string, error) {
+	e, err := ExportAll(&ExportInfo{FileName: name + ".json", Description: name})
+	if err != nil {
+		return nil, "", err
+	}
+	return normalizeExport(e)
+}
+
+// TakeSnapshot stores a new snapshot of the whole configuration, automatic
+// snapshots are not stored if the configuration has not changed since the last snapshot
+func TakeSnapshot(name string, description string, user string, auto bool) (*config.SnapshotCfg, error) {
+	e, sum, err := currentExport(name)
+	if err != nil {
+		return nil, fmt.Errorf("Error on snapshot %s: %s", name, err)
+	}
+	return storeSnapshot(e, sum, name, description, user, auto)
+}
+
+// storeSnapshot stores the normalized export with its checksum as a new snapshot
+func storeSnapshot(e *ExportData, sum string, name string, description string, user string, auto bool) (*config.SnapshotCfg, error) {
+	if auto {
+		last, err := dbc.GetLastSnapshotCfg()
+		if err != nil {
+			return nil, fmt.Errorf("Error on snapshot %s: %s", name, err)
+		}
+		if last != nil && last.Checksum == sum {
+			log.Infof("Configuration not changed since snapshot %s [id %d], skipping snapshot %s", last.Name, last.ID, name)
+			return last, nil
+		}
+	}
+	e.Info.Author = user
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("Error on snapshot %s: %s", name, err)
+	}
+	s := &config.SnapshotCfg{
+		Name:        name,
+		Description: description,
+		Auto:        auto,
+		User:        user,
+		Timestamp:   e.Info.CreationDate,
+		Checksum:    sum,
+		NumObjects:  len(e.Objects),
+		Data:        string(data),
+	}
+	if err = dbc.AddSnapshotCfg(s); err != nil {
+		return nil, err
+	}
+	s.Data = ""
+	return s, nil
+}
+
+// AutoSnapshot takes an automatic snapshot, errors are only logged
+func AutoSnapshot(reason string) {
+	name := fmt.Sprintf("auto %s %s", reason, time.Now().Format("2006-01-02 15:04:05"))
+	if _, err := TakeSnapshot(name, "automatic snapshot taken before "+reason, "", true); err != nil {
+		log.Errorf("Error on automatic configuration snapshot: %s", err)
+	}
+}
+
+// LoadSnapshot returns the snapshot and its decoded configuration
+func LoadSnapshot(id int64) (*config.SnapshotCfg, *ExportData, error) {
+	s, err := dbc.GetSnapshotCfgByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	e := &ExportData{}
+	if err = json.Unmarshal([]byte(s.Data), e); err != nil {
+		return nil, nil, fmt.Errorf("Error on decode snapshot %d: %s", id, err)
+	}
+	return s, e, nil
+}
+
+func objectKey(o *ExportObject) string {
+	return o.ObjectTypeID + "/" + o.ObjectID
+}
+
+// diffExports returns the objects differences to go from the a configuration to b
+// (both normalized), added and changed objects are in b order, removed ones in reverse a order
+func diffExports(a, b *ExportData) []*SnapshotObjectDiff {
+	am := make(map[string]*ExportObject)
+	for _, o := range a.Objects {
+		am[objectKey(o)] = o
+	}
+	bm := make(map[string]*ExportObject)
+	diff := []*SnapshotObjectDiff{}
+	for _, o := range b.Objects {
+		bm[objectKey(o)] = o
+		before := am[objectKey(o)]
+		if before == nil {
+			after, _ := o.ObjectCfg.(map[string]interface{})
+			diff = append(diff, &SnapshotObjectDiff{ObjectTypeID: o.ObjectTypeID, ObjectID: o.ObjectID, Status: SnapshotObjAdded, Diff: config.CfgMapDiff(nil, after)})
+			continue
+		}
+		bcfg, _ := before.ObjectCfg.(map[string]interface{})
+		acfg, _ := o.ObjectCfg.(map[string]interface{})
+		if d := config.CfgMapDiff(bcfg, acfg); len(d) > 0 {
+			diff = append(diff, &SnapshotObjectDiff{ObjectTypeID: o.ObjectTypeID, ObjectID: o.ObjectID, Status: SnapshotObjChanged, Diff: d})
+		}
+	}
+	for i := len(a.Objects) - 1; i >= 0; i-- {
+		o := a.Objects[i]
+		if _, ok := bm[objectKey(o)]; !ok {
+			before, _ := o.ObjectCfg.(map[string]interface{})
+			diff = append(diff, &SnapshotObjectDiff{ObjectTypeID: o.ObjectTypeID, ObjectID: o.ObjectID, Status: SnapshotObjRemoved, Diff: config.CfgMapDiff(before, nil)})
+		}
+	}
+	return diff
+}
+
+// DiffSnapshots returns the changes from snapshot "from" to snapshot "to",
+// a 0 id means the current configuration
+func DiffSnapshots(from int64, to int64) ([]*SnapshotObjectDiff, error) {
+	load := func(id int64) (*ExportData, error) {
+		if id == 0 {
+			e, _, err := currentExport("current")
+			return e, err
+		}
+		_, e, err := LoadSnapshot(id)
+		return e, err
+	}
+	a, err := load(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := load(to)
+	if err != nil {
+		return nil, err
+	}
+	return diffExports(a, b), nil
+}
+
+// applyObjectDiff adds, updates or deletes an object to get the o state
+// and records the change in the audit log
+func applyObjectDiff(tx *config.DatabaseCfg, d *SnapshotObjectDiff, o *ExportObject, user string) error {
+	var err error
+	var obj interface{}
+	var action string
+	before, _ := tx.GetCfgObjectByID(d.ObjectTypeID, d.ObjectID)
+	if d.Status != SnapshotObjRemoved {
+		if obj, err = decodeObject(o); err != nil {
+			return err
+		}
+	}
+	switch d.Status {
+	case SnapshotObjRemoved:
+		action = config.AuditActionDelete
+		_, err = tx.DelCfgObject(d.ObjectTypeID, d.ObjectID)
+	case SnapshotObjAdded:
+		action = config.AuditActionAdd
+		before = nil
+		_, err = tx.AddCfgObject(d.ObjectTypeID, obj)
+	case SnapshotObjChanged:
+		action = config.AuditActionUpdate
+		_, err = tx.UpdateCfgObject(d.ObjectTypeID, d.ObjectID, obj)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = tx.AddAuditCfg(user, d.ObjectTypeID, action, d.ObjectID, before); err != nil {
+		return fmt.Errorf("Error on record audit log: %s", err)
+	}
+	return nil
+}
+
+// RestoreSnapshot sets the configuration database as it was when the snapshot was taken,
+// only the changed objects are written in a single transaction rolled back on any error.
+// The previous configuration is kept in an automatic snapshot after the changes are committed.
+// Returns the applied changes (the configuration should be reloaded after).
+func RestoreSnapshot(id int64, user string) ([]*SnapshotObjectDiff, error) {
+	s, snap, err := LoadSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("auto restore %d %s", id, time.Now().Format("2006-01-02 15:04:05"))
+	current, sum, err := currentExport(name)
+	if err != nil {
+		return nil, err
+	}
+	objs := make(map[string]*ExportObject)
+	for _, o := range snap.Objects {
+		objs[objectKey(o)] = o
+	}
+	diff := diffExports(current, snap)
+	log.Infof("Restoring configuration snapshot %s [id %d] by user %s: %d objects to change", s.Name, s.ID, user, len(diff))
+	err = dbc.Transaction(func(tx *config.DatabaseCfg) error {
+		for _, d := range diff {
+			if err := applyObjectDiff(tx, d, objs[d.ObjectTypeID+"/"+d.ObjectID], user); err != nil {
+				return fmt.Errorf("Error on restore snapshot %d, object %s %s (%s): %s", id, d.ObjectTypeID, d.ObjectID, d.Status, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = storeSnapshot(current, sum, name, "automatic snapshot taken before restore of snapshot "+s.Name, user, true); err != nil {
+		log.Errorf("Error on automatic configuration snapshot before restore: %s", err)
+	}
+	return diff, nil
+}
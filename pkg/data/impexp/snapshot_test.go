@@ -0,0 +1,124 @@
+package impexp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// newTestDB sets an initialized sqlite database on a temporary dir as the package database
+// and returns the function to release it
+func newTestDB(t *testing.T) (*config.DatabaseCfg, func()) {
+	log = logrus.New()
+	log.Out = ioutil.Discard
+	config.SetLogger(log)
+	dir, err := ioutil.TempDir("", "snmpcollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.SetDirs(dir, dir, dir)
+	db := &config.DatabaseCfg{Type: "sqlite3", Name: "snmpcollector"}
+	db.InitDB()
+	SetDB(db)
+	return db, func() { os.RemoveAll(dir) }
+}
+
+func TestSnapshotDiffRestore(t *testing.T) {
+	db, release := newTestDB(t)
+	defer release()
+
+	influx := config.InfluxCfg{ID: "influx1", Host: "127.0.0.1", Port: 8086, DB: "snmp", Retention: "autogen"}
+	dev := config.SnmpDeviceCfg{ID: "dev1", Host: "127.0.0.1", Port: 161, SnmpVersion: "2c", Community: "public", Active: true, Freq: 60, OutDB: "influx1"}
+	if _, err := db.AddInfluxCfg(influx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddSnmpDeviceCfg(dev); err != nil {
+		t.Fatal(err)
+	}
+	s, err := TakeSnapshot("initial", "", "admin", false)
+	if err != nil {
+		t.Fatalf("TakeSnapshot error: %s", err)
+	}
+	if s.NumObjects != 2 {
+		t.Errorf("snapshot with %d objects, want 2", s.NumObjects)
+	}
+	// unchanged configuration should not generate automatic snapshots
+	if a, err := TakeSnapshot("auto", "", "", true); err != nil || a.ID != s.ID {
+		t.Errorf("unexpected automatic snapshot %+v, error %v", a, err)
+	}
+
+	dev.Freq = 30
+	if _, err := db.UpdateSnmpDeviceCfg("dev1", dev); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddSnmpDeviceCfg(config.SnmpDeviceCfg{ID: "dev2", Host: "127.0.0.2", Port: 161, SnmpVersion: "2c", Community: "public", Freq: 60, OutDB: "influx1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := DiffSnapshots(s.ID, 0)
+	if err != nil {
+		t.Fatalf("DiffSnapshots error: %s", err)
+	}
+	status := make(map[string]string)
+	for _, d := range diff {
+		status[d.ObjectID] = d.Status
+	}
+	if len(diff) != 2 || status["dev1"] != SnapshotObjChanged || status["dev2"] != SnapshotObjAdded {
+		t.Fatalf("unexpected diff %+v", status)
+	}
+
+	changes, err := RestoreSnapshot(s.ID, "admin")
+	if err != nil {
+		t.Fatalf("RestoreSnapshot error: %s", err)
+	}
+	if len(changes) != 2 {
+		t.Errorf("restore with %d changes, want 2", len(changes))
+	}
+	if got, err := db.GetSnmpDeviceCfgByID("dev1"); err != nil || got.Freq != 60 {
+		t.Errorf("device not restored: %+v, error %v", got, err)
+	}
+	if _, err := db.GetSnmpDeviceCfgByID("dev2"); err == nil {
+		t.Error("device added after snapshot not removed on restore")
+	}
+	if diff, err := DiffSnapshots(s.ID, 0); err != nil || len(diff) != 0 {
+		t.Errorf("unexpected diff after restore %+v, error %v", diff, err)
+	}
+	// the previous configuration is kept in an automatic snapshot
+	snaps, err := db.GetSnapshotCfgArray(config.FilterEq("auto", true))
+	if err != nil || len(snaps) != 1 {
+		t.Errorf("unexpected automatic snapshots %+v, error %v", snaps, err)
+	}
+
+	// a restore failing on any object does not change anything
+	_, snap, err := LoadSnapshot(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap.Objects = append(snap.Objects, &ExportObject{ObjectTypeID: "maintwindowcfg", ObjectID: "invalid",
+		ObjectCfg: map[string]interface{}{"ID": "invalid", "Active": true, "Mode": "pause"}})
+	data, _ := json.Marshal(snap)
+	broken := &config.SnapshotCfg{Name: "broken", Data: string(data)}
+	if err := db.AddSnapshotCfg(broken); err != nil {
+		t.Fatal(err)
+	}
+	dev.Freq = 30
+	if _, err := db.UpdateSnmpDeviceCfg("dev1", dev); err != nil {
+		t.Fatal(err)
+	}
+	if changes, err := RestoreSnapshot(broken.ID, "admin2"); err == nil {
+		t.Fatalf("expected error on restore with invalid objects, changes %+v", changes)
+	}
+	if got, err := db.GetSnmpDeviceCfgByID("dev1"); err != nil || got.Freq != 30 {
+		t.Errorf("device changed on failed restore: %+v, error %v", got, err)
+	}
+	if audit, err := db.GetAuditCfgArray(&config.AuditQuery{User: "admin2"}); err != nil || len(audit) != 0 {
+		t.Errorf("unexpected audit entries on failed restore %+v, error %v", audit, err)
+	}
+	if snaps, err := db.GetSnapshotCfgArray(config.FilterEq("auto", true)); err != nil || len(snaps) != 1 {
+		t.Errorf("unexpected automatic snapshots on failed restore %+v, error %v", snaps, err)
+	}
+}
@@ -0,0 +1,252 @@
+package impexp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/yaml.v2"
+)
+
+// prometheus snmp_exporter module definitions (only the fields used on conversion),
+// generator.yml modules have walk/get lists and the generated snmp.yml ones also the metrics
+type snmpExporterIndex struct {
+	Labelname string `yaml:"labelname"`
+}
+
+type snmpExporterLookup struct {
+	Labels    []string `yaml:"labels"`
+	Labelname string   `yaml:"labelname"`
+	Oid       string   `yaml:"oid"`
+}
+
+type snmpExporterMetric struct {
+	Name       string               `yaml:"name"`
+	Oid        string               `yaml:"oid"`
+	Type       string               `yaml:"type"`
+	Help       string               `yaml:"help"`
+	Indexes    []snmpExporterIndex  `yaml:"indexes"`
+	Lookups    []snmpExporterLookup `yaml:"lookups"`
+	EnumValues map[int]string       `yaml:"enum_values"`
+}
+
+type snmpExporterModule struct {
+	Walk    []string             `yaml:"walk"`
+	Get     []string             `yaml:"get"`
+	Metrics []snmpExporterMetric `yaml:"metrics"`
+}
+
+// snmpExporterEnum returns the ENUM/BITS extradata for the enum values
+func snmpExporterEnum(values map[int]string) string {
+	var keys []int
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	var s []string
+	for _, k := range keys {
+		s = append(s, fmt.Sprintf("%s(%d)", values[k], k))
+	}
+	return strings.Join(s, ",")
+}
+
+// snmpExporterMetricCfg converts a snmp_exporter metric (or lookup if isTag) into a metric
+func snmpExporterMetricCfg(id string, name string, oid string, typ string, enum map[int]string, isTag bool) (*config.SnmpMetricCfg, error) {
+	oid, err := normalizeOID(oid)
+	if err != nil {
+		return nil, err
+	}
+	m := &config.SnmpMetricCfg{
+		ID:          id,
+		FieldName:   name,
+		BaseOID:     oid,
+		IsTag:       isTag,
+		Conversion:  config.STRING,
+		Description: "imported from snmp_exporter " + name,
+	}
+	switch typ {
+	case "gauge":
+		m.DataSrcType = "INTEGER"
+		m.Conversion = config.INTEGER
+	case "counter":
+		// raw counter value as snmp_exporter does
+		m.DataSrcType = "Counter64"
+		m.Conversion = config.INTEGER
+	case "DisplayString", "OctetString", "DateAndTime", "":
+		m.DataSrcType = "OCTETSTRING"
+	case "PhysAddress48":
+		m.DataSrcType = "HWADDR"
+	case "InetAddressIPv4", "IpAddr":
+		m.DataSrcType = "IpAddress"
+	case "EnumAsInfo", "EnumAsStateSet":
+		m.DataSrcType = "ENUM"
+		m.ExtraData = snmpExporterEnum(enum)
+	case "Bits":
+		m.DataSrcType = "BITS"
+		m.ExtraData = snmpExporterEnum(enum)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+	if (m.DataSrcType == "ENUM" || m.DataSrcType == "BITS") && len(m.ExtraData) == 0 {
+		return nil, fmt.Errorf("type %s without enum_values", typ)
+	}
+	return m, nil
+}
+
+// snmpExporterMetrics converts a generated module metrics, metrics are grouped by their indexes
+// in a measurement (a "value" one for the scalar metrics). The first lookup with the same
+// labels as the indexes is used as index tag, the other ones are added as tag metrics
+func (c *converter) snmpExporterMetrics(module string, mod *snmpExporterModule, group *config.MGroupsCfg) {
+	measurements := make(map[string]*config.MeasurementCfg)
+	oids := make(map[string]map[string]bool)
+	var order []string
+	addField := func(meas *config.MeasurementCfg, m *config.SnmpMetricCfg) {
+		// duplicated OIDs are not allowed in the same measurement
+		if oids[meas.ID][m.BaseOID] {
+			return
+		}
+		oids[meas.ID][m.BaseOID] = true
+		c.add("snmpmetriccfg", m.ID, m)
+		meas.Fields = append(meas.Fields, config.MeasurementFieldReport{ID: m.ID, Report: 1})
+	}
+	for i := range mod.Metrics {
+		mt := &mod.Metrics[i]
+		var labels []string
+		for _, idx := range mt.Indexes {
+			labels = append(labels, idx.Labelname)
+		}
+		key := strings.Join(labels, ",")
+		meas, ok := measurements[key]
+		if !ok {
+			meas = &config.MeasurementCfg{ID: convertID(module), Name: module, GetMode: "value", Description: "imported from snmp_exporter module " + module}
+			if len(labels) > 0 {
+				meas.ID = convertID(append([]string{module}, labels...)...)
+				meas.Name = meas.ID
+				meas.GetMode = "indexed"
+				meas.Description += " indexed by " + key
+			}
+			measurements[key] = meas
+			oids[meas.ID] = make(map[string]bool)
+			order = append(order, key)
+		}
+		oid := mt.Oid
+		if len(labels) == 0 {
+			// scalar instance
+			oid += ".0"
+		}
+		m, err := snmpExporterMetricCfg(convertID(module, mt.Name), mt.Name, oid, mt.Type, mt.EnumValues, false)
+		if err != nil {
+			c.warnf("module %s: metric %s skipped: %s", module, mt.Name, err)
+			continue
+		}
+		if len(labels) > 0 && len(meas.IndexOID) == 0 {
+			meas.IndexOID = m.BaseOID
+			meas.IndexTag = labels[0]
+			meas.IndexAsValue = true
+		}
+		addField(meas, m)
+		for _, l := range mt.Lookups {
+			if strings.Join(l.Labels, ",") != key {
+				c.warnf("module %s: metric %s lookup %s with labels %s skipped: only lookups by the metric indexes are supported", module, mt.Name, l.Labelname, strings.Join(l.Labels, ","))
+				continue
+			}
+			lm, err := snmpExporterMetricCfg(convertID(module, l.Labelname), l.Labelname, l.Oid, "", nil, true)
+			if err != nil {
+				c.warnf("module %s: lookup %s skipped: %s", module, l.Labelname, err)
+				continue
+			}
+			if meas.IndexAsValue {
+				// the lookup value is a better index tag than the index itself
+				meas.IndexOID = lm.BaseOID
+				meas.IndexTag = lm.FieldName
+				meas.IndexAsValue = false
+				oids[meas.ID][lm.BaseOID] = true
+				continue
+			}
+			if meas.IndexOID != lm.BaseOID {
+				addField(meas, lm)
+			}
+		}
+	}
+	for _, key := range order {
+		if meas := measurements[key]; c.addMeasurement(meas) {
+			group.Measurements = append(group.Measurements, meas.ID)
+		}
+	}
+}
+
+// snmpExporterGenerator converts a generator module, only numeric OIDs can be translated:
+// get OIDs as scalar metrics and walk OIDs as columns of an indexed measurement
+func (c *converter) snmpExporterGenerator(module string, mod *snmpExporterModule, group *config.MGroupsCfg) {
+	value := &config.MeasurementCfg{ID: convertID(module), Name: module, GetMode: "value", Description: "imported from snmp_exporter generator module " + module}
+	walk := &config.MeasurementCfg{ID: convertID(module, "walk"), Name: module, GetMode: "indexed", IndexTag: "index", IndexAsValue: true, Description: "imported from snmp_exporter generator module " + module}
+	add := func(meas *config.MeasurementCfg, oid string) {
+		noid, err := normalizeOID(oid)
+		if err != nil {
+			c.warnf("module %s: %s skipped: %s, import the snmp.yml generated from this module instead", module, oid, err)
+			return
+		}
+		name := convertID("oid", strings.TrimPrefix(noid, "."))
+		m := &config.SnmpMetricCfg{ID: convertID(module, name), FieldName: name, BaseOID: noid, DataSrcType: "INTEGER", Conversion: config.INTEGER, Description: "imported from snmp_exporter generator " + oid}
+		if len(meas.IndexOID) == 0 && meas.GetMode == "indexed" {
+			meas.IndexOID = noid
+		}
+		c.add("snmpmetriccfg", m.ID, m)
+		meas.Fields = append(meas.Fields, config.MeasurementFieldReport{ID: m.ID, Report: 1})
+	}
+	for _, oid := range mod.Get {
+		add(value, oid)
+	}
+	for _, oid := range mod.Walk {
+		add(walk, oid)
+	}
+	for _, meas := range []*config.MeasurementCfg{value, walk} {
+		if len(meas.Fields) > 0 && c.addMeasurement(meas) {
+			group.Measurements = append(group.Measurements, meas.ID)
+		}
+	}
+}
+
+// ConvertSnmpExporter translates prometheus snmp_exporter modules, from the snmp.yml file generated by
+// the generator (with resolved OIDs, types, indexes and lookups) or from a generator.yml one (only numeric OIDs,
+// MIB names can not be resolved). Each module is converted into a measurement group.
+// Returns the objects to import and the conversion warnings (skipped or partially converted objects)
+func ConvertSnmpExporter(data []byte) (*ExportData, []string, error) {
+	cfg := struct {
+		Modules map[string]*snmpExporterModule `yaml:"modules"`
+	}{}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, nil, fmt.Errorf("Error on read snmp_exporter configuration: %s", err)
+	}
+	if len(cfg.Modules) == 0 {
+		// snmp.yml files generated before auths section was added have the modules at top level
+		if err := yaml.Unmarshal(data, &cfg.Modules); err != nil {
+			return nil, nil, fmt.Errorf("Error on read snmp_exporter configuration: %s", err)
+		}
+	}
+	var names []string
+	for k, mod := range cfg.Modules {
+		if mod != nil {
+			names = append(names, k)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil, fmt.Errorf("No modules found in the snmp_exporter configuration")
+	}
+	sort.Strings(names)
+	c := newConverter()
+	for _, name := range names {
+		mod := cfg.Modules[name]
+		group := &config.MGroupsCfg{ID: convertID("snmp_exporter", name), Description: "imported from snmp_exporter module " + name}
+		if len(mod.Metrics) > 0 {
+			c.snmpExporterMetrics(name, mod, group)
+		} else {
+			c.snmpExporterGenerator(name, mod, group)
+		}
+		if len(group.Measurements) > 0 {
+			c.add("measgroupcfg", group.ID, group)
+		}
+	}
+	return c.export(&ExportInfo{FileName: "snmp.yml", Description: "imported from snmp_exporter modules"})
+}
@@ -0,0 +1,192 @@
+package impexp
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/pelletier/go-toml"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// telegraf inputs.snmp plugin configuration (only the fields used on conversion)
+type telegrafSnmpField struct {
+	Name           string `toml:"name"`
+	Oid            string `toml:"oid"`
+	IsTag          bool   `toml:"is_tag"`
+	Conversion     string `toml:"conversion"`
+	OidIndexSuffix string `toml:"oid_index_suffix"`
+}
+
+type telegrafSnmpTable struct {
+	Name        string              `toml:"name"`
+	Oid         string              `toml:"oid"`
+	InheritTags []string            `toml:"inherit_tags"`
+	Fields      []telegrafSnmpField `toml:"field"`
+}
+
+type telegrafSnmp struct {
+	Name   string              `toml:"name"`
+	Fields []telegrafSnmpField `toml:"field"`
+	Tables []telegrafSnmpTable `toml:"table"`
+}
+
+type telegrafConfig struct {
+	Inputs struct {
+		Snmp []telegrafSnmp `toml:"snmp"`
+	} `toml:"inputs"`
+}
+
+var telegrafFloatConvRe = regexp.MustCompile(`^float\(([0-9]+)\)$`)
+
+// telegrafMetric converts a telegraf field into a metric
+func (c *converter) telegrafMetric(measID string, f *telegrafSnmpField) (*config.SnmpMetricCfg, error) {
+	if len(f.OidIndexSuffix) > 0 {
+		return nil, fmt.Errorf("oid_index_suffix is not supported")
+	}
+	oid, err := normalizeOID(f.Oid)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Name) == 0 {
+		return nil, fmt.Errorf("name is required for OID %s", oid)
+	}
+	m := &config.SnmpMetricCfg{
+		ID:          convertID(measID, f.Name),
+		FieldName:   f.Name,
+		BaseOID:     oid,
+		DataSrcType: "INTEGER",
+		Conversion:  config.INTEGER,
+		IsTag:       f.IsTag,
+		Description: "imported from telegraf inputs.snmp field " + f.Oid,
+	}
+	if f.IsTag {
+		m.DataSrcType = "OCTETSTRING"
+		m.Conversion = config.STRING
+	}
+	switch f.Conversion {
+	case "":
+	case "int":
+		m.DataSrcType = "INTEGER"
+		m.Conversion = config.INTEGER
+	case "float":
+		m.DataSrcType = "INTEGER"
+		m.Conversion = config.FLOAT
+	case "hwaddr":
+		m.DataSrcType = "HWADDR"
+		m.Conversion = config.STRING
+	case "ipaddr":
+		m.DataSrcType = "IpAddress"
+		m.Conversion = config.STRING
+	default:
+		match := telegrafFloatConvRe.FindStringSubmatch(f.Conversion)
+		if match == nil {
+			c.warnf("metric %s: unsupported conversion %q, converted as integer", m.ID, f.Conversion)
+			break
+		}
+		n, _ := strconv.Atoi(match[1])
+		m.DataSrcType = "INTEGER"
+		m.Conversion = config.FLOAT
+		m.Scale = math.Pow10(-n)
+	}
+	return m, nil
+}
+
+// telegrafTable converts a telegraf table into an indexed measurement, the first tag field
+// is used as index (if there is no one the index is used as tag value)
+func (c *converter) telegrafTable(t *telegrafSnmpTable) *config.MeasurementCfg {
+	name := t.Name
+	if len(name) == 0 {
+		name = convertID("table", t.Oid)
+	}
+	meas := &config.MeasurementCfg{
+		ID:          convertID(name),
+		Name:        name,
+		GetMode:     "indexed",
+		Description: "imported from telegraf inputs.snmp table " + t.Oid,
+	}
+	if len(t.InheritTags) > 0 {
+		c.warnf("measurement %s: inherit_tags are not supported", meas.ID)
+	}
+	var firstOID string
+	for i := range t.Fields {
+		f := &t.Fields[i]
+		m, err := c.telegrafMetric(meas.ID, f)
+		if err != nil {
+			c.warnf("measurement %s: field %s skipped: %s", meas.ID, f.Name, err)
+			continue
+		}
+		if m.IsTag && len(meas.IndexOID) == 0 {
+			meas.IndexOID = m.BaseOID
+			meas.IndexTag = m.FieldName
+			continue
+		}
+		if len(firstOID) == 0 {
+			firstOID = m.BaseOID
+		}
+		c.add("snmpmetriccfg", m.ID, m)
+		meas.Fields = append(meas.Fields, config.MeasurementFieldReport{ID: m.ID, Report: 1})
+	}
+	if len(meas.IndexOID) == 0 && len(meas.Fields) > 0 {
+		// telegraf index_as_tag
+		meas.IndexOID = firstOID
+		meas.IndexTag = "index"
+		meas.IndexAsValue = true
+	}
+	return meas
+}
+
+// ConvertTelegrafSnmp translates the [[inputs.snmp]] sections of a telegraf configuration file:
+// top level fields are converted into a "value" measurement named as the input (default "snmp"),
+// each table into an "indexed" measurement, and each input into a measurement group.
+// Returns the objects to import and the conversion warnings (skipped or partially converted objects)
+func ConvertTelegrafSnmp(data []byte) (*ExportData, []string, error) {
+	cfg := telegrafConfig{}
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		return nil, nil, fmt.Errorf("Error on read telegraf configuration: %s", err)
+	}
+	if len(cfg.Inputs.Snmp) == 0 {
+		return nil, nil, fmt.Errorf("No [[inputs.snmp]] sections found in the telegraf configuration")
+	}
+	c := newConverter()
+	for i := range cfg.Inputs.Snmp {
+		in := &cfg.Inputs.Snmp[i]
+		name := in.Name
+		if len(name) == 0 {
+			name = "snmp"
+		}
+		group := &config.MGroupsCfg{
+			ID:          convertID("telegraf", name, strconv.Itoa(i+1)),
+			Description: "imported from telegraf inputs.snmp " + name,
+		}
+		meas := &config.MeasurementCfg{
+			ID:          convertID(name),
+			Name:        name,
+			GetMode:     "value",
+			Description: "imported from telegraf inputs.snmp fields",
+		}
+		for j := range in.Fields {
+			f := &in.Fields[j]
+			m, err := c.telegrafMetric(meas.ID, f)
+			if err != nil {
+				c.warnf("measurement %s: field %s skipped: %s", meas.ID, f.Name, err)
+				continue
+			}
+			c.add("snmpmetriccfg", m.ID, m)
+			meas.Fields = append(meas.Fields, config.MeasurementFieldReport{ID: m.ID, Report: 1})
+		}
+		if len(in.Fields) > 0 && c.addMeasurement(meas) {
+			group.Measurements = append(group.Measurements, meas.ID)
+		}
+		for j := range in.Tables {
+			if tm := c.telegrafTable(&in.Tables[j]); c.addMeasurement(tm) {
+				group.Measurements = append(group.Measurements, tm.ID)
+			}
+		}
+		if len(group.Measurements) > 0 {
+			c.add("measgroupcfg", group.ID, group)
+		}
+	}
+	return c.export(&ExportInfo{FileName: "telegraf.conf", Description: "imported from telegraf inputs.snmp"})
+}
@@ -13,6 +13,7 @@ func (m *Measurement) GetInfluxPoint(hostTags map[string]string) (int64, int64,
 	var measSent int64
 	var measError int64
 	var ptarray []*client.Point
+	var lastValues []*LastValueRow
 
 	switch m.cfg.GetMode {
 	case "value":
@@ -47,6 +48,7 @@ func (m *Measurement) GetInfluxPoint(hostTags map[string]string) (int64, int64,
 			measSent++
 			k.Valid = true
 		}
+		lastValues = append(lastValues, newLastValueRow("0", k, Tags, Fields, t, err == nil))
 
 	case "indexed", "indexed_it":
 		var t time.Time
@@ -83,9 +85,12 @@ func (m *Measurement) GetInfluxPoint(hostTags map[string]string) (int64, int64,
 				measSent++
 				vIdx.Valid = true
 			}
+			lastValues = append(lastValues, newLastValueRow(idx, vIdx, Tags, Fields, t, err == nil))
 		}
 
 	}
+	m.Stats.Points = measSent
+	m.lastValues = lastValues
 
 	return metSent, metError, measSent, measError, ptarray
 
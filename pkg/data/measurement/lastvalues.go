@@ -0,0 +1,57 @@
+package measurement
+
+import (
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/data/metric"
+)
+
+// LastValue is the last gathered value of a measurement field
+type LastValue struct {
+	Value interface{}
+	Time  time.Time
+	Valid bool // false if the value has not been updated on the last gather
+}
+
+// LastValueRow is the last point built for a measurement index ( "0" on value measurements )
+type LastValueRow struct {
+	Index  string
+	Tags   map[string]string
+	Time   time.Time
+	Valid  bool // false if the point could not be sent to the output
+	Fields map[string]*LastValue
+}
+
+// newLastValueRow gets the row values after the influx point fields and tags
+// have been built from the row metrics, metrics not sent are also added with
+// its validity flag
+func newLastValueRow(idx string, row *MetricRow, tags map[string]string, fields map[string]interface{}, t time.Time, valid bool) *LastValueRow {
+	lv := &LastValueRow{
+		Index:  idx,
+		Tags:   make(map[string]string, len(tags)),
+		Time:   t,
+		Valid:  valid,
+		Fields: make(map[string]*LastValue, len(fields)),
+	}
+	for k, v := range tags {
+		lv.Tags[k] = v
+	}
+	for _, mtr := range row.Data {
+		if mtr.Report == metric.NeverReport || mtr.IsTag() || mtr.GetDataSrcType() == "MULTISTRINGPARSER" {
+			continue
+		}
+		lv.Fields[mtr.GetFieldName()] = &LastValue{Value: mtr.CookedValue, Time: mtr.CurTime, Valid: mtr.Valid && mtr.CookedValue != nil}
+	}
+	// fields without its own metric ( multistring parsers )
+	for k, v := range fields {
+		if _, ok := lv.Fields[k]; !ok {
+			lv.Fields[k] = &LastValue{Value: v, Time: t, Valid: true}
+		}
+	}
+	return lv
+}
+
+// GetLastValues returns the rows built on the last GetInfluxPoint call
+func (m *Measurement) GetLastValues() []*LastValueRow {
+	return m.lastValues
+}
@@ -0,0 +1,91 @@
+package measurement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/metric"
+	"github.com/toni-moreno/snmpcollector/pkg/mock"
+)
+
+func TestMeasurementLastValues(t *testing.T) {
+	l := logrus.New()
+	mock.SetLogger(l)
+	config.SetLogger(l)
+
+	s := &mock.SnmpServer{
+		Listen: "127.0.0.1:1163",
+		Want: []gosnmp.SnmpPDU{
+			{Name: ".1.1.1", Type: gosnmp.Integer, Value: int(51)},
+			{Name: ".1.1.2", Type: gosnmp.Integer, Value: int(52)},
+			{Name: ".1.2.1", Type: gosnmp.OctetString, Value: "eth1"},
+			{Name: ".1.2.2", Type: gosnmp.OctetString, Value: "eth2"},
+			{Name: ".1.3.1", Type: gosnmp.Integer, Value: int(1)},
+			{Name: ".1.3.2", Type: gosnmp.Integer, Value: int(2)},
+		},
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("error on start snmp mock server: %s", err)
+	}
+	defer s.Stop()
+
+	cli := &gosnmp.GoSNMP{Target: "127.0.0.1", Port: 1163, Version: gosnmp.Version2c, Community: "test1", Timeout: 5 * time.Second, Logger: l}
+	if err := cli.Connect(); err != nil {
+		t.Fatalf("Connect() err: %v", err)
+	}
+	defer cli.Conn.Close()
+
+	metrics := map[string]*config.SnmpMetricCfg{
+		"value_input":  {ID: "value_input", FieldName: "input", BaseOID: ".1.1", DataSrcType: "Integer32", Conversion: 1},
+		"value_hidden": {ID: "value_hidden", FieldName: "hidden", BaseOID: ".1.3", DataSrcType: "Integer32", Conversion: 1},
+	}
+	vars := map[string]interface{}{}
+	cfg := &config.MeasurementCfg{
+		ID:       "interfaces_data",
+		Name:     "interfaces",
+		GetMode:  "indexed",
+		IndexOID: ".1.2",
+		IndexTag: "portName",
+		Fields: []config.MeasurementFieldReport{
+			{ID: "value_input", Report: metric.AlwaysReport},
+			{ID: "value_hidden", Report: metric.NeverReport},
+		},
+	}
+	cfg.Init(&metrics, vars)
+
+	m, err := New(cfg, l, cli, false)
+	if err != nil {
+		t.Fatalf("Can not create measurement %s", err)
+	}
+	if err := ProcessMeasurementFull(m, vars); err != nil {
+		t.Fatalf("Can not process measurement %s", err)
+	}
+	m.GetInfluxPoint(map[string]string{"device": "sw01"})
+
+	rows := make(map[string]*LastValueRow)
+	for _, r := range m.GetLastValues() {
+		rows[r.Index] = r
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	r := rows["eth2"]
+	if r == nil || !r.Valid || r.Tags["device"] != "sw01" || r.Tags["portName"] != "eth2" || r.Time.IsZero() {
+		t.Fatalf("unexpected row %+v", r)
+	}
+	if len(r.Fields) != 1 || r.Fields["input"] == nil || r.Fields["input"].Value != int64(52) || !r.Fields["input"].Valid {
+		t.Errorf("unexpected row fields %+v", r.Fields)
+	}
+
+	// values not updated on the next gather are kept as not valid
+	m.InvalidateMetrics()
+	m.GetInfluxPoint(map[string]string{})
+	for _, r := range m.GetLastValues() {
+		if f := r.Fields["input"]; f == nil || f.Valid {
+			t.Errorf("expected obsolete field on row %+v", r)
+		}
+	}
+}
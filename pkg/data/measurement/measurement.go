@@ -51,8 +51,10 @@ type Measurement struct {
 	log              *logrus.Logger
 	snmpClient       *gosnmp.GoSNMP
 	DisableBulk      bool                                `json:"-"`
+	Stats            MeasStats                           //last gather cycle stats
 	GetData          func() (int64, int64, int64, error) `json:"-"`
 	Walk             func(string, gosnmp.WalkFunc) error `json:"-"`
+	lastValues       []*LastValueRow                     //rows built on the last GetInfluxPoint
 }
 
 //New  creates object with config , log + goSnmp client
@@ -301,6 +303,7 @@ func (m *Measurement) SnmpWalkData() (int64, int64, int64, error) {
 	var gathered int64
 	var processed int64
 	var errors int64
+	var timeouts int64
 
 	setRawData := func(pdu gosnmp.SnmpPDU) error {
 		m.Debugf("DEBUG pdu [%+v] || Value type %T [%x]", pdu, pdu.Value, pdu.Type)
@@ -324,8 +327,12 @@ func (m *Measurement) SnmpWalkData() (int64, int64, int64, error) {
 		if err := m.Walk(v.BaseOID, setRawData); err != nil {
 			m.Errorf("SNMP WALK (%s) for OID (%s) get error: %s\n", m.snmpClient.Target, v.BaseOID, err)
 			errors += int64(m.MetricTable.Len())
+			if isTimeout(err) {
+				timeouts++
+			}
 		}
 	}
+	m.setGatherStats(now, gathered, errors, timeouts)
 
 	return gathered, processed, errors, nil
 }
@@ -449,6 +456,8 @@ func (m *Measurement) SnmpGetData() (int64, int64, int64, error) {
 	now := time.Now()
 	var sent int64
 	var errs int64
+	var pdus int64
+	var timeouts int64
 	l := len(m.snmpOids)
 	for i := 0; i < l; i += snmp.MaxOids {
 		end := i + snmp.MaxOids
@@ -463,8 +472,12 @@ func (m *Measurement) SnmpGetData() (int64, int64, int64, error) {
 			m.Debugf("selected OIDS %+v", m.snmpOids[i:end])
 			m.Errorf("SNMP (%s) for OIDs (%d/%d) get error: %s\n", m.snmpClient.Target, i, end, err)
 			errs++
+			if isTimeout(err) {
+				timeouts++
+			}
 			continue
 		}
+		pdus += int64(len(pkt.Variables))
 
 		for _, pdu := range pkt.Variables {
 			m.Debugf("DEBUG pdu [%+v] || Value type %T [%x] ", pdu, pdu.Value, pdu.Type)
@@ -483,6 +496,7 @@ func (m *Measurement) SnmpGetData() (int64, int64, int64, error) {
 			}
 		}
 	}
+	m.setGatherStats(now, pdus, errs, timeouts)
 
 	return int64(l), sent, errs, nil
 }
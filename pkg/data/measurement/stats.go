@@ -0,0 +1,51 @@
+package measurement
+
+import (
+	"strings"
+	"time"
+)
+
+// MeasStats gather statistics of the last cycle for the measurement
+type MeasStats struct {
+	GatherStartTime  int64   // unix time
+	GatherDuration   float64 // seconds taken by the SNMP walk/get queries
+	PDUs             int64   // SNMP PDUs (variables) received
+	Errors           int64   // SNMP queries or PDUs with errors
+	Timeouts         int64   // SNMP queries with timeout
+	RowsBeforeFilter int     // table rows on the device ( 1 on value measurements )
+	RowsAfterFilter  int     // table rows gathered after applying the filter
+	Points           int64   // points sent to the output
+}
+
+// isTimeout checks if the SNMP query error is a timeout ( the same check done by gosnmp )
+func isTimeout(err error) bool {
+	return strings.Contains(err.Error(), "timeout")
+}
+
+// setGatherStats updates the stats after the SNMP queries
+func (m *Measurement) setGatherStats(start time.Time, pdus int64, errors int64, timeouts int64) {
+	m.Stats.GatherStartTime = start.Unix()
+	m.Stats.GatherDuration = time.Since(start).Seconds()
+	m.Stats.PDUs = pdus
+	m.Stats.Errors = errors
+	m.Stats.Timeouts = timeouts
+	m.Stats.RowsBeforeFilter = len(m.AllIndexedLabels)
+	m.Stats.RowsAfterFilter = len(m.CurIndexedLabels)
+	if m.cfg.GetMode == "value" {
+		m.Stats.RowsBeforeFilter, m.Stats.RowsAfterFilter = 1, 1
+	}
+}
+
+// GetStatsFields returns the last cycle stats as selfmon fields
+func (m *Measurement) GetStatsFields() map[string]interface{} {
+	return map[string]interface{}{
+		"gather_start_time":  m.Stats.GatherStartTime,
+		"gather_duration":    m.Stats.GatherDuration,
+		"snmp_pdus":          m.Stats.PDUs,
+		"snmp_errors":        m.Stats.Errors,
+		"snmp_timeouts":      m.Stats.Timeouts,
+		"rows_before_filter": m.Stats.RowsBeforeFilter,
+		"rows_after_filter":  m.Stats.RowsAfterFilter,
+		"points":             m.Stats.Points,
+	}
+}
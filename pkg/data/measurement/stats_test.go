@@ -0,0 +1,76 @@
+package measurement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/metric"
+	"github.com/toni-moreno/snmpcollector/pkg/mock"
+)
+
+func TestMeasurementStats(t *testing.T) {
+	l := logrus.New()
+	mock.SetLogger(l)
+	config.SetLogger(l)
+
+	s := &mock.SnmpServer{
+		Listen: "127.0.0.1:1162",
+		Want: []gosnmp.SnmpPDU{
+			{Name: ".1.1.1", Type: gosnmp.Integer, Value: int(51)},
+			{Name: ".1.1.2", Type: gosnmp.Integer, Value: int(52)},
+			{Name: ".1.1.3", Type: gosnmp.Integer, Value: int(53)},
+			{Name: ".1.2.1", Type: gosnmp.OctetString, Value: "eth1"},
+			{Name: ".1.2.2", Type: gosnmp.OctetString, Value: "eth2"},
+			{Name: ".1.2.3", Type: gosnmp.OctetString, Value: "eth3"},
+		},
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("error on start snmp mock server: %s", err)
+	}
+	defer s.Stop()
+
+	cli := &gosnmp.GoSNMP{Target: "127.0.0.1", Port: 1162, Version: gosnmp.Version2c, Community: "test1", Timeout: 5 * time.Second, Logger: l}
+	if err := cli.Connect(); err != nil {
+		t.Fatalf("Connect() err: %v", err)
+	}
+	defer cli.Conn.Close()
+
+	metrics := map[string]*config.SnmpMetricCfg{
+		"value_input": {ID: "value_input", FieldName: "input", BaseOID: ".1.1", DataSrcType: "Integer32", Conversion: 1},
+	}
+	vars := map[string]interface{}{}
+	cfg := &config.MeasurementCfg{
+		ID:       "interfaces_data",
+		Name:     "interfaces_data",
+		GetMode:  "indexed",
+		IndexOID: ".1.2",
+		IndexTag: "portName",
+		Fields:   []config.MeasurementFieldReport{{ID: "value_input", Report: metric.AlwaysReport}},
+	}
+	cfg.Init(&metrics, vars)
+
+	m, err := New(cfg, l, cli, false)
+	if err != nil {
+		t.Fatalf("Can not create measurement %s", err)
+	}
+	if err := ProcessMeasurementFull(m, vars); err != nil {
+		t.Fatalf("Can not process measurement %s", err)
+	}
+	m.GetInfluxPoint(map[string]string{})
+
+	want := MeasStats{PDUs: 3, RowsBeforeFilter: 3, RowsAfterFilter: 3, Points: 3}
+	got := m.Stats
+	if got.GatherStartTime == 0 || got.GatherDuration <= 0 {
+		t.Errorf("gather time not set: %+v", got)
+	}
+	got.GatherStartTime, got.GatherDuration = 0, 0
+	if got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+	if f := m.GetStatsFields(); f["points"] != int64(3) || f["rows_after_filter"] != 3 {
+		t.Errorf("unexpected selfmon fields %+v", f)
+	}
+}
@@ -18,8 +18,10 @@ import (
 	"github.com/kelseyhightower/envconfig"
 
 	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/alert"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/bus"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/maint"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/selfmon"
 	"github.com/toni-moreno/snmpcollector/pkg/config"
@@ -34,6 +36,8 @@ var (
 	quit       = make(chan struct{})
 	startTime  = time.Now()
 	getversion bool
+	dbdryrun   bool
+	syncplan   bool
 	httpListen = ":8080"
 	appdir     = os.Getenv("PWD")
 	homeDir    string
@@ -71,6 +75,8 @@ func flags() *flag.FlagSet {
 	f.StringVar(&homeDir, "home", homeDir, "home directory")
 	f.StringVar(&dataDir, "data", dataDir, "Data directory")
 	f.StringVar(&pidFile, "pidfile", pidFile, "path to pid file")
+	f.BoolVar(&dbdryrun, "dbmigrate-dryrun", dbdryrun, "show pending database schema migrations and exit")
+	f.BoolVar(&syncplan, "cfgsync-plan", syncplan, "show the changes to sync the database with the [cfgsync] dir and exit")
 	f.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
 		f.VisitAll(func(flag *flag.Flag) {
@@ -174,8 +180,6 @@ func init() {
 
 	output.SetLogger(log)
 	selfmon.SetLogger(log)
-	//devices needs access to all db loaded data
-	device.SetDBConfig(&agent.DBConfig)
 	device.SetLogDir(logDir)
 
 	measurement.SetConfDir(confDir)
@@ -188,15 +192,90 @@ func init() {
 
 	impexp.SetLogger(log)
 	bus.SetLogger(log)
+	alert.SetLogger(log)
+	maint.SetLogger(log)
 	//
 	log.Infof("Set Default directories : \n   - Exec: %s\n   - Config: %s\n   -Logs: %s\n -Home: %s\n", appdir, confDir, logDir, homeDir)
 }
 
+func dbMigrateDryRun() {
+	dbc := &agent.MainConfig.Database
+	if err := dbc.OpenDB(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error on open database: %s\n", err)
+		os.Exit(1)
+	}
+	current, pending, err := dbc.GetMigrationPlan()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error on get database migration plan: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Database [%s] %s schema version: %d\n", dbc.Type, dbc.Name, current)
+	if len(pending) == 0 {
+		fmt.Println("No pending migrations")
+		os.Exit(0)
+	}
+	fmt.Println("Pending migrations:")
+	for _, m := range pending {
+		fmt.Printf("  %4d: %s\n", m.Version, m.Description)
+	}
+	if dbc.Type == "sqlite3" {
+		fmt.Println("The sqlite database file will be backed up before applying them")
+	}
+	os.Exit(0)
+}
+
+func cfgSyncPlan() {
+	dir := agent.MainConfig.CfgSync.Dir
+	if len(dir) == 0 {
+		fmt.Fprintln(os.Stderr, "Error no configuration sync directory set ([cfgsync] dir)")
+		os.Exit(1)
+	}
+	// dry-run: the database is only opened, without applying migrations
+	dbc := &agent.MainConfig.Database
+	if err := dbc.OpenDB(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error on open database: %s\n", err)
+		os.Exit(1)
+	}
+	current, pending, err := dbc.GetMigrationPlan()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error on get database migration plan: %s\n", err)
+		os.Exit(1)
+	}
+	if len(pending) > 0 {
+		fmt.Fprintf(os.Stderr, "Error database schema version %d has %d pending migrations, they should be applied before planning a configuration sync\n", current, len(pending))
+		os.Exit(1)
+	}
+	impexp.SetDB(dbc)
+	plan, err := impexp.PlanCfgSync(dir, agent.MainConfig.CfgSync.Prune)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error on configuration sync plan: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Configuration directory %s: %d files, %d objects (prune %t)\n", dir, len(plan.Files), plan.NumObjects, plan.Prune)
+	if len(plan.Changes) == 0 {
+		fmt.Println("No changes")
+		os.Exit(0)
+	}
+	for _, c := range plan.Changes {
+		fmt.Printf("  %-8s %s %s\n", c.Status, c.ObjectTypeID, c.ObjectID)
+		for _, d := range c.Diff {
+			fmt.Printf("           %s: %v => %v\n", d.Field, d.Before, d.After)
+		}
+	}
+	os.Exit(0)
+}
+
 func main() {
 
 	defer func() {
 		//errorLog.Close()
 	}()
+	if dbdryrun {
+		dbMigrateDryRun()
+	}
+	if syncplan {
+		cfgSyncPlan()
+	}
 	writePIDFile()
 	//Init BD config
 	c := make(chan os.Signal)
@@ -227,6 +306,16 @@ func main() {
 	agent.MainConfig.Database.InitDB()
 	measurement.SetDB(&agent.MainConfig.Database)
 	impexp.SetDB(&agent.MainConfig.Database)
+	agent.SetPreReloadHook(func() { impexp.AutoSnapshot("reload") })
+	if cs := agent.MainConfig.CfgSync; cs.OnStart && len(cs.Dir) > 0 {
+		plan, err := impexp.PlanCfgSync(cs.Dir, cs.Prune)
+		if err == nil {
+			_, err = impexp.ApplyCfgSync(plan, "cfgsync")
+		}
+		if err != nil {
+			log.Errorf("Error on configuration sync from %s (database not changed): %s", cs.Dir, err)
+		}
+	}
 
 	agent.Start()
 
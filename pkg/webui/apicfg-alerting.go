@@ -0,0 +1,178 @@
+package webui
+
+import (
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPICfgAlerting Alert rules and receivers API REST creator
+func NewAPICfgAlerting(m *macaron.Macaron) error {
+
+	bind := binding.Bind
+
+	m.Group("/api/cfg/alertrule", func() {
+		m.Get("/", reqSignedIn, GetAlertRules)
+		m.Post("/", reqAdmin, bind(config.AlertRuleCfg{}), AddAlertRule)
+		m.Put("/:id", reqAdmin, bind(config.AlertRuleCfg{}), UpdateAlertRule)
+		m.Delete("/:id", reqAdmin, DeleteAlertRule)
+		m.Get("/:id", reqSignedIn, GetAlertRuleByID)
+	})
+
+	m.Group("/api/cfg/alertreceiver", func() {
+		m.Get("/", reqSignedIn, GetAlertReceivers)
+		m.Post("/", reqAdmin, bind(config.AlertReceiverCfg{}), AddAlertReceiver)
+		m.Put("/:id", reqAdmin, bind(config.AlertReceiverCfg{}), UpdateAlertReceiver)
+		m.Delete("/:id", reqAdmin, DeleteAlertReceiver)
+		m.Get("/:id", reqSignedIn, GetAlertReceiverByID)
+		m.Get("/checkondel/:id", reqSignedIn, GetAlertReceiverAffectOnDel)
+	})
+	return nil
+}
+
+// GetAlertRules Return alert rules list to frontend
+func GetAlertRules(ctx *Context) {
+	cfgarray, ok := getCfgObjectPage(ctx, "alertrulecfg")
+	if !ok {
+		return
+	}
+	ctx.JSON(200, cfgarray)
+}
+
+// AddAlertRule Insert new alert rule to the internal BBDD
+func AddAlertRule(ctx *Context, dev config.AlertRuleCfg) {
+	log.Printf("ADDING AlertRule %+v", dev)
+	affected, err := agent.MainConfig.Database.AddAlertRuleCfg(dev)
+	if err != nil {
+		log.Warningf("Error on insert alert rule %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		auditChange(ctx, "alertrulecfg", config.AuditActionAdd, dev.ID, nil)
+		ctx.JSON(200, &dev)
+	}
+}
+
+// UpdateAlertRule Update alert rule
+func UpdateAlertRule(ctx *Context, dev config.AlertRuleCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
+	id := ctx.Params(":id")
+	log.Debugf("Tying to update: %+v", dev)
+	before := auditBefore("alertrulecfg", id)
+	affected, err := agent.MainConfig.Database.UpdateAlertRuleCfg(id, dev)
+	if err != nil {
+		log.Warningf("Error on update alert rule %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		auditChange(ctx, "alertrulecfg", config.AuditActionUpdate, dev.ID, before)
+		ctx.JSON(200, &dev)
+	}
+}
+
+// DeleteAlertRule removes the alert rule
+func DeleteAlertRule(ctx *Context) {
+	id := ctx.Params(":id")
+	log.Debugf("Tying to delete: %+v", id)
+	before := auditBefore("alertrulecfg", id)
+	affected, err := agent.MainConfig.Database.DelAlertRuleCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete alert rule %s  , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		auditChange(ctx, "alertrulecfg", config.AuditActionDelete, id, before)
+		ctx.JSON(200, "deleted")
+	}
+}
+
+// GetAlertRuleByID returns the alert rule
+func GetAlertRuleByID(ctx *Context) {
+	id := ctx.Params(":id")
+	dev, err := agent.MainConfig.Database.GetAlertRuleCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get alert rule %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &dev)
+	}
+}
+
+// GetAlertReceivers Return alert receivers list to frontend
+func GetAlertReceivers(ctx *Context) {
+	cfgarray, ok := getCfgObjectPage(ctx, "alertreceivercfg")
+	if !ok {
+		return
+	}
+	ctx.JSON(200, cfgarray)
+}
+
+// AddAlertReceiver Insert new alert receiver to the internal BBDD
+func AddAlertReceiver(ctx *Context, dev config.AlertReceiverCfg) {
+	log.Printf("ADDING AlertReceiver %+v", dev)
+	affected, err := agent.MainConfig.Database.AddAlertReceiverCfg(dev)
+	if err != nil {
+		log.Warningf("Error on insert alert receiver %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		auditChange(ctx, "alertreceivercfg", config.AuditActionAdd, dev.ID, nil)
+		ctx.JSON(200, &dev)
+	}
+}
+
+// UpdateAlertReceiver Update alert receiver
+func UpdateAlertReceiver(ctx *Context, dev config.AlertReceiverCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
+	id := ctx.Params(":id")
+	log.Debugf("Tying to update: %+v", dev)
+	before := auditBefore("alertreceivercfg", id)
+	affected, err := agent.MainConfig.Database.UpdateAlertReceiverCfg(id, dev)
+	if err != nil {
+		log.Warningf("Error on update alert receiver %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		auditChange(ctx, "alertreceivercfg", config.AuditActionUpdate, dev.ID, before)
+		ctx.JSON(200, &dev)
+	}
+}
+
+// DeleteAlertReceiver removes the alert receiver and its references on the rules
+func DeleteAlertReceiver(ctx *Context) {
+	id := ctx.Params(":id")
+	log.Debugf("Tying to delete: %+v", id)
+	before := auditBefore("alertreceivercfg", id)
+	affected, err := agent.MainConfig.Database.DelAlertReceiverCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete alert receiver %s  , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		auditChange(ctx, "alertreceivercfg", config.AuditActionDelete, id, before)
+		ctx.JSON(200, "deleted")
+	}
+}
+
+// GetAlertReceiverByID returns the alert receiver
+func GetAlertReceiverByID(ctx *Context) {
+	id := ctx.Params(":id")
+	dev, err := agent.MainConfig.Database.GetAlertReceiverCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get alert receiver %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &dev)
+	}
+}
+
+// GetAlertReceiverAffectOnDel returns the rules using the alert receiver
+func GetAlertReceiverAffectOnDel(ctx *Context) {
+	id := ctx.Params(":id")
+	obarray, err := agent.MainConfig.Database.GetAlertReceiverCfgAffectOnDel(id)
+	if err != nil {
+		log.Warningf("Error on get object array for alert receiver %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &obarray)
+	}
+}
@@ -0,0 +1,175 @@
+package webui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// APITokenForm to create a new API token
+type APITokenForm struct {
+	ID          string    `binding:"Required"`
+	Role        string    `binding:"Required;In(viewer,operator,admin)"`
+	Scope       string    // optional object ID or ID prefix ending with "*"
+	Description string    //
+	ExpiresAt   time.Time // zero means never
+}
+
+// APITokenCreated the new token info with its secret (only returned on creation)
+type APITokenCreated struct {
+	Token  *config.APITokenCfg
+	Secret string
+}
+
+// NewAPICfgAPITokens API Tokens REST API creator
+func NewAPICfgAPITokens(m *macaron.Macaron) error {
+
+	bind := binding.Bind
+
+	m.Group("/api/cfg/apitokens", func() {
+		m.Get("/", reqSignedIn, reqUserSession, GetAPITokens)
+		m.Post("/", reqSignedIn, reqUserSession, bind(APITokenForm{}), AddAPIToken)
+		m.Get("/:id", reqSignedIn, reqUserSession, GetAPITokenByID)
+		m.Post("/revoke/:id", reqSignedIn, reqUserSession, RevokeAPIToken)
+		m.Delete("/:id", reqSignedIn, reqUserSession, DeleteAPIToken)
+	})
+
+	return nil
+}
+
+// reqUserSession denies API token requests, tokens can not manage tokens. Tokens
+// are owned by local users, external (ldap, oidc) users can only manage them as admins
+func reqUserSession(ctx *Context) {
+	if ctx.APIToken != nil {
+		ctx.JSON(403, "access forbidden: API tokens can only be managed from a user session")
+		return
+	}
+	if ctx.AuthSource != AuthSourceLocal && ctx.UserRole != config.RoleAdmin {
+		ctx.JSON(403, fmt.Sprintf("access forbidden: API tokens can only be managed by local users, not %s ones", ctx.AuthSource))
+	}
+}
+
+// ownsAPIToken checks if the session user is the token owner, external users with
+// the same name as a local one are not
+func ownsAPIToken(ctx *Context, tok *config.APITokenCfg) bool {
+	return ctx.AuthSource == AuthSourceLocal && tok.Owner == ctx.SignedInUser
+}
+
+// getOwnAPIToken returns the token if the session user is its owner or an admin
+func getOwnAPIToken(ctx *Context, id string) (*config.APITokenCfg, error) {
+	tok, err := agent.MainConfig.Database.GetAPITokenCfgByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !ownsAPIToken(ctx, &tok) && ctx.UserRole != config.RoleAdmin {
+		return nil, fmt.Errorf("Error no values have been returned with this id %s in the API token table", id)
+	}
+	return &tok, nil
+}
+
+// GetAPITokens returns the user tokens (all of them for admins)
+func GetAPITokens(ctx *Context) {
+	var filter *config.Filter
+	if ctx.UserRole != config.RoleAdmin {
+		// only local users here (checked by reqUserSession)
+		filter = config.FilterEq("owner", ctx.SignedInUser)
+	}
+	q, err := listQuery(ctx)
+	if err != nil {
+		listError(ctx, "API tokens", err)
+		return
+	}
+	cfgarray, total, err := agent.MainConfig.Database.GetAPITokenCfgPage(filter, q)
+	if err != nil {
+		listError(ctx, "API tokens", err)
+		return
+	}
+	setTotalCount(ctx, total)
+	ctx.JSON(200, &cfgarray)
+}
+
+// AddAPIToken creates a new token owned by the session user, token role can not
+// be greater than the user one
+func AddAPIToken(ctx *Context, form APITokenForm) {
+	// token role is checked against the owner on each use
+	if ctx.AuthSource != AuthSourceLocal {
+		ctx.JSON(403, fmt.Sprintf("access forbidden: API tokens can only be created by local users, not %s ones", ctx.AuthSource))
+		return
+	}
+	if !config.RoleAllows(ctx.UserRole, form.Role) {
+		ctx.JSON(403, fmt.Sprintf("access forbidden: user role %s can not create %s tokens", ctx.UserRole, form.Role))
+		return
+	}
+	if !form.ExpiresAt.IsZero() && form.ExpiresAt.Before(time.Now()) {
+		ctx.JSON(400, "Token expiration time should be in the future")
+		return
+	}
+	tok := config.APITokenCfg{
+		ID:          form.ID,
+		Role:        form.Role,
+		Scope:       form.Scope,
+		Owner:       ctx.SignedInUser,
+		Description: form.Description,
+		ExpiresAt:   form.ExpiresAt,
+	}
+	secret, err := agent.MainConfig.Database.AddAPITokenCfg(tok)
+	if err != nil {
+		log.Warningf("Error on insert new API token %s, error: %s", form.ID, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	created, err := agent.MainConfig.Database.GetAPITokenCfgByID(form.ID)
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, &APITokenCreated{Token: &created, Secret: secret})
+}
+
+// GetAPITokenByID returns the token info
+func GetAPITokenByID(ctx *Context) {
+	id := ctx.Params(":id")
+	tok, err := getOwnAPIToken(ctx, id)
+	if err != nil {
+		log.Warningf("Error on get API token %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, tok)
+}
+
+// RevokeAPIToken disables the token keeping its info
+func RevokeAPIToken(ctx *Context) {
+	id := ctx.Params(":id")
+	if _, err := getOwnAPIToken(ctx, id); err != nil {
+		ctx.JSON(404, err.Error())
+		return
+	}
+	if _, err := agent.MainConfig.Database.RevokeAPITokenCfg(id); err != nil {
+		log.Warningf("Error on revoke API token %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	log.Infof("API token %s revoked by %s", id, ctx.SignedInUser)
+	ctx.JSON(200, "revoked")
+}
+
+// DeleteAPIToken removes the token
+func DeleteAPIToken(ctx *Context) {
+	id := ctx.Params(":id")
+	if _, err := getOwnAPIToken(ctx, id); err != nil {
+		ctx.JSON(404, err.Error())
+		return
+	}
+	if _, err := agent.MainConfig.Database.DelAPITokenCfg(id); err != nil {
+		log.Warningf("Error on delete API token %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	log.Infof("API token %s deleted by %s", id, ctx.SignedInUser)
+	ctx.JSON(200, "deleted")
+}
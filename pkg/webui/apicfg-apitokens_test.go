@@ -0,0 +1,69 @@
+package webui
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+func TestAPITokensOwnerAuthSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snmpcollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetLogger(log)
+	agent.SetLogger(log)
+	config.SetDirs(dir, dir, dir)
+	agent.MainConfig.Database = config.DatabaseCfg{Type: "sqlite3", Name: "snmpcollector"}
+	agent.MainConfig.Database.InitDB()
+
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Use(func(c *macaron.Context) {
+		ctx := &Context{Context: c}
+		ctx.IsSignedIn, ctx.SignedInUser = true, c.Req.Header.Get("X-Test-User")
+		ctx.UserRole, ctx.AuthSource = c.Req.Header.Get("X-Test-Role"), c.Req.Header.Get("X-Test-Source")
+		c.Map(ctx)
+	})
+	NewAPICfgAPITokens(m)
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+
+	request := func(source string, role string, method string, path string, body string) int {
+		req, _ := http.NewRequest(method, srv.URL+"/api/cfg/apitokens"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", "alice")
+		req.Header.Set("X-Test-Role", role)
+		req.Header.Set("X-Test-Source", source)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := request(AuthSourceLocal, config.RoleOperator, "POST", "/", `{"ID":"alice_token","Role":"viewer"}`); code != 200 {
+		t.Fatalf("local user token creation got status %d", code)
+	}
+	// an external user with the same name as the local owner
+	for _, r := range []struct{ method, path string }{{"GET", "/"}, {"GET", "/alice_token"}, {"POST", "/revoke/alice_token"}, {"DELETE", "/alice_token"}} {
+		if code := request(AuthSourceLDAP, config.RoleOperator, r.method, r.path, ""); code != 403 {
+			t.Errorf("ldap user %s %s got status %d, want 403", r.method, r.path, code)
+		}
+	}
+	if code := request(AuthSourceLocal, config.RoleOperator, "GET", "/alice_token", ""); code != 200 {
+		t.Errorf("local owner get token got status %d", code)
+	}
+	if code := request(AuthSourceOIDC, config.RoleAdmin, "DELETE", "/alice_token", ""); code != 200 {
+		t.Errorf("external admin delete token got status %d", code)
+	}
+}
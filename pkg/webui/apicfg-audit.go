@@ -0,0 +1,105 @@
+package webui
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPICfgAudit Configuration Audit Log API REST creator
+func NewAPICfgAudit(m *macaron.Macaron) error {
+
+	m.Group("/api/cfg/audit", func() {
+		m.Get("/", reqSignedIn, GetAudit)
+		m.Get("/:id", reqSignedIn, GetAuditByID)
+		m.Post("/revert/:id", reqAdmin, RevertAudit)
+	})
+
+	return nil
+}
+
+// auditBefore get the object state before changing it
+func auditBefore(objtype string, id string) interface{} {
+	obj, err := agent.MainConfig.Database.GetCfgObjectByID(objtype, id)
+	if err != nil {
+		log.Warningf("Error on get audit state for %s with id %s: %s", objtype, id, err)
+		return nil
+	}
+	return obj
+}
+
+// auditChange record a successful configuration change done by the session user
+func auditChange(ctx *Context, objtype string, action string, id string, before interface{}) {
+	_, err := agent.MainConfig.Database.AddAuditCfg(ctx.SignedInUser, objtype, action, id, before)
+	if err != nil {
+		log.Warningf("Error on record audit log for %s %s with id %s: %s", action, objtype, id, err)
+	}
+}
+
+// GetAudit Return audit log entries, could be filtered by
+// user, objtype, objid, action, from, to (RFC3339) and limit query params
+func GetAudit(ctx *Context) {
+	q := config.AuditQuery{
+		User:    ctx.Query("user"),
+		ObjType: ctx.Query("objtype"),
+		ObjID:   ctx.Query("objid"),
+		Action:  ctx.Query("action"),
+		Limit:   ctx.QueryInt("limit"),
+	}
+	var err error
+	if from := ctx.Query("from"); len(from) > 0 {
+		if q.From, err = time.Parse(time.RFC3339, from); err != nil {
+			ctx.JSON(400, err.Error())
+			return
+		}
+	}
+	if to := ctx.Query("to"); len(to) > 0 {
+		if q.To, err = time.Parse(time.RFC3339, to); err != nil {
+			ctx.JSON(400, err.Error())
+			return
+		}
+	}
+	audit, err := agent.MainConfig.Database.GetAuditCfgArray(&q)
+	if err != nil {
+		log.Errorf("Error on get audit log :%+s", err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, &audit)
+}
+
+// GetAuditByID Return a single audit log entry
+func GetAuditByID(ctx *Context) {
+	id, err := strconv.ParseInt(ctx.Params(":id"), 10, 64)
+	if err != nil {
+		ctx.JSON(400, err.Error())
+		return
+	}
+	a, err := agent.MainConfig.Database.GetAuditCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get audit log entry %d , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, a)
+}
+
+// RevertAudit undo a single change, returns the new audit log entry for the revert
+func RevertAudit(ctx *Context) {
+	id, err := strconv.ParseInt(ctx.Params(":id"), 10, 64)
+	if err != nil {
+		ctx.JSON(400, err.Error())
+		return
+	}
+	log.Infof("Reverting audited change %d by user %s", id, ctx.SignedInUser)
+	a, err := agent.MainConfig.Database.RevertAuditCfg(id, ctx.SignedInUser)
+	if err != nil {
+		log.Warningf("Error on revert audit log entry %d , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, a)
+}
@@ -0,0 +1,77 @@
+package webui
+
+import (
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/data/impexp"
+	"gopkg.in/macaron.v1"
+)
+
+// CfgSyncResult changes done by a configuration sync and the reload duration (if any)
+type CfgSyncResult struct {
+	Changes        []*impexp.SnapshotObjectDiff
+	Reloaded       bool
+	ReloadDuration time.Duration
+}
+
+// NewAPICfgSync Declarative Configuration Sync API REST creator
+func NewAPICfgSync(m *macaron.Macaron) error {
+
+	m.Group("/api/cfg/sync", func() {
+		m.Get("/plan", reqSignedIn, PlanCfgSync)
+		m.Post("/apply", reqAdmin, ApplyCfgSync)
+	})
+
+	return nil
+}
+
+// cfgSyncPlan get the plan for the configured sync directory
+func cfgSyncPlan(ctx *Context) *impexp.CfgSyncPlan {
+	cs := agent.MainConfig.CfgSync
+	if len(cs.Dir) == 0 {
+		ctx.JSON(404, "Error no configuration sync directory set ([cfgsync] dir)")
+		return nil
+	}
+	plan, err := impexp.PlanCfgSync(cs.Dir, cs.Prune)
+	if err != nil {
+		log.Warningf("Error on configuration sync plan from %s: %s", cs.Dir, err)
+		ctx.JSON(400, err.Error())
+		return nil
+	}
+	return plan
+}
+
+// PlanCfgSync Return the changes needed to sync the database with the configuration directory (dry-run)
+func PlanCfgSync(ctx *Context) {
+	if plan := cfgSyncPlan(ctx); plan != nil {
+		ctx.JSON(200, plan)
+	}
+}
+
+// ApplyCfgSync sync the database with the configuration directory, reload only if something changed
+func ApplyCfgSync(ctx *Context) {
+	if agent.CheckReloadProcess() {
+		ctx.JSON(405, "There is another reload process running.... please wait until finished ")
+		return
+	}
+	plan := cfgSyncPlan(ctx)
+	if plan == nil {
+		return
+	}
+	changes, err := impexp.ApplyCfgSync(plan, ctx.SignedInUser)
+	if err != nil {
+		log.Errorf("Error on configuration sync (all changes rolled back), error: %s", err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	res := &CfgSyncResult{Changes: changes}
+	if len(changes) > 0 {
+		if res.ReloadDuration, err = agent.ReloadConf(); err != nil {
+			ctx.JSON(405, err.Error())
+			return
+		}
+		res.Reloaded = true
+	}
+	ctx.JSON(200, res)
+}
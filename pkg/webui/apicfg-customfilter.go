@@ -14,9 +14,9 @@ func NewAPICfgCustomFilter(m *macaron.Macaron) error {
 
 	m.Group("/api/cfg/customfilter", func() {
 		m.Get("/", reqSignedIn, GetCustomFilter)
-		m.Post("/", reqSignedIn, bind(config.CustomFilterCfg{}), AddCustomFilter)
-		m.Put("/:id", reqSignedIn, bind(config.CustomFilterCfg{}), UpdateCustomFilter)
-		m.Delete("/:id", reqSignedIn, DeleteCustomFilter)
+		m.Post("/", reqAdmin, bind(config.CustomFilterCfg{}), AddCustomFilter)
+		m.Put("/:id", reqAdmin, bind(config.CustomFilterCfg{}), UpdateCustomFilter)
+		m.Delete("/:id", reqAdmin, DeleteCustomFilter)
 		m.Get("/:id", reqSignedIn, GetCustomFilterByID)
 		m.Get("/checkondel/:id", reqSignedIn, GetCustomFiltersAffectOnDel)
 	})
@@ -26,14 +26,12 @@ func NewAPICfgCustomFilter(m *macaron.Macaron) error {
 
 // GetCustomFilter Return measurements groups list to frontend
 func GetCustomFilter(ctx *Context) {
-	cfgarray, err := agent.MainConfig.Database.GetCustomFilterCfgArray("")
-	if err != nil {
-		ctx.JSON(404, err.Error())
-		log.Errorf("Error on get Custom Filter :%+s", err)
+	cfgarray, ok := getCfgObjectPage(ctx, "customfiltercfg")
+	if !ok {
 		return
 	}
-	ctx.JSON(200, &cfgarray)
-	log.Debugf("Getting Measurement Filter %+v", &cfgarray)
+	ctx.JSON(200, cfgarray)
+	log.Debugf("Getting Measurement Filter %+v", cfgarray)
 }
 
 // AddCustomFilter Insert new measurement groups to de internal BBDD --pending--
@@ -44,6 +42,7 @@ func AddCustomFilter(ctx *Context, dev config.CustomFilterCfg) {
 		log.Warningf("Error on insert Measurment Filter %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "customfiltercfg", config.AuditActionAdd, dev.ID, nil)
 		//TODO: review if needed return data  or affected
 		ctx.JSON(200, &dev)
 	}
@@ -51,13 +50,18 @@ func AddCustomFilter(ctx *Context, dev config.CustomFilterCfg) {
 
 // UpdateCustomFilter --pending--
 func UpdateCustomFilter(ctx *Context, dev config.CustomFilterCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
 	id := ctx.Params(":id")
 	log.Debugf("Tying to update: %+v", dev)
+	before := auditBefore("customfiltercfg", id)
 	affected, err := agent.MainConfig.Database.UpdateCustomFilterCfg(id, dev)
 	if err != nil {
 		log.Warningf("Error on update Measurment Filter %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "customfiltercfg", config.AuditActionUpdate, dev.ID, before)
 		//TODO: review if needed return device data
 		ctx.JSON(200, &dev)
 	}
@@ -67,11 +71,13 @@ func UpdateCustomFilter(ctx *Context, dev config.CustomFilterCfg) {
 func DeleteCustomFilter(ctx *Context) {
 	id := ctx.Params(":id")
 	log.Debugf("Tying to delete: %+v", id)
+	before := auditBefore("customfiltercfg", id)
 	affected, err := agent.MainConfig.Database.DelCustomFilterCfg(id)
 	if err != nil {
 		log.Warningf("Error on delete Measurement Filter %s  , affected : %+v , error: %s", id, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "customfiltercfg", config.AuditActionDelete, id, before)
 		ctx.JSON(200, "deleted")
 	}
 }
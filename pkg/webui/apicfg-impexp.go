@@ -34,7 +34,11 @@ func NewAPICfgImportExport(m *macaron.Macaron) error {
 	})
 
 	m.Group("/api/cfg/import", func() {
-		m.Post("/", reqSignedIn, binding.MultipartForm(UploadForm{}), ImportDataFile)
+		m.Post("/", reqAdmin, binding.MultipartForm(UploadForm{}), ImportDataFile)
+		m.Post("/telegraf", reqAdmin, binding.MultipartForm(UploadForm{}), ImportTelegrafFile)
+		m.Post("/snmpexporter", reqAdmin, binding.MultipartForm(UploadForm{}), ImportSnmpExporterFile)
+		m.Post("/preview", reqAdmin, binding.MultipartForm(UploadForm{}), ImportPreviewFile)
+		m.Post("/apply", reqAdmin, binding.Json(ImportApplyForm{}), ImportApply)
 	})
 	return nil
 }
@@ -45,55 +49,152 @@ func NewAPICfgImportExport(m *macaron.Macaron) error {
 
 // ImportCheck import check struct
 type ImportCheck struct {
-	IsOk    bool
-	Message string
-	Data    *impexp.ExportData
+	IsOk     bool
+	Message  string
+	Data     *impexp.ExportData
+	Warnings []string `json:",omitempty"` // conversion warnings (only on foreign configuration imports)
 }
 
-// ImportDataFile import data from uploaded file
-func ImportDataFile(ctx *Context, uf UploadForm) {
+// readUploadedFile returns the uploaded file contents
+func readUploadedFile(ctx *Context, uf UploadForm) ([]byte, bool) {
 	if (UploadForm{}) == uf {
 		log.Error("Error no data in expected struct")
 		ctx.JSON(404, "Error no data in expected struct")
-		return
+		return nil, false
 	}
 	log.Debugf("Uploaded data :%+v", uf)
 	if uf.ExportFile == nil {
 		ctx.JSON(404, "Error no file uploaded struct")
-		return
+		return nil, false
 	}
 	log.Debugf("Uploaded File : %+v", uf)
 	file, err := uf.ExportFile.Open()
 	if err != nil {
 		log.Warningf("Error on Open Uploaded File: %s", err)
 		ctx.JSON(404, err.Error())
-		return
+		return nil, false
 	}
+	defer file.Close()
 	buf := new(bytes.Buffer)
 	buf.ReadFrom(file)
-	s := buf.String()
-	log.Debug("FILE DATA: %s", s)
+	log.Debugf("FILE DATA: %s", buf.String())
+	return buf.Bytes(), true
+}
+
+// importData checks and imports the data with the uploaded form options
+func importData(ctx *Context, uf UploadForm, ImportedData *impexp.ExportData, warnings []string) {
+	log.Debugf("IMPORTED STRUCT %+v", ImportedData)
+
+	a, err := ImportedData.ImportCheck()
+
+	if err != nil && uf.AutoRename == false && uf.OverWrite == false {
+		ctx.JSON(200, &ImportCheck{IsOk: false, Message: err.Error(), Data: a, Warnings: warnings})
+		return
+	}
+	err = ImportedData.Import(uf.OverWrite, uf.AutoRename, ctx.SignedInUser)
+	if err != nil {
+		log.Errorf("Some Error happened on import data: %s", err)
+		ctx.JSON(200, &ImportCheck{IsOk: false, Message: err.Error(), Data: ImportedData, Warnings: warnings})
+		return
+	}
+	ctx.JSON(200, &ImportCheck{IsOk: true, Message: "all objects have been  imported", Data: ImportedData, Warnings: warnings})
+}
+
+// ImportDataFile import data from uploaded file
+func ImportDataFile(ctx *Context, uf UploadForm) {
+	data, ok := readUploadedFile(ctx, uf)
+	if !ok {
+		return
+	}
 	ImportedData := impexp.ExportData{}
-	if err := json.Unmarshal(buf.Bytes(), &ImportedData); err != nil {
+	if err := json.Unmarshal(data, &ImportedData); err != nil {
 		log.Errorf("Error in data to struct (json-unmarshal) procces: %s", err)
 		ctx.JSON(404, err.Error())
 		return
 	}
-	log.Debugf("IMPORTED STRUCT %+v", ImportedData)
+	importData(ctx, uf, &ImportedData, nil)
+}
 
-	a, err := ImportedData.ImportCheck()
+// importConverted import data from an uploaded foreign configuration file
+func importConverted(ctx *Context, uf UploadForm, convert func([]byte) (*impexp.ExportData, []string, error)) {
+	data, ok := readUploadedFile(ctx, uf)
+	if !ok {
+		return
+	}
+	ImportedData, warnings, err := convert(data)
+	if err != nil {
+		log.Errorf("Error on convert uploaded configuration: %s", err)
+		ctx.JSON(200, &ImportCheck{IsOk: false, Message: err.Error(), Warnings: warnings})
+		return
+	}
+	for _, w := range warnings {
+		log.Warnf("Import conversion: %s", w)
+	}
+	importData(ctx, uf, ImportedData, warnings)
+}
 
-	if err != nil && uf.AutoRename == false && uf.OverWrite == false {
-		ctx.JSON(200, &ImportCheck{IsOk: false, Message: err.Error(), Data: a})
+// ImportTelegrafFile import metrics, measurements and groups from a telegraf inputs.snmp configuration file
+func ImportTelegrafFile(ctx *Context, uf UploadForm) {
+	importConverted(ctx, uf, impexp.ConvertTelegrafSnmp)
+}
+
+// ImportSnmpExporterFile import metrics, measurements and groups from snmp_exporter snmp.yml/generator.yml file
+func ImportSnmpExporterFile(ctx *Context, uf UploadForm) {
+	importConverted(ctx, uf, impexp.ConvertSnmpExporter)
+}
+
+// ImportPreviewResult the uploaded data and its import preview
+type ImportPreviewResult struct {
+	Preview *impexp.ImportPreview
+	Data    *impexp.ExportData
+}
+
+// ImportApplyForm the data to import with the strategies chosen for each object
+type ImportApplyForm struct {
+	Data       *impexp.ExportData
+	Strategies []*impexp.ImportStrategy
+}
+
+// ImportApplyResult import result (all or none objects are imported)
+type ImportApplyResult struct {
+	IsOk    bool
+	Message string
+	Objects []*impexp.ImportPreviewObject
+}
+
+// ImportPreviewFile returns the import preview (status, diffs and dependencies) of the uploaded file objects
+func ImportPreviewFile(ctx *Context, uf UploadForm) {
+	data, ok := readUploadedFile(ctx, uf)
+	if !ok {
+		return
+	}
+	ImportedData := impexp.ExportData{}
+	if err := json.Unmarshal(data, &ImportedData); err != nil {
+		log.Errorf("Error in data to struct (json-unmarshal) procces: %s", err)
+		ctx.JSON(404, err.Error())
 		return
 	}
-	err = ImportedData.Import(uf.OverWrite, uf.AutoRename)
+	p, err := impexp.PreviewImport(&ImportedData)
 	if err != nil {
-		log.Errorf("Some Error happened on import data: %s", err)
-		ctx.JSON(200, &ImportCheck{IsOk: false, Message: err.Error(), Data: &ImportedData})
+		log.Warningf("Error on import preview: %s", err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, &ImportPreviewResult{Preview: p, Data: &ImportedData})
+}
+
+// ImportApply imports the data with the chosen strategies in a single transaction
+func ImportApply(ctx *Context, form ImportApplyForm) {
+	if form.Data == nil {
+		ctx.JSON(400, "Error no data to import")
+		return
+	}
+	objs, err := impexp.ApplyImport(form.Data, form.Strategies, ctx.SignedInUser)
+	if err != nil {
+		ctx.JSON(200, &ImportApplyResult{IsOk: false, Message: "Import rolled back: " + err.Error(), Objects: objs})
 		return
 	}
-	ctx.JSON(200, &ImportCheck{IsOk: true, Message: "all objects have been  imported", Data: &ImportedData})
+	ctx.JSON(200, &ImportApplyResult{IsOk: true, Message: "all objects have been imported", Objects: objs})
 }
 
 /****************/
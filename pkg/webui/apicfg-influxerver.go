@@ -17,12 +17,12 @@ func NewAPICfgInfluxServer(m *macaron.Macaron) error {
 
 	m.Group("/api/cfg/influxservers", func() {
 		m.Get("/", reqSignedIn, GetInfluxServer)
-		m.Post("/", reqSignedIn, bind(config.InfluxCfg{}), AddInfluxServer)
-		m.Put("/:id", reqSignedIn, bind(config.InfluxCfg{}), UpdateInfluxServer)
-		m.Delete("/:id", reqSignedIn, DeleteInfluxServer)
+		m.Post("/", reqAdmin, bind(config.InfluxCfg{}), AddInfluxServer)
+		m.Put("/:id", reqAdmin, bind(config.InfluxCfg{}), UpdateInfluxServer)
+		m.Delete("/:id", reqAdmin, DeleteInfluxServer)
 		m.Get("/:id", reqSignedIn, GetInfluxServerByID)
 		m.Get("/checkondel/:id", reqSignedIn, GetInfluxAffectOnDel)
-		m.Post("/ping/", reqSignedIn, bind(config.InfluxCfg{}), PingInfluxServer)
+		m.Post("/ping/", reqOperator, bind(config.InfluxCfg{}), PingInfluxServer)
 	})
 
 	return nil
@@ -30,14 +30,12 @@ func NewAPICfgInfluxServer(m *macaron.Macaron) error {
 
 // GetInfluxServer Return Server Array
 func GetInfluxServer(ctx *Context) {
-	cfgarray, err := agent.MainConfig.Database.GetInfluxCfgArray("")
-	if err != nil {
-		ctx.JSON(404, err.Error())
-		log.Errorf("Error on get Influx db :%+s", err)
+	cfgarray, ok := getCfgObjectPage(ctx, "influxcfg")
+	if !ok {
 		return
 	}
-	ctx.JSON(200, &cfgarray)
-	log.Debugf("Getting DEVICEs %+v", &cfgarray)
+	ctx.JSON(200, cfgarray)
+	log.Debugf("Getting DEVICEs %+v", cfgarray)
 }
 
 // AddInfluxServer Insert new measurement groups to de internal BBDD --pending--
@@ -48,6 +46,7 @@ func AddInfluxServer(ctx *Context, dev config.InfluxCfg) {
 		log.Warningf("Error on insert new Backend %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "influxcfg", config.AuditActionAdd, dev.ID, nil)
 		//TODO: review if needed return data  or affected
 		ctx.JSON(200, &dev)
 	}
@@ -55,12 +54,17 @@ func AddInfluxServer(ctx *Context, dev config.InfluxCfg) {
 
 // UpdateInfluxServer --pending--
 func UpdateInfluxServer(ctx *Context, dev config.InfluxCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
 	id := ctx.Params(":id")
 	log.Debugf("Tying to update: %+v", dev)
+	before := auditBefore("influxcfg", id)
 	affected, err := agent.MainConfig.Database.UpdateInfluxCfg(id, dev)
 	if err != nil {
 		log.Warningf("Error on update Influx db %s  , affected : %+v , error: %s", dev.ID, affected, err)
 	} else {
+		auditChange(ctx, "influxcfg", config.AuditActionUpdate, dev.ID, before)
 		//TODO: review if needed return device data
 		ctx.JSON(200, &dev)
 	}
@@ -70,11 +74,13 @@ func UpdateInfluxServer(ctx *Context, dev config.InfluxCfg) {
 func DeleteInfluxServer(ctx *Context) {
 	id := ctx.Params(":id")
 	log.Debugf("Tying to delete: %+v", id)
+	before := auditBefore("influxcfg", id)
 	affected, err := agent.MainConfig.Database.DelInfluxCfg(id)
 	if err != nil {
 		log.Warningf("Error on delete influx db %s  , affected : %+v , error: %s", id, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "influxcfg", config.AuditActionDelete, id, before)
 		ctx.JSON(200, "deleted")
 	}
 }
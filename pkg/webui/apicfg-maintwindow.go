@@ -0,0 +1,90 @@
+package webui
+
+import (
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPICfgMaintWindow Maintenance windows API REST creator
+func NewAPICfgMaintWindow(m *macaron.Macaron) error {
+
+	bind := binding.Bind
+
+	m.Group("/api/cfg/maintwindow", func() {
+		m.Get("/", reqSignedIn, GetMaintWindows)
+		m.Post("/", reqAdmin, bind(config.MaintWindowCfg{}), AddMaintWindow)
+		m.Put("/:id", reqAdmin, bind(config.MaintWindowCfg{}), UpdateMaintWindow)
+		m.Delete("/:id", reqAdmin, DeleteMaintWindow)
+		m.Get("/:id", reqSignedIn, GetMaintWindowByID)
+	})
+	return nil
+}
+
+// GetMaintWindows Return maintenance windows list to frontend
+func GetMaintWindows(ctx *Context) {
+	cfgarray, ok := getCfgObjectPage(ctx, "maintwindowcfg")
+	if !ok {
+		return
+	}
+	ctx.JSON(200, cfgarray)
+}
+
+// AddMaintWindow Insert new maintenance window to the internal BBDD
+func AddMaintWindow(ctx *Context, dev config.MaintWindowCfg) {
+	log.Printf("ADDING MaintWindow %+v", dev)
+	affected, err := agent.MainConfig.Database.AddMaintWindowCfg(dev)
+	if err != nil {
+		log.Warningf("Error on insert maintenance window %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		auditChange(ctx, "maintwindowcfg", config.AuditActionAdd, dev.ID, nil)
+		ctx.JSON(200, &dev)
+	}
+}
+
+// UpdateMaintWindow Update maintenance window
+func UpdateMaintWindow(ctx *Context, dev config.MaintWindowCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
+	id := ctx.Params(":id")
+	log.Debugf("Tying to update: %+v", dev)
+	before := auditBefore("maintwindowcfg", id)
+	affected, err := agent.MainConfig.Database.UpdateMaintWindowCfg(id, dev)
+	if err != nil {
+		log.Warningf("Error on update maintenance window %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		auditChange(ctx, "maintwindowcfg", config.AuditActionUpdate, dev.ID, before)
+		ctx.JSON(200, &dev)
+	}
+}
+
+// DeleteMaintWindow removes the maintenance window
+func DeleteMaintWindow(ctx *Context) {
+	id := ctx.Params(":id")
+	log.Debugf("Tying to delete: %+v", id)
+	before := auditBefore("maintwindowcfg", id)
+	affected, err := agent.MainConfig.Database.DelMaintWindowCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete maintenance window %s  , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		auditChange(ctx, "maintwindowcfg", config.AuditActionDelete, id, before)
+		ctx.JSON(200, "deleted")
+	}
+}
+
+// GetMaintWindowByID returns the maintenance window
+func GetMaintWindowByID(ctx *Context) {
+	id := ctx.Params(":id")
+	dev, err := agent.MainConfig.Database.GetMaintWindowCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get maintenance window %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &dev)
+	}
+}
@@ -17,9 +17,9 @@ func NewAPICfgMeasFilters(m *macaron.Macaron) error {
 
 	m.Group("/api/cfg/measfilters", func() {
 		m.Get("/", reqSignedIn, GetMeasFilter)
-		m.Post("/", reqSignedIn, bind(config.MeasFilterCfg{}), AddMeasFilter)
-		m.Put("/:id", reqSignedIn, bind(config.MeasFilterCfg{}), UpdateMeasFilter)
-		m.Delete("/:id", reqSignedIn, DeleteMeasFilter)
+		m.Post("/", reqAdmin, bind(config.MeasFilterCfg{}), AddMeasFilter)
+		m.Put("/:id", reqAdmin, bind(config.MeasFilterCfg{}), UpdateMeasFilter)
+		m.Delete("/:id", reqAdmin, DeleteMeasFilter)
 		m.Get("/:id", reqSignedIn, GetMeasFilterByID)
 		m.Get("/checkondel/:id", reqSignedIn, GetMeasFiltersAffectOnDel)
 	})
@@ -33,14 +33,12 @@ func NewAPICfgMeasFilters(m *macaron.Macaron) error {
 
 // GetMeasFilter Return measurements groups list to frontend
 func GetMeasFilter(ctx *Context) {
-	cfgarray, err := agent.MainConfig.Database.GetMeasFilterCfgArray("")
-	if err != nil {
-		ctx.JSON(404, err.Error())
-		log.Errorf("Error on get Measurement Filter :%+s", err)
+	cfgarray, ok := getCfgObjectPage(ctx, "measfiltercfg")
+	if !ok {
 		return
 	}
-	ctx.JSON(200, &cfgarray)
-	log.Debugf("Getting Measurement Filter %+v", &cfgarray)
+	ctx.JSON(200, cfgarray)
+	log.Debugf("Getting Measurement Filter %+v", cfgarray)
 }
 
 // AddMeasFilter Insert new measurement groups to de internal BBDD --pending--
@@ -73,6 +71,7 @@ func AddMeasFilter(ctx *Context, dev config.MeasFilterCfg) {
 		log.Warningf("Error on insert Measurment Filter %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "measfiltercfg", config.AuditActionAdd, dev.ID, nil)
 		//TODO: review if needed return data  or affected
 		ctx.JSON(200, &dev)
 	}
@@ -80,13 +79,18 @@ func AddMeasFilter(ctx *Context, dev config.MeasFilterCfg) {
 
 // UpdateMeasFilter --pending--
 func UpdateMeasFilter(ctx *Context, dev config.MeasFilterCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
 	id := ctx.Params(":id")
 	log.Debugf("Tying to update: %+v", dev)
+	before := auditBefore("measfiltercfg", id)
 	affected, err := agent.MainConfig.Database.UpdateMeasFilterCfg(id, dev)
 	if err != nil {
 		log.Warningf("Error on update Measurment Filter %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "measfiltercfg", config.AuditActionUpdate, dev.ID, before)
 		//TODO: review if needed return device data
 		ctx.JSON(200, &dev)
 	}
@@ -96,6 +100,7 @@ func UpdateMeasFilter(ctx *Context, dev config.MeasFilterCfg) {
 func DeleteMeasFilter(ctx *Context) {
 	id := ctx.Params(":id")
 	log.Debugf("Tying to delete: %+v", id)
+	before := auditBefore("measfiltercfg", id)
 	affected, err := agent.MainConfig.Database.DelMeasFilterCfg(id)
 	if err != nil {
 		log.Warningf("Error on delete Measurement Filter %s  , affected : %+v , error: %s", id, affected, err)
@@ -104,6 +109,7 @@ func DeleteMeasFilter(ctx *Context) {
 		/*MEASUREMENT GROUPS
 		  /****************/
 
+		auditChange(ctx, "measfiltercfg", config.AuditActionDelete, id, before)
 		ctx.JSON(200, "deleted")
 	}
 }
@@ -14,9 +14,9 @@ func NewAPICfgMeasGroup(m *macaron.Macaron) error {
 
 	m.Group("/api/cfg/measgroup", func() {
 		m.Get("/", reqSignedIn, GetMeasGroup)
-		m.Post("/", reqSignedIn, bind(config.MGroupsCfg{}), AddMeasGroup)
-		m.Put("/:id", reqSignedIn, bind(config.MGroupsCfg{}), UpdateMeasGroup)
-		m.Delete("/:id", reqSignedIn, DeleteMeasGroup)
+		m.Post("/", reqAdmin, bind(config.MGroupsCfg{}), AddMeasGroup)
+		m.Put("/:id", reqAdmin, bind(config.MGroupsCfg{}), UpdateMeasGroup)
+		m.Delete("/:id", reqAdmin, DeleteMeasGroup)
 		m.Get("/:id", reqSignedIn, GetMeasGroupByID)
 		m.Get("/checkondel/:id", reqSignedIn, GetMeasGroupsAffectOnDel)
 	})
@@ -26,14 +26,12 @@ func NewAPICfgMeasGroup(m *macaron.Macaron) error {
 
 // GetMeasGroup Return measurements groups list to frontend
 func GetMeasGroup(ctx *Context) {
-	cfgarray, err := agent.MainConfig.Database.GetMGroupsCfgArray("")
-	if err != nil {
-		ctx.JSON(404, err.Error())
-		log.Errorf("Error on get Measurement Group :%+s", err)
+	cfgarray, ok := getCfgObjectPage(ctx, "measgroupcfg")
+	if !ok {
 		return
 	}
-	ctx.JSON(200, &cfgarray)
-	log.Debugf("Getting Meas Group %+v", &cfgarray)
+	ctx.JSON(200, cfgarray)
+	log.Debugf("Getting Meas Group %+v", cfgarray)
 }
 
 // AddMeasGroup Insert new measurement groups to de internal BBDD --pending--
@@ -44,6 +42,7 @@ func AddMeasGroup(ctx *Context, dev config.MGroupsCfg) {
 		log.Warningf("Error on insert Measurement Group %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "measgroupcfg", config.AuditActionAdd, dev.ID, nil)
 		//TODO: review if needed return data  or affected
 		ctx.JSON(200, &dev)
 	}
@@ -51,13 +50,18 @@ func AddMeasGroup(ctx *Context, dev config.MGroupsCfg) {
 
 // UpdateMeasGroup --pending--
 func UpdateMeasGroup(ctx *Context, dev config.MGroupsCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
 	id := ctx.Params(":id")
 	log.Debugf("Tying to update: %+v", dev)
+	before := auditBefore("measgroupcfg", id)
 	affected, err := agent.MainConfig.Database.UpdateMGroupsCfg(id, dev)
 	if err != nil {
 		log.Warningf("Error on update Measurement Group %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "measgroupcfg", config.AuditActionUpdate, dev.ID, before)
 		//TODO: review if needed return device data
 		ctx.JSON(200, &dev)
 	}
@@ -67,11 +71,13 @@ func UpdateMeasGroup(ctx *Context, dev config.MGroupsCfg) {
 func DeleteMeasGroup(ctx *Context) {
 	id := ctx.Params(":id")
 	log.Debugf("Tying to delete: %+v", id)
+	before := auditBefore("measgroupcfg", id)
 	affected, err := agent.MainConfig.Database.DelMGroupsCfg(id)
 	if err != nil {
 		log.Warningf("Error on delete Measurement Group %s  , affected : %+v , error: %s", id, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "measgroupcfg", config.AuditActionDelete, id, before)
 		ctx.JSON(200, "deleted")
 	}
 }
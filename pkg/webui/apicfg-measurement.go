@@ -15,9 +15,9 @@ func NewAPICfgMeasurement(m *macaron.Macaron) error {
 	m.Group("/api/cfg/measurement", func() {
 		m.Get("/", reqSignedIn, GetMeas)
 		m.Get("/type/:type", reqSignedIn, GetMeasByType)
-		m.Post("/", reqSignedIn, bind(config.MeasurementCfg{}), AddMeas)
-		m.Put("/:id", reqSignedIn, bind(config.MeasurementCfg{}), UpdateMeas)
-		m.Delete("/:id", reqSignedIn, DeleteMeas)
+		m.Post("/", reqAdmin, bind(config.MeasurementCfg{}), AddMeas)
+		m.Put("/:id", reqAdmin, bind(config.MeasurementCfg{}), UpdateMeas)
+		m.Delete("/:id", reqAdmin, DeleteMeas)
 		m.Get("/:id", reqSignedIn, GetMeasByID)
 		m.Get("/checkondel/:id", reqSignedIn, GetMeasAffectOnDel)
 	})
@@ -31,20 +31,18 @@ func NewAPICfgMeasurement(m *macaron.Macaron) error {
 
 // GetMeas Return measurements list to frontend
 func GetMeas(ctx *Context) {
-	cfgarray, err := agent.MainConfig.Database.GetMeasurementCfgArray("")
-	if err != nil {
-		ctx.JSON(404, err.Error())
-		log.Errorf("Error on get Influx Measurements :%+s", err)
+	cfgarray, ok := getCfgObjectPage(ctx, "measurementcfg")
+	if !ok {
 		return
 	}
-	ctx.JSON(200, &cfgarray)
-	log.Debugf("Getting Measurements %+v", &cfgarray)
+	ctx.JSON(200, cfgarray)
+	log.Debugf("Getting Measurements %+v", cfgarray)
 }
 
 // GetMeasByType Return measurements list to frontend
 func GetMeasByType(ctx *Context) {
 	t := ctx.Params(":type")
-	cfgarray, err := agent.MainConfig.Database.GetMeasurementCfgArray("getmode like '%" + t + "%'")
+	cfgarray, err := agent.MainConfig.Database.GetMeasurementCfgArray(config.FilterLike("getmode", t))
 	if err != nil {
 		ctx.JSON(404, err.Error())
 		log.Errorf("Error on get Influx Measurements :%+s", err)
@@ -62,6 +60,7 @@ func AddMeas(ctx *Context, dev config.MeasurementCfg) {
 		log.Warningf("Error on insert Measurement %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "measurementcfg", config.AuditActionAdd, dev.ID, nil)
 		//TODO: review if needed return data  or affected
 		ctx.JSON(200, &dev)
 	}
@@ -69,13 +68,18 @@ func AddMeas(ctx *Context, dev config.MeasurementCfg) {
 
 // UpdateMeas --pending--
 func UpdateMeas(ctx *Context, dev config.MeasurementCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
 	id := ctx.Params(":id")
 	log.Debugf("Tying to update: %+v", dev)
+	before := auditBefore("measurementcfg", id)
 	affected, err := agent.MainConfig.Database.UpdateMeasurementCfg(id, dev)
 	if err != nil {
 		log.Warningf("Error on update Measurement %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "measurementcfg", config.AuditActionUpdate, dev.ID, before)
 		//TODO: review if needed return device data
 		ctx.JSON(200, &dev)
 	}
@@ -85,11 +89,13 @@ func UpdateMeas(ctx *Context, dev config.MeasurementCfg) {
 func DeleteMeas(ctx *Context) {
 	id := ctx.Params(":id")
 	log.Debugf("Tying to delete: %+v", id)
+	before := auditBefore("measurementcfg", id)
 	affected, err := agent.MainConfig.Database.DelMeasurementCfg(id)
 	if err != nil {
 		log.Warningf("Error on delete Measurement %s  , affected : %+v , error: %s", id, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "measurementcfg", config.AuditActionDelete, id, before)
 		ctx.JSON(200, "deleted")
 	}
 }
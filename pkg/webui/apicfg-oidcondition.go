@@ -14,9 +14,9 @@ func NewAPICfgOidCondition(m *macaron.Macaron) error {
 
 	m.Group("/api/cfg/oidcondition", func() {
 		m.Get("/", reqSignedIn, GetOidConditions)
-		m.Post("/", reqSignedIn, bind(config.OidConditionCfg{}), AddOidCondition)
-		m.Put("/:id", reqSignedIn, bind(config.OidConditionCfg{}), UpdateOidCondition)
-		m.Delete("/:id", reqSignedIn, DeleteOidCondition)
+		m.Post("/", reqAdmin, bind(config.OidConditionCfg{}), AddOidCondition)
+		m.Put("/:id", reqAdmin, bind(config.OidConditionCfg{}), UpdateOidCondition)
+		m.Delete("/:id", reqAdmin, DeleteOidCondition)
 		m.Get("/:id", reqSignedIn, GetOidConditionByID)
 		m.Get("/checkondel/:id", reqSignedIn, GetOidConditionAffectOnDel)
 	})
@@ -25,14 +25,12 @@ func NewAPICfgOidCondition(m *macaron.Macaron) error {
 
 // GetOidConditions Return metrics list to frontend
 func GetOidConditions(ctx *Context) {
-	cfgarray, err := agent.MainConfig.Database.GetOidConditionCfgArray("")
-	if err != nil {
-		ctx.JSON(404, err.Error())
-		log.Errorf("Error on get OID contition :%+s", err)
+	cfgarray, ok := getCfgObjectPage(ctx, "oidconditioncfg")
+	if !ok {
 		return
 	}
-	ctx.JSON(200, &cfgarray)
-	log.Debugf("Getting OID contitions %+v", &cfgarray)
+	ctx.JSON(200, cfgarray)
+	log.Debugf("Getting OID contitions %+v", cfgarray)
 }
 
 // AddOidCondition Insert new condition to de internal BBDD --pending--
@@ -43,6 +41,7 @@ func AddOidCondition(ctx *Context, dev config.OidConditionCfg) {
 		log.Warningf("Error on insert OID condition %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "oidconditioncfg", config.AuditActionAdd, dev.ID, nil)
 		//TODO: review if needed return data  or affected
 		ctx.JSON(200, &dev)
 	}
@@ -50,13 +49,18 @@ func AddOidCondition(ctx *Context, dev config.OidConditionCfg) {
 
 // UpdateOidCondition Update OID contition
 func UpdateOidCondition(ctx *Context, dev config.OidConditionCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
 	id := ctx.Params(":id")
 	log.Debugf("Tying to update: %+v", dev)
+	before := auditBefore("oidconditioncfg", id)
 	affected, err := agent.MainConfig.Database.UpdateOidConditionCfg(id, dev)
 	if err != nil {
 		log.Warningf("Error on update OID Condition %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "oidconditioncfg", config.AuditActionUpdate, dev.ID, before)
 		//TODO: review if needed return device data
 		ctx.JSON(200, &dev)
 	}
@@ -66,11 +70,13 @@ func UpdateOidCondition(ctx *Context, dev config.OidConditionCfg) {
 func DeleteOidCondition(ctx *Context) {
 	id := ctx.Params(":id")
 	log.Debugf("Tying to delete: %+v", id)
+	before := auditBefore("oidconditioncfg", id)
 	affected, err := agent.MainConfig.Database.DelOidConditionCfg(id)
 	if err != nil {
 		log.Warningf("Error on delete OidCondition %s  , affected : %+v , error: %s", id, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "oidconditioncfg", config.AuditActionDelete, id, before)
 		ctx.JSON(200, "deleted")
 	}
 }
@@ -0,0 +1,153 @@
+package webui
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/data/impexp"
+	"gopkg.in/macaron.v1"
+)
+
+// SnapshotForm data to create a new snapshot
+type SnapshotForm struct {
+	Name        string `binding:"Required"`
+	Description string
+}
+
+// SnapshotRestoreResult changes done by a snapshot restore and the reload duration
+type SnapshotRestoreResult struct {
+	Changes        []*impexp.SnapshotObjectDiff
+	ReloadDuration time.Duration
+}
+
+// NewAPICfgSnapshot Configuration Snapshots API REST creator
+func NewAPICfgSnapshot(m *macaron.Macaron) error {
+
+	bind := binding.Bind
+
+	m.Group("/api/cfg/snapshot", func() {
+		m.Get("/", reqSignedIn, GetSnapshots)
+		m.Post("/", reqAdmin, bind(SnapshotForm{}), AddSnapshot)
+		m.Get("/:id", reqSignedIn, GetSnapshotByID)
+		m.Delete("/:id", reqAdmin, DeleteSnapshot)
+		m.Get("/diff/:id", reqSignedIn, DiffSnapshot)
+		m.Get("/diff/:id/:id2", reqSignedIn, DiffSnapshot)
+		m.Post("/restore/:id", reqAdmin, RestoreSnapshot)
+	})
+
+	return nil
+}
+
+// snapshotID get snapshot id from a param (0 if empty)
+func snapshotID(ctx *Context, param string) (int64, bool) {
+	p := ctx.Params(param)
+	if len(p) == 0 {
+		return 0, true
+	}
+	id, err := strconv.ParseInt(p, 10, 64)
+	if err != nil {
+		ctx.JSON(400, err.Error())
+		return 0, false
+	}
+	return id, true
+}
+
+// GetSnapshots Return snapshot list (without data)
+func GetSnapshots(ctx *Context) {
+	snaps, err := agent.MainConfig.Database.GetSnapshotCfgArray(nil)
+	if err != nil {
+		log.Errorf("Error on get snapshots :%+s", err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, &snaps)
+}
+
+// AddSnapshot take a new snapshot of the current configuration
+func AddSnapshot(ctx *Context, sf SnapshotForm) {
+	log.Printf("ADDING configuration snapshot %s by user %s", sf.Name, ctx.SignedInUser)
+	s, err := impexp.TakeSnapshot(sf.Name, sf.Description, ctx.SignedInUser, false)
+	if err != nil {
+		log.Warningf("Error on take snapshot %s , error: %s", sf.Name, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, s)
+}
+
+// GetSnapshotByID Return a snapshot with its exported configuration
+func GetSnapshotByID(ctx *Context) {
+	id, ok := snapshotID(ctx, ":id")
+	if !ok {
+		return
+	}
+	s, err := agent.MainConfig.Database.GetSnapshotCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get snapshot %d , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, s)
+}
+
+// DeleteSnapshot remove a snapshot
+func DeleteSnapshot(ctx *Context) {
+	id, ok := snapshotID(ctx, ":id")
+	if !ok {
+		return
+	}
+	log.Debugf("Trying to delete snapshot: %d", id)
+	affected, err := agent.MainConfig.Database.DelSnapshotCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete snapshot %d , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, "deleted")
+}
+
+// DiffSnapshot Return changes from snapshot :id to snapshot :id2 (or to the current configuration if not set)
+func DiffSnapshot(ctx *Context) {
+	id, ok := snapshotID(ctx, ":id")
+	if !ok {
+		return
+	}
+	id2, ok := snapshotID(ctx, ":id2")
+	if !ok {
+		return
+	}
+	diff, err := impexp.DiffSnapshots(id, id2)
+	if err != nil {
+		log.Warningf("Error on diff snapshot %d with %d , error: %s", id, id2, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, &diff)
+}
+
+// RestoreSnapshot set the configuration as it was on the snapshot and reload it
+func RestoreSnapshot(ctx *Context) {
+	id, ok := snapshotID(ctx, ":id")
+	if !ok {
+		return
+	}
+	if agent.CheckReloadProcess() {
+		ctx.JSON(405, "There is another reload process running.... please wait until finished ")
+		return
+	}
+	log.Infof("Restoring configuration snapshot %d by user %s", id, ctx.SignedInUser)
+	changes, err := impexp.RestoreSnapshot(id, ctx.SignedInUser)
+	if err != nil {
+		log.Errorf("Error on restore snapshot %d (all changes rolled back), error: %s", id, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	d, err := agent.ReloadConf()
+	if err != nil {
+		ctx.JSON(405, err.Error())
+		return
+	}
+	ctx.JSON(200, &SnapshotRestoreResult{Changes: changes, ReloadDuration: d})
+}
@@ -1,9 +1,13 @@
 package webui
 
 import (
+	"bytes"
+	"mime/multipart"
+
 	"github.com/go-macaron/binding"
 	"github.com/toni-moreno/snmpcollector/pkg/agent"
 	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/impexp"
 	"github.com/toni-moreno/snmpcollector/pkg/data/snmp"
 	"gopkg.in/macaron.v1"
 )
@@ -16,16 +20,20 @@ func NewAPICfgSnmpDevice(m *macaron.Macaron) error {
 	// Data sources
 	m.Group("/api/cfg/snmpdevice", func() {
 		m.Get("/", reqSignedIn, GetSNMPDevices)
-		m.Post("/", reqSignedIn, bind(config.SnmpDeviceCfg{}), AddSNMPDevice)
-		m.Post("/:mode", reqSignedIn, bind(config.SnmpDeviceCfg{}), AddSNMPDevice)
-		m.Put("/:id", reqSignedIn, bind(config.SnmpDeviceCfg{}), UpdateSNMPDevice)
-		m.Put("/:id/:mode", reqSignedIn, bind(config.SnmpDeviceCfg{}), UpdateSNMPDevice)
-		m.Delete("/:id", reqSignedIn, DeleteSNMPDevice)
-		m.Delete("/:id/:mode", reqSignedIn, DeleteSNMPDevice)
+		m.Post("/", reqAdmin, bind(config.SnmpDeviceCfg{}), AddSNMPDevice)
+		m.Post("/:mode", reqAdmin, bind(config.SnmpDeviceCfg{}), AddSNMPDevice)
+		m.Put("/:id", reqAdmin, bind(config.SnmpDeviceCfg{}), UpdateSNMPDevice)
+		m.Put("/:id/:mode", reqAdmin, bind(config.SnmpDeviceCfg{}), UpdateSNMPDevice)
+		m.Delete("/:id", reqAdmin, DeleteSNMPDevice)
+		m.Delete("/:id/:mode", reqAdmin, DeleteSNMPDevice)
 		m.Get("/:id", reqSignedIn, GetSNMPDeviceByID)
 		m.Get("/checkondel/:id", reqSignedIn, GetSNMPDevicesAffectOnDel)
 	})
 
+	m.Group("/api/cfg/bulkdevice", func() {
+		m.Post("/", reqAdmin, binding.MultipartForm(DeviceCSVForm{}), OnboardSNMPDevices)
+	})
+
 	return nil
 }
 
@@ -37,15 +45,13 @@ type DeviceStatMap struct {
 
 // GetSNMPDevices Return snmpdevice list to frontend
 func GetSNMPDevices(ctx *Context) {
-	devcfgarray, err := agent.MainConfig.Database.GetSnmpDeviceCfgArray("")
-	if err != nil {
-		ctx.JSON(404, err.Error())
-		log.Errorf("Error on get Devices :%+s", err)
+	devcfgarray, ok := getCfgObjectPage(ctx, "snmpdevicecfg")
+	if !ok {
 		return
 	}
 
 	dsmap := []*DeviceStatMap{}
-	for _, v := range devcfgarray {
+	for _, v := range devcfgarray.([]*config.SnmpDeviceCfg) {
 		rt := agent.IsDeviceInRuntime(v.ID)
 		dsmap = append(dsmap, &DeviceStatMap{*v, rt})
 	}
@@ -81,8 +87,7 @@ func addDeviceOnline(mode string, id string, dev *config.SnmpDeviceCfg) error {
 	}
 
 	//Next Adding to the Device Runtime
-	agent.AddDeviceInRuntime(dev.ID, dev)
-	return nil
+	return agent.AddDeviceInRuntime(dev.ID, dev)
 }
 
 // AddSNMPDevice Insert new snmpdevice to de internal BBDD --pending--
@@ -104,6 +109,7 @@ func AddSNMPDevice(ctx *Context, dev config.SnmpDeviceCfg) {
 			log.Warningf("Error on insert for device %s  , error: %s", dev.ID, err)
 			ctx.JSON(404, err.Error())
 		} else {
+			auditChange(ctx, "snmpdevicecfg", config.AuditActionAdd, dev.ID, nil)
 			ctx.JSON(200, &dev)
 		}
 	default:
@@ -112,6 +118,7 @@ func AddSNMPDevice(ctx *Context, dev config.SnmpDeviceCfg) {
 			log.Warningf("Error on insert for device %s  , affected : %+v , error: %s", dev.ID, affected, err)
 			ctx.JSON(404, err.Error())
 		} else {
+			auditChange(ctx, "snmpdevicecfg", config.AuditActionAdd, dev.ID, nil)
 			//TODO: review if needed return data  or affected
 			ctx.JSON(200, &dev)
 		}
@@ -121,6 +128,9 @@ func AddSNMPDevice(ctx *Context, dev config.SnmpDeviceCfg) {
 
 // UpdateSNMPDevice --pending--
 func UpdateSNMPDevice(ctx *Context, dev config.SnmpDeviceCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
 	id := ctx.Params(":id")
 	mode := ctx.Params(":mode")
 	log.Printf("UPDATING DEVICE %s in mode(%s)", id, mode)
@@ -148,20 +158,24 @@ func UpdateSNMPDevice(ctx *Context, dev config.SnmpDeviceCfg) {
 			ctx.JSON(404, err.Error())
 			return
 		}
+		before := auditBefore("snmpdevicecfg", id)
 		err = addDeviceOnline("update", id, &dev)
 		if err != nil {
 			log.Warningf("Error on insert for device %s  , error: %s", dev.ID, err)
 			ctx.JSON(404, err.Error())
 		} else {
+			auditChange(ctx, "snmpdevicecfg", config.AuditActionUpdate, dev.ID, before)
 			ctx.JSON(200, &dev)
 		}
 	default:
 		log.Debugf("Tying to update device  %s on  database: %+v", id, dev)
+		before := auditBefore("snmpdevicecfg", id)
 		affected, err := agent.MainConfig.Database.UpdateSnmpDeviceCfg(id, dev)
 		if err != nil {
 			log.Warningf("Error on update for device %s  , affected : %+v , error: %s", dev.ID, affected, err)
 			ctx.JSON(404, err.Error())
 		} else {
+			auditChange(ctx, "snmpdevicecfg", config.AuditActionUpdate, dev.ID, before)
 			//TODO: review if needed return device data
 			ctx.JSON(200, &dev)
 		}
@@ -194,11 +208,13 @@ func DeleteSNMPDevice(ctx *Context) {
 		fallthrough
 	default:
 		log.Debugf("Tying to delete device on database: %s", id)
+		before := auditBefore("snmpdevicecfg", id)
 		affected, err := agent.MainConfig.Database.DelSnmpDeviceCfg(id)
 		if err != nil {
 			log.Warningf("Error on delete1 for device %s  , affected : %+v , error: %s", id, affected, err)
 			ctx.JSON(404, err.Error())
 		} else {
+			auditChange(ctx, "snmpdevicecfg", config.AuditActionDelete, id, before)
 			ctx.JSON(200, "deleted")
 		}
 	}
@@ -228,3 +244,60 @@ func GetSNMPDevicesAffectOnDel(ctx *Context) {
 		ctx.JSON(200, &obarray)
 	}
 }
+
+// DeviceCSVForm bulk device onboarding upload form
+type DeviceCSVForm struct {
+	Template string // default template device for rows without template
+	DryRun   bool
+	CSVFile  *multipart.FileHeader
+}
+
+// DeviceOnboardResult bulk device onboarding result
+type DeviceOnboardResult struct {
+	IsOk          bool
+	Message       string
+	Report        *impexp.DeviceOnboardReport
+	RuntimeErrors []string `json:",omitempty"` // devices added to the database but not to the runtime
+}
+
+// OnboardSNMPDevices creates devices from an uploaded CSV file (host,id,site,tags,credential,template)
+// and adds them to the runtime without reload, on dry run only the validation report is returned
+func OnboardSNMPDevices(ctx *Context, form DeviceCSVForm) {
+	if form.CSVFile == nil {
+		ctx.JSON(400, "Error no CSV file uploaded")
+		return
+	}
+	if !form.DryRun && agent.CheckReloadProcess() {
+		ctx.JSON(405, "There is another reload process running.... please wait until finished ")
+		return
+	}
+	file, err := form.CSVFile.Open()
+	if err != nil {
+		log.Warningf("Error on Open Uploaded File: %s", err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	defer file.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(file)
+	report, err := impexp.OnboardDevices(buf.Bytes(), form.Template, form.DryRun, ctx.SignedInUser)
+	if err != nil {
+		log.Warningf("Error on bulk device onboarding: %s", err)
+		ctx.JSON(200, &DeviceOnboardResult{IsOk: false, Message: err.Error(), Report: report})
+		return
+	}
+	result := &DeviceOnboardResult{IsOk: true, Report: report}
+	if form.DryRun {
+		result.IsOk = report.Invalid == 0
+		result.Message = "dry run: no device has been added"
+		ctx.JSON(200, result)
+		return
+	}
+	for _, row := range report.Rows {
+		if err := agent.AddDeviceInRuntime(row.ID, row.Device); err != nil {
+			result.RuntimeErrors = append(result.RuntimeErrors, row.ID+": "+err.Error())
+		}
+	}
+	result.Message = "all devices have been added"
+	ctx.JSON(200, result)
+}
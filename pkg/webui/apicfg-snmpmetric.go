@@ -14,9 +14,9 @@ func NewAPICfgSnmpMetric(m *macaron.Macaron) error {
 
 	m.Group("/api/cfg/metric", func() {
 		m.Get("/", reqSignedIn, GetMetrics)
-		m.Post("/", reqSignedIn, bind(config.SnmpMetricCfg{}), AddMetric)
-		m.Put("/:id", reqSignedIn, bind(config.SnmpMetricCfg{}), UpdateMetric)
-		m.Delete("/:id", reqSignedIn, DeleteMetric)
+		m.Post("/", reqAdmin, bind(config.SnmpMetricCfg{}), AddMetric)
+		m.Put("/:id", reqAdmin, bind(config.SnmpMetricCfg{}), UpdateMetric)
+		m.Delete("/:id", reqAdmin, DeleteMetric)
 		m.Get("/:id", reqSignedIn, GetMetricByID)
 		m.Get("/checkondel/:id", reqSignedIn, GetMetricsAffectOnDel)
 		m.Post("/convmodes", reqSignedIn, bind(config.SnmpMetricCfg{}), GetConversionModes)
@@ -27,14 +27,12 @@ func NewAPICfgSnmpMetric(m *macaron.Macaron) error {
 
 // GetMetrics Return metrics list to frontend
 func GetMetrics(ctx *Context) {
-	cfgarray, err := agent.MainConfig.Database.GetSnmpMetricCfgArray("")
-	if err != nil {
-		ctx.JSON(404, err.Error())
-		log.Errorf("Error on get Metrics :%+s", err)
+	cfgarray, ok := getCfgObjectPage(ctx, "snmpmetriccfg")
+	if !ok {
 		return
 	}
-	ctx.JSON(200, &cfgarray)
-	log.Debugf("Getting Metrics %+v", &cfgarray)
+	ctx.JSON(200, cfgarray)
+	log.Debugf("Getting Metrics %+v", cfgarray)
 }
 
 // AddMetric Insert new metric to de internal BBDD --pending--
@@ -45,6 +43,7 @@ func AddMetric(ctx *Context, dev config.SnmpMetricCfg) {
 		log.Warningf("Error on insert Metric %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "snmpmetriccfg", config.AuditActionAdd, dev.ID, nil)
 		//TODO: review if needed return data  or affected
 		ctx.JSON(200, &dev)
 	}
@@ -52,13 +51,18 @@ func AddMetric(ctx *Context, dev config.SnmpMetricCfg) {
 
 // UpdateMetric --pending--
 func UpdateMetric(ctx *Context, dev config.SnmpMetricCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
 	id := ctx.Params(":id")
 	log.Debugf("Tying to update: %+v", dev)
+	before := auditBefore("snmpmetriccfg", id)
 	affected, err := agent.MainConfig.Database.UpdateSnmpMetricCfg(id, dev)
 	if err != nil {
 		log.Warningf("Error on update Metric %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "snmpmetriccfg", config.AuditActionUpdate, dev.ID, before)
 		//TODO: review if needed return device data
 		ctx.JSON(200, &dev)
 	}
@@ -68,11 +72,13 @@ func UpdateMetric(ctx *Context, dev config.SnmpMetricCfg) {
 func DeleteMetric(ctx *Context) {
 	id := ctx.Params(":id")
 	log.Debugf("Tying to delete: %+v", id)
+	before := auditBefore("snmpmetriccfg", id)
 	affected, err := agent.MainConfig.Database.DelSnmpMetricCfg(id)
 	if err != nil {
 		log.Warningf("Error on delete Metric %s  , affected : %+v , error: %s", id, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "snmpmetriccfg", config.AuditActionDelete, id, before)
 		ctx.JSON(200, "deleted")
 	}
 }
@@ -0,0 +1,175 @@
+package webui
+
+import (
+	"fmt"
+
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// UserInfo the signed in user information
+type UserInfo struct {
+	ID         string
+	Role       string
+	FullName   string
+	Email      string
+	Builtin    bool   // admin user from the config file
+	AuthSource string // local, ldap, oidc or apitoken
+}
+
+// PasswordForm to change the signed in user password
+type PasswordForm struct {
+	Password    string `binding:"Required"`
+	NewPassword string `binding:"Required"`
+}
+
+// NewAPICfgUsers Web UI Users API REST creator
+func NewAPICfgUsers(m *macaron.Macaron) error {
+
+	bind := binding.Bind
+
+	m.Group("/api/cfg/users", func() {
+		m.Get("/", reqAdmin, reqUserSession, GetUsers)
+		m.Post("/", reqAdmin, reqUserSession, bind(config.UserCfg{}), AddUser)
+		m.Put("/:id", reqAdmin, reqUserSession, bind(config.UserCfg{}), UpdateUser)
+		m.Delete("/:id", reqAdmin, reqUserSession, DeleteUser)
+		m.Get("/:id", reqAdmin, reqUserSession, GetUserByID)
+	})
+
+	m.Group("/api/user", func() {
+		m.Get("/", reqSignedIn, GetSignedInUser)
+		m.Put("/password", reqSignedIn, reqUserSession, bind(PasswordForm{}), ChangeSignedInUserPassword)
+	})
+
+	return nil
+}
+
+// GetUsers Return users array (without passwords)
+func GetUsers(ctx *Context) {
+	q, err := listQuery(ctx)
+	if err != nil {
+		listError(ctx, "Users", err)
+		return
+	}
+	cfgarray, total, err := agent.MainConfig.Database.GetUserCfgPage(q)
+	if err != nil {
+		listError(ctx, "Users", err)
+		return
+	}
+	setTotalCount(ctx, total)
+	for _, u := range cfgarray {
+		u.Password = ""
+	}
+	ctx.JSON(200, &cfgarray)
+}
+
+// AddUser Insert new user into the database
+func AddUser(ctx *Context, dev config.UserCfg) {
+	log.Printf("ADDING User %s with role %s", dev.ID, dev.Role)
+	if dev.ID == confHTTP.AdminUser {
+		ctx.JSON(400, fmt.Sprintf("User %s is reserved for the config file admin user", dev.ID))
+		return
+	}
+	affected, err := agent.MainConfig.Database.AddUserCfg(dev)
+	if err != nil {
+		log.Warningf("Error on insert new User %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	log.Infof("User %s added by %s", dev.ID, ctx.SignedInUser)
+	dev.Password = ""
+	ctx.JSON(200, &dev)
+}
+
+// UpdateUser updates user data, password is only changed if set
+func UpdateUser(ctx *Context, dev config.UserCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
+	id := ctx.Params(":id")
+	if dev.ID == confHTTP.AdminUser {
+		ctx.JSON(400, fmt.Sprintf("User %s is reserved for the config file admin user", dev.ID))
+		return
+	}
+	affected, err := agent.MainConfig.Database.UpdateUserCfg(id, dev)
+	if err != nil {
+		log.Warningf("Error on update User %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	log.Infof("User %s updated by %s", id, ctx.SignedInUser)
+	dev.Password = ""
+	ctx.JSON(200, &dev)
+}
+
+// DeleteUser removes the user, its open sessions are not valid anymore
+func DeleteUser(ctx *Context) {
+	id := ctx.Params(":id")
+	affected, err := agent.MainConfig.Database.DelUserCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete User %s  , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	log.Infof("User %s deleted by %s", id, ctx.SignedInUser)
+	ctx.JSON(200, "deleted")
+}
+
+// GetUserByID returns the user data (without password)
+func GetUserByID(ctx *Context) {
+	id := ctx.Params(":id")
+	dev, err := agent.MainConfig.Database.GetUserCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get User %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	dev.Password = ""
+	ctx.JSON(200, &dev)
+}
+
+// GetSignedInUser returns the signed in user info and role
+func GetSignedInUser(ctx *Context) {
+	info := UserInfo{ID: ctx.SignedInUser, Role: ctx.UserRole, AuthSource: ctx.AuthSource}
+	if ctx.AuthSource != AuthSourceLocal {
+		ctx.JSON(200, &info)
+		return
+	}
+	if ctx.SignedInUser == confHTTP.AdminUser {
+		info.Builtin = true
+		ctx.JSON(200, &info)
+		return
+	}
+	if u, err := agent.MainConfig.Database.GetUserCfgByID(ctx.SignedInUser); err == nil {
+		info.FullName = u.FullName
+		info.Email = u.Email
+	}
+	ctx.JSON(200, &info)
+}
+
+// ChangeSignedInUserPassword changes the password of the signed in user
+func ChangeSignedInUserPassword(ctx *Context, pass PasswordForm) {
+	if ctx.AuthSource != AuthSourceLocal {
+		ctx.JSON(400, fmt.Sprintf("The password of %s users can not be changed here", ctx.AuthSource))
+		return
+	}
+	if ctx.SignedInUser == confHTTP.AdminUser {
+		ctx.JSON(400, "The config file admin user password can only be changed in the config file")
+		return
+	}
+	u, err := agent.MainConfig.Database.CheckUserCfgPassword(ctx.SignedInUser, pass.Password)
+	if err != nil {
+		log.Warningf("Error on change password for User %s: %s", ctx.SignedInUser, err)
+		ctx.JSON(400, "ERROR current password not match")
+		return
+	}
+	u.Password = pass.NewPassword
+	if _, err := agent.MainConfig.Database.UpdateUserCfg(u.ID, u); err != nil {
+		ctx.JSON(404, err.Error())
+		return
+	}
+	log.Infof("User %s changed its password", u.ID)
+	ctx.JSON(200, "password changed")
+}
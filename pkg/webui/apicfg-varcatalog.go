@@ -14,9 +14,9 @@ func NewAPICfgVarCatalog(m *macaron.Macaron) error {
 
 	m.Group("/api/cfg/varcatalog", func() {
 		m.Get("/", reqSignedIn, GetVarCatalog)
-		m.Post("/", reqSignedIn, bind(config.VarCatalogCfg{}), AddVarCatalog)
-		m.Put("/:id", reqSignedIn, bind(config.VarCatalogCfg{}), UpdateVarCatalog)
-		m.Delete("/:id", reqSignedIn, DeleteVarCatalog)
+		m.Post("/", reqAdmin, bind(config.VarCatalogCfg{}), AddVarCatalog)
+		m.Put("/:id", reqAdmin, bind(config.VarCatalogCfg{}), UpdateVarCatalog)
+		m.Delete("/:id", reqAdmin, DeleteVarCatalog)
 		m.Get("/:id", reqSignedIn, GetVarCatalogByID)
 		m.Get("/checkondel/:id", reqSignedIn, GetInfluxAffectOnDel)
 	})
@@ -26,14 +26,12 @@ func NewAPICfgVarCatalog(m *macaron.Macaron) error {
 
 // GetVarCatalog Return Server Array
 func GetVarCatalog(ctx *Context) {
-	cfgarray, err := agent.MainConfig.Database.GetVarCatalogCfgArray("")
-	if err != nil {
-		ctx.JSON(404, err.Error())
-		log.Errorf("Error on get VarCatalogiable :%+s", err)
+	cfgarray, ok := getCfgObjectPage(ctx, "varcatalogcfg")
+	if !ok {
 		return
 	}
-	ctx.JSON(200, &cfgarray)
-	log.Debugf("Getting Gloval Variable s %+v", &cfgarray)
+	ctx.JSON(200, cfgarray)
+	log.Debugf("Getting Gloval Variable s %+v", cfgarray)
 }
 
 // AddVarCatalog Insert new global var into the database
@@ -44,6 +42,7 @@ func AddVarCatalog(ctx *Context, dev config.VarCatalogCfg) {
 		log.Warningf("Error on insert new Global Variable %s  , affected : %+v , error: %s", dev.ID, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "varcatalogcfg", config.AuditActionAdd, dev.ID, nil)
 		//TODO: review if needed return data  or affected
 		ctx.JSON(200, &dev)
 	}
@@ -51,12 +50,17 @@ func AddVarCatalog(ctx *Context, dev config.VarCatalogCfg) {
 
 // UpdateVarCatalog --pending--
 func UpdateVarCatalog(ctx *Context, dev config.VarCatalogCfg) {
+	if !tokenIDInScope(ctx, dev.ID) {
+		return
+	}
 	id := ctx.Params(":id")
 	log.Debugf("Tying to update: %+v", dev)
+	before := auditBefore("varcatalogcfg", id)
 	affected, err := agent.MainConfig.Database.UpdateVarCatalogCfg(id, dev)
 	if err != nil {
 		log.Warningf("Error on update Global Variable %s  , affected : %+v , error: %s", dev.ID, affected, err)
 	} else {
+		auditChange(ctx, "varcatalogcfg", config.AuditActionUpdate, dev.ID, before)
 		//TODO: review if needed return device data
 		ctx.JSON(200, &dev)
 	}
@@ -66,11 +70,13 @@ func UpdateVarCatalog(ctx *Context, dev config.VarCatalogCfg) {
 func DeleteVarCatalog(ctx *Context) {
 	id := ctx.Params(":id")
 	log.Debugf("Trying to delete: %+v", id)
+	before := auditBefore("varcatalogcfg", id)
 	affected, err := agent.MainConfig.Database.DelVarCatalogCfg(id)
 	if err != nil {
 		log.Warningf("Error on delete Global Variable %s  , affected : %+v , error: %s", id, affected, err)
 		ctx.JSON(404, err.Error())
 	} else {
+		auditChange(ctx, "varcatalogcfg", config.AuditActionDelete, id, before)
 		ctx.JSON(200, "deleted")
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/go-macaron/binding"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/toni-moreno/snmpcollector/pkg/agent"
 	"github.com/toni-moreno/snmpcollector/pkg/config"
 	"github.com/toni-moreno/snmpcollector/pkg/data/snmp"
@@ -17,19 +18,95 @@ func NewAPIRtAgent(m *macaron.Macaron) error {
 	bind := binding.Bind
 
 	m.Group("/api/rt/agent", func() {
-		m.Get("/reload/", reqSignedIn, AgentReloadConf)
-		m.Post("/snmpconsole/ping/", reqSignedIn, bind(config.SnmpDeviceCfg{}), PingSNMPDevice)
-		m.Post("/snmpconsole/query/:getmode/:obtype/:data", reqSignedIn, bind(config.SnmpDeviceCfg{}), QuerySNMPDevice)
+		m.Get("/reload/", reqOperator, AgentReloadConf)
+		m.Post("/snmpconsole/ping/", reqOperator, bind(config.SnmpDeviceCfg{}), PingSNMPDevice)
+		m.Post("/snmpconsole/query/:getmode/:obtype/:data", reqOperator, bind(config.SnmpDeviceCfg{}), QuerySNMPDevice)
 		m.Get("/info/version/", RTGetVersion)
+		m.Get("/info/bus/", reqSignedIn, RTGetBusInfo)
 	})
 
+	// runtime events stream ( device state, gathers, outputs and reloads )
+	m.Get("/api/rt/events", RTGetEvents)
+
+	// current pending and firing alerts
+	m.Get("/api/rt/alerts", reqSignedIn, RTGetAlerts)
+
+	// maintenance windows state and selected devices
+	m.Get("/api/rt/maintenance", reqSignedIn, RTGetMaintWindows)
+
+	// unauthenticated probes for orchestrators ( kubernetes, load balancers... )
+	m.Get("/healthz", RTGetHealthz)
+	m.Get("/readyz", RTGetReadyz)
+
+	if prom := agent.MainConfig.Prometheus; prom.Enabled {
+		path := prom.Path
+		if len(path) == 0 {
+			path = "/metrics"
+		}
+		handler := promhttp.HandlerFor(agent.GetPrometheusRegistry(), promhttp.HandlerOpts{ErrorLog: log})
+		if prom.RequireAuth {
+			m.Get(path, reqSignedIn, handler.ServeHTTP)
+		} else {
+			m.Get(path, handler.ServeHTTP)
+		}
+		log.Infof("Prometheus metrics endpoint enabled on %s", path)
+	}
+
 	return nil
 }
 
-// AgentReloadConf xx
+// RTGetHealthz liveness probe: 503 while reloading configuration or if the
+// configuration database is unreachable
+func RTGetHealthz(ctx *Context) {
+	h := agent.GetHealth(&agent.MainConfig.Health)
+	if !h.Live || h.Reloading {
+		if h.Reloading {
+			ctx.Header().Set("Retry-After", "5")
+		}
+		ctx.JSON(503, h)
+		return
+	}
+	ctx.JSON(200, h)
+}
+
+// RTGetReadyz readiness probe: 503 while reloading configuration, with outputs
+// not sending or too full, or with too few devices connected
+func RTGetReadyz(ctx *Context) {
+	h := agent.GetHealth(&agent.MainConfig.Health)
+	if !h.Ready {
+		if h.Reloading {
+			ctx.Header().Set("Retry-After", "5")
+		}
+		ctx.JSON(503, h)
+		return
+	}
+	ctx.JSON(200, h)
+}
+
+// RTGetAlerts returns the current alerts, filtered by the state query param (pending or firing)
+func RTGetAlerts(ctx *Context) {
+	ctx.JSON(200, agent.GetAlerts(ctx.Query("state")))
+}
+
+// RTGetMaintWindows returns the active maintenance windows state ( current or next window ) and the devices they select
+func RTGetMaintWindows(ctx *Context) {
+	ctx.JSON(200, agent.GetMaintWindows())
+}
+
+// RTGetBusInfo returns the internal bus subscribers delivery stats
+func RTGetBusInfo(ctx *Context) {
+	ctx.JSON(200, agent.Bus.SubscriberStats())
+}
+
+// AgentReloadConf reloads the configuration restarting only the changed devices,
+// or all of them with the full=true query param
 func AgentReloadConf(ctx *Context) {
 	log.Info("trying to reload configuration for all devices")
-	time, err := agent.ReloadConf()
+	reload := agent.ReloadConf
+	if ctx.QueryBool("full") {
+		reload = agent.FullReloadConf
+	}
+	time, err := reload()
 	if err != nil {
 		ctx.JSON(405, err.Error())
 		return
@@ -0,0 +1,57 @@
+package webui
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+func TestHealthProbesWhileReloading(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snmpcollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.SetLogger(log)
+	agent.SetLogger(log)
+	config.SetDirs(dir, dir, dir)
+	agent.MainConfig.Database = config.DatabaseCfg{Type: "sqlite3", Name: "snmpcollector"}
+	agent.MainConfig.Database.InitDB()
+
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Use(func(c *macaron.Context) {
+		c.Map(&Context{Context: c})
+	})
+	m.Get("/healthz", RTGetHealthz)
+	m.Get("/readyz", RTGetReadyz)
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+
+	check := func(path string, want int) {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s got status %d, want %d", path, resp.StatusCode, want)
+		}
+		if want == 503 && len(resp.Header.Get("Retry-After")) == 0 {
+			t.Errorf("%s without Retry-After header while reloading", path)
+		}
+	}
+	check("/healthz", 200)
+	check("/readyz", 200)
+
+	agent.CheckAndSetReloadProcess()
+	defer agent.CheckAndUnSetReloadProcess()
+	check("/healthz", 503)
+	check("/readyz", 503)
+}
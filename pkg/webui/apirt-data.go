@@ -0,0 +1,56 @@
+package webui
+
+import (
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/cache"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPIRtData Runtime last gathered values REST API creator
+func NewAPIRtData(m *macaron.Macaron) error {
+
+	m.Group("/api/rt/data", func() {
+		m.Get("/:id", reqSignedIn, RTGetDataList)
+		m.Get("/:id/:measurement", reqSignedIn, RTGetData)
+	})
+
+	return nil
+}
+
+// RTGetDataList returns the device measurements with last values
+func RTGetDataList(ctx *Context) {
+	list, err := agent.LastValues.List(ctx.Params(":id"))
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, list)
+}
+
+// getDataQuery builds the last values query from the request params: "fields"
+// comma separated list, "valid=true" to get only valid values and any other
+// param as tag filter ( "*" suffix to match by prefix )
+func getDataQuery(ctx *Context) *cache.Query {
+	q := &cache.Query{
+		Tags:      make(map[string]string),
+		Fields:    splitQueryList(ctx, "fields"),
+		ValidOnly: ctx.QueryBool("valid"),
+	}
+	for k, v := range ctx.Req.URL.Query() {
+		if k == "fields" || k == "valid" || len(v) == 0 {
+			continue
+		}
+		q.Tags[k] = v[0]
+	}
+	return q
+}
+
+// RTGetData returns the last gathered values of the device measurement ( ID or name )
+func RTGetData(ctx *Context) {
+	e, err := agent.LastValues.Get(ctx.Params(":id"), ctx.Params(":measurement"), getDataQuery(ctx))
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, e)
+}
@@ -16,16 +16,16 @@ func NewAPIRtDevice(m *macaron.Macaron) error {
 	m.Group("/api/rt/device", func() {
 		m.Get("/info/", reqSignedIn, RTGetInfo)
 		m.Get("/info/:id", reqSignedIn, RTGetInfo)
-		m.Put("/status/activate/:id", reqSignedIn, RTActivateDev)
-		m.Put("/status/deactivate/:id", reqSignedIn, RTDeactivateDev)
-		m.Put("/debug/activate/:id", reqSignedIn, RTActSnmpDebugDev)
-		m.Put("/debug/deactivate/:id", reqSignedIn, RTDeactSnmpDebugDev)
-		m.Get("/snmpreset/:id/:mode", reqSignedIn, RTSnmpReset)
-		m.Get("/forcegather/:id", reqSignedIn, RTForceGather)
-		m.Put("/log/setloglevel/:id/:level", reqSignedIn, RTSetLogLevelDev)
+		m.Put("/status/activate/:id", reqOperator, RTActivateDev)
+		m.Put("/status/deactivate/:id", reqOperator, RTDeactivateDev)
+		m.Put("/debug/activate/:id", reqOperator, RTActSnmpDebugDev)
+		m.Put("/debug/deactivate/:id", reqOperator, RTDeactSnmpDebugDev)
+		m.Get("/snmpreset/:id/:mode", reqOperator, RTSnmpReset)
+		m.Get("/forcegather/:id", reqOperator, RTForceGather)
+		m.Put("/log/setloglevel/:id/:level", reqOperator, RTSetLogLevelDev)
 		m.Get("/log/getdevicelog/:id", reqSignedIn, RTGetLogFileDev)
-		m.Get("/filter/forcefltupdate/:id", reqSignedIn, RTForceFltUpdate)
-		m.Get("/snmpmaxrep/:id/:maxrep", reqSignedIn, RTSnmpSetMaxRep)
+		m.Get("/filter/forcefltupdate/:id", reqOperator, RTForceFltUpdate)
+		m.Get("/snmpmaxrep/:id/:maxrep", reqOperator, RTSnmpSetMaxRep)
 	})
 
 	return nil
@@ -186,7 +186,17 @@ func RTGetInfo(ctx *Context) {
 
 		//get only one device info
 	} else {
-		devstats := agent.GetDevStats()
+		q, err := listQuery(ctx)
+		if err != nil {
+			listError(ctx, "runtime devices", err)
+			return
+		}
+		devstats, total, err := pageDevStats(agent.GetDevStats(), q)
+		if err != nil {
+			listError(ctx, "runtime devices", err)
+			return
+		}
+		setTotalCount(ctx, total)
 		ctx.JSON(200, &devstats)
 	}
 	return
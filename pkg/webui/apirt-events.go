@@ -0,0 +1,77 @@
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/bus"
+)
+
+// eventsKeepAlive is the period of the comments sent to keep idle connections open
+const eventsKeepAlive = 15 * time.Second
+
+// splitQueryList returns the comma separated values of the query param
+func splitQueryList(ctx *Context, name string) []string {
+	var list []string
+	for _, v := range strings.Split(ctx.Query(name), ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// RTGetEvents streams the runtime events as Server-Sent Events, optionally
+// filtered by the device and type (topic patterns like "device.*") comma
+// separated query params. Scoped API tokens only get the events of the
+// devices in its scope.
+func RTGetEvents(ctx *Context) {
+	if !ctx.IsSignedIn {
+		accessForbidden(ctx)
+		return
+	}
+	sub := agent.Bus.Subscribe(bus.SubOptions{
+		Name:    "events-" + ctx.SignedInUser + "@" + ctx.RemoteAddr(),
+		Topics:  splitQueryList(ctx, "type"),
+		Devices: splitQueryList(ctx, "device"),
+	})
+	defer sub.Unsubscribe()
+	log.Infof("Runtime events subscription from %s [%s]", ctx.SignedInUser, ctx.Req.URL.RawQuery)
+
+	h := ctx.Resp.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	ctx.Resp.WriteHeader(200)
+	fmt.Fprint(ctx.Resp, ": connected\n\n")
+	ctx.Resp.Flush()
+
+	keepalive := time.NewTicker(eventsKeepAlive)
+	defer keepalive.Stop()
+	var id int64
+	for {
+		select {
+		case <-ctx.Req.Context().Done():
+			log.Infof("Runtime events subscription from %s closed (dropped events %d)", ctx.SignedInUser, sub.Dropped())
+			return
+		case <-keepalive.C:
+			fmt.Fprint(ctx.Resp, ": keepalive\n\n")
+		case e := <-sub.C:
+			if ctx.APIToken != nil && !ctx.APIToken.InScope(e.Device) {
+				continue
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				log.Warnf("Error on marshal runtime event %s: %s", e.Type, err)
+				continue
+			}
+			id++
+			fmt.Fprintf(ctx.Resp, "id: %d\nevent: %s\ndata: %s\n\n", id, e.Type, data)
+		}
+		ctx.Resp.Flush()
+	}
+}
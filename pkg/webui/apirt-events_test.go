@@ -0,0 +1,60 @@
+package webui
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/bus"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+func TestRTGetEvents(t *testing.T) {
+	bus.SetLogger(log)
+	m := macaron.New()
+	m.Use(func(c *macaron.Context) {
+		ctx := &Context{Context: c, IsSignedIn: true, APIToken: &config.APITokenCfg{Scope: "dev*"}}
+		c.Map(ctx)
+	})
+	m.Get("/api/rt/events", RTGetEvents)
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/rt/events?type=gather.done,reload.start")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("got content type %q", ct)
+	}
+	// the subscription is active once the connected comment is received
+	r := bufio.NewReader(resp.Body)
+	if l, _ := r.ReadString('\n'); !strings.HasPrefix(l, ": connected") {
+		t.Fatalf("unexpected first line %q", l)
+	}
+	agent.Bus.Publish(&bus.Event{Type: bus.EventReloadStart})                 // out of token scope
+	agent.Bus.Publish(&bus.Event{Type: bus.EventGatherDone, Device: "other"}) // out of token scope
+	agent.Bus.Publish(&bus.Event{Type: bus.EventFilterChanged, Device: "dev1"})
+	agent.Bus.Publish(&bus.Event{Type: bus.EventGatherDone, Device: "dev1", Time: time.Unix(0, 0).UTC()})
+
+	var lines []string
+	for len(lines) < 3 {
+		l, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l = strings.TrimSpace(l); len(l) > 0 {
+			lines = append(lines, l)
+		}
+	}
+	want := []string{"id: 1", "event: gather.done", `data: {"Time":"1970-01-01T00:00:00Z","Type":"gather.done","Device":"dev1"}`}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got event %q, want %q", lines, want)
+	}
+}
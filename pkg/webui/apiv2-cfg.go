@@ -0,0 +1,168 @@
+package webui
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// apiV2CfgTypes are the configuration object collections by API path
+var apiV2CfgTypes = []struct {
+	path    string
+	objtype string // generic config object type (as in import/export)
+	name    string
+}{
+	{"snmpdevices", "snmpdevicecfg", "SNMP device"},
+	{"influxservers", "influxcfg", "InfluxDB server"},
+	{"measurements", "measurementcfg", "measurement"},
+	{"snmpmetrics", "snmpmetriccfg", "SNMP metric"},
+	{"measgroups", "measgroupcfg", "measurement group"},
+	{"measfilters", "measfiltercfg", "measurement filter"},
+	{"customfilters", "customfiltercfg", "custom filter"},
+	{"oidconditions", "oidconditioncfg", "OID condition"},
+	{"varcatalog", "varcatalogcfg", "catalog variable"},
+	{"alertrules", "alertrulecfg", "alert rule"},
+	{"alertreceivers", "alertreceivercfg", "alert receiver"},
+	{"maintwindows", "maintwindowcfg", "maintenance window"},
+}
+
+// apiV2CfgRoutes returns the CRUD routes of each configuration object type,
+// changes are only applied to the running devices after a reload
+func apiV2CfgRoutes() []*apiV2Route {
+	var routes []*apiV2Route
+	for _, t := range apiV2CfgTypes {
+		obj, _ := config.NewCfgObject(t.objtype)
+		list := reflect.New(reflect.SliceOf(reflect.TypeOf(obj))).Elem().Interface()
+		path := "/config/" + t.path
+		objtype := t.objtype
+		routes = append(routes,
+			&apiV2Route{method: "GET", path: path, tag: "config", summary: fmt.Sprintf("List %s objects", t.name),
+				role: config.RoleViewer, response: list, status: 200, list: true,
+				handler: func(ctx *Context) { apiV2CfgList(ctx, objtype) }},
+			&apiV2Route{method: "POST", path: path, tag: "config", summary: fmt.Sprintf("Create a %s", t.name),
+				role: config.RoleAdmin, request: obj, response: obj, status: 201,
+				handler: func(ctx *Context) { apiV2CfgAdd(ctx, objtype) }},
+			&apiV2Route{method: "GET", path: path + "/:id", tag: "config", summary: fmt.Sprintf("Get a %s", t.name),
+				role: config.RoleViewer, response: obj, status: 200,
+				handler: func(ctx *Context) { apiV2CfgGet(ctx, objtype) }},
+			&apiV2Route{method: "PUT", path: path + "/:id", tag: "config", summary: fmt.Sprintf("Update (or rename) a %s", t.name),
+				role: config.RoleAdmin, request: obj, response: obj, status: 200,
+				handler: func(ctx *Context) { apiV2CfgUpdate(ctx, objtype) }},
+			&apiV2Route{method: "DELETE", path: path + "/:id", tag: "config", summary: fmt.Sprintf("Delete a %s", t.name),
+				role: config.RoleAdmin, status: 204,
+				handler: func(ctx *Context) { apiV2CfgDelete(ctx, objtype) }},
+		)
+	}
+	return routes
+}
+
+// apiV2CfgExists checks if the object exists, sending a not found error if not
+func apiV2CfgExists(ctx *Context, objtype string, id string) bool {
+	if _, err := agent.MainConfig.Database.GetCfgObjectByID(objtype, id); err != nil {
+		apiV2Error(ctx, 404, err.Error())
+		return false
+	}
+	return true
+}
+
+// cfgObjectID returns the ID field of any configuration object pointer
+func cfgObjectID(obj interface{}) string {
+	return reflect.ValueOf(obj).Elem().FieldByName("ID").String()
+}
+
+func apiV2CfgList(ctx *Context, objtype string) {
+	q, ok := apiV2ListQuery(ctx)
+	if !ok {
+		return
+	}
+	cfgarray, total, err := agent.MainConfig.Database.GetCfgObjectPage(objtype, q)
+	if errors.Is(err, config.ErrInvalidListQuery) {
+		apiV2Error(ctx, 400, err.Error())
+		return
+	}
+	if err != nil {
+		log.Errorf("Error on get %s list :%+s", objtype, err)
+		apiV2Error(ctx, 500, err.Error())
+		return
+	}
+	setTotalCount(ctx, total)
+	ctx.JSON(200, cfgarray)
+}
+
+func apiV2CfgGet(ctx *Context, objtype string) {
+	obj, err := agent.MainConfig.Database.GetCfgObjectByID(objtype, ctx.Params(":id"))
+	if err != nil {
+		apiV2Error(ctx, 404, err.Error())
+		return
+	}
+	ctx.JSON(200, obj)
+}
+
+func apiV2CfgAdd(ctx *Context, objtype string) {
+	obj, _ := config.NewCfgObject(objtype)
+	if !apiV2Bind(ctx, obj) {
+		return
+	}
+	id := cfgObjectID(obj)
+	if _, err := agent.MainConfig.Database.GetCfgObjectByID(objtype, id); err == nil {
+		apiV2Error(ctx, 409, fmt.Sprintf("There is already a %s object with id %s", objtype, id))
+		return
+	}
+	if _, err := agent.MainConfig.Database.AddCfgObject(objtype, obj); err != nil {
+		log.Warningf("Error on insert new %s %s, error: %s", objtype, id, err)
+		apiV2Error(ctx, 500, err.Error())
+		return
+	}
+	auditChange(ctx, objtype, config.AuditActionAdd, id, nil)
+	ctx.Header().Set("Location", strings.TrimSuffix(ctx.Req.URL.Path, "/")+"/"+id)
+	ctx.JSON(201, obj)
+}
+
+func apiV2CfgUpdate(ctx *Context, objtype string) {
+	id := ctx.Params(":id")
+	if !apiV2CfgExists(ctx, objtype, id) {
+		return
+	}
+	obj, _ := config.NewCfgObject(objtype)
+	if !apiV2Bind(ctx, obj) {
+		return
+	}
+	newid := cfgObjectID(obj)
+	// objects can not be renamed out of the token scope
+	if !apiV2TokenInScope(ctx, newid) {
+		return
+	}
+	if newid != id {
+		if _, err := agent.MainConfig.Database.GetCfgObjectByID(objtype, newid); err == nil {
+			apiV2Error(ctx, 409, fmt.Sprintf("There is already a %s object with id %s", objtype, newid))
+			return
+		}
+	}
+	before := auditBefore(objtype, id)
+	if _, err := agent.MainConfig.Database.UpdateCfgObject(objtype, id, obj); err != nil {
+		log.Warningf("Error on update %s %s, error: %s", objtype, id, err)
+		apiV2Error(ctx, 500, err.Error())
+		return
+	}
+	auditChange(ctx, objtype, config.AuditActionUpdate, newid, before)
+	ctx.JSON(200, obj)
+}
+
+func apiV2CfgDelete(ctx *Context, objtype string) {
+	id := ctx.Params(":id")
+	if !apiV2CfgExists(ctx, objtype, id) {
+		return
+	}
+	before := auditBefore(objtype, id)
+	if _, err := agent.MainConfig.Database.DelCfgObject(objtype, id); err != nil {
+		log.Warningf("Error on delete %s %s, error: %s", objtype, id, err)
+		apiV2Error(ctx, 500, err.Error())
+		return
+	}
+	auditChange(ctx, objtype, config.AuditActionDelete, id, before)
+	ctx.Status(204)
+}
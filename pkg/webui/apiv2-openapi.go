@@ -0,0 +1,223 @@
+package webui
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+)
+
+// openAPISpec is the generated OpenAPI document, built once on first request
+var (
+	openAPISpec     map[string]interface{}
+	openAPISpecOnce sync.Once
+)
+
+func getAPIv2Spec(ctx *Context) {
+	openAPISpecOnce.Do(func() {
+		openAPISpec = newOpenAPISpec(apiV2Routes())
+	})
+	ctx.JSON(200, openAPISpec)
+}
+
+// openAPIGen builds the OpenAPI schemas of the Go types used on the API
+type openAPIGen struct {
+	schemas map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the OpenAPI schema of t, named structs are added to the components
+func (g *openAPIGen) schema(t reflect.Type) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		if len(t.Name()) == 0 {
+			return g.structSchema(t)
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			g.schemas[t.Name()] = nil // avoid recursion
+			g.schemas[t.Name()] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	// interface{} could be anything
+	return map[string]interface{}{}
+}
+
+// bindingRule returns the argument of a binding rule like "In(a,b)" or "Default(1)"
+var bindingRule = regexp.MustCompile(`^(\w+)(?:\((.*)\))?$`)
+
+// structSchema returns the object schema with the exported struct fields as they
+// are encoded to JSON, required fields and enums are taken from binding tags
+func (g *openAPIGen) structSchema(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+		name := f.Name
+		jsontag := strings.Split(f.Tag.Get("json"), ",")
+		if jsontag[0] == "-" {
+			continue
+		}
+		if len(jsontag[0]) > 0 {
+			name = jsontag[0]
+		}
+		s := g.schema(f.Type)
+		for _, rule := range strings.Split(f.Tag.Get("binding"), ";") {
+			m := bindingRule.FindStringSubmatch(rule)
+			if m == nil {
+				continue
+			}
+			switch m[1] {
+			case "Required":
+				required = append(required, name)
+			case "In":
+				if s["type"] == "string" {
+					var enum []interface{}
+					for _, v := range strings.Split(m[2], ",") {
+						enum = append(enum, v)
+					}
+					s["enum"] = enum
+				}
+			case "Default":
+				switch s["type"] {
+				case "string":
+					s["default"] = m[2]
+				case "integer":
+					if v, err := strconv.Atoi(m[2]); err == nil {
+						s["default"] = v
+					}
+				}
+			}
+		}
+		props[name] = s
+	}
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// pathParam converts macaron ":name" path params to OpenAPI "{name}" ones
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// newOpenAPISpec returns the OpenAPI 3 document of the routes
+func newOpenAPISpec(routes []*apiV2Route) map[string]interface{} {
+	g := &openAPIGen{schemas: make(map[string]interface{})}
+	errResponse := map[string]interface{}{
+		"description": "Error",
+		"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(APIv2Error{}))}},
+	}
+	paths := make(map[string]interface{})
+	for _, r := range routes {
+		path := pathParam.ReplaceAllString(r.path, "{$1}")
+		op := map[string]interface{}{
+			"tags":        []string{r.tag},
+			"summary":     r.summary,
+			"operationId": strings.ToLower(r.method) + strings.Replace(strings.Title(strings.NewReplacer("/", " ", ":", " by ").Replace(r.path)), " ", "", -1),
+		}
+		var params []interface{}
+		for _, m := range pathParam.FindAllStringSubmatch(r.path, -1) {
+			params = append(params, map[string]interface{}{"name": m[1], "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"}})
+		}
+		query := r.query
+		if r.list {
+			query = append(query,
+				apiV2Param{"limit", "integer", "maximum number of objects, all of them if not set"},
+				apiV2Param{"offset", "integer", "number of objects to skip"},
+				apiV2Param{"sort", "string", "comma separated sort fields, \"-\" prefix for descending order"},
+			)
+			op["description"] = "Any other query param is a field filter: text fields contain the value and other fields are equal to it."
+		}
+		for _, q := range query {
+			params = append(params, map[string]interface{}{"name": q.name, "in": "query", "description": q.description, "schema": map[string]interface{}{"type": q.typ}})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if r.request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(r.request))}},
+			}
+		}
+		ok := map[string]interface{}{"description": "OK"}
+		if r.response != nil {
+			ok["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(r.response))}}
+		}
+		if r.list {
+			ok["headers"] = map[string]interface{}{TotalCountHeader: map[string]interface{}{
+				"description": "total count of selected objects",
+				"schema":      map[string]interface{}{"type": "integer"},
+			}}
+		}
+		op["responses"] = map[string]interface{}{fmt.Sprint(r.status): ok, "default": errResponse}
+		if len(r.role) > 0 {
+			op["description"] = strings.TrimSpace(fmt.Sprintf("Required role: %s. %s", r.role, op["description"]))
+		} else {
+			op["security"] = []interface{}{}
+		}
+		item, found := paths[path].(map[string]interface{})
+		if !found {
+			item = make(map[string]interface{})
+			paths[path] = item
+		}
+		item[strings.ToLower(r.method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "snmpcollector API",
+			"version":     agent.Version,
+			"description": "Versioned REST API, errors are always returned as JSON error objects.",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": APIv2Prefix}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "description": "API token"},
+				"cookieAuth": map[string]interface{}{"type": "apiKey", "in": "cookie", "name": "snmpcollector-sess-" + cookie, "description": "web UI session from POST /login"},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"cookieAuth": []string{}},
+		},
+	}
+}
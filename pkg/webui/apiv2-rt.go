@@ -0,0 +1,227 @@
+package webui
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/alert"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/bus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/maint"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// rtRequestTimeout is the default time waiting for the device runtime requests
+const rtRequestTimeout = 60 * time.Second
+
+// getRTRequestTimeout returns the "timeout" query param (in seconds) or the default one
+func getRTRequestTimeout(ctx *Context) time.Duration {
+	if t := ctx.QueryInt("timeout"); t > 0 {
+		return time.Duration(t) * time.Second
+	}
+	return rtRequestTimeout
+}
+
+// rtRequestStatus returns the HTTP status for the device runtime request errors
+func rtRequestStatus(err error) int {
+	if errors.Is(err, bus.ErrTimeout) {
+		return 504
+	}
+	return 409
+}
+
+// APIv2Enabled request body to enable or disable a device runtime feature
+type APIv2Enabled struct {
+	Enabled bool
+}
+
+// APIv2LogLevel request body to change the device log level
+type APIv2LogLevel struct {
+	Level string `binding:"Required;In(panic,fatal,error,warning,info,debug)"`
+}
+
+// APIv2MaxRepetitions request body to change the device SNMP bulk max repetitions
+type APIv2MaxRepetitions struct {
+	MaxRepetitions uint8 `binding:"Required"`
+}
+
+// APIv2SnmpReset request body to reset the device SNMP connection
+type APIv2SnmpReset struct {
+	Mode string `binding:"Required;In(soft,hard)"` // hard also rebuilds the measurements
+}
+
+// APIv2Reload configuration reload result
+type APIv2Reload struct {
+	Full     bool    // all devices restarted, not only the changed ones
+	Duration float64 // seconds
+}
+
+// apiV2RtRoutes returns the agent and running devices routes
+func apiV2RtRoutes() []*apiV2Route {
+	return []*apiV2Route{
+		{method: "GET", path: "/info", tag: "info", summary: "Agent version and instance",
+			response: &agent.RInfo{}, status: 200, handler: apiV2GetInfo},
+		{method: "POST", path: "/runtime/reload", tag: "runtime", summary: "Reload the configuration restarting the changed devices",
+			role: config.RoleOperator, response: &APIv2Reload{}, status: 200, handler: apiV2Reload,
+			query: []apiV2Param{{"full", "boolean", "restart all devices"}}},
+		{method: "GET", path: "/runtime/devices", tag: "runtime", summary: "List running devices stats",
+			role: config.RoleViewer, response: []*device.DevStat{}, status: 200, list: true, handler: apiV2RtList},
+		{method: "GET", path: "/runtime/devices/:id", tag: "runtime", summary: "Get running device info",
+			role: config.RoleViewer, response: map[string]interface{}{}, status: 200, handler: apiV2RtGet},
+		{method: "GET", path: "/runtime/alerts", tag: "runtime", summary: "List the current pending and firing alerts",
+			role: config.RoleViewer, response: []*alert.Alert{}, status: 200, handler: apiV2RtAlerts,
+			query: []apiV2Param{{"state", "string", "pending or firing"}}},
+		{method: "GET", path: "/runtime/maintenance", tag: "runtime", summary: "List the maintenance windows state and selected devices",
+			role: config.RoleViewer, response: []*maint.WindowInfo{}, status: 200, handler: apiV2RtMaintWindows},
+		{method: "GET", path: "/runtime/devices/:id/log", tag: "runtime", summary: "Download the device log file",
+			role: config.RoleViewer, status: 200, handler: apiV2RtLog},
+		apiV2RtAction("PUT", "active", "Activate or deactivate the device gathering", 204, &APIv2Enabled{},
+			func(d *device.SnmpDevice, body interface{}) { d.RTActivate(body.(*APIv2Enabled).Enabled) }),
+		apiV2RtAction("PUT", "snmpdebug", "Enable or disable the device SNMP debug", 204, &APIv2Enabled{},
+			func(d *device.SnmpDevice, body interface{}) { d.RTActSnmpDebug(body.(*APIv2Enabled).Enabled) }),
+		apiV2RtAction("PUT", "loglevel", "Change the device log level", 204, &APIv2LogLevel{},
+			func(d *device.SnmpDevice, body interface{}) { d.RTSetLogLevel(body.(*APIv2LogLevel).Level) }),
+		apiV2RtAction("PUT", "maxrepetitions", "Change the device SNMP bulk max repetitions", 204, &APIv2MaxRepetitions{},
+			func(d *device.SnmpDevice, body interface{}) {
+				d.RTActSnmpMaxRep(body.(*APIv2MaxRepetitions).MaxRepetitions)
+			}),
+		{method: "POST", path: "/runtime/devices/:id/forcegather", tag: "runtime", summary: "Gather the device measurements now and wait until finished",
+			role: config.RoleOperator, response: &device.DevStat{}, status: 200, handler: apiV2RtForceGather,
+			query: []apiV2Param{{"timeout", "integer", "seconds waiting for the gather (default 60), 504 error if exceeded"}}},
+		apiV2RtAction("POST", "snmpreset", "Reset the device SNMP connection", 202, &APIv2SnmpReset{},
+			func(d *device.SnmpDevice, body interface{}) { d.SnmpReset(body.(*APIv2SnmpReset).Mode) }),
+		apiV2RtAction("POST", "filterupdate", "Update the device measurement filters now", 202, nil,
+			func(d *device.SnmpDevice, body interface{}) { d.ForceFltUpdate() }),
+	}
+}
+
+// apiV2RtReloading sends a service unavailable error while reloading
+func apiV2RtReloading(ctx *Context) bool {
+	if agent.CheckReloadProcess() {
+		ctx.Header().Set("Retry-After", "5")
+		apiV2Error(ctx, 503, "There is a reload process running, please wait until finished")
+		return true
+	}
+	return false
+}
+
+// apiV2RtGetDevice gets the running device, on error the response is sent
+func apiV2RtGetDevice(ctx *Context) (*device.SnmpDevice, bool) {
+	if apiV2RtReloading(ctx) {
+		return nil, false
+	}
+	d, err := agent.GetDevice(ctx.Params(":id"))
+	if err != nil {
+		apiV2Error(ctx, 404, err.Error())
+		return nil, false
+	}
+	return d, true
+}
+
+// apiV2RtAction returns an operator route doing the action on the running device
+// with the request body decoded into a new object of the body type (if not nil)
+func apiV2RtAction(method string, action string, summary string, status int, body interface{}, do func(d *device.SnmpDevice, body interface{})) *apiV2Route {
+	return &apiV2Route{method: method, path: "/runtime/devices/:id/" + action, tag: "runtime", summary: summary,
+		role: config.RoleOperator, request: body, status: status,
+		handler: func(ctx *Context) {
+			var req interface{}
+			if body != nil {
+				req = reflect.New(reflect.TypeOf(body).Elem()).Interface()
+				if !apiV2Bind(ctx, req) {
+					return
+				}
+			}
+			d, ok := apiV2RtGetDevice(ctx)
+			if !ok {
+				return
+			}
+			log.Infof("runtime %s on device %s by %s", action, ctx.Params(":id"), ctx.SignedInUser)
+			do(d, req)
+			ctx.Status(status)
+		},
+	}
+}
+
+// apiV2RtForceGather does a device gather returning the device stats after it
+func apiV2RtForceGather(ctx *Context) {
+	d, ok := apiV2RtGetDevice(ctx)
+	if !ok {
+		return
+	}
+	log.Infof("runtime forcegather on device %s by %s", ctx.Params(":id"), ctx.SignedInUser)
+	stats, err := d.ForceGatherWait(getRTRequestTimeout(ctx))
+	if err != nil {
+		apiV2Error(ctx, rtRequestStatus(err), err.Error())
+		return
+	}
+	ctx.JSON(200, stats)
+}
+
+func apiV2RtAlerts(ctx *Context) {
+	ctx.JSON(200, agent.GetAlerts(ctx.Query("state")))
+}
+
+func apiV2RtMaintWindows(ctx *Context) {
+	ctx.JSON(200, agent.GetMaintWindows())
+}
+
+func apiV2GetInfo(ctx *Context) {
+	ctx.JSON(200, agent.GetRInfo())
+}
+
+func apiV2Reload(ctx *Context) {
+	full := ctx.QueryBool("full")
+	reload := agent.ReloadConf
+	if full {
+		reload = agent.FullReloadConf
+	}
+	log.Infof("trying to reload configuration (full: %t) by %s", full, ctx.SignedInUser)
+	d, err := reload()
+	if err != nil {
+		ctx.Header().Set("Retry-After", "5")
+		apiV2Error(ctx, 503, err.Error())
+		return
+	}
+	ctx.JSON(200, &APIv2Reload{Full: full, Duration: d.Seconds()})
+}
+
+func apiV2RtList(ctx *Context) {
+	q, ok := apiV2ListQuery(ctx)
+	if !ok {
+		return
+	}
+	devstats, total, err := pageDevStats(agent.GetDevStats(), q)
+	if errors.Is(err, config.ErrInvalidListQuery) {
+		apiV2Error(ctx, 400, err.Error())
+		return
+	}
+	if err != nil {
+		apiV2Error(ctx, 500, err.Error())
+		return
+	}
+	setTotalCount(ctx, total)
+	ctx.JSON(200, devstats)
+}
+
+func apiV2RtGet(ctx *Context) {
+	if apiV2RtReloading(ctx) {
+		return
+	}
+	json, err := agent.GetDeviceJSONInfo(ctx.Params(":id"))
+	if err != nil {
+		apiV2Error(ctx, 404, err.Error())
+		return
+	}
+	ctx.RawAsJSON(200, json)
+}
+
+func apiV2RtLog(ctx *Context) {
+	d, ok := apiV2RtGetDevice(ctx)
+	if !ok {
+		return
+	}
+	ctx.ServeFile(d.GetLogFilePath(), fmt.Sprintf("%s.log", ctx.Params(":id")))
+}
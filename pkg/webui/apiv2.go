@@ -0,0 +1,142 @@
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// APIv2Prefix is the path prefix of the versioned REST API, the unversioned
+// /api routes (v1) are kept for the web UI
+const APIv2Prefix = "/api/v2"
+
+// APIv2Error is the JSON error object returned by any failed /api/v2 request
+type APIv2Error struct {
+	Code    int      // HTTP status code
+	Status  string   // HTTP status text
+	Message string   //
+	Details []string `json:",omitempty"` // validation errors
+}
+
+// apiV2Error sends the JSON error object
+func apiV2Error(ctx *Context, code int, message string, details ...string) {
+	ctx.JSON(code, &APIv2Error{Code: code, Status: http.StatusText(code), Message: message, Details: details})
+}
+
+// apiV2Param is a documented query param
+type apiV2Param struct {
+	name        string
+	typ         string // OpenAPI type
+	description string
+}
+
+// apiV2Route is a /api/v2 route, the same definition is used to register and
+// to document it in the OpenAPI spec
+type apiV2Route struct {
+	method   string
+	path     string // relative to the prefix, ":name" are path params
+	tag      string
+	summary  string
+	role     string      // required role, empty for public routes
+	request  interface{} // JSON request body type, nil if none
+	response interface{} // JSON success response type, nil if none
+	status   int         // success status code
+	list     bool        // accepts list query params and sets the total count header
+	query    []apiV2Param
+	handler  func(ctx *Context)
+}
+
+// apiV2Routes returns all the /api/v2 routes
+func apiV2Routes() []*apiV2Route {
+	routes := []*apiV2Route{
+		{method: "GET", path: "/openapi.json", tag: "info", summary: "OpenAPI 3 document of this API",
+			response: map[string]interface{}{}, status: 200, handler: getAPIv2Spec},
+	}
+	routes = append(routes, apiV2RtRoutes()...)
+	return append(routes, apiV2CfgRoutes()...)
+}
+
+// NewAPIv2 versioned REST API creator
+func NewAPIv2(m *macaron.Macaron) error {
+	for _, r := range apiV2Routes() {
+		handlers := []macaron.Handler{}
+		if len(r.role) > 0 {
+			handlers = append(handlers, reqAPIv2Role(r.role))
+		}
+		m.Handle(r.method, APIv2Prefix+r.path, append(handlers, r.handler))
+	}
+	// unknown routes inside the API get error objects instead of the web UI
+	m.Any(APIv2Prefix+"/*", func(ctx *Context) {
+		apiV2Error(ctx, 404, fmt.Sprintf("Unknown API route %s %s", ctx.Req.Method, ctx.Req.URL.Path))
+	})
+	return nil
+}
+
+// reqAPIv2Role allows only signed in users (or API tokens in scope) with the
+// permissions of the required role
+func reqAPIv2Role(role string) macaron.Handler {
+	return func(ctx *Context) {
+		if !ctx.IsSignedIn {
+			apiV2Error(ctx, 401, "Authentication required: sign in or use an \"Authorization: Bearer <token>\" header")
+			return
+		}
+		if !config.RoleAllows(ctx.UserRole, role) {
+			log.Warnf("User %s with role %s has not %s permissions for %s %s", ctx.SignedInUser, ctx.UserRole, role, ctx.Req.Method, ctx.Req.RequestURI)
+			apiV2Error(ctx, 403, fmt.Sprintf("Access forbidden: %s role required", role))
+			return
+		}
+		apiV2TokenInScope(ctx, ctx.Params(":id"))
+	}
+}
+
+// apiV2TokenInScope checks the API token scope against the object ID, sending the error if not allowed
+func apiV2TokenInScope(ctx *Context, id string) bool {
+	if ctx.APIToken == nil || ctx.APIToken.InScope(id) {
+		return true
+	}
+	log.Warnf("API token %s with scope %q not allowed for %s %s", ctx.APIToken.ID, ctx.APIToken.Scope, ctx.Req.Method, ctx.Req.RequestURI)
+	apiV2Error(ctx, 403, fmt.Sprintf("Access forbidden: out of API token scope %q", ctx.APIToken.Scope))
+	return false
+}
+
+// apiV2Bind decodes the JSON request body into obj (a pointer) and validates it with
+// the same binding rules of the v1 API, on error the response is sent
+func apiV2Bind(ctx *Context, obj interface{}) bool {
+	if ct := ctx.Req.Header.Get("Content-Type"); len(ct) > 0 && !strings.HasPrefix(ct, "application/json") {
+		apiV2Error(ctx, 415, fmt.Sprintf("Unsupported content type %s, should be application/json", ct))
+		return false
+	}
+	if err := json.NewDecoder(ctx.Req.Request.Body).Decode(obj); err != nil {
+		if err == io.EOF {
+			apiV2Error(ctx, 400, "Empty request body")
+		} else {
+			apiV2Error(ctx, 400, "Invalid JSON request body", err.Error())
+		}
+		return false
+	}
+	if errs := binding.RawValidate(obj); len(errs) > 0 {
+		var details []string
+		for _, e := range errs {
+			details = append(details, strings.TrimSpace(fmt.Sprintf("%s: %s %s", strings.Join(e.FieldNames, ","), e.Classification, e.Message)))
+		}
+		apiV2Error(ctx, 422, "Invalid request body", details...)
+		return false
+	}
+	return true
+}
+
+// apiV2ListQuery gets the list query params, on error the response is sent
+func apiV2ListQuery(ctx *Context) (*config.ListQuery, bool) {
+	q, err := listQuery(ctx)
+	if err != nil {
+		apiV2Error(ctx, 400, err.Error())
+		return nil, false
+	}
+	return q, true
+}
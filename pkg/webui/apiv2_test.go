@@ -0,0 +1,213 @@
+package webui
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// testSignIn signs in the requests with the role in the "X-Test-Role" header, as
+// an API token if the "X-Test-Scope" header is set
+func testSignIn(c *macaron.Context) {
+	ctx := &Context{Context: c}
+	if role := c.Req.Header.Get("X-Test-Role"); len(role) > 0 {
+		ctx.IsSignedIn, ctx.UserRole, ctx.SignedInUser = true, role, "test"
+	}
+	if scope := c.Req.Header.Get("X-Test-Scope"); len(scope) > 0 {
+		ctx.APIToken = &config.APITokenCfg{ID: "test", Role: ctx.UserRole, Scope: scope}
+	}
+	c.Map(ctx)
+}
+
+// newTestAPIv2 returns a test server with the v2 API and an empty configuration
+// database, requests are signed in by testSignIn
+func newTestAPIv2(t *testing.T) (*httptest.Server, func()) {
+	dir, err := ioutil.TempDir("", "snmpcollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.SetLogger(log)
+	agent.SetLogger(log)
+	config.SetDirs(dir, dir, dir)
+	agent.MainConfig.Database = config.DatabaseCfg{Type: "sqlite3", Name: "snmpcollector"}
+	agent.MainConfig.Database.InitDB()
+
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Use(testSignIn)
+	NewAPIv2(m)
+	srv := httptest.NewServer(m)
+	return srv, func() {
+		srv.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// testAPIv2Request does the request returning the response with its body
+func testAPIv2Request(t *testing.T, srv *httptest.Server, role string, method string, path string, body string) (*http.Response, []byte) {
+	req, err := http.NewRequest(method, srv.URL+APIv2Prefix+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(role) > 0 {
+		req.Header.Set("X-Test-Role", role)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := ioutil.ReadAll(resp.Body)
+	return resp, data
+}
+
+func TestAPIv2ConfigObjects(t *testing.T) {
+	srv, release := newTestAPIv2(t)
+	defer release()
+
+	influx := `{"ID":"influx1","Host":"localhost","Port":8086,"DB":"snmp","User":"u","Password":"p","Retention":"autogen"}`
+	tests := []struct {
+		name   string
+		role   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"not signed in", "", "GET", "/config/influxservers", "", 401},
+		{"viewer can not create", config.RoleViewer, "POST", "/config/influxservers", influx, 403},
+		{"invalid json", config.RoleAdmin, "POST", "/config/influxservers", `{"ID":`, 400},
+		{"missing required fields", config.RoleAdmin, "POST", "/config/influxservers", `{"ID":"influx1"}`, 422},
+		{"create", config.RoleAdmin, "POST", "/config/influxservers", influx, 201},
+		{"create duplicated", config.RoleAdmin, "POST", "/config/influxservers", influx, 409},
+		{"get", config.RoleViewer, "GET", "/config/influxservers/influx1", "", 200},
+		{"list", config.RoleViewer, "GET", "/config/influxservers?sort=-id&limit=10", "", 200},
+		{"list unknown field", config.RoleViewer, "GET", "/config/influxservers?unknown=1", "", 400},
+		{"update", config.RoleAdmin, "PUT", "/config/influxservers/influx1", strings.Replace(influx, "localhost", "influx.example.org", 1), 200},
+		{"update unknown", config.RoleAdmin, "PUT", "/config/influxservers/influx2", influx, 404},
+		{"delete", config.RoleAdmin, "DELETE", "/config/influxservers/influx1", "", 204},
+		{"get deleted", config.RoleViewer, "GET", "/config/influxservers/influx1", "", 404},
+		{"runtime device not running", config.RoleOperator, "POST", "/runtime/devices/sw1/forcegather", "", 404},
+		{"runtime invalid action body", config.RoleOperator, "POST", "/runtime/devices/sw1/snmpreset", `{"Mode":"medium"}`, 422},
+		{"unknown route", config.RoleViewer, "GET", "/unknown", "", 404},
+	}
+	for _, tt := range tests {
+		resp, data := testAPIv2Request(t, srv, tt.role, tt.method, tt.path, tt.body)
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: got status %d, want %d: %s", tt.name, resp.StatusCode, tt.status, data)
+			continue
+		}
+		if tt.status < 400 {
+			continue
+		}
+		// all errors should be JSON error objects
+		var e APIv2Error
+		if err := json.Unmarshal(data, &e); err != nil || e.Code != tt.status || len(e.Message) == 0 {
+			t.Errorf("%s: invalid error object %s", tt.name, data)
+		}
+		if tt.status == 422 && len(e.Details) == 0 {
+			t.Errorf("%s: validation error without details %s", tt.name, data)
+		}
+	}
+
+	testAPIv2Request(t, srv, config.RoleAdmin, "POST", "/config/influxservers", influx)
+	resp, data := testAPIv2Request(t, srv, config.RoleViewer, "GET", "/config/influxservers?host=local", "")
+	var list []*config.InfluxCfg
+	if err := json.Unmarshal(data, &list); err != nil || len(list) != 1 || resp.Header.Get(TotalCountHeader) != "1" {
+		t.Errorf("unexpected list %s with total count %q", data, resp.Header.Get(TotalCountHeader))
+	}
+}
+
+func TestAPIv2OpenAPISpec(t *testing.T) {
+	srv, release := newTestAPIv2(t)
+	defer release()
+
+	resp, data := testAPIv2Request(t, srv, "", "GET", "/openapi.json", "")
+	if resp.StatusCode != 200 {
+		t.Fatalf("spec not served without authentication: %d %s", resp.StatusCode, data)
+	}
+	var spec struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("unexpected OpenAPI version %q", spec.OpenAPI)
+	}
+	// every route is documented
+	for _, r := range apiV2Routes() {
+		path := pathParam.ReplaceAllString(r.path, "{$1}")
+		if _, ok := spec.Paths[path][strings.ToLower(r.method)]; !ok {
+			t.Errorf("route %s %s not documented", r.method, r.path)
+		}
+	}
+	// every schema reference exists
+	for _, ref := range regexp.MustCompile(`"#/components/schemas/(\w+)"`).FindAllStringSubmatch(string(data), -1) {
+		if _, ok := spec.Components.Schemas[ref[1]]; !ok {
+			t.Errorf("undefined schema %s", ref[1])
+		}
+	}
+	if _, ok := spec.Components.Schemas["SnmpDeviceCfg"]; !ok {
+		t.Error("SnmpDeviceCfg schema not documented")
+	}
+}
+
+func TestAPITokenScopeRename(t *testing.T) {
+	srv, release := newTestAPIv2(t)
+	defer release()
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Use(testSignIn)
+	NewAPICfgVarCatalog(m)
+	srv1 := httptest.NewServer(m)
+	defer srv1.Close()
+
+	for _, id := range []string{"site1_factor", "site1_offset"} {
+		if _, err := agent.MainConfig.Database.AddVarCatalogCfg(config.VarCatalogCfg{ID: id, Type: "integer", Value: "8"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	request := func(url string, id string, body string) int {
+		req, _ := http.NewRequest("PUT", url+id, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-Role", config.RoleAdmin)
+		req.Header.Set("X-Test-Scope", "site1_*")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	for _, api := range []struct{ name, url, id string }{
+		{"v1", srv1.URL + "/api/cfg/varcatalog/", "site1_factor"},
+		{"v2", srv.URL + APIv2Prefix + "/config/varcatalog/", "site1_offset"},
+	} {
+		if code := request(api.url, api.id, `{"ID":"site2_var","Type":"integer","Value":"16"}`); code != 403 {
+			t.Errorf("%s rename out of the token scope got status %d, want 403", api.name, code)
+		}
+		if _, err := agent.MainConfig.Database.GetVarCatalogCfgByID(api.id); err != nil {
+			t.Errorf("%s object renamed out of the token scope: %s", api.name, err)
+		}
+		if code := request(api.url, api.id, `{"ID":"`+api.id+`_new","Type":"integer","Value":"16"}`); code != 200 {
+			t.Errorf("%s rename in the token scope got status %d", api.name, code)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package webui
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/ldap.v3"
+)
+
+// ldapAuth authenticates users with a LDAP bind and maps their groups to roles
+type ldapAuth struct {
+	cfg   *config.LDAPConfig
+	roles roleMapping
+}
+
+func newLDAPAuth(cfg *config.LDAPConfig) *ldapAuth {
+	if len(cfg.UserFilter) == 0 {
+		cfg.UserFilter = "(uid=%s)"
+	}
+	if len(cfg.GroupAttribute) == 0 {
+		cfg.GroupAttribute = "memberOf"
+	}
+	return &ldapAuth{cfg: cfg, roles: newRoleMapping(cfg.GroupRoles)}
+}
+
+func (a *ldapAuth) Name() string {
+	return AuthSourceLDAP
+}
+
+// dial connects to the ldap:// or ldaps:// server URL
+func (a *ldapAuth) dial() (*ldap.Conn, error) {
+	u, err := url.Parse(a.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP URL %s: %s", a.cfg.URL, err)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		host = u.Host
+	}
+	tlsCfg := &tls.Config{ServerName: host, InsecureSkipVerify: a.cfg.InsecureSkipVerify}
+	var conn *ldap.Conn
+	switch u.Scheme {
+	case "ldap":
+		if len(port) == 0 {
+			port = "389"
+		}
+		conn, err = ldap.Dial("tcp", net.JoinHostPort(host, port))
+	case "ldaps":
+		if len(port) == 0 {
+			port = "636"
+		}
+		conn, err = ldap.DialTLS("tcp", net.JoinHostPort(host, port), tlsCfg)
+	default:
+		return nil, fmt.Errorf("invalid LDAP URL %s: unknown scheme %s", a.cfg.URL, u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(10 * time.Second)
+	if a.cfg.StartTLS && u.Scheme == "ldap" {
+		if err := conn.StartTLS(tlsCfg); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// search returns the entries found with filter under base
+func (a *ldapAuth) search(conn *ldap.Conn, base string, scope int, filter string, attrs []string) ([]*ldap.Entry, error) {
+	req := ldap.NewSearchRequest(base, scope, ldap.NeverDerefAliases, 0, 10, false, filter, attrs, nil)
+	res, err := conn.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Entries, nil
+}
+
+// Authenticate binds as the user and returns the role mapped from its groups
+func (a *ldapAuth) Authenticate(user string, password string) (string, error) {
+	// an empty password is an anonymous bind in most servers
+	if len(password) == 0 {
+		return "", fmt.Errorf("empty password for LDAP user %s", user)
+	}
+	conn, err := a.dial()
+	if err != nil {
+		return "", fmt.Errorf("error on connect to LDAP server: %s", err)
+	}
+	defer conn.Close()
+
+	var userDN string
+	var groups []string
+	if len(a.cfg.BindDN) > 0 {
+		// search the user with the service account
+		if err := conn.Bind(a.cfg.BindDN, a.cfg.BindPassword); err != nil {
+			return "", fmt.Errorf("error on LDAP service account bind: %s", err)
+		}
+		filter := fmt.Sprintf(a.cfg.UserFilter, ldap.EscapeFilter(user))
+		entries, err := a.search(conn, a.cfg.BaseDN, ldap.ScopeWholeSubtree, filter, []string{"dn", a.cfg.GroupAttribute})
+		if err != nil {
+			return "", fmt.Errorf("error on LDAP user %s search: %s", user, err)
+		}
+		if len(entries) != 1 {
+			return "", fmt.Errorf("LDAP user %s not found (%d entries)", user, len(entries))
+		}
+		userDN = entries[0].DN
+		groups = entries[0].GetAttributeValues(a.cfg.GroupAttribute)
+		if err := conn.Bind(userDN, password); err != nil {
+			return "", fmt.Errorf("error on LDAP user %s bind: %s", user, err)
+		}
+	} else {
+		userDN = fmt.Sprintf(a.cfg.UserDN, escapeDNValue(user))
+		if err := conn.Bind(userDN, password); err != nil {
+			return "", fmt.Errorf("error on LDAP user %s bind: %s", user, err)
+		}
+		// read its own groups once bound
+		entries, err := a.search(conn, userDN, ldap.ScopeBaseObject, "(objectClass=*)", []string{a.cfg.GroupAttribute})
+		if err == nil && len(entries) == 1 {
+			groups = entries[0].GetAttributeValues(a.cfg.GroupAttribute)
+		}
+	}
+	if len(a.cfg.GroupFilter) > 0 {
+		filter := fmt.Sprintf(a.cfg.GroupFilter, ldap.EscapeFilter(userDN))
+		entries, err := a.search(conn, a.cfg.GroupBaseDN, ldap.ScopeWholeSubtree, filter, []string{"dn"})
+		if err != nil {
+			return "", fmt.Errorf("error on LDAP user %s groups search: %s", user, err)
+		}
+		for _, e := range entries {
+			groups = append(groups, e.DN)
+		}
+	}
+	role := a.roles.role(groups, a.cfg.DefaultRole)
+	if !config.ValidRole(role) {
+		return "", fmt.Errorf("no role mapped for LDAP user %s with groups %s", user, strings.Join(groups, ";"))
+	}
+	return role, nil
+}
+
+// escapeDNValue escapes the special characters of a DN attribute value (RFC 4514)
+func escapeDNValue(v string) string {
+	var b strings.Builder
+	for i, c := range v {
+		switch {
+		case strings.ContainsRune(",+\"\\<>;=", c),
+			i == 0 && (c == ' ' || c == '#'),
+			i == len(v)-1 && c == ' ':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,154 @@
+package webui
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	goldap "github.com/lor00x/goldap/message"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	ldapserver "github.com/vjeantet/ldapserver"
+)
+
+// testLDAPEntry a user or group of the test LDAP server
+type testLDAPEntry struct {
+	password string
+	attrs    map[string][]string
+}
+
+// testLDAPDir is the test LDAP server directory
+var testLDAPDir = map[string]*testLDAPEntry{
+	"cn=reader,dc=example,dc=org": {password: "readerpass"},
+	"uid=alice,ou=people,dc=example,dc=org": {password: "alicepass", attrs: map[string][]string{
+		"uid":      {"alice"},
+		"memberOf": {"cn=admins,ou=groups,dc=example,dc=org", "cn=noc,ou=groups,dc=example,dc=org"},
+	}},
+	"uid=bob,ou=people,dc=example,dc=org":   {password: "bobpass", attrs: map[string][]string{"uid": {"bob"}}},
+	"uid=carol,ou=people,dc=example,dc=org": {password: "carolpass", attrs: map[string][]string{"uid": {"carol"}}},
+	"cn=noc,ou=groups,dc=example,dc=org": {attrs: map[string][]string{
+		"member": {"uid=bob,ou=people,dc=example,dc=org"},
+	}},
+}
+
+// matchTestLDAPFilter matches simple "(attr=value)" equality filters
+func matchTestLDAPFilter(e *testLDAPEntry, filter string) bool {
+	kv := strings.SplitN(strings.TrimSuffix(strings.TrimPrefix(filter, "("), ")"), "=", 2)
+	if len(kv) != 2 {
+		return false
+	}
+	if kv[0] == "objectClass" && kv[1] == "*" {
+		return true
+	}
+	for _, v := range e.attrs[kv[0]] {
+		if strings.EqualFold(v, kv[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// newTestLDAPServer starts an in-process LDAP server with the test directory
+func newTestLDAPServer(t *testing.T) (string, func()) {
+	ldapserver.Logger = ldapserver.DiscardingLogger
+	routes := ldapserver.NewRouteMux()
+	routes.Bind(func(w ldapserver.ResponseWriter, m *ldapserver.Message) {
+		r := m.GetBindRequest()
+		res := ldapserver.NewBindResponse(ldapserver.LDAPResultSuccess)
+		if e, ok := testLDAPDir[string(r.Name())]; !ok || len(e.password) == 0 || e.password != string(r.AuthenticationSimple()) {
+			res.SetResultCode(ldapserver.LDAPResultInvalidCredentials)
+		}
+		w.Write(res)
+	})
+	routes.Search(func(w ldapserver.ResponseWriter, m *ldapserver.Message) {
+		r := m.GetSearchRequest()
+		base := strings.ToLower(string(r.BaseObject()))
+		for dn, e := range testLDAPDir {
+			if r.Scope() == ldapserver.SearchRequestScopeBaseObject && dn != base ||
+				!strings.HasSuffix(dn, base) || !matchTestLDAPFilter(e, r.FilterString()) {
+				continue
+			}
+			entry := ldapserver.NewSearchResultEntry(dn)
+			for k, vals := range e.attrs {
+				for _, v := range vals {
+					entry.AddAttribute(goldap.AttributeDescription(k), goldap.AttributeValue(v))
+				}
+			}
+			w.Write(entry)
+		}
+		w.Write(ldapserver.NewSearchResultDoneResponse(ldapserver.LDAPResultSuccess))
+	})
+	server := ldapserver.NewServer()
+	server.Handle(routes)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	go server.ListenAndServe(addr)
+	for i := 0; i < 50; i++ {
+		if c, err := net.Dial("tcp", addr); err == nil {
+			c.Close()
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return "ldap://" + addr, server.Stop
+}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	url, stop := newTestLDAPServer(t)
+	defer stop()
+
+	groupRoles := []string{
+		"cn=admins,ou=groups,dc=example,dc=org=admin",
+		"cn=noc,ou=groups,dc=example,dc=org=operator",
+	}
+	search := newLDAPAuth(&config.LDAPConfig{
+		URL:          url,
+		BindDN:       "cn=reader,dc=example,dc=org",
+		BindPassword: "readerpass",
+		BaseDN:       "ou=people,dc=example,dc=org",
+		GroupBaseDN:  "ou=groups,dc=example,dc=org",
+		GroupFilter:  "(member=%s)",
+		GroupRoles:   groupRoles,
+		DefaultRole:  config.RoleViewer,
+	})
+	direct := newLDAPAuth(&config.LDAPConfig{
+		URL:        url,
+		UserDN:     "uid=%s,ou=people,dc=example,dc=org",
+		GroupRoles: groupRoles,
+	})
+
+	tests := []struct {
+		name     string
+		auth     *ldapAuth
+		user     string
+		password string
+		role     string // empty if login should fail
+	}{
+		{"memberOf groups", search, "alice", "alicepass", config.RoleAdmin},
+		{"group search", search, "bob", "bobpass", config.RoleOperator},
+		{"default role", search, "carol", "carolpass", config.RoleViewer},
+		{"bad password", search, "alice", "bad", ""},
+		{"empty password", search, "alice", "", ""},
+		{"unknown user", search, "dave", "davepass", ""},
+		{"direct bind", direct, "alice", "alicepass", config.RoleAdmin},
+		{"direct bind without role", direct, "carol", "carolpass", ""},
+		{"direct bind bad password", direct, "bob", "alicepass", ""},
+	}
+	for _, tt := range tests {
+		role, err := tt.auth.Authenticate(tt.user, tt.password)
+		if len(tt.role) == 0 {
+			if err == nil {
+				t.Errorf("%s: login accepted with role %s", tt.name, role)
+			}
+			continue
+		}
+		if err != nil || role != tt.role {
+			t.Errorf("%s: got role %q error %v, want role %s", tt.name, role, err, tt.role)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package webui
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"golang.org/x/oauth2"
+)
+
+// OIDC login flow session keys
+const (
+	SessKeyOIDCState = "oidc_state"
+	SessKeyOIDCNonce = "oidc_nonce"
+)
+
+// oidcAuth authenticates users with the OpenID Connect authorization code flow
+// and maps a claim of their ID token to roles
+type oidcAuth struct {
+	cfg   *config.OIDCConfig
+	roles roleMapping
+	// provider discovery is done on first use, the issuer could be down on start
+	sync.Mutex
+	provider *oidc.Provider
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+func newOIDCAuth(cfg *config.OIDCConfig) *oidcAuth {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+	if len(cfg.UsernameClaim) == 0 {
+		cfg.UsernameClaim = "preferred_username"
+	}
+	if len(cfg.RoleClaim) == 0 {
+		cfg.RoleClaim = "groups"
+	}
+	return &oidcAuth{cfg: cfg, roles: newRoleMapping(cfg.ClaimRoles)}
+}
+
+// init discovers the issuer endpoints and keys
+func (a *oidcAuth) init(ctx context.Context) error {
+	a.Lock()
+	defer a.Unlock()
+	if a.provider != nil {
+		return nil
+	}
+	provider, err := oidc.NewProvider(ctx, a.cfg.IssuerURL)
+	if err != nil {
+		return fmt.Errorf("error on OIDC issuer %s discovery: %s", a.cfg.IssuerURL, err)
+	}
+	scopes := a.cfg.Scopes
+	hasOpenID := false
+	for _, s := range scopes {
+		hasOpenID = hasOpenID || s == oidc.ScopeOpenID
+	}
+	if !hasOpenID {
+		scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+	}
+	a.oauth = &oauth2.Config{
+		ClientID:     a.cfg.ClientID,
+		ClientSecret: a.cfg.ClientSecret,
+		RedirectURL:  a.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+	a.verifier = provider.Verifier(&oidc.Config{ClientID: a.cfg.ClientID})
+	a.provider = provider
+	return nil
+}
+
+// randomString returns a random hex string to be used as OIDC state or nonce
+func randomString() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// AuthURL returns the issuer URL to redirect the user to login
+func (a *oidcAuth) AuthURL(ctx context.Context, state string, nonce string) (string, error) {
+	if err := a.init(ctx); err != nil {
+		return "", err
+	}
+	return a.oauth.AuthCodeURL(state, oidc.Nonce(nonce)), nil
+}
+
+// claimValues returns a string or string array claim as a string array
+func claimValues(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []interface{}:
+		var values []string
+		for _, i := range val {
+			if s, ok := i.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// Exchange gets the ID token for the authorization code and returns the user name and role
+func (a *oidcAuth) Exchange(ctx context.Context, code string, nonce string) (string, string, error) {
+	if err := a.init(ctx); err != nil {
+		return "", "", err
+	}
+	token, err := a.oauth.Exchange(ctx, code)
+	if err != nil {
+		return "", "", fmt.Errorf("error on OIDC code exchange: %s", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return "", "", fmt.Errorf("no id_token field in OIDC token response")
+	}
+	idToken, err := a.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return "", "", fmt.Errorf("error on OIDC ID token verification: %s", err)
+	}
+	if idToken.Nonce != nonce {
+		return "", "", fmt.Errorf("invalid OIDC ID token nonce")
+	}
+	claims := make(map[string]interface{})
+	if err := idToken.Claims(&claims); err != nil {
+		return "", "", fmt.Errorf("error on OIDC ID token claims: %s", err)
+	}
+	user, _ := claims[a.cfg.UsernameClaim].(string)
+	if len(user) == 0 {
+		return "", "", fmt.Errorf("no %s claim in OIDC ID token for subject %s", a.cfg.UsernameClaim, idToken.Subject)
+	}
+	values := claimValues(claims[a.cfg.RoleClaim])
+	role := a.roles.role(values, a.cfg.DefaultRole)
+	if !config.ValidRole(role) {
+		return "", "", fmt.Errorf("no role mapped for OIDC user %s with %s claim %v", user, a.cfg.RoleClaim, values)
+	}
+	return user, role, nil
+}
+
+// oidcLoginHandler redirects the user to the issuer login page
+func oidcLoginHandler(ctx *Context) {
+	if oidcAuthenticator == nil {
+		ctx.JSON(404, "OpenID Connect login is not enabled")
+		return
+	}
+	state, err := randomString()
+	if err == nil {
+		var nonce string
+		if nonce, err = randomString(); err == nil {
+			var url string
+			if url, err = oidcAuthenticator.AuthURL(ctx.Req.Context(), state, nonce); err == nil {
+				ctx.Session.Set(SessKeyOIDCState, state)
+				ctx.Session.Set(SessKeyOIDCNonce, nonce)
+				ctx.Redirect(url)
+				return
+			}
+		}
+	}
+	log.Errorf("OIDC login ERROR: %s", err)
+	ctx.JSON(500, err.Error())
+}
+
+// oidcCallbackHandler signs in the user redirected back from the issuer
+func oidcCallbackHandler(ctx *Context) {
+	if oidcAuthenticator == nil {
+		ctx.JSON(404, "OpenID Connect login is not enabled")
+		return
+	}
+	state, _ := ctx.Session.Get(SessKeyOIDCState).(string)
+	nonce, _ := ctx.Session.Get(SessKeyOIDCNonce).(string)
+	ctx.Session.Delete(SessKeyOIDCState)
+	ctx.Session.Delete(SessKeyOIDCNonce)
+	if len(state) == 0 || ctx.Query("state") != state {
+		log.Warnf("OIDC login ERROR: invalid state")
+		ctx.JSON(400, "ERROR invalid OIDC login state")
+		return
+	}
+	if e := ctx.Query("error"); len(e) > 0 {
+		log.Warnf("OIDC login ERROR: %s %s", e, ctx.Query("error_description"))
+		ctx.JSON(400, "ERROR on OIDC login: "+e)
+		return
+	}
+	user, role, err := oidcAuthenticator.Exchange(ctx.Req.Context(), ctx.Query("code"), nonce)
+	if err != nil {
+		log.Warnf("OIDC login ERROR: %s", err)
+		ctx.JSON(400, "ERROR on OIDC login")
+		return
+	}
+	signIn(ctx, user, role, AuthSourceOIDC)
+	ctx.Redirect("/")
+}
@@ -0,0 +1,185 @@
+package webui
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// testOIDCIssuer is a mock OpenID Connect issuer, the authorize endpoint logs in
+// the user given in the "login_hint" parameter without asking for credentials
+type testOIDCIssuer struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]map[string]interface{} // user claims
+	sync.Mutex
+	codes map[string]map[string]interface{} // issued code claims (with nonce)
+}
+
+func newTestOIDCIssuer(t *testing.T) *testOIDCIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &testOIDCIssuer{key: key, codes: make(map[string]map[string]interface{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                iss.URL,
+			"authorization_endpoint":                iss.URL + "/authorize",
+			"token_endpoint":                        iss.URL + "/token",
+			"jwks_uri":                              iss.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		claims, ok := iss.claims[q.Get("login_hint")]
+		if !ok {
+			http.Redirect(w, r, q.Get("redirect_uri")+"?error=access_denied&state="+q.Get("state"), http.StatusFound)
+			return
+		}
+		code, _ := randomString()
+		c := map[string]interface{}{"nonce": q.Get("nonce"), "aud": q.Get("client_id")}
+		for k, v := range claims {
+			c[k] = v
+		}
+		iss.Lock()
+		iss.codes[code] = c
+		iss.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code="+code+"&state="+q.Get("state"), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		iss.Lock()
+		claims, ok := iss.codes[r.Form.Get("code")]
+		delete(iss.codes, r.Form.Get("code"))
+		iss.Unlock()
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		claims["iss"] = iss.URL
+		claims["iat"] = time.Now().Unix()
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     iss.sign(t, claims),
+		})
+	})
+	iss.Server = httptest.NewServer(mux)
+	return iss
+}
+
+// sign returns the signed JWT with the claims
+func (iss *testOIDCIssuer) sign(t *testing.T, claims map[string]interface{}) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: iss.key}, (&jose.SignerOptions{}).WithHeader("kid", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := json.Marshal(claims)
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jws.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// login follows the authorize redirect returning the callback query
+func (iss *testOIDCIssuer) login(t *testing.T, authURL string, user string) url.Values {
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL + "&login_hint=" + user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc.Query()
+}
+
+func TestOIDCAuthCodeFlow(t *testing.T) {
+	iss := newTestOIDCIssuer(t)
+	defer iss.Close()
+	iss.claims = map[string]map[string]interface{}{
+		"alice": {"sub": "1", "preferred_username": "alice", "groups": []string{"snmp-admins", "staff"}},
+		"bob":   {"sub": "2", "preferred_username": "bob", "groups": "snmp-operators"},
+		"carol": {"sub": "3", "preferred_username": "carol", "groups": []string{"staff"}},
+	}
+	a := newOIDCAuth(&config.OIDCConfig{
+		IssuerURL:    iss.URL,
+		ClientID:     "snmpcollector",
+		ClientSecret: "secret",
+		RedirectURL:  "http://snmpcollector.example.org/login/oidc/callback",
+		ClaimRoles:   []string{"snmp-admins=admin", "snmp-operators=operator"},
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		user string
+		role string // empty if login should fail
+	}{
+		{"alice", config.RoleAdmin},
+		{"bob", config.RoleOperator},
+		{"carol", ""},
+	}
+	for _, tt := range tests {
+		authURL, err := a.AuthURL(ctx, "state-"+tt.user, "nonce-"+tt.user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := iss.login(t, authURL, tt.user)
+		if q.Get("state") != "state-"+tt.user {
+			t.Errorf("%s: unexpected callback state %q", tt.user, q.Get("state"))
+		}
+		user, role, err := a.Exchange(ctx, q.Get("code"), "nonce-"+tt.user)
+		if len(tt.role) == 0 {
+			if err == nil {
+				t.Errorf("%s: login accepted with role %s", tt.user, role)
+			}
+			continue
+		}
+		if err != nil || user != tt.user || role != tt.role {
+			t.Errorf("%s: got user %q role %q error %v, want role %s", tt.user, user, role, err, tt.role)
+		}
+	}
+
+	// replayed ID token from another login
+	authURL, _ := a.AuthURL(ctx, "state", "nonce-1")
+	q := iss.login(t, authURL, "alice")
+	if _, _, err := a.Exchange(ctx, q.Get("code"), "nonce-2"); err == nil {
+		t.Error("ID token with invalid nonce accepted")
+	}
+	// codes can only be used once
+	if _, _, err := a.Exchange(ctx, q.Get("code"), "nonce-1"); err == nil {
+		t.Error("authorization code accepted twice")
+	}
+}
@@ -0,0 +1,103 @@
+package webui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// Authentication sources stored on the user session
+const (
+	AuthSourceLocal = "local"
+	AuthSourceLDAP  = "ldap"
+	AuthSourceOIDC  = "oidc"
+	// AuthSourceAPIToken is only set on the request context
+	AuthSourceAPIToken = "apitoken"
+)
+
+// Authenticator checks the login form user credentials and returns the user role
+type Authenticator interface {
+	// Name is the authentication source stored on the user session
+	Name() string
+	Authenticate(user string, password string) (string, error)
+}
+
+// authenticators are tried in order on login, first successful one signs in the user
+var authenticators []Authenticator
+
+// oidcAuthenticator is set if the OpenID Connect login is enabled
+var oidcAuthenticator *oidcAuth
+
+// initAuthenticators sets the enabled authenticators, local users are always enabled
+func initAuthenticators(cfg *config.HTTPConfig) {
+	authenticators = []Authenticator{&localAuth{}}
+	if cfg.LDAP.Enabled {
+		log.Infof("WEBUI: LDAP authentication enabled with server %s", cfg.LDAP.URL)
+		authenticators = append(authenticators, newLDAPAuth(&cfg.LDAP))
+	}
+	oidcAuthenticator = nil
+	if cfg.OIDC.Enabled {
+		log.Infof("WEBUI: OpenID Connect authentication enabled with issuer %s", cfg.OIDC.IssuerURL)
+		oidcAuthenticator = newOIDCAuth(&cfg.OIDC)
+	}
+}
+
+// localAuth authenticates the config file admin user and the database users
+type localAuth struct{}
+
+func (a *localAuth) Name() string {
+	return AuthSourceLocal
+}
+
+func (a *localAuth) Authenticate(user string, password string) (string, error) {
+	if user == confHTTP.AdminUser {
+		if password == confHTTP.AdminPassword {
+			return config.RoleAdmin, nil
+		}
+		return "", fmt.Errorf("password not match for admin user %s", user)
+	}
+	u, err := agent.MainConfig.Database.CheckUserCfgPassword(user, password)
+	if err != nil {
+		return "", err
+	}
+	return u.Role, nil
+}
+
+// roleMapping maps external groups or claim values to roles
+type roleMapping map[string]string
+
+// newRoleMapping parses "VALUE=role" entries, values are case insensitive and
+// could contain "=" (LDAP DNs) so the last one is the separator
+func newRoleMapping(entries []string) roleMapping {
+	m := make(roleMapping)
+	for _, e := range entries {
+		i := strings.LastIndex(e, "=")
+		if i <= 0 {
+			log.Warnf("Invalid role mapping %q, should be VALUE=role", e)
+			continue
+		}
+		value, role := strings.TrimSpace(e[:i]), strings.TrimSpace(e[i+1:])
+		if !config.ValidRole(role) {
+			log.Warnf("Invalid role mapping %q, unknown role %s", e, role)
+			continue
+		}
+		m[strings.ToLower(value)] = role
+	}
+	return m
+}
+
+// role returns the role with more permissions mapped from values, or defRole if none
+func (m roleMapping) role(values []string, defRole string) string {
+	role := ""
+	for _, v := range values {
+		if r, ok := m[strings.ToLower(strings.TrimSpace(v))]; ok && !config.RoleAllows(role, r) {
+			role = r
+		}
+	}
+	if len(role) == 0 {
+		return defRole
+	}
+	return role
+}
@@ -0,0 +1,44 @@
+package webui
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+func TestMain(m *testing.M) {
+	log = logrus.New()
+	log.Out = ioutil.Discard
+	os.Exit(m.Run())
+}
+
+func TestRoleMapping(t *testing.T) {
+	m := newRoleMapping([]string{
+		"cn=NOC,ou=groups,dc=example,dc=org=operator",
+		"cn=admins,ou=groups,dc=example,dc=org=admin",
+		"readers=viewer",
+		"badentry",
+		"others=superuser",
+	})
+	if len(m) != 3 {
+		t.Errorf("unexpected role mapping %v", m)
+	}
+	tests := []struct {
+		values  []string
+		defRole string
+		want    string
+	}{
+		{[]string{"cn=noc,ou=groups,dc=example,dc=org"}, "", config.RoleOperator},
+		{[]string{"readers", "cn=admins,ou=groups,dc=example,dc=org", "cn=noc,ou=groups,dc=example,dc=org"}, "", config.RoleAdmin},
+		{[]string{"others"}, config.RoleViewer, config.RoleViewer},
+		{nil, "", ""},
+	}
+	for _, tt := range tests {
+		if got := m.role(tt.values, tt.defRole); got != tt.want {
+			t.Errorf("role(%v, %q) = %q, want %q", tt.values, tt.defRole, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package webui
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// TotalCountHeader is the response header with the total count of paginated lists
+const TotalCountHeader = "X-Total-Count"
+
+// listQuery returns the list query from the request "limit", "offset" and "sort"
+// (comma separated fields, "-" prefix for descending order) query params, any
+// other query param is a field filter. Without params all objects are returned
+func listQuery(ctx *Context) (*config.ListQuery, error) {
+	q := &config.ListQuery{Fields: make(map[string]string)}
+	for k, vals := range ctx.Req.URL.Query() {
+		v := vals[len(vals)-1]
+		var err error
+		switch k {
+		case "limit":
+			q.Limit, err = strconv.Atoi(v)
+			if err == nil && q.Limit < 0 {
+				err = errors.New("negative value")
+			}
+		case "offset":
+			q.Offset, err = strconv.Atoi(v)
+			if err == nil && q.Offset < 0 {
+				err = errors.New("negative value")
+			}
+		case "sort":
+			for _, s := range vals {
+				for _, f := range strings.Split(s, ",") {
+					if f = strings.TrimSpace(f); len(f) > 0 {
+						q.Sort = append(q.Sort, f)
+					}
+				}
+			}
+		default:
+			q.Fields[k] = v
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %s %q: %s", config.ErrInvalidListQuery, k, v, err)
+		}
+	}
+	return q, nil
+}
+
+// listError sends the list query errors as bad requests and any other as not found
+func listError(ctx *Context, what string, err error) {
+	if errors.Is(err, config.ErrInvalidListQuery) {
+		ctx.JSON(400, err.Error())
+		return
+	}
+	log.Errorf("Error on get %s :%+s", what, err)
+	ctx.JSON(404, err.Error())
+}
+
+// setTotalCount sets the total count header of a paginated list
+func setTotalCount(ctx *Context, total int64) {
+	ctx.Resp.Header().Set(TotalCountHeader, strconv.FormatInt(total, 10))
+}
+
+// getCfgObjectPage gets the list page of the configuration objects selected by the
+// request query params and sets the total count header, on error the response is sent
+func getCfgObjectPage(ctx *Context, objtype string) (interface{}, bool) {
+	q, err := listQuery(ctx)
+	if err != nil {
+		listError(ctx, objtype, err)
+		return nil, false
+	}
+	cfgarray, total, err := agent.MainConfig.Database.GetCfgObjectPage(objtype, q)
+	if err != nil {
+		listError(ctx, objtype, err)
+		return nil, false
+	}
+	setTotalCount(ctx, total)
+	return cfgarray, true
+}
+
+// devStatField returns the device stat field by case insensitive name, only the
+// device id (the map key) and the scalar fields could be used in list queries
+func devStatField(id string, s *device.DevStat, name string) (reflect.Value, bool) {
+	if strings.EqualFold(name, "id") {
+		return reflect.ValueOf(id), true
+	}
+	v := reflect.ValueOf(s).Elem()
+	f := v.FieldByNameFunc(func(n string) bool { return strings.EqualFold(n, name) })
+	if !f.IsValid() || !f.CanInterface() {
+		return reflect.Value{}, false
+	}
+	switch f.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int:
+		return f, true
+	}
+	return reflect.Value{}, false
+}
+
+// matchDevStat checks the (already validated) list query field filters with the
+// same semantics of the configuration lists: text fields contain the value, others are equal
+func matchDevStat(id string, s *device.DevStat, q *config.ListQuery) (bool, error) {
+	for name, value := range q.Fields {
+		f, _ := devStatField(id, s, name)
+		switch f.Kind() {
+		case reflect.String:
+			if !strings.Contains(strings.ToLower(f.String()), strings.ToLower(value)) {
+				return false, nil
+			}
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return false, fmt.Errorf("%w: invalid value %q for field %s", config.ErrInvalidListQuery, value, name)
+			}
+			if f.Bool() != b {
+				return false, nil
+			}
+		case reflect.Int:
+			i, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return false, fmt.Errorf("%w: invalid value %q for field %s", config.ErrInvalidListQuery, value, name)
+			}
+			if f.Int() != i {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// lessDevStatField compares a device stat field of two devices
+func lessDevStatField(a reflect.Value, b reflect.Value) (less bool, equal bool) {
+	switch a.Kind() {
+	case reflect.String:
+		return a.String() < b.String(), a.String() == b.String()
+	case reflect.Bool:
+		return !a.Bool() && b.Bool(), a.Bool() == b.Bool()
+	}
+	return a.Int() < b.Int(), a.Int() == b.Int()
+}
+
+// pageDevStats returns the page of runtime device stats selected by the list
+// query, in the requested order, and the total selected count
+func pageDevStats(devstats map[string]*device.DevStat, q *config.ListQuery) ([]*device.DevStat, int64, error) {
+	// fields are checked even without devices
+	for name := range q.Fields {
+		if _, ok := devStatField("", &device.DevStat{}, name); !ok {
+			return nil, 0, fmt.Errorf("%w: unknown filter field %s", config.ErrInvalidListQuery, name)
+		}
+	}
+	for _, name := range q.Sort {
+		if _, ok := devStatField("", &device.DevStat{}, strings.TrimLeft(name, "+-")); !ok {
+			return nil, 0, fmt.Errorf("%w: unknown sort field %s", config.ErrInvalidListQuery, name)
+		}
+	}
+	var ids []string
+	for id, s := range devstats {
+		ok, err := matchDevStat(id, s, q)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		for _, name := range q.Sort {
+			desc := strings.HasPrefix(name, "-")
+			name = strings.TrimLeft(name, "+-")
+			a, _ := devStatField(ids[i], devstats[ids[i]], name)
+			b, _ := devStatField(ids[j], devstats[ids[j]], name)
+			if less, equal := lessDevStatField(a, b); !equal {
+				return less != desc
+			}
+		}
+		return ids[i] < ids[j]
+	})
+
+	total := int64(len(ids))
+	if q.Offset >= len(ids) {
+		ids = nil
+	} else {
+		ids = ids[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(ids) {
+		ids = ids[:q.Limit]
+	}
+	page := make([]*device.DevStat, 0, len(ids))
+	for _, id := range ids {
+		page = append(page, devstats[id])
+	}
+	return page, total, nil
+}
@@ -0,0 +1,65 @@
+package webui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+func TestPageDevStats(t *testing.T) {
+	devstats := map[string]*device.DevStat{
+		"sw1": {DeviceActive: true, DeviceConnected: true, NumMetrics: 10, SysDescription: "Cisco IOS"},
+		"sw2": {DeviceActive: true, DeviceConnected: false, NumMetrics: 30, SysDescription: "Juniper"},
+		"sw3": {DeviceActive: false, NumMetrics: 20, SysDescription: "cisco NX-OS"},
+		"rt1": {DeviceActive: true, DeviceConnected: true, NumMetrics: 40},
+	}
+	for id, s := range devstats {
+		s.Init(id, nil, nil)
+	}
+	tests := []struct {
+		q     config.ListQuery
+		want  string
+		total int64
+	}{
+		{config.ListQuery{}, "[rt1 sw1 sw2 sw3]", 4},
+		{config.ListQuery{Limit: 2, Offset: 1}, "[sw1 sw2]", 4},
+		{config.ListQuery{Offset: 4}, "[]", 4},
+		{config.ListQuery{Sort: []string{"-NumMetrics"}, Limit: 2}, "[rt1 sw2]", 4},
+		{config.ListQuery{Sort: []string{"NumMetrics"}}, "[sw1 sw3 sw2 rt1]", 4},
+		{config.ListQuery{Fields: map[string]string{"sysdescription": "CISCO"}}, "[sw1 sw3]", 2},
+		{config.ListQuery{Fields: map[string]string{"DeviceActive": "true", "deviceconnected": "true"}, Sort: []string{"numMetrics"}, Limit: 1}, "[sw1]", 2},
+		{config.ListQuery{Fields: map[string]string{"id": "sw"}, Sort: []string{"-id"}, Offset: 1}, "[sw2 sw1]", 3},
+	}
+	for _, tt := range tests {
+		page, total, err := pageDevStats(devstats, &tt.q)
+		if err != nil {
+			t.Errorf("query %+v: %s", tt.q, err)
+			continue
+		}
+		// the page is sent in order with the device IDs
+		data, _ := json.Marshal(page)
+		var items []struct{ ID string }
+		json.Unmarshal(data, &items)
+		ids := []string{}
+		for _, i := range items {
+			ids = append(ids, i.ID)
+		}
+		if got := fmt.Sprint(ids); got != tt.want || total != tt.total {
+			t.Errorf("query %+v: got %s total %d, want %s total %d", tt.q, got, total, tt.want, tt.total)
+		}
+	}
+
+	for _, q := range []config.ListQuery{
+		{Fields: map[string]string{"TagMap": "x"}},
+		{Fields: map[string]string{"NumMetrics": "many"}},
+		{Sort: []string{"-mutex"}},
+	} {
+		if _, _, err := pageDevStats(devstats, &q); !errors.Is(err, config.ErrInvalidListQuery) {
+			t.Errorf("query %+v: expected invalid list query, got %v", q, err)
+		}
+	}
+}
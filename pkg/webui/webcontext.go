@@ -2,7 +2,11 @@ package webui
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
 	"gopkg.in/macaron.v1"
 )
 
@@ -10,8 +14,11 @@ import (
 type Context struct {
 	*macaron.Context
 	SignedInUser string
+	UserRole     string
+	AuthSource   string // local, ldap, oidc or apitoken
 	Session      SessionStore
 	IsSignedIn   bool
+	APIToken     *config.APITokenCfg // set when signed in with a Bearer API token
 }
 
 func accessForbidden(ctx *Context) {
@@ -20,12 +27,108 @@ func accessForbidden(ctx *Context) {
 	//c.Redirect("/login")
 }
 
+// tokenInScope checks the API token scope against the route object ID
+func tokenInScope(ctx *Context) bool {
+	return tokenIDInScope(ctx, ctx.Params(":id"))
+}
+
+// tokenIDInScope checks the API token scope against the object ID, also needed on
+// updates for the body object ID ( scoped tokens can not rename objects out of scope )
+func tokenIDInScope(ctx *Context, id string) bool {
+	if ctx.APIToken == nil || ctx.APIToken.InScope(id) {
+		return true
+	}
+	log.Warnf("API token %s with scope %q not allowed for %s %s", ctx.APIToken.ID, ctx.APIToken.Scope, ctx.Req.Method, ctx.Req.RequestURI)
+	ctx.JSON(403, fmt.Sprintf("access forbidden: out of API token scope %q", ctx.APIToken.Scope))
+	return false
+}
+
+// reqSignedIn allows any signed in user (viewer role)
 var reqSignedIn = func(ctx *Context) {
 	if !ctx.IsSignedIn {
 		accessForbidden(ctx)
 		log.Infof("CONTEXT %+v", ctx)
 		return
 	}
+	tokenInScope(ctx)
+}
+
+// reqRole allows only signed in users with the permissions of the required role
+func reqRole(role string) macaron.Handler {
+	return func(ctx *Context) {
+		if !ctx.IsSignedIn {
+			accessForbidden(ctx)
+			return
+		}
+		if !config.RoleAllows(ctx.UserRole, role) {
+			log.Warnf("User %s with role %s has not %s permissions for %s %s", ctx.SignedInUser, ctx.UserRole, role, ctx.Req.Method, ctx.Req.RequestURI)
+			ctx.JSON(403, fmt.Sprintf("access forbidden: %s role required", role))
+			return
+		}
+		tokenInScope(ctx)
+	}
+}
+
+var (
+	// reqOperator allows runtime actions
+	reqOperator = reqRole(config.RoleOperator)
+	// reqAdmin allows configuration changes
+	reqAdmin = reqRole(config.RoleAdmin)
+)
+
+// userRole returns the current role of the local user, empty if it does not exist anymore
+func userRole(name string) string {
+	if name == confHTTP.AdminUser {
+		return config.RoleAdmin
+	}
+	user, err := agent.MainConfig.Database.GetUserCfgByID(name)
+	if err != nil {
+		return ""
+	}
+	return user.Role
+}
+
+// apiTokenLastUsedPeriod avoids a database write on each API token request
+const apiTokenLastUsedPeriod = time.Minute
+
+// initContextWithAPIToken signs in with an "Authorization: Bearer <token>" header, the
+// token role is limited by the current role of the user who created it
+func initContextWithAPIToken(ctx *Context, header string) bool {
+	if !strings.HasPrefix(header, "Bearer ") {
+		log.Warnf("Unsupported authorization header from %s", ctx.RemoteAddr())
+		return false
+	}
+	secret := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	tok, err := agent.MainConfig.Database.GetAPITokenCfgBySecret(secret)
+	if err != nil {
+		log.Warnf("Invalid API token from %s: %s", ctx.RemoteAddr(), err)
+		return false
+	}
+	now := time.Now()
+	if err := tok.CheckValid(now); err != nil {
+		log.Warnf("Invalid API token from %s: %s", ctx.RemoteAddr(), err)
+		return false
+	}
+	// tokens are only created by local users
+	role := tok.Role
+	if owner := userRole(tok.Owner); !config.RoleAllows(owner, role) {
+		role = owner
+	}
+	if len(role) == 0 {
+		log.Warnf("API token %s owner %s does not exist anymore", tok.ID, tok.Owner)
+		return false
+	}
+	if now.Sub(tok.LastUsedAt) > apiTokenLastUsedPeriod {
+		if err := agent.MainConfig.Database.UpdateAPITokenCfgLastUsed(tok.ID, now); err != nil {
+			log.Warnf("Error on update API token %s last used time: %s", tok.ID, err)
+		}
+	}
+	ctx.SignedInUser = "token:" + tok.ID
+	ctx.UserRole = role
+	ctx.APIToken = &tok
+	ctx.AuthSource = AuthSourceAPIToken
+	ctx.IsSignedIn = true
+	return true
 }
 
 func initContextWithUserSessionCookie(ctx *Context) bool {
@@ -37,7 +140,22 @@ func initContextWithUserSessionCookie(ctx *Context) bool {
 	userID := ctx.Session.Get(SessKeyUserID)
 
 	if userID != nil {
-		ctx.SignedInUser = ctx.Session.Get(SessKeyUserID).(string)
+		source, _ := ctx.Session.Get(SessKeyAuthSource).(string)
+		var role string
+		if len(source) == 0 || source == AuthSourceLocal {
+			// role is checked on each request to apply user changes to the open sessions
+			source = AuthSourceLocal
+			role = userRole(userID.(string))
+		} else {
+			// external users role is set on login
+			role, _ = ctx.Session.Get(SessKeyUserRole).(string)
+		}
+		if len(role) == 0 {
+			return false
+		}
+		ctx.SignedInUser = userID.(string)
+		ctx.UserRole = role
+		ctx.AuthSource = source
 		ctx.IsSignedIn = true
 		return true
 	}
@@ -61,7 +179,10 @@ func GetContextHandler() macaron.Handler {
 		// then init session and look for userId in session
 		// then look for api key in session (special case for render calls via api)
 		// then test if anonymous access is enabled
-		if initContextWithUserSessionCookie(ctx) {
+		// API tokens do not need any session
+		if header := c.Req.Header.Get("Authorization"); len(header) > 0 {
+			initContextWithAPIToken(ctx, header)
+		} else if initContextWithUserSessionCookie(ctx) {
 
 		}
 
@@ -175,7 +175,11 @@ func WebServer(publicPath string, httpListen string, cfg *config.HTTPConfig, id
 			Section: "cache",
 		}))*/
 
+	initAuthenticators(cfg)
+
 	m.Post("/login", bind(UserLogin{}), myLoginHandler)
+	m.Get("/login/oidc", oidcLoginHandler)
+	m.Get("/login/oidc/callback", oidcCallbackHandler)
 	m.Post("/logout", myLogoutHandler)
 
 	NewAPICfgVarCatalog(m)
@@ -198,10 +202,27 @@ func WebServer(publicPath string, httpListen string, cfg *config.HTTPConfig, id
 
 	NewAPICfgImportExport(m)
 
+	NewAPICfgAudit(m)
+
+	NewAPICfgSnapshot(m)
+
+	NewAPICfgSync(m)
+
+	NewAPICfgUsers(m)
+
+	NewAPICfgAPITokens(m)
+
+	NewAPICfgAlerting(m)
+	NewAPICfgMaintWindow(m)
+
 	NewAPIRtAgent(m)
 
 	NewAPIRtDevice(m)
 
+	NewAPIRtData(m)
+
+	NewAPIv2(m)
+
 	//Begin server
 
 	var listen string
@@ -237,18 +258,34 @@ func WebServer(publicPath string, httpListen string, cfg *config.HTTPConfig, id
 /*LOGIN
 /****************/
 
+// signIn sets the session for the authenticated user
+func signIn(ctx *Context, user string, role string, source string) {
+	ctx.SignedInUser = user
+	ctx.UserRole = role
+	ctx.AuthSource = source
+	ctx.IsSignedIn = true
+	ctx.Session.Set(SessKeyUserID, user)
+	ctx.Session.Set(SessKeyAuthSource, source)
+	if source != AuthSourceLocal {
+		ctx.Session.Set(SessKeyUserRole, role)
+	}
+	log.Infof("User %s login OK with role %s (%s)", user, role, source)
+}
+
 func myLoginHandler(ctx *Context, user UserLogin) {
 	//fmt.Printf("USER LOGIN: USER: +%#v (Config: %#v)", user, confHTTP)
-	if user.UserName == confHTTP.AdminUser && user.Password == confHTTP.AdminPassword {
-		ctx.SignedInUser = user.UserName
-		ctx.IsSignedIn = true
-		ctx.Session.Set(SessKeyUserID, user.UserName)
-		log.Println("Admin login OK")
+	for _, a := range authenticators {
+		role, err := a.Authenticate(user.UserName, user.Password)
+		if err != nil {
+			log.Debugf("User %s %s login ERROR: %s", user.UserName, a.Name(), err)
+			continue
+		}
+		signIn(ctx, user.UserName, role, a.Name())
 		ctx.JSON(200, cookie)
-	} else {
-		log.Println("Admin login ERROR")
-		ctx.JSON(400, "ERROR user or password not match")
+		return
 	}
+	log.Warnf("User %s login ERROR: user or password not match", user.UserName)
+	ctx.JSON(400, "ERROR user or password not match")
 }
 
 func myLogoutHandler(ctx *Context) {
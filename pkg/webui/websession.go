@@ -11,7 +11,9 @@ import (
 
 // SessKeyUserID type of session key
 const (
-	SessKeyUserID = "uid"
+	SessKeyUserID     = "uid"
+	SessKeyAuthSource = "auth"
+	SessKeyUserRole   = "role" // only for external (ldap, oidc) users
 )
 
 var sessionManager *session.Manager
@@ -91,6 +93,8 @@ type SessionStore interface {
 	Set(interface{}, interface{}) error
 	// Get gets value by given key in session.
 	Get(interface{}) interface{}
+	// Delete deletes a key from session.
+	Delete(interface{}) error
 	// ID returns current session ID.
 	ID() string
 	// Release releases session resource and save data to provider.
@@ -130,6 +134,14 @@ func (s *SessionWrapper) Get(k interface{}) interface{} {
 	return nil
 }
 
+// Delete session Key deletion
+func (s *SessionWrapper) Delete(k interface{}) error {
+	if s.session != nil {
+		return s.session.Delete(k)
+	}
+	return nil
+}
+
 // ID get session identificator
 func (s *SessionWrapper) ID() string {
 	if s.session != nil {
@@ -59,6 +59,10 @@ export class AvailableTableActions {
             'true','false'
             ]
           },
+          {'title' : 'OverrunPolicy', 'type':'boolean', 'options' : [
+            'skip','immediate','autoraise'
+            ]
+          },
           {'title' : 'DisableBulk', 'type':'boolean', 'options' : [
             'true','false'
             ]
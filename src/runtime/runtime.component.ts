@@ -32,6 +32,7 @@ export class RuntimeComponent implements OnDestroy {
   editmode: string; //list , create, modify
   isRequesting: boolean = false;
   runtime_devs: Array<any>;
+  eventsSubscription: any;
 
   mySubscription: any;
   filter: string;
@@ -55,6 +56,8 @@ export class RuntimeComponent implements OnDestroy {
   private data: Array<any> = [];
   public activeDevices: number;
   public noConnectedDevices: number;
+  public chronicOverrunDevices: number;
+  public flappingDevices: number;
   public dataTable: Array<any> = [];
   public finalData: Array<Array<any>> = [];
   public columns: Array<any> = [];
@@ -191,6 +194,8 @@ export class RuntimeComponent implements OnDestroy {
     this.length = sortedData.length;
     this.activeDevices = sortedData.filter((item) => { return item.DeviceActive }).length
     this.noConnectedDevices = sortedData.filter((item) => { if (item.DeviceActive === true && item.DeviceConnected === false) return true }).length
+    this.chronicOverrunDevices = sortedData.filter((item) => { return item.ChronicOverrun === true }).length
+    this.flappingDevices = sortedData.filter((item) => { return item.Flapping === true }).length
   }
 
   public onExtraActionClicked(data: any) {
@@ -532,12 +537,30 @@ export class RuntimeComponent implements OnDestroy {
           this.isRequesting = false;
         this.activeFilter = this.deactiveFilter = this.noConnectedFilter = false;
         this.onChangeTable(this.config);
+        this.subscribeRuntimeEvents();
       },
       err => console.error(err),
       () => console.log('DONE')
       );
   }
 
+  // subscribeRuntimeEvents updates the device rows with the server pushed stats
+  subscribeRuntimeEvents() {
+    if (this.eventsSubscription) return;
+    this.eventsSubscription = this.runtimeService.getRuntimeEvents(['gather.done', 'device.connect', 'device.disconnect'])
+      .subscribe(
+      event => {
+        let row = _.find(this.runtime_devs, { 'ID': event.Device });
+        if (!row || this.editmode !== 'list') return;
+        // update in place to keep the row on the filtered data
+        Object.assign(row, this.runtimeService.getRuntimeRow(event.Device, event.Data));
+        if (!row.ChronicOverrun && !row.Flapping) delete row['class'];
+        this.onChangeTable(this.config);
+      },
+      err => console.error(err)
+      );
+  }
+
   toogleActiveFilter(option: string) {
     if (this.activeFilter === false && option === 'active') {
       this.noConnectedFilter = false;
@@ -566,6 +589,7 @@ export class RuntimeComponent implements OnDestroy {
   ngOnDestroy() {
     clearInterval(this.intervalStatus);
     if (this.mySubscription) this.mySubscription.unsubscribe();
+    if (this.eventsSubscription) this.eventsSubscription.unsubscribe();
   }
 
 }
@@ -10,7 +10,10 @@ export const RuntimeComponentConfig: any =
       { title: 'Get.Errs', name: 'Counter7', tooltip: 'SnmpOIDGetErrors:number of  oid with errors for all measurements ' },
       { title: 'M.Errs', name: 'Counter14', tooltip: 'MeasurementSentErrors: number of measuremenets  formatted with errors ' },
       { title: 'G.Time', name: 'Counter16', tooltip: 'CycleGatherDuration time: elapsed time taken to get all measurement info', transform: 'elapsedseconds' },
-      { title: 'F.Time', name: 'Counter18', tooltip: 'CycleGatherDuration time: elapsed time taken to compute all applicable filters on the device', transform: 'elapsedseconds' }
+      { title: 'F.Time', name: 'Counter18', tooltip: 'CycleGatherDuration time: elapsed time taken to compute all applicable filters on the device', transform: 'elapsedseconds' },
+      { title: 'Overruns', name: 'GatherOverruns', tooltip: 'GatherOverruns: gather cycles taking longer than the polling period (highlighted when chronic on the last cycles)' },
+      { title: 'State Age', name: 'StateDuration', tooltip: 'StateDuration: time since the last up/down state change (highlighted when flapping)', transform: 'elapsedseconds' },
+      { title: 'RTT', name: 'LastRTT', tooltip: 'LastRTT: response time of the last connectivity probe', transform: 'elapsedseconds' }
     ],
   }; 
 
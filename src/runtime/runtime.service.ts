@@ -20,34 +20,54 @@ export class RuntimeService {
         .map((runtime_devs) => {
             let result = [];
             if (runtime_devs) {
-                _.forEach(runtime_devs,function(value,key){
-                    let tmp : any = {};
-                  console.log("KEY: ",key);
-                  console.log("FOREACH LOOP",value,key);
-                  tmp.ID = key;
-                  _.forEach(value, function(val,key) {
-                     if (key == "Counters") {
-                        let i = 0;
-                         for (let a of val) {
-                             tmp['Counter'+i]=a;
-                             i++;
-                         }
-                     } else tmp[key] = val;
-                     if (key == "TagMap") {
-                         tmp['TagMap']=[];
-                         for (let a in val) {
-                             tmp['TagMap'].push(a+'='+val[a])
-                         }
-                     }
-                  });
-                  result.push(tmp);
-                  //result.push({'ID': key, 'value' :value});
+                _.forEach(runtime_devs, (value) => {
+                  result.push(this.getRuntimeRow(value.ID, value));
                 });
             }
             return result;
         });
     }
 
+    // getRuntimeRow flattens the device stats as a runtime table row
+    getRuntimeRow(id: string, value: any) {
+        let tmp : any = {};
+        tmp.ID = id;
+        _.forEach(value, function(val,key) {
+           if (key == "Counters") {
+              let i = 0;
+               for (let a of val) {
+                   tmp['Counter'+i]=a;
+                   i++;
+               }
+           } else tmp[key] = val;
+           if (key == "TagMap") {
+               tmp['TagMap']=[];
+               for (let a in val) {
+                   tmp['TagMap'].push(a+'='+val[a])
+               }
+           }
+        });
+        if (tmp.ChronicOverrun === true) {
+          tmp['class'] = { 'ID': 'bg-warning', 'GatherOverruns': 'bg-warning' };
+        }
+        if (tmp.Flapping === true) {
+          tmp['class'] = Object.assign(tmp['class'] || {}, { 'ID': 'bg-danger', 'StateDuration': 'bg-danger' });
+        }
+        return tmp;
+    }
+
+    // getRuntimeEvents returns the server-sent runtime events of the given types
+    getRuntimeEvents(types: Array<string>) : Observable<any> {
+        return Observable.create((observer) => {
+            let source = new EventSource('/api/rt/events?type=' + types.join(','), { withCredentials: true });
+            for (let t of types) {
+                source.addEventListener(t, (e: any) => observer.next(JSON.parse(e.data)));
+            }
+            source.onerror = (e) => console.log("Runtime events stream error, reconnecting...", e);
+            return () => source.close();
+        });
+    }
+
     getRuntimeById(id : string) {
         // return an observable
         return this.httpAPI.get('/api/rt/device/info/'+id)
@@ -131,6 +131,7 @@ export class SnmpDeviceCfgComponent {
       Freq: [this.snmpdevForm ? this.snmpdevForm.value.Freq : 60, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
       UpdateFltFreq: [this.snmpdevForm ? this.snmpdevForm.value.UpdateFltFreq : 60, Validators.compose([Validators.required, ValidationService.uintegerAndLessOneValidator])],
       ConcurrentGather: [this.snmpdevForm ? this.snmpdevForm.value.ConcurrentGather : 'true', Validators.required],
+      OverrunPolicy: [this.snmpdevForm ? this.snmpdevForm.value.OverrunPolicy : 'skip', Validators.required],
       OutDB: [this.snmpdevForm ? this.snmpdevForm.value.OutDB :  '', Validators.required],
       LogLevel: [this.snmpdevForm ? this.snmpdevForm.value.LogLevel : 'info', Validators.required],
       SnmpDebug: [this.snmpdevForm ? this.snmpdevForm.value.SnmpDebug : 'false', Validators.required],